@@ -0,0 +1,138 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// formatTypes are the types of the formats that are defined by OpenAPI and JSON Schema.
+var formatTypes = map[string][]string{
+	"int32":         {"integer"},
+	"int64":         {"integer"},
+	"float":         {"number"},
+	"double":        {"number"},
+	"byte":          {"string"},
+	"binary":        {"string"},
+	"date":          {"string"},
+	"date-time":     {"string"},
+	"time":          {"string"},
+	"duration":      {"string"},
+	"password":      {"string"},
+	"email":         {"string"},
+	"idn-email":     {"string"},
+	"hostname":      {"string"},
+	"idn-hostname":  {"string"},
+	"ipv4":          {"string"},
+	"ipv6":          {"string"},
+	"uri":           {"string"},
+	"uri-reference": {"string"},
+	"iri":           {"string"},
+	"iri-reference": {"string"},
+	"uri-template":  {"string"},
+	"uuid":          {"string"},
+	"json-pointer":  {"string"},
+	"regex":         {"string"},
+}
+
+// dataKeys are the keys whose values are instances of schemas, such as examples,
+// enums and defaults, rather than parts of the description.
+var dataKeys = map[string]bool{
+	"const":    true,
+	"default":  true,
+	"enum":     true,
+	"example":  true,
+	"examples": true,
+}
+
+// CheckDocument returns diagnostics for the parts of an OpenAPI document that
+// compile but deserve attention: deprecated elements, values beside $refs,
+// which are ignored, and formats that are unknown or don't match their types.
+// nameMaps are the JSON pointers of the mappings of names in the document, as
+// in FindMisplacedExtensions. Instance data and x- extension values aren't checked.
+func CheckDocument(info *yaml.Node, nameMaps []string) []*Diagnostic {
+	diagnostics := make([]*Diagnostic, 0)
+	add := func(node *yaml.Node, pointer string, severity Severity, code string, message string) {
+		diagnostics = append(diagnostics, &Diagnostic{Severity: severity, Code: code, Message: message, Line: node.Line, Column: node.Column, Location: "#" + pointer})
+	}
+	walkDescription(info, "", nameMaps, func(node *yaml.Node, pointer string) {
+		values := make(map[string]*yaml.Node)
+		keys := make([]string, 0)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i].Value)
+			values[node.Content[i].Value] = unwrapNode(node.Content[i+1])
+		}
+		if _, ok := values["$ref"]; ok && len(keys) > 1 {
+			ignored := make([]string, 0)
+			for _, key := range keys {
+				if key != "$ref" {
+					ignored = append(ignored, key)
+				}
+			}
+			add(node, pointer, SeverityWarning, CodeIgnoredValue, fmt.Sprintf("has values beside $ref that are ignored: %s", strings.Join(ignored, ", ")))
+		}
+		if deprecated := values["deprecated"]; deprecated != nil && deprecated.ShortTag() == "!!bool" && deprecated.Value == "true" {
+			add(node, pointer, SeverityInfo, CodeDeprecated, "is deprecated")
+		}
+		format, typ := values["format"], values["type"]
+		if format != nil && format.Kind == yaml.ScalarNode && format.ShortTag() == "!!str" {
+			types, ok := formatTypes[format.Value]
+			if !ok {
+				add(format, pointer+"/format", SeverityInfo, CodeUnknownFormat, fmt.Sprintf("has unknown format: %s", format.Value))
+			} else if typ != nil && typ.Kind == yaml.ScalarNode && !StringArrayContainsValue(types, typ.Value) {
+				add(format, pointer+"/format", SeverityWarning, CodeFormatMismatch, fmt.Sprintf("has format %s, which is for %s values, with type %s", format.Value, types[0], typ.Value))
+			}
+		}
+	})
+	return diagnostics
+}
+
+// walkDescription calls f for the objects of a description with their JSON
+// pointers. It skips mappings of names, but not their values, and it doesn't
+// descend into instance data or x- extension values.
+func walkDescription(node *yaml.Node, pointer string, nameMaps []string, f func(node *yaml.Node, pointer string)) {
+	node = unwrapNode(node)
+	if node == nil {
+		return
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		names := matchesPointer(pointer, nameMaps)
+		if !names {
+			f(node, pointer)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if strings.HasPrefix(key, "x-") || (!names && dataKeys[key] && !isDefaultResponse(pointer, key)) {
+				continue
+			}
+			walkDescription(node.Content[i+1], pointer+"/"+escapePointerToken(key), nameMaps, f)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkDescription(child, pointer+"/"+strconv.Itoa(i), nameMaps, f)
+		}
+	}
+}
+
+// isDefaultResponse returns true for the default responses of operations,
+// which share their key with schema defaults.
+func isDefaultResponse(pointer, key string) bool {
+	return key == "default" && strings.HasSuffix(pointer, "/responses") && !strings.HasSuffix(pointer, "/properties/responses")
+}
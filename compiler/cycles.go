@@ -0,0 +1,415 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// A RefCycle is a cycle of $refs. Its elements are the locations of the $refs,
+// written as JSON pointer fragments that are prefixed with a filename when
+// they aren't in the document that was searched. Each $ref refers to a value
+// that contains the next one, and the last $ref refers to a value that
+// contains the first, so dereferencing them would never finish.
+type RefCycle []string
+
+// String returns the cycle in the form "A -> B -> A".
+func (c RefCycle) String() string {
+	if len(c) == 0 {
+		return ""
+	}
+	return strings.Join(c, " -> ") + " -> " + c[0]
+}
+
+// FindRefCycles finds the $ref cycles of a document, following $refs into other
+// documents. Every elementary cycle is reported once, including cycles that share
+// $refs with others. Each reported cycle ends with a $ref that closes it, and
+// every cycle in the document contains at least one of these closing $refs, so
+// the document can be dereferenced completely if they are left in place.
+// References that can't be resolved are ignored.
+func (s *Session) FindRefCycles(filename string, root *yaml.Node) ([]RefCycle, error) {
+	f := s.newCycleFinder(filename, root)
+	if err := f.find(); err != nil {
+		return nil, err
+	}
+	return f.cycles, nil
+}
+
+// Dereference returns a copy of a document with its $refs replaced by the values
+// that they refer to, along with the document's $ref cycles. $refs that close
+// cycles are left in place, with their values rewritten to be relative to the
+// document when they are copied from other documents.
+func (s *Session) Dereference(filename string, root *yaml.Node) (*yaml.Node, []RefCycle, error) {
+	f := s.newCycleFinder(filename, root)
+	if err := f.find(); err != nil {
+		return nil, nil, err
+	}
+	d := &dereferencer{finder: f, values: make(map[string]*yaml.Node)}
+	return d.copy(filename, root, ""), f.cycles, nil
+}
+
+// refSite is the location of a $ref.
+type refSite struct {
+	filename string
+	pointer  string
+	ref      string
+}
+
+func (r refSite) key() string {
+	return r.filename + "#" + r.pointer
+}
+
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// cycleFinder searches the graph of $refs depth-first. There is an edge from one
+// $ref to another if the value that the first refers to contains the second.
+// The $refs whose edges go back to $refs that are being visited close cycles,
+// and the cycles are then enumerated with Johnson's algorithm.
+type cycleFinder struct {
+	session   *Session
+	filename  string
+	root      *yaml.Node
+	sites     map[string]refSite
+	edges     map[string][]string
+	state     map[string]int
+	order     []string
+	closing   map[string]bool
+	backEdges map[[2]string]bool
+	cycles    []RefCycle
+}
+
+func (s *Session) newCycleFinder(filename string, root *yaml.Node) *cycleFinder {
+	// Local $refs in the document are resolved in root, even if it hasn't been read from filename.
+	s.mutex.Lock()
+	if _, ok := s.infos[filename]; !ok {
+		s.infos[filename] = root
+	}
+	s.mutex.Unlock()
+	return &cycleFinder{
+		session:   s,
+		filename:  filename,
+		root:      root,
+		sites:     make(map[string]refSite),
+		edges:     make(map[string][]string),
+		state:     make(map[string]int),
+		closing:   make(map[string]bool),
+		backEdges: make(map[[2]string]bool),
+	}
+}
+
+func (f *cycleFinder) find() error {
+	for _, key := range f.collect(f.filename, f.root, "") {
+		if f.state[key] == unvisited {
+			if err := f.visit(key); err != nil {
+				return err
+			}
+		}
+	}
+	f.cycles = f.elementaryCycles()
+	return nil
+}
+
+func (f *cycleFinder) visit(key string) error {
+	f.state[key] = visiting
+	f.order = append(f.order, key)
+	next, err := f.next(key)
+	if err != nil {
+		return err
+	}
+	for _, n := range next {
+		switch f.state[n] {
+		case unvisited:
+			if err := f.visit(n); err != nil {
+				return err
+			}
+		case visiting:
+			f.backEdges[[2]string{key, n}] = true
+			f.closing[key] = true
+		}
+	}
+	f.state[key] = visited
+	return nil
+}
+
+// elementaryCycles returns every elementary cycle of the graph of $refs. The
+// $refs are numbered in the order that they were visited, and the cycles whose
+// lowest-numbered $ref is each one are found in turn, searching only the $refs
+// that are strongly connected to it among the ones that aren't lower.
+func (f *cycleFinder) elementaryCycles() []RefCycle {
+	index := make(map[string]int, len(f.order))
+	for i, key := range f.order {
+		index[key] = i
+	}
+	reverse := make(map[string][]string)
+	for _, key := range f.order {
+		for _, n := range f.edges[key] {
+			reverse[n] = append(reverse[n], key)
+		}
+	}
+	var cycles []RefCycle
+	for i, start := range f.order {
+		// The $refs that are reachable from start and that start is reachable from.
+		forward := reachable(start, f.edges, index, i)
+		component := make(map[string]bool)
+		for key := range reachable(start, reverse, index, i) {
+			if forward[key] {
+				component[key] = true
+			}
+		}
+		blocked := make(map[string]bool)
+		blockers := make(map[string][]string)
+		var unblock func(key string)
+		unblock = func(key string) {
+			blocked[key] = false
+			for _, b := range blockers[key] {
+				if blocked[b] {
+					unblock(b)
+				}
+			}
+			blockers[key] = nil
+		}
+		var stack []string
+		var circuit func(key string) bool
+		circuit = func(key string) bool {
+			found := false
+			stack = append(stack, key)
+			blocked[key] = true
+			for _, n := range f.edges[key] {
+				if !component[n] {
+					continue
+				}
+				if n == start {
+					cycles = append(cycles, f.refCycle(stack))
+					found = true
+				} else if !blocked[n] && circuit(n) {
+					found = true
+				}
+			}
+			if found {
+				unblock(key)
+			} else {
+				for _, n := range f.edges[key] {
+					if component[n] {
+						blockers[n] = append(blockers[n], key)
+					}
+				}
+			}
+			stack = stack[:len(stack)-1]
+			return found
+		}
+		circuit(start)
+	}
+	return cycles
+}
+
+// reachable returns the $refs that can be reached from start along edges
+// without passing through $refs that are numbered lower than lowest.
+func reachable(start string, edges map[string][]string, index map[string]int, lowest int) map[string]bool {
+	found := map[string]bool{start: true}
+	pending := []string{start}
+	for len(pending) > 0 {
+		key := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, n := range edges[key] {
+			if !found[n] && index[n] >= lowest {
+				found[n] = true
+				pending = append(pending, n)
+			}
+		}
+	}
+	return found
+}
+
+// refCycle returns the locations of a cycle of $refs, rotated to end with a
+// $ref whose edge to the first one closes the cycle.
+func (f *cycleFinder) refCycle(keys []string) RefCycle {
+	first := 0
+	for i, key := range keys {
+		if f.backEdges[[2]string{key, keys[(i+1)%len(keys)]}] {
+			first = (i + 1) % len(keys)
+			break
+		}
+	}
+	cycle := make(RefCycle, 0, len(keys))
+	for i := range keys {
+		cycle = append(cycle, f.location(keys[(first+i)%len(keys)]))
+	}
+	return cycle
+}
+
+// next returns the $refs that are contained in the value that a $ref refers to.
+func (f *cycleFinder) next(key string) ([]string, error) {
+	if next, ok := f.edges[key]; ok {
+		return next, nil
+	}
+	filename, pointer, target, err := f.target(f.sites[key])
+	if err != nil {
+		return nil, err
+	}
+	var next []string
+	if target != nil {
+		next = f.collect(filename, target, pointer)
+	}
+	f.edges[key] = next
+	return next, nil
+}
+
+// target returns the file, pointer, and value that a $ref refers to.
+// Limit errors are returned, and other errors leave the $ref unresolved.
+func (f *cycleFinder) target(site refSite) (string, string, *yaml.Node, error) {
+	parts := strings.SplitN(site.ref, "#", 2)
+	filename := site.filename
+	if parts[0] != "" {
+		filename = refFilename(site.filename, parts[0])
+	}
+	pointer := ""
+	if len(parts) == 2 && parts[1] != "/" {
+		pointer = parts[1]
+	}
+	info, err := f.session.ReadInfoForRef(site.filename, site.ref)
+	if err != nil {
+		if _, ok := err.(*LimitError); ok {
+			return "", "", nil, err
+		}
+		return "", "", nil, nil
+	}
+	return filename, pointer, info, nil
+}
+
+// collect returns the $refs in a value, which is at pointer in filename.
+func (f *cycleFinder) collect(filename string, node *yaml.Node, pointer string) []string {
+	keys := make([]string, 0)
+	var walk func(node *yaml.Node, pointer string)
+	walk = func(node *yaml.Node, pointer string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, pointer)
+			}
+		case yaml.AliasNode:
+			if node.Alias != nil {
+				walk(node.Alias, pointer)
+			}
+		case yaml.MappingNode:
+			if ref := refValue(node); ref != "" {
+				site := refSite{filename: filename, pointer: pointer, ref: ref}
+				f.sites[site.key()] = site
+				keys = append(keys, site.key())
+				return
+			}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], pointer+"/"+escapePointerToken(node.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+	walk(node, pointer)
+	return keys
+}
+
+// location returns the location of a $ref for reports.
+func (f *cycleFinder) location(key string) string {
+	site := f.sites[key]
+	if site.filename == f.filename {
+		return "#" + site.pointer
+	}
+	return site.key()
+}
+
+// relativeRef returns a $ref to a pointer in filename that can be used in the searched document.
+func (f *cycleFinder) relativeRef(filename, pointer string) string {
+	if filename == f.filename {
+		return "#" + pointer
+	}
+	basedir, _ := filepath.Split(f.filename)
+	if basedir != "" && strings.HasPrefix(filename, basedir) {
+		filename = filename[len(basedir):]
+	}
+	return filename + "#" + pointer
+}
+
+func escapePointerToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// dereferencer copies values with their $refs replaced. Dereferenced values
+// are saved and shared by all of the $refs that refer to them.
+type dereferencer struct {
+	finder *cycleFinder
+	values map[string]*yaml.Node
+}
+
+func (d *dereferencer) copy(filename string, node *yaml.Node, pointer string) *yaml.Node {
+	switch node.Kind {
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			return d.copy(filename, node.Alias, pointer)
+		}
+	case yaml.MappingNode:
+		if ref := refValue(node); ref != "" {
+			return d.replace(refSite{filename: filename, pointer: pointer, ref: ref}, node)
+		}
+	}
+	result := *node
+	result.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		switch node.Kind {
+		case yaml.MappingNode:
+			if i%2 == 0 {
+				result.Content[i] = child
+			} else {
+				result.Content[i] = d.copy(filename, child, pointer+"/"+escapePointerToken(node.Content[i-1].Value))
+			}
+		case yaml.SequenceNode:
+			result.Content[i] = d.copy(filename, child, pointer+"/"+strconv.Itoa(i))
+		default:
+			result.Content[i] = d.copy(filename, child, pointer)
+		}
+	}
+	return &result
+}
+
+// replace returns the value of a $ref, or a copy of the $ref if it closes a cycle or can't be resolved.
+func (d *dereferencer) replace(site refSite, node *yaml.Node) *yaml.Node {
+	f := d.finder
+	filename, pointer, target, _ := f.target(site)
+	if target == nil || f.closing[site.key()] {
+		ref := site.ref
+		if target != nil && site.filename != f.filename {
+			ref = f.relativeRef(filename, pointer)
+		}
+		mapping := NewMappingNode()
+		mapping.Content = append(mapping.Content, NewScalarNodeForString("$ref"), NewScalarNodeForString(ref))
+		return mapping
+	}
+	key := filename + "#" + pointer
+	if value, ok := d.values[key]; ok {
+		return value
+	}
+	value := d.copy(filename, target, pointer)
+	d.values[key] = value
+	return value
+}
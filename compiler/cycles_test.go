@@ -0,0 +1,123 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// readSessionDocument reads a document in a new session.
+func readSessionDocument(t *testing.T, filename string) (*Session, *yaml.Node) {
+	s := NewSession(nil, nil)
+	bytes, err := s.ReadBytesForFile(filename)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	info, err := s.ReadInfoFromBytes(filename, bytes)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return s, info
+}
+
+func TestFindRefCycles(t *testing.T) {
+	for _, test := range []struct {
+		filename string
+		cycles   []RefCycle
+	}{
+		{"../examples/v2.0/yaml/petstore.yaml", nil},
+		{"../testdata/cycles/recursive-v2.yaml", []RefCycle{
+			{"#/definitions/Tree/properties/children/items"},
+			{"#/definitions/Person/properties/employer", "#/definitions/Company/properties/employees/items"},
+			{"#/definitions/Folder/properties/files/items", "../testdata/cycles/recursive-files.yaml#/File/properties/folder"},
+		}},
+		{"../testdata/cycles/recursive-v3.yaml", []RefCycle{
+			{"#/components/schemas/Node/properties/next"},
+			{"#/components/schemas/Alias", "#/components/schemas/Other"},
+		}},
+		// The cycle through child and parent shares both of its $refs with the
+		// cycle through child, sibling and parent.
+		{"../testdata/cycles/overlapping-v3.yaml", []RefCycle{
+			{"#/components/schemas/Parent/properties/child", "#/components/schemas/Child/properties/sibling", "#/components/schemas/Child/properties/parent"},
+			{"#/components/schemas/Parent/properties/child", "#/components/schemas/Child/properties/parent"},
+			{"#/components/schemas/Child/properties/sibling"},
+		}},
+	} {
+		s, info := readSessionDocument(t, test.filename)
+		cycles, err := s.FindRefCycles(test.filename, info)
+		s.Close()
+		if err != nil {
+			t.Errorf("%s: %+v", test.filename, err)
+			continue
+		}
+		if !reflect.DeepEqual(cycles, test.cycles) {
+			t.Errorf("%s: unexpected cycles %v", test.filename, cycles)
+		}
+	}
+}
+
+func TestRefCycleString(t *testing.T) {
+	cycle := RefCycle{"#/a", "#/b"}
+	if cycle.String() != "#/a -> #/b -> #/a" {
+		t.Errorf("unexpected string %q", cycle.String())
+	}
+}
+
+func TestDereference(t *testing.T) {
+	filename := "../testdata/cycles/recursive-v2.yaml"
+	s, info := readSessionDocument(t, filename)
+	defer s.Close()
+	result, cycles, err := s.Dereference(filename, info)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(cycles) != 3 {
+		t.Errorf("unexpected cycles %v", cycles)
+	}
+	root := result.Content[0]
+	for _, test := range []struct {
+		pointer string
+		ref     string
+		value   string
+	}{
+		// References are replaced by their values.
+		{"/paths/~1trees/get/responses/200/schema/type", "", "object"},
+		{"/definitions/Person/properties/employer/type", "", "object"},
+		{"/definitions/Folder/properties/files/items/properties/name/type", "", "string"},
+		// References that close cycles are left in place.
+		{"/definitions/Tree/properties/children/items", "#/definitions/Tree", ""},
+		{"/paths/~1trees/get/responses/200/schema/properties/children/items", "#/definitions/Tree", ""},
+		{"/definitions/Company/properties/employees/items", "#/definitions/Person", ""},
+		{"/definitions/Person/properties/employer/properties/employees/items", "#/definitions/Person", ""},
+		// References copied from other documents are relative to the dereferenced document.
+		{"/definitions/Folder/properties/files/items/properties/folder", "#/definitions/Folder", ""},
+	} {
+		node := nodeAtPointer(root, test.pointer)
+		if node == nil {
+			t.Errorf("%s: missing value", test.pointer)
+		} else if refValue(node) != test.ref {
+			t.Errorf("%s: expected $ref %q, found %q", test.pointer, test.ref, refValue(node))
+		} else if test.value != "" && node.Value != test.value {
+			t.Errorf("%s: expected %q, found %q", test.pointer, test.value, node.Value)
+		}
+	}
+	// The original document is unchanged.
+	if refValue(nodeAtPointer(info.Content[0], "/paths/~1trees/get/responses/200/schema")) != "#/definitions/Tree" {
+		t.Errorf("the original document was modified")
+	}
+}
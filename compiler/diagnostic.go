@@ -0,0 +1,146 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity int
+
+// Severities, from least to most severe.
+const (
+	SeverityInfo Severity = iota + 1
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// ParseSeverity returns the severity with a name that String returns.
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity: %s", name)
+}
+
+// Diagnostic codes. Codes are stable, so tools can use them to identify and filter diagnostics.
+const (
+	CodeCompileError       = "compile-error"
+	CodeInvalidProperty    = "invalid-property"
+	CodeMissingProperty    = "missing-property"
+	CodeInvalidValue       = "invalid-value"
+	CodeDuplicateKey       = "duplicate-key"
+	CodeCoercedValue       = "coerced-value"
+	CodeMisplacedExtension = "misplaced-extension"
+	CodeDeprecated         = "deprecated"
+	CodeIgnoredValue       = "ignored-value"
+	CodeUnknownFormat      = "unknown-format"
+	CodeFormatMismatch     = "format-type-mismatch"
+	CodeLimitExceeded      = "limit-exceeded"
+)
+
+// A Diagnostic is a problem or a notable property of a document. It is an error,
+// so compilers can return diagnostics with their other errors. Diagnostics with
+// severities below SeverityError don't cause compilation to fail.
+type Diagnostic struct {
+	Severity Severity
+	// Code identifies the kind of diagnostic.
+	Code    string
+	Message string
+	// Line and Column are the position of the diagnostic, or zero if it isn't known.
+	Line   int
+	Column int
+	// Location describes where the diagnostic is in the document, such as with a JSON pointer.
+	Location string
+}
+
+// NewDiagnostic creates a Diagnostic at the node of a context.
+func NewDiagnostic(context *Context, severity Severity, code string, message string) *Diagnostic {
+	d := &Diagnostic{Severity: severity, Code: code, Message: message}
+	if context != nil {
+		d.Location = context.Description()
+		if context.Node != nil {
+			d.Line, d.Column = context.Node.Line, context.Node.Column
+		}
+	}
+	return d
+}
+
+// Error returns the position, location and message of a diagnostic.
+func (d *Diagnostic) Error() string {
+	parts := make([]string, 0)
+	if d.Line > 0 {
+		parts = append(parts, fmt.Sprintf("[%d,%d]", d.Line, d.Column))
+	}
+	if d.Location != "" {
+		parts = append(parts, d.Location)
+	}
+	return strings.Join(append(parts, d.Message), " ")
+}
+
+// String returns a diagnostic with its severity and code.
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s[%s] %s", d.Severity, d.Code, d.Error())
+}
+
+// DiagnosticsForError returns the diagnostics of an error or an ErrorGroup.
+// Errors that aren't diagnostics are converted to diagnostics with a severity,
+// and with codes that are inferred from their types and messages.
+func DiagnosticsForError(err error, severity Severity) []*Diagnostic {
+	diagnostics := make([]*Diagnostic, 0)
+	for _, err := range flattenErrors(err) {
+		diagnostics = append(diagnostics, diagnosticForError(err, severity))
+	}
+	return diagnostics
+}
+
+func diagnosticForError(err error, severity Severity) *Diagnostic {
+	switch e := err.(type) {
+	case *Diagnostic:
+		return e
+	case *StructureError:
+		return &Diagnostic{Severity: severity, Code: e.Code, Message: e.Message, Line: e.Line, Column: e.Column, Location: "#" + e.Pointer}
+	case *LimitError:
+		return &Diagnostic{Severity: severity, Code: CodeLimitExceeded, Message: e.Error()}
+	case *Error:
+		d := NewDiagnostic(e.Context, severity, CodeCompileError, e.Message)
+		switch {
+		case isInvalidPropertyError(e):
+			d.Code = CodeInvalidProperty
+		case strings.HasPrefix(e.Message, "is missing required "):
+			d.Code = CodeMissingProperty
+		case strings.HasPrefix(e.Message, "has unexpected value"), strings.HasPrefix(e.Message, "contains an invalid "):
+			d.Code = CodeInvalidValue
+		}
+		return d
+	}
+	return &Diagnostic{Severity: severity, Code: CodeCompileError, Message: err.Error()}
+}
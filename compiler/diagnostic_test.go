@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestDiagnosticsForError(t *testing.T) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Line: 3, Column: 5}
+	context := NewContext("info", node, NewContext("$root", nil, nil))
+	err := NewErrorGroupOrNil([]error{
+		NewError(context, "has invalid property: unknown"),
+		NewErrorGroupOrNil([]error{
+			NewError(context, "is missing required property: name"),
+			NewError(context, "has unexpected value for type: 1 (int)"),
+		}),
+		&StructureError{Line: 1, Column: 2, Pointer: "/a", Message: "has duplicate key: b", Code: CodeDuplicateKey},
+		&LimitError{Limit: LimitDepth, Max: 10},
+		NewDiagnostic(context, SeverityInfo, CodeDeprecated, "is deprecated"),
+	})
+	expected := []string{
+		"error[invalid-property] [3,5] $root.info has invalid property: unknown",
+		"error[missing-property] [3,5] $root.info is missing required property: name",
+		"error[invalid-value] [3,5] $root.info has unexpected value for type: 1 (int)",
+		"error[duplicate-key] [1,2] #/a has duplicate key: b",
+		"error[limit-exceeded] depth limit of 10 exceeded",
+		"info[deprecated] [3,5] $root.info is deprecated",
+	}
+	diagnostics := DiagnosticsForError(err, SeverityError)
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("unexpected diagnostics %v", diagnostics)
+	}
+	for i, d := range diagnostics {
+		if d.String() != expected[i] {
+			t.Errorf("expected %q, found %q", expected[i], d.String())
+		}
+	}
+	// Diagnostics below SeverityError don't fail compilation.
+	warnings, err := ModeDefault.Classify(err, nil)
+	if len(warnings) != 1 || len(flattenErrors(err)) != 5 {
+		t.Errorf("unexpected warnings %v and errors %v", warnings, err)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		if parsed, err := ParseSeverity(s.String()); err != nil || parsed != s {
+			t.Errorf("%s: unexpected result %v %v", s, parsed, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Errorf("unknown severity was accepted")
+	}
+}
+
+func TestCheckDocument(t *testing.T) {
+	var info yaml.Node
+	err := yaml.Unmarshal([]byte(`
+openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      deprecated: true
+      responses:
+        default:
+          description: An error.
+          content:
+            application/json:
+              schema:
+                type: string
+                format: slug
+              example:
+                deprecated: true
+              examples:
+                pet:
+                  value:
+                    format: slug
+      x-payload:
+        $ref: "#/x"
+        format: slug
+components:
+  schemas:
+    Pet:
+      type: object
+      enum:
+        - format: slug
+      default:
+        deprecated: true
+      properties:
+        $ref:
+          type: string
+        example:
+          type: string
+          format: slug
+`), &info)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := []string{
+		"info[deprecated] [6,7] #/paths/~1pets/get is deprecated",
+		"info[unknown-format] [14,25] #/paths/~1pets/get/responses/default/content/application~1json/schema/format has unknown format: slug",
+		"info[unknown-format] [37,19] #/components/schemas/Pet/properties/example/format has unknown format: slug",
+	}
+	// Examples, enums, defaults and extensions are data, and the keys of
+	// maps of names are names.
+	diagnostics := CheckDocument(&info, []string{"/components/schemas", "properties", "content", "examples"})
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("unexpected diagnostics %v", diagnostics)
+	}
+	for i, d := range diagnostics {
+		if d.String() != expected[i] {
+			t.Errorf("expected %q, found %q", expected[i], d.String())
+		}
+	}
+}
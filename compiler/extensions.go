@@ -15,11 +15,331 @@
 package compiler
 
 import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
 	"github.com/google/gnostic-models/compiler"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	yaml "gopkg.in/yaml.v3"
+
+	extensions "github.com/google/gnostic/extensions"
 )
 
 // ExtensionHandler describes a binary that is called by the compiler to handle specification extensions.
 type ExtensionHandler = compiler.ExtensionHandler
 
-// CallExtension calls a binary extension handler.
-var CallExtension = compiler.CallExtension
+// An ExtensionProcessor handles the values of specification extensions in the
+// compiler's process. It returns false for the extensions that it doesn't handle.
+type ExtensionProcessor interface {
+	ProcessExtension(extensionName string, in *yaml.Node) (handled bool, message proto.Message, err error)
+}
+
+// ExtensionProcessorFunc is a function that is an ExtensionProcessor.
+type ExtensionProcessorFunc func(extensionName string, in *yaml.Node) (bool, proto.Message, error)
+
+// ProcessExtension calls f.
+func (f ExtensionProcessorFunc) ProcessExtension(extensionName string, in *yaml.Node) (bool, proto.Message, error) {
+	return f(extensionName, in)
+}
+
+var extensionProcessors = make(map[string]ExtensionProcessor)
+var extensionProcessorsMutex sync.Mutex
+
+// RegisterExtensionProcessor registers a processor that is called in place of the
+// handler binary with a name, such as "gnostic-x-sampleone". A nil processor
+// removes the registration.
+func RegisterExtensionProcessor(handlerName string, processor ExtensionProcessor) {
+	extensionProcessorsMutex.Lock()
+	defer extensionProcessorsMutex.Unlock()
+	if processor == nil {
+		delete(extensionProcessors, handlerName)
+	} else {
+		extensionProcessors[handlerName] = processor
+	}
+}
+
+// ExtensionProcessorForHandler returns the processor that is registered for a
+// handler binary, or nil if there isn't one.
+func ExtensionProcessorForHandler(handlerName string) ExtensionProcessor {
+	extensionProcessorsMutex.Lock()
+	defer extensionProcessorsMutex.Unlock()
+	return extensionProcessors[handlerName]
+}
+
+// CallExtension calls the extension handlers of a context with the value of a
+// specification extension, and returns the first value that a handler handles.
+// Handlers with registered processors are called directly. Other handlers use
+// the results of the session's batches, and binaries are only run for single
+// values that weren't in a batch.
+func CallExtension(context *Context, in *yaml.Node, extensionName string) (handled bool, response *anypb.Any, err error) {
+	if context == nil || context.ExtensionHandlers == nil {
+		return false, nil, nil
+	}
+	batches := batchesForHandlers(context.ExtensionHandlers)
+	for _, handler := range *(context.ExtensionHandlers) {
+		if handler.Name == "" {
+			continue
+		}
+		var result *extensions.ExtensionHandlerResponse
+		if processor := ExtensionProcessorForHandler(handler.Name); processor != nil {
+			result = processExtension(processor, extensionName, in)
+		} else if batch := batches.batch(handler.Name); batch != nil && batch.missing {
+			continue
+		} else if r, ok := batch.lookup(in, extensionName); ok {
+			result = r
+		} else {
+			// Errors running handlers are ignored, and the next handler is tried.
+			result, _ = runExtensionHandler(handler.Name, newExtensionHandlerRequest(in, extensionName))
+		}
+		if result == nil {
+			continue
+		}
+		if len(result.Errors) != 0 {
+			return true, nil, NewError(context, fmt.Sprintf("has value for %s that extension handler %s couldn't handle: %s", extensionName, handler.Name, strings.Join(result.Errors, ", ")))
+		}
+		if result.Handled {
+			return true, result.Value, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// processExtension calls a processor and returns its result as an ExtensionHandlerResponse.
+func processExtension(processor ExtensionProcessor, extensionName string, in *yaml.Node) *extensions.ExtensionHandlerResponse {
+	handled, message, err := processor.ProcessExtension(extensionName, in)
+	response := &extensions.ExtensionHandlerResponse{Handled: handled}
+	if err != nil {
+		response.Errors = append(response.Errors, err.Error())
+	} else if handled {
+		response.Value, err = anypb.New(message)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+		}
+	}
+	return response
+}
+
+func newExtensionHandlerRequest(in *yaml.Node, extensionName string) *extensions.ExtensionHandlerRequest {
+	yamlData, _ := yaml.Marshal(in)
+	return &extensions.ExtensionHandlerRequest{
+		CompilerVersion: &extensions.Version{
+			Major: 0,
+			Minor: 1,
+			Patch: 0,
+		},
+		Wrapper: &extensions.Wrapper{
+			Version:       "unknown", // TODO: set this to the type/version of spec being parsed.
+			Yaml:          string(yamlData),
+			ExtensionName: extensionName,
+		},
+	}
+}
+
+// runExtensionHandler runs a handler binary with a single request.
+func runExtensionHandler(name string, request *extensions.ExtensionHandlerRequest) (*extensions.ExtensionHandlerResponse, error) {
+	requestBytes, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(name)
+	cmd.Stdin = bytes.NewReader(requestBytes)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	response := &extensions.ExtensionHandlerResponse{}
+	if err := proto.Unmarshal(output, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// runExtensionHandlerBatch runs a handler binary with the --batch flag, which
+// reads size-delimited requests and writes a size-delimited response for each.
+func runExtensionHandlerBatch(name string, requests []*extensions.ExtensionHandlerRequest) ([]*extensions.ExtensionHandlerResponse, error) {
+	var input bytes.Buffer
+	for _, request := range requests {
+		if _, err := protodelim.MarshalTo(&input, request); err != nil {
+			return nil, err
+		}
+	}
+	cmd := exec.Command(name, "--batch")
+	cmd.Stdin = &input
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	reader := bytes.NewReader(output)
+	responses := make([]*extensions.ExtensionHandlerResponse, len(requests))
+	for i := range responses {
+		responses[i] = &extensions.ExtensionHandlerResponse{}
+		if err := protodelim.UnmarshalFrom(reader, responses[i]); err != nil {
+			return nil, fmt.Errorf("extension handler %s returned an invalid batch: %v", name, err)
+		}
+	}
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("extension handler %s returned more responses than requests", name)
+	}
+	return responses, nil
+}
+
+// extensionValue identifies the value of a specification extension in a document.
+type extensionValue struct {
+	node *yaml.Node
+	name string
+}
+
+// An extensionBatch holds the responses of a handler binary to the extension values of a document.
+type extensionBatch struct {
+	responses map[extensionValue]*extensions.ExtensionHandlerResponse
+	// missing is true if the handler binary couldn't be found.
+	missing bool
+}
+
+func (b *extensionBatch) lookup(in *yaml.Node, extensionName string) (*extensions.ExtensionHandlerResponse, bool) {
+	if b == nil {
+		return nil, false
+	}
+	response, ok := b.responses[extensionValue{node: in, name: extensionName}]
+	return response, ok
+}
+
+// extensionBatches holds the extension handler batches of a session. The
+// contexts of the session find it with batchesForHandlers from their extension
+// handler lists.
+type extensionBatches struct {
+	mutex    sync.Mutex
+	batches  map[string]*extensionBatch
+	handlers []*[]ExtensionHandler
+}
+
+// linkedBatches maps the extension handler lists of the contexts of sessions to
+// the sessions' batches. It refers to the batches and not to the sessions, so
+// sessions that aren't closed are still collected, and their lists are unlinked
+// when they are.
+var linkedBatches = make(map[*[]ExtensionHandler]*extensionBatches)
+var linkedBatchesMutex sync.Mutex
+
+func newExtensionBatches() *extensionBatches {
+	return &extensionBatches{batches: make(map[string]*extensionBatch)}
+}
+
+// link makes the batches available to the contexts that use a handler list.
+func (b *extensionBatches) link(handlers *[]ExtensionHandler) {
+	linkedBatchesMutex.Lock()
+	defer linkedBatchesMutex.Unlock()
+	linkedBatches[handlers] = b
+	b.mutex.Lock()
+	b.handlers = append(b.handlers, handlers)
+	b.mutex.Unlock()
+}
+
+// unlink removes the batches and their handler lists.
+func (b *extensionBatches) unlink() {
+	linkedBatchesMutex.Lock()
+	defer linkedBatchesMutex.Unlock()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, handlers := range b.handlers {
+		delete(linkedBatches, handlers)
+	}
+	b.handlers = nil
+	b.batches = make(map[string]*extensionBatch)
+}
+
+// batchesForHandlers returns the batches of the session that created a handler
+// list, or nil if it wasn't created by a session that is still open.
+func batchesForHandlers(handlers *[]ExtensionHandler) *extensionBatches {
+	linkedBatchesMutex.Lock()
+	defer linkedBatchesMutex.Unlock()
+	return linkedBatches[handlers]
+}
+
+func (b *extensionBatches) batch(handlerName string) *extensionBatch {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.batches[handlerName]
+}
+
+func (b *extensionBatches) setBatch(handlerName string, batch *extensionBatch) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.batches[handlerName] = batch
+}
+
+// RunExtensionHandlers runs each handler binary once with all of the extension
+// values of a document, so that compiling the document with the session's
+// contexts doesn't run a binary for every value. Handlers with registered
+// processors aren't run. Handlers that don't support batches are run for single
+// values when the document is compiled.
+func (s *Session) RunExtensionHandlers(info *yaml.Node, handlers []ExtensionHandler) {
+	values := findExtensionValues(info)
+	if len(values) == 0 {
+		return
+	}
+	requests := make([]*extensions.ExtensionHandlerRequest, len(values))
+	for i, value := range values {
+		requests[i] = newExtensionHandlerRequest(value.node, value.name)
+	}
+	for _, handler := range handlers {
+		if handler.Name == "" || ExtensionProcessorForHandler(handler.Name) != nil {
+			continue
+		}
+		previous := s.batches.batch(handler.Name)
+		if previous != nil && previous.missing {
+			continue
+		}
+		responses, err := runExtensionHandlerBatch(handler.Name, requests)
+		batch := &extensionBatch{responses: make(map[extensionValue]*extensions.ExtensionHandlerResponse)}
+		if _, ok := err.(*exec.Error); ok {
+			batch.missing = true
+		} else if err != nil {
+			continue
+		} else {
+			if previous != nil {
+				for value, response := range previous.responses {
+					batch.responses[value] = response
+				}
+			}
+			for i, value := range values {
+				batch.responses[value] = responses[i]
+			}
+		}
+		s.batches.setBatch(handler.Name, batch)
+	}
+}
+
+// findExtensionValues returns the values of the x- keys in the mappings of a
+// document. The nodes are the ones that the compilers pass to CallExtension.
+func findExtensionValues(node *yaml.Node) []extensionValue {
+	values := make([]extensionValue, 0)
+	var find func(node *yaml.Node)
+	find = func(node *yaml.Node) {
+		if node == nil {
+			return
+		}
+		switch node.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, child := range node.Content {
+				find(child)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if key := node.Content[i].Value; strings.HasPrefix(key, "x-") {
+					values = append(values, extensionValue{node: node.Content[i+1], name: key})
+				}
+				find(node.Content[i+1])
+			}
+		}
+	}
+	find(node)
+	return values
+}
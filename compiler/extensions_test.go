@@ -0,0 +1,192 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	yaml "gopkg.in/yaml.v3"
+
+	extensions "github.com/google/gnostic/extensions"
+)
+
+// TestMain runs the test binary as an extension handler when it is called by
+// the scripts that testExtensionHandler writes.
+func TestMain(m *testing.M) {
+	if os.Getenv("GNOSTIC_TEST_EXTENSION_HANDLER") != "" {
+		if os.Getenv("GNOSTIC_TEST_EXTENSION_HANDLER") == "single" && len(os.Args) > 1 && os.Args[1] == "--batch" {
+			os.Exit(1)
+		}
+		extensions.Main(func(name string, yamlInput string) (bool, proto.Message, error) {
+			var node yaml.Node
+			if err := yaml.Unmarshal([]byte(yamlInput), &node); err != nil {
+				return true, nil, err
+			}
+			return handleTestExtension(name, node.Content[0])
+		})
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// handleTestExtension handles the x-test- extensions with string values.
+func handleTestExtension(name string, in *yaml.Node) (bool, proto.Message, error) {
+	if !strings.HasPrefix(name, "x-test-") {
+		return false, nil, nil
+	}
+	if in.Kind != yaml.ScalarNode {
+		return true, nil, errors.New("value is not a string")
+	}
+	return true, wrapperspb.String(strings.ToUpper(in.Value)), nil
+}
+
+// testExtensionHandler writes a script that runs the test binary as an extension
+// handler in a mode and logs its arguments, and returns its path and log file.
+func testExtensionHandler(t *testing.T, mode string) (string, string) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	dir, err := ioutil.TempDir("", "gnostic-extensions")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	handler := filepath.Join(dir, "gnostic-x-test")
+	log := filepath.Join(dir, "calls")
+	script := fmt.Sprintf("#!/bin/sh\necho \"call $@\" >> %s\nGNOSTIC_TEST_EXTENSION_HANDLER=%s exec %s \"$@\"\n", log, mode, executable)
+	if err := ioutil.WriteFile(handler, []byte(script), 0755); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return handler, log
+}
+
+const testExtensionsDocument = `
+x-test-one: one
+paths:
+  /a:
+    x-test-two: two
+    x-other: 1
+  /b:
+    - x-test-three: three
+`
+
+// callTestExtensions calls CallExtension for the extension values of a document
+// and returns the handled values.
+func callTestExtensions(t *testing.T, context *Context, info *yaml.Node) map[string]string {
+	results := make(map[string]string)
+	for _, value := range findExtensionValues(info) {
+		handled, response, err := CallExtension(context, value.node, value.name)
+		if err != nil {
+			t.Fatalf("%s: %+v", value.name, err)
+		}
+		if !handled {
+			continue
+		}
+		message := &wrapperspb.StringValue{}
+		if err := response.UnmarshalTo(message); err != nil {
+			t.Fatalf("%s: %+v", value.name, err)
+		}
+		results[value.name] = message.Value
+	}
+	return results
+}
+
+var testExtensionResults = map[string]string{"x-test-one": "ONE", "x-test-two": "TWO", "x-test-three": "THREE"}
+
+func checkTestExtensionResults(t *testing.T, results map[string]string) {
+	if len(results) != len(testExtensionResults) {
+		t.Errorf("unexpected results %v", results)
+	}
+	for name, value := range testExtensionResults {
+		if results[name] != value {
+			t.Errorf("%s: expected %q, found %q", name, value, results[name])
+		}
+	}
+}
+
+// readCalls returns the calls that a test handler logged.
+func readCalls(t *testing.T, log string) []string {
+	bytes, err := ioutil.ReadFile(log)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(bytes)), "\n")
+}
+
+func TestExtensionProcessor(t *testing.T) {
+	RegisterExtensionProcessor("gnostic-x-test-processor", ExtensionProcessorFunc(handleTestExtension))
+	defer RegisterExtensionProcessor("gnostic-x-test-processor", nil)
+	info := parseNode(t, testExtensionsDocument)
+	handlers := []ExtensionHandler{{Name: "gnostic-x-test-processor"}}
+	context := NewContextWithExtensions("$root", info, nil, &handlers)
+	checkTestExtensionResults(t, callTestExtensions(t, context, info))
+	// Errors of processors are returned.
+	handled, _, err := CallExtension(context, parseNode(t, "[1]"), "x-test-list")
+	if !handled || err == nil || !strings.Contains(err.Error(), "value is not a string") {
+		t.Errorf("unexpected result %v %v", handled, err)
+	}
+}
+
+func TestExtensionHandlerBatch(t *testing.T) {
+	handler, log := testExtensionHandler(t, "batch")
+	defer os.RemoveAll(filepath.Dir(handler))
+	info := parseNode(t, testExtensionsDocument)
+	s := NewSession(nil, nil)
+	defer s.Close()
+	handlers := []ExtensionHandler{{Name: handler}}
+	s.RunExtensionHandlers(info, handlers)
+	checkTestExtensionResults(t, callTestExtensions(t, s.NewContext("$root", info, &handlers), info))
+	// The handler was run once for all of the values.
+	if calls := readCalls(t, log); len(calls) != 1 || calls[0] != "call --batch" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
+
+func TestExtensionHandlerWithoutBatches(t *testing.T) {
+	handler, log := testExtensionHandler(t, "single")
+	defer os.RemoveAll(filepath.Dir(handler))
+	info := parseNode(t, testExtensionsDocument)
+	s := NewSession(nil, nil)
+	defer s.Close()
+	handlers := []ExtensionHandler{{Name: handler}}
+	s.RunExtensionHandlers(info, handlers)
+	checkTestExtensionResults(t, callTestExtensions(t, s.NewContext("$root", info, &handlers), info))
+	// The batch failed, so the handler was run for each value.
+	if calls := readCalls(t, log); len(calls) != 5 || calls[0] != "call --batch" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
+
+func TestMissingExtensionHandler(t *testing.T) {
+	info := parseNode(t, testExtensionsDocument)
+	s := NewSession(nil, nil)
+	defer s.Close()
+	handlers := []ExtensionHandler{{Name: "gnostic-x-missing-handler"}}
+	s.RunExtensionHandlers(info, handlers)
+	if batch := s.batches.batch("gnostic-x-missing-handler"); batch == nil || !batch.missing {
+		t.Errorf("expected the handler to be missing")
+	}
+	if results := callTestExtensions(t, s.NewContext("$root", info, &handlers), info); len(results) != 0 {
+		t.Errorf("unexpected results %v", results)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// A Fetcher gets the remote documents that are read by the compiler.
+type Fetcher interface {
+	Fetch(fileurl string) ([]byte, error)
+}
+
+// FetcherFunc adapts a function to the Fetcher interface.
+type FetcherFunc func(fileurl string) ([]byte, error)
+
+// Fetch calls f(fileurl).
+func (f FetcherFunc) Fetch(fileurl string) ([]byte, error) {
+	return f(fileurl)
+}
+
+// NotFoundError is returned by fetchers that don't have a local copy of a document.
+type NotFoundError struct {
+	URL string
+}
+
+func (e *NotFoundError) Error() string {
+	return "no local copy of " + e.URL
+}
+
+// Fetchers tries a list of fetchers in order. Fetchers that return a
+// NotFoundError are skipped, and if none of them have the document,
+// the last NotFoundError is returned.
+type Fetchers []Fetcher
+
+// Fetch gets a document from the first fetcher that has it.
+func (fetchers Fetchers) Fetch(fileurl string) ([]byte, error) {
+	for _, fetcher := range fetchers {
+		bytes, err := fetcher.Fetch(fileurl)
+		if _, ok := err.(*NotFoundError); ok {
+			continue
+		}
+		return bytes, err
+	}
+	return nil, &NotFoundError{URL: fileurl}
+}
+
+// Catalog maps URLs to local files, like an XML catalog. Entries that end
+// with "/" map all of the URLs that begin with them to files in a directory.
+type Catalog struct {
+	entries map[string]string
+	// prefixes are the entries that end with "/", longest first.
+	prefixes []string
+}
+
+// NewCatalog returns a catalog with the specified URL to filename mappings.
+func NewCatalog(entries map[string]string) *Catalog {
+	c := &Catalog{entries: entries, prefixes: make([]string, 0)}
+	for key := range entries {
+		if strings.HasSuffix(key, "/") {
+			c.prefixes = append(c.prefixes, key)
+		}
+	}
+	sort.Slice(c.prefixes, func(i, j int) bool {
+		return len(c.prefixes[i]) > len(c.prefixes[j])
+	})
+	return c
+}
+
+// ReadCatalog reads a catalog from a YAML or JSON file that maps URLs to
+// filenames. Relative filenames are relative to the catalog file.
+func ReadCatalog(filename string) (*Catalog, error) {
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]string
+	if err := yaml.Unmarshal(bytes, &entries); err != nil {
+		return nil, fmt.Errorf("invalid catalog %s: %s", filename, err.Error())
+	}
+	dir := filepath.Dir(filename)
+	for key, value := range entries {
+		if !filepath.IsAbs(value) {
+			entries[key] = filepath.Join(dir, value)
+		}
+	}
+	return NewCatalog(entries), nil
+}
+
+// Filename returns the local file for a URL. It returns a NotFoundError for
+// URLs that aren't in the catalog and an error for URLs that map to files
+// outside of the directory of their prefix.
+func (c *Catalog) Filename(fileurl string) (string, error) {
+	if filename, ok := c.entries[fileurl]; ok {
+		return filename, nil
+	}
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(fileurl, prefix) {
+			return joinUnder(c.entries[prefix], fileurl, filepath.FromSlash(fileurl[len(prefix):]))
+		}
+	}
+	return "", &NotFoundError{URL: fileurl}
+}
+
+// Fetch reads the local file for a URL.
+func (c *Catalog) Fetch(fileurl string) ([]byte, error) {
+	filename, err := c.Filename(fileurl)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// joinUnder joins a path to a directory and returns an error if the result
+// is outside of the directory, as it is when the path has ".." segments
+// that climb above it.
+func joinUnder(directory, fileurl string, elem ...string) (string, error) {
+	filename := filepath.Join(append([]string{directory}, elem...)...)
+	rel, err := filepath.Rel(filepath.Clean(directory), filename)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s maps to a file outside of %s", fileurl, directory)
+	}
+	return filename, nil
+}
+
+// VendorFetcher reads documents from a directory of vendored copies.
+// The copy of a URL is stored at DIRECTORY/HOST/PATH, so the copy of
+// https://example.com/schemas/pet.yaml is DIRECTORY/example.com/schemas/pet.yaml.
+type VendorFetcher struct {
+	Directory string
+}
+
+// Fetch reads the vendored copy of a URL.
+func (v *VendorFetcher) Fetch(fileurl string) ([]byte, error) {
+	u, err := url.Parse(fileurl)
+	if err != nil || u.Host == "" {
+		return nil, &NotFoundError{URL: fileurl}
+	}
+	filename, err := joinUnder(v.Directory, fileurl, u.Host, filepath.FromSlash(u.Path))
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, &NotFoundError{URL: fileurl}
+	}
+	return bytes, err
+}
+
+// HTTPFetcher gets documents with HTTP GET requests.
+type HTTPFetcher struct {
+	// Headers maps URL prefixes to headers that are added to requests for
+	// the URLs that begin with them. A prefix matches a URL only where the
+	// prefix ends with "/" or is followed by "/" in the URL, so the headers
+	// for https://example.com are not sent to https://example.com.evil.org.
+	// Headers are never sent to other URLs, including the targets of redirects.
+	Headers map[string]http.Header
+	// Client is used to make requests. If it is nil, requests are made with
+	// a client that uses http.DefaultTransport.
+	Client *http.Client
+	// MaxBytes is the maximum size of a document. If it is zero, sizes are unlimited.
+	MaxBytes int64
+}
+
+// hasURLPrefix returns true if a URL begins with a prefix at a path boundary.
+func hasURLPrefix(fileurl, prefix string) bool {
+	if !strings.HasPrefix(fileurl, prefix) {
+		return false
+	}
+	return len(fileurl) == len(prefix) || strings.HasSuffix(prefix, "/") || fileurl[len(prefix)] == '/'
+}
+
+// setHeaders replaces the scoped headers of a request with the ones for its URL.
+func (h *HTTPFetcher) setHeaders(request *http.Request) {
+	for _, header := range h.Headers {
+		for name := range header {
+			request.Header.Del(name)
+		}
+	}
+	for prefix, header := range h.Headers {
+		if !hasURLPrefix(request.URL.String(), prefix) {
+			continue
+		}
+		for name, values := range header {
+			for _, value := range values {
+				request.Header.Add(name, value)
+			}
+		}
+	}
+}
+
+// Fetch gets a document from its URL.
+func (h *HTTPFetcher) Fetch(fileurl string) ([]byte, error) {
+	request, err := http.NewRequest("GET", fileurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	h.setHeaders(request)
+	client := &http.Client{Transport: http.DefaultTransport}
+	if h.Client != nil {
+		c := *h.Client
+		client = &c
+	}
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		// Redirected requests copy the headers of the original request.
+		h.setHeaders(request)
+		if checkRedirect != nil {
+			return checkRedirect(request, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("Error downloading %s: %s", fileurl, response.Status)
+	}
+	if h.MaxBytes <= 0 {
+		return ioutil.ReadAll(response.Body)
+	}
+	bytes, err := ioutil.ReadAll(io.LimitReader(response.Body, h.MaxBytes+1))
+	if err == nil && int64(len(bytes)) > h.MaxBytes {
+		return nil, &LimitError{Limit: LimitBytes, Max: h.MaxBytes, Location: fileurl}
+	}
+	return bytes, err
+}
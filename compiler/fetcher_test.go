@@ -0,0 +1,175 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const petURL = "https://example.com/schemas/pet.yaml"
+
+func TestCatalog(t *testing.T) {
+	catalog, err := ReadCatalog("../testdata/fetcher/catalog.yaml")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	filename, err := catalog.Filename(petURL)
+	if err != nil || filename != filepath.FromSlash("../testdata/fetcher/vendor/example.com/schemas/pet.yaml") {
+		t.Errorf("unexpected filename %s", filename)
+	}
+	if bytes, err := catalog.Fetch(petURL); err != nil || !strings.Contains(string(bytes), "Pets:") {
+		t.Errorf("unexpected result %s %+v", bytes, err)
+	}
+	if _, err := catalog.Fetch("https://example.org/pet.yaml"); err == nil {
+		t.Errorf("expected an error for an unmapped URL")
+	} else if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("unexpected error %+v", err)
+	}
+
+	// Exact entries take precedence over prefixes.
+	catalog = NewCatalog(map[string]string{
+		"https://example.com/":         "a",
+		"https://example.com/schemas/": "b",
+		petURL:                         "c.yaml",
+	})
+	for url, expected := range map[string]string{
+		petURL:                               "c.yaml",
+		"https://example.com/schemas/x.yaml": filepath.Join("b", "x.yaml"),
+		"https://example.com/y/z.yaml":       filepath.Join("a", "y", "z.yaml"),
+	} {
+		if filename, _ := catalog.Filename(url); filename != expected {
+			t.Errorf("%s: expected %s, found %s", url, expected, filename)
+		}
+	}
+
+	// URLs can't map to files outside of the directories of their prefixes.
+	for _, url := range []string{
+		"https://example.com/../../etc/passwd",
+		"https://example.com/schemas/../../outside.yaml",
+	} {
+		if _, err := catalog.Filename(url); err == nil || !strings.Contains(err.Error(), "outside of") {
+			t.Errorf("%s: unexpected error %+v", url, err)
+		}
+	}
+	if filename, err := catalog.Filename("https://example.com/schemas/x/../y.yaml"); err != nil || filename != filepath.Join("b", "y.yaml") {
+		t.Errorf("unexpected result %s %+v", filename, err)
+	}
+}
+
+func TestVendorFetcher(t *testing.T) {
+	vendor := &VendorFetcher{Directory: "../testdata/fetcher/vendor"}
+	if bytes, err := vendor.Fetch(petURL); err != nil || !strings.Contains(string(bytes), "Pets:") {
+		t.Errorf("unexpected result %s %+v", bytes, err)
+	}
+	if _, err := vendor.Fetch("https://example.com/missing.yaml"); err == nil {
+		t.Errorf("expected an error for a missing file")
+	} else if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("unexpected error %+v", err)
+	}
+
+	// URLs can't map to files outside of the vendored directory.
+	for _, url := range []string{
+		"https://example.com/../../catalog.yaml",
+		"https://example.com/%2e%2e/%2e%2e/catalog.yaml",
+		"https://../catalog.yaml",
+	} {
+		if _, err := vendor.Fetch(url); err == nil || !strings.Contains(err.Error(), "outside of") {
+			t.Errorf("%s: unexpected error %+v", url, err)
+		}
+	}
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(other.URL+"/pet.yaml", http.StatusFound))
+	defer redirect.Close()
+
+	fetcher := &HTTPFetcher{Headers: map[string]http.Header{
+		server.URL:   {"Authorization": []string{"Bearer token"}},
+		redirect.URL: {"Authorization": []string{"Bearer token"}},
+	}}
+	if bytes, err := fetcher.Fetch(server.URL + "/pet.yaml"); err != nil || string(bytes) != "ok" {
+		t.Errorf("unexpected result %s %+v", bytes, err)
+	}
+	if _, err := (&HTTPFetcher{}).Fetch(server.URL); err == nil {
+		t.Errorf("expected an error for an unauthorized request")
+	}
+	// Headers are only sent to the URLs that they are scoped to.
+	if _, err := fetcher.Fetch(other.URL + "/pet.yaml"); err == nil {
+		t.Errorf("expected an error for a request to another server")
+	}
+	if _, err := fetcher.Fetch(redirect.URL + "/pet.yaml"); err == nil {
+		t.Errorf("expected an error for a redirect to another server")
+	}
+	for prefix, matches := range map[string]bool{
+		"https://example.com":         true,
+		"https://example.com/":        true,
+		"https://example.com/schemas": true,
+		"https://example.com/schema":  false,
+		"https://example.co":          false,
+	} {
+		if hasURLPrefix(petURL, prefix) != matches {
+			t.Errorf("%s: expected %t", prefix, matches)
+		}
+	}
+	if hasURLPrefix("https://example.com.evil.org/pet.yaml", "https://example.com") {
+		t.Errorf("unexpected match for another host")
+	}
+}
+
+func TestSessionFetcher(t *testing.T) {
+	session := NewSession(Fetchers{
+		&VendorFetcher{Directory: "../testdata/fetcher/vendor"},
+		FetcherFunc(func(fileurl string) ([]byte, error) {
+			return []byte("type: string"), nil
+		}),
+	}, nil)
+	defer session.Close()
+
+	// Remote references are read with the session's fetcher.
+	info, err := session.ReadInfoForRef("", petURL+"#/Pet")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if MapValueForKey(info, "required") == nil {
+		t.Errorf("unexpected result %+v", info)
+	}
+	bytes, err := session.ReadBytesForFile("https://example.org/string.yaml")
+	if err != nil || string(bytes) != "type: string" {
+		t.Errorf("unexpected result %s %+v", bytes, err)
+	}
+
+	// Without a fallback, documents that aren't available locally are errors.
+	offline := NewSession(Fetchers{&VendorFetcher{Directory: "../testdata/fetcher/vendor"}}, nil)
+	defer offline.Close()
+	if _, err := offline.ReadBytesForFile("https://example.org/missing.yaml"); err == nil ||
+		!strings.Contains(err.Error(), "no local copy of https://example.org/missing.yaml") {
+		t.Errorf("unexpected error %+v", err)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Limits bound the resources that are used to compile a document, so that
+// hostile inputs can't exhaust memory or the stack. Zero values are unlimited.
+type Limits struct {
+	// MaxBytes is the maximum size of a document.
+	MaxBytes int64
+	// MaxDepth is the maximum nesting depth of a document, counted after aliases are expanded.
+	MaxDepth int
+	// MaxAliasExpansions is the maximum number of YAML aliases that are expanded when a
+	// document is read, counting aliases inside of expanded aliases.
+	MaxAliasExpansions int64
+	// MaxRefHops is the maximum number of $refs that can be followed to reach a value
+	// that isn't a $ref.
+	MaxRefHops int
+	// MaxFetches is the maximum number of remote documents that can be fetched.
+	MaxFetches int
+}
+
+// Names of limits in LimitErrors.
+const (
+	LimitBytes          = "bytes"
+	LimitDepth          = "depth"
+	LimitAliasExpansion = "alias expansions"
+	LimitRefHops        = "ref hops"
+	LimitFetches        = "fetches"
+)
+
+// LimitError is returned when a document exceeds one of its Limits.
+type LimitError struct {
+	// Limit is the name of the limit that was exceeded.
+	Limit string
+	// Max is the value of the limit.
+	Max int64
+	// Location describes where the limit was exceeded.
+	Location string
+}
+
+func (e *LimitError) Error() string {
+	message := fmt.Sprintf("%s limit of %d exceeded", e.Limit, e.Max)
+	if e.Location != "" {
+		message += " at " + e.Location
+	}
+	return message
+}
+
+// CheckSize returns an error if a document is larger than MaxBytes.
+func (l *Limits) CheckSize(filename string, size int64) error {
+	if l.MaxBytes > 0 && size > l.MaxBytes {
+		return &LimitError{Limit: LimitBytes, Max: l.MaxBytes, Location: filename}
+	}
+	return nil
+}
+
+// ReadInfoFromBytes checks the size of a document, unmarshals it, and checks
+// the depth and alias expansions of the result.
+func (l *Limits) ReadInfoFromBytes(filename string, bytes []byte) (*yaml.Node, error) {
+	if err := l.CheckSize(filename, int64(len(bytes))); err != nil {
+		return nil, err
+	}
+	info, err := ReadInfoFromBytes(filename, bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.CheckNode(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// nodeMeasure is the expanded size of a node.
+type nodeMeasure struct {
+	depth   int
+	aliases int64
+}
+
+// CheckNode returns an error if a node is nested more deeply than MaxDepth or
+// if expanding its aliases would expand more than MaxAliasExpansions aliases.
+// Aliases that contain themselves can't be expanded and always exceed MaxAliasExpansions.
+func (l *Limits) CheckNode(node *yaml.Node) error {
+	c := &nodeChecker{limits: l, measures: make(map[*yaml.Node]nodeMeasure), active: make(map[*yaml.Node]bool)}
+	_, err := c.measure(node, 1)
+	return err
+}
+
+type nodeChecker struct {
+	limits   *Limits
+	measures map[*yaml.Node]nodeMeasure
+	active   map[*yaml.Node]bool
+}
+
+// measure computes the expanded depth and alias count of a node at a given depth.
+// Measures are saved so that repeated aliases are only measured once.
+func (c *nodeChecker) measure(node *yaml.Node, depth int) (nodeMeasure, error) {
+	if c.limits.MaxDepth > 0 && depth > c.limits.MaxDepth {
+		return nodeMeasure{}, &LimitError{Limit: LimitDepth, Max: int64(c.limits.MaxDepth), Location: fmt.Sprintf("line %d", node.Line)}
+	}
+	if m, ok := c.measures[node]; ok {
+		if c.limits.MaxDepth > 0 && depth-1+m.depth > c.limits.MaxDepth {
+			return nodeMeasure{}, &LimitError{Limit: LimitDepth, Max: int64(c.limits.MaxDepth), Location: fmt.Sprintf("line %d", node.Line)}
+		}
+		return m, nil
+	}
+	if c.active[node] {
+		return nodeMeasure{}, &LimitError{Limit: LimitAliasExpansion, Max: c.limits.MaxAliasExpansions, Location: fmt.Sprintf("recursive alias at line %d", node.Line)}
+	}
+	c.active[node] = true
+	defer delete(c.active, node)
+
+	result := nodeMeasure{depth: 1}
+	children := node.Content
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		// An alias is replaced by the node it refers to, without adding a level of nesting.
+		m, err := c.measure(node.Alias, depth)
+		if err != nil {
+			return nodeMeasure{}, err
+		}
+		result = nodeMeasure{depth: m.depth, aliases: saturatingAdd(m.aliases, 1)}
+		children = nil
+	}
+	for _, child := range children {
+		m, err := c.measure(child, depth+1)
+		if err != nil {
+			return nodeMeasure{}, err
+		}
+		if m.depth+1 > result.depth {
+			result.depth = m.depth + 1
+		}
+		result.aliases = saturatingAdd(result.aliases, m.aliases)
+	}
+	if c.limits.MaxAliasExpansions > 0 && result.aliases > c.limits.MaxAliasExpansions {
+		return nodeMeasure{}, &LimitError{Limit: LimitAliasExpansion, Max: c.limits.MaxAliasExpansions, Location: fmt.Sprintf("line %d", node.Line)}
+	}
+	c.measures[node] = result
+	return result, nil
+}
+
+func saturatingAdd(a, b int64) int64 {
+	if a > (1<<63-1)-b {
+		return 1<<63 - 1
+	}
+	return a + b
+}
+
+// CheckRefs follows the $ref chains of a document and returns an error if reaching
+// a value that isn't a $ref takes more than MaxRefHops hops. References that can't
+// be resolved are ignored here and reported when the document is compiled.
+func (l *Limits) CheckRefs(filename string, root *yaml.Node) error {
+	return l.checkRefs(filename, root, ReadInfoForRef)
+}
+
+// checkRefs checks $ref chains, reading referenced documents with readInfoForRef.
+func (l *Limits) checkRefs(filename string, root *yaml.Node, readInfoForRef func(string, string) (*yaml.Node, error)) error {
+	if l.MaxRefHops <= 0 {
+		return nil
+	}
+	for root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	var err error
+	visited := make(map[*yaml.Node]bool)
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if err != nil || visited[node] {
+			return
+		}
+		visited[node] = true
+		if ref := refValue(node); ref != "" {
+			err = l.followRefs(filename, root, ref, readInfoForRef)
+			return
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(root)
+	return err
+}
+
+// refValue returns the value of the $ref of a mapping node.
+func refValue(node *yaml.Node) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "$ref" {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+func (l *Limits) followRefs(filename string, root *yaml.Node, ref string, readInfoForRef func(string, string) (*yaml.Node, error)) error {
+	start := ref
+	base := filename
+	for hops := 1; ; hops++ {
+		if hops > l.MaxRefHops {
+			return &LimitError{Limit: LimitRefHops, Max: int64(l.MaxRefHops), Location: start}
+		}
+		var target *yaml.Node
+		parts := strings.SplitN(ref, "#", 2)
+		if parts[0] == "" && root != nil {
+			if len(parts) == 2 {
+				target = nodeAtPointer(root, parts[1])
+			}
+		} else {
+			info, err := readInfoForRef(base, ref)
+			if err != nil {
+				return nil
+			}
+			target = info
+			if parts[0] != "" {
+				base = refFilename(base, parts[0])
+			}
+			// Local references in the target are resolved in its own document.
+			root = nil
+		}
+		if target == nil {
+			return nil
+		}
+		ref = refValue(target)
+		if ref == "" {
+			return nil
+		}
+	}
+}
+
+// refFilename returns the name of the file that a $ref in basefile refers to.
+// It matches the resolution of ReadInfoForRef.
+func refFilename(basefile string, filename string) string {
+	if _, err := url.ParseRequestURI(filename); err != nil {
+		basedir, _ := filepath.Split(basefile)
+		return basedir + filename
+	}
+	return filename
+}
+
+// nodeAtPointer returns the node at a JSON pointer in a document.
+func nodeAtPointer(node *yaml.Node, pointer string) *yaml.Node {
+	if pointer == "" || pointer == "/" {
+		return node
+	}
+	for _, key := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		key = strings.Replace(strings.Replace(key, "~1", "/", -1), "~0", "~", -1)
+		if node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					next = node.Content[i+1]
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// limitFetcher counts the documents that are fetched and checks their sizes.
+type limitFetcher struct {
+	limits  *Limits
+	fetcher Fetcher
+	mutex   sync.Mutex
+	fetches int
+}
+
+// Fetcher returns a fetcher that fails after MaxFetches documents have been fetched
+// and rejects documents that are larger than MaxBytes. HTTPFetchers should also have
+// their MaxBytes set so that large documents aren't read into memory.
+func (l *Limits) Fetcher(fetcher Fetcher) Fetcher {
+	return &limitFetcher{limits: l, fetcher: fetcher}
+}
+
+func (f *limitFetcher) Fetch(fileurl string) ([]byte, error) {
+	f.mutex.Lock()
+	f.fetches++
+	fetches := f.fetches
+	f.mutex.Unlock()
+	if f.limits.MaxFetches > 0 && fetches > f.limits.MaxFetches {
+		return nil, &LimitError{Limit: LimitFetches, Max: int64(f.limits.MaxFetches), Location: fileurl}
+	}
+	bytes, err := f.fetcher.Fetch(fileurl)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.limits.CheckSize(fileurl, int64(len(bytes))); err != nil {
+		return nil, err
+	}
+	return bytes, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// checkLimitError verifies that err is a LimitError for the specified limit.
+func checkLimitError(t *testing.T, name string, err error, limit string) {
+	if limit == "" {
+		if err != nil {
+			t.Errorf("%s: unexpected error %+v", name, err)
+		}
+		return
+	}
+	if e, ok := err.(*LimitError); !ok || e.Limit != limit {
+		t.Errorf("%s: expected a %s limit error, found %+v", name, limit, err)
+	}
+}
+
+func TestNodeLimits(t *testing.T) {
+	ClearCaches()
+	defer ClearCaches()
+	laughs, err := ioutil.ReadFile("../testdata/limits/billion-laughs.yaml")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	deep := strings.Repeat("[", 50) + strings.Repeat("]", 50)
+	recursive := "a: &a [*a]"
+	for _, test := range []struct {
+		name   string
+		limits Limits
+		text   string
+		limit  string
+	}{
+		{"billion laughs", Limits{MaxAliasExpansions: 1000}, string(laughs), LimitAliasExpansion},
+		{"billion laughs without limits", Limits{}, string(laughs), ""},
+		{"expanded depth", Limits{MaxDepth: 10}, string(laughs), LimitDepth},
+		{"allowed depth", Limits{MaxDepth: 12}, string(laughs), ""},
+		{"deep", Limits{MaxDepth: 40}, deep, LimitDepth},
+		{"size", Limits{MaxBytes: 100}, string(laughs), LimitBytes},
+		{"recursive alias", Limits{}, recursive, LimitAliasExpansion},
+	} {
+		_, err := test.limits.ReadInfoFromBytes("", []byte(test.text))
+		checkLimitError(t, test.name, err, test.limit)
+	}
+}
+
+func TestRefLimits(t *testing.T) {
+	ClearCaches()
+	defer ClearCaches()
+	text := `
+a: {$ref: "#/b"}
+b: {$ref: "#/c"}
+c: {$ref: "#/d/0"}
+d: [{type: string}]
+x: {$ref: "#/y"}
+y: {$ref: "#/x"}
+`
+	chain := text[:strings.Index(text, "x:")]
+	for _, test := range []struct {
+		name   string
+		limits Limits
+		text   string
+		limit  string
+	}{
+		{"long chain", Limits{MaxRefHops: 2}, chain, LimitRefHops},
+		{"allowed chain", Limits{MaxRefHops: 3}, chain, ""},
+		{"cycle", Limits{MaxRefHops: 100}, text, LimitRefHops},
+		{"unlimited", Limits{}, text, ""},
+	} {
+		info, err := ReadInfoFromBytes("", []byte(test.text))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		checkLimitError(t, test.name, test.limits.CheckRefs("", info), test.limit)
+	}
+}
+
+func TestFetchLimits(t *testing.T) {
+	limits := &Limits{MaxFetches: 2, MaxBytes: 4}
+	fetcher := limits.Fetcher(FetcherFunc(func(fileurl string) ([]byte, error) {
+		return []byte(strings.TrimPrefix(fileurl, "https://example.com/")), nil
+	}))
+	_, err := fetcher.Fetch("https://example.com/ok")
+	checkLimitError(t, "first fetch", err, "")
+	_, err = fetcher.Fetch("https://example.com/too-large")
+	checkLimitError(t, "large fetch", err, LimitBytes)
+	_, err = fetcher.Fetch("https://example.com/ok")
+	checkLimitError(t, "third fetch", err, LimitFetches)
+}
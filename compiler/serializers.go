@@ -0,0 +1,165 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// An ExtensionSerializer writes the messages of handled extension values as yaml.
+// Registered ExtensionProcessors that are also ExtensionSerializers write the
+// values of the extensions that they handle. It returns false for the extensions
+// that it doesn't write.
+type ExtensionSerializer interface {
+	SerializeExtension(extensionName string, message proto.Message) (handled bool, node *yaml.Node, err error)
+}
+
+// ToRawInfo returns the yaml of a compiled document, like the document's own
+// ToRawInfo method, with the messages of its handled extension values written
+// in their yaml form. Values that are unchanged keep the yaml that they were
+// read from. Messages are written by the serializers of handlers, by their own
+// ToRawInfo methods, or as scalars if they are wrappers. The values of other
+// messages, and of messages with types that aren't linked into the program,
+// are written as they were read. The document isn't changed. Errors from
+// serializers are returned with the yaml, in which those values are written
+// as they were read.
+func ToRawInfo(document proto.Message, handlers []ExtensionHandler) (*yaml.Node, error) {
+	document = proto.Clone(document)
+	m, ok := document.(interface{ ToRawInfo() *yaml.Node })
+	if !ok {
+		return nil, fmt.Errorf("unable to write %T as yaml", document)
+	}
+	errs := make([]error, 0)
+	forEachExtensionValue(document.ProtoReflect(), func(name string, value protoreflect.Message) {
+		fields := value.Descriptor().Fields()
+		valueField, yamlField := fields.ByName("value"), fields.ByName("yaml")
+		if valueField == nil || yamlField == nil || !value.Has(valueField) {
+			return
+		}
+		packed, ok := value.Get(valueField).Message().Interface().(*anypb.Any)
+		if !ok {
+			return
+		}
+		node, err := serializeExtension(name, packed, handlers)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to write %s: %v", name, err))
+			return
+		}
+		if node == nil {
+			return
+		}
+		original := value.Get(yamlField).String()
+		if equivalentYAML(original, node) {
+			return
+		}
+		bytes, err := yaml.Marshal(node)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to write %s: %v", name, err))
+			return
+		}
+		value.Set(yamlField, protoreflect.ValueOfString(string(bytes)))
+	})
+	return m.ToRawInfo(), NewErrorGroupOrNil(errs)
+}
+
+// serializeExtension returns the yaml of a handled extension value, or nil if
+// it can't be written.
+func serializeExtension(name string, packed *anypb.Any, handlers []ExtensionHandler) (*yaml.Node, error) {
+	message, err := packed.UnmarshalNew()
+	if err != nil {
+		return nil, nil
+	}
+	for _, handler := range handlers {
+		if serializer, ok := ExtensionProcessorForHandler(handler.Name).(ExtensionSerializer); ok {
+			handled, node, err := serializer.SerializeExtension(name, message)
+			if err != nil || handled {
+				return node, err
+			}
+		}
+	}
+	if m, ok := message.(interface{ ToRawInfo() *yaml.Node }); ok {
+		return m.ToRawInfo(), nil
+	}
+	switch m := message.(type) {
+	case *wrapperspb.StringValue:
+		return NewScalarNodeForString(m.Value), nil
+	case *wrapperspb.Int64Value:
+		return NewScalarNodeForInt(m.Value), nil
+	case *wrapperspb.DoubleValue:
+		return NewScalarNodeForFloat(m.Value), nil
+	case *wrapperspb.BoolValue:
+		return NewScalarNodeForBool(m.Value), nil
+	}
+	return nil, nil
+}
+
+// equivalentYAML returns true if yaml text and a node have the same values.
+func equivalentYAML(text string, node *yaml.Node) bool {
+	if text == "" {
+		return false
+	}
+	var original, serialized interface{}
+	if err := yaml.Unmarshal([]byte(text), &original); err != nil {
+		return false
+	}
+	if err := node.Decode(&serialized); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(original, serialized)
+}
+
+// forEachExtensionValue calls f with the name and Any message of every extension
+// value in the specification_extension and vendor_extension fields of a message.
+func forEachExtensionValue(m protoreflect.Message, f func(name string, value protoreflect.Message)) {
+	m.Range(func(field protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if field.Message() == nil {
+			return true
+		}
+		switch {
+		case field.IsMap():
+			if field.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					forEachExtensionValue(v.Message(), f)
+					return true
+				})
+			}
+		case field.IsList():
+			list := v.List()
+			isExtension := field.Name() == "specification_extension" || field.Name() == "vendor_extension"
+			for i := 0; i < list.Len(); i++ {
+				item := list.Get(i).Message()
+				if !isExtension {
+					forEachExtensionValue(item, f)
+					continue
+				}
+				fields := item.Descriptor().Fields()
+				nameField, valueField := fields.ByName("name"), fields.ByName("value")
+				if nameField != nil && valueField != nil && item.Has(valueField) {
+					f(item.Get(nameField).String(), item.Get(valueField).Message())
+				}
+			}
+		default:
+			forEachExtensionValue(v.Message(), f)
+		}
+		return true
+	})
+}
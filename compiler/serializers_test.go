@@ -0,0 +1,149 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/google/gnostic/compiler"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+// pointHandler reads and writes x-point values as structs.
+type pointHandler struct{}
+
+func (pointHandler) ProcessExtension(name string, in *yaml.Node) (bool, proto.Message, error) {
+	if name != "x-point" {
+		return false, nil, nil
+	}
+	var value map[string]interface{}
+	if err := in.Decode(&value); err != nil {
+		return true, nil, err
+	}
+	s, err := structpb.NewStruct(value)
+	return true, s, err
+}
+
+func (pointHandler) SerializeExtension(name string, message proto.Message) (bool, *yaml.Node, error) {
+	s, ok := message.(*structpb.Struct)
+	if name != "x-point" || !ok {
+		return false, nil, nil
+	}
+	var node yaml.Node
+	err := node.Encode(s.AsMap())
+	return true, &node, err
+}
+
+// nameHandler reads x-name values as strings.
+func nameHandler(name string, in *yaml.Node) (bool, proto.Message, error) {
+	if name != "x-name" {
+		return false, nil, nil
+	}
+	return true, wrapperspb.String(in.Value), nil
+}
+
+const serializerDocument = `openapi: 3.0.0
+info:
+  title: Points
+  version: 1.0.0
+paths: {}
+x-point: {y: 2, x: 1}
+x-name: origin
+x-other: [1, 2]
+`
+
+func compileSerializerDocument(t *testing.T, handlers []compiler.ExtensionHandler) *openapi_v3.Document {
+	var info yaml.Node
+	if err := yaml.Unmarshal([]byte(serializerDocument), &info); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	s := compiler.NewSession(nil, nil)
+	defer s.Close()
+	root := info.Content[0]
+	document, err := openapi_v3.NewDocument(root, s.NewContext("$root", root, &handlers))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return document
+}
+
+func documentYAML(t *testing.T, document *openapi_v3.Document, handlers []compiler.ExtensionHandler) string {
+	info, err := compiler.ToRawInfo(document, handlers)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	bytes, err := yaml.Marshal(info)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return string(bytes)
+}
+
+// setExtension replaces the message of an extension value.
+func setExtension(t *testing.T, document *openapi_v3.Document, name string, message proto.Message) {
+	for _, extension := range document.SpecificationExtension {
+		if extension.Name == name {
+			value, err := anypb.New(message)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			extension.Value.Value = value
+			return
+		}
+	}
+	t.Fatalf("missing extension %s", name)
+}
+
+func TestToRawInfoWithExtensions(t *testing.T) {
+	compiler.RegisterExtensionProcessor("gnostic-x-test-point", pointHandler{})
+	compiler.RegisterExtensionProcessor("gnostic-x-test-name", compiler.ExtensionProcessorFunc(nameHandler))
+	defer compiler.RegisterExtensionProcessor("gnostic-x-test-point", nil)
+	defer compiler.RegisterExtensionProcessor("gnostic-x-test-name", nil)
+	handlers := []compiler.ExtensionHandler{{Name: "gnostic-x-test-point"}, {Name: "gnostic-x-test-name"}}
+
+	// Unchanged values are written as they were read, with their keys in order.
+	document := compileSerializerDocument(t, handlers)
+	if output := documentYAML(t, document, handlers); !strings.Contains(output, "x-point:\n    y: 2\n    x: 1\n") {
+		t.Errorf("unexpected output\n%s", output)
+	}
+
+	// Changed values are written with serializers and as wrapped scalars.
+	document = compileSerializerDocument(t, handlers)
+	point, err := structpb.NewStruct(map[string]interface{}{"x": 3, "y": 4})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	setExtension(t, document, "x-point", point)
+	setExtension(t, document, "x-name", wrapperspb.String("destination"))
+	output := documentYAML(t, document, handlers)
+	for _, expected := range []string{"x-point:\n    x: 3\n    \"y\": 4\n", "x-name: destination\n", "x-other:\n    - 1\n    - 2\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("missing %q in output\n%s", expected, output)
+		}
+	}
+	// The document keeps the yaml that its values were read from.
+	for _, extension := range document.SpecificationExtension {
+		if extension.Name == "x-name" && extension.Value.Yaml != "origin\n" {
+			t.Errorf("unexpected yaml %q", extension.Value.Yaml)
+		}
+	}
+}
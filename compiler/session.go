@@ -0,0 +1,299 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// A Session holds the state of one compilation: the files and parsed documents
+// that it has read, the fetcher that gets remote documents, and the limits that
+// bound its resources. Sessions share nothing with each other, so concurrent
+// compilations in separate sessions are isolated. A Session is safe for
+// concurrent use.
+type Session struct {
+	limits  Limits
+	fetcher Fetcher
+	mutex   sync.Mutex
+	files   map[string][]byte
+	infos   map[string]*yaml.Node
+	batches *extensionBatches
+	// unlinker unlinks the batches when the session is collected. The session
+	// refers to itself through its fetcher, and finalizers don't run on cycles.
+	unlinker *batchUnlinker
+}
+
+type batchUnlinker struct {
+	batches *extensionBatches
+}
+
+// NewSession returns a session that gets remote documents with fetcher and
+// enforces limits. If fetcher is nil, remote documents are fetched with HTTP GET
+// requests, and if limits is nil, resources are unlimited.
+func NewSession(fetcher Fetcher, limits *Limits) *Session {
+	s := &Session{
+		files:   make(map[string][]byte),
+		infos:   make(map[string]*yaml.Node),
+		batches: newExtensionBatches(),
+	}
+	if limits != nil {
+		s.limits = *limits
+	}
+	if fetcher == nil {
+		fetcher = &HTTPFetcher{MaxBytes: s.limits.MaxBytes}
+	}
+	s.fetcher = s.limits.Fetcher(fetcher)
+	// Sessions that aren't closed release their batches when they are collected.
+	s.unlinker = &batchUnlinker{batches: s.batches}
+	runtime.SetFinalizer(s.unlinker, func(u *batchUnlinker) { u.batches.unlink() })
+	return s
+}
+
+// Limits returns the limits of the session.
+func (s *Session) Limits() *Limits {
+	return &s.limits
+}
+
+// NewContext returns a root context for compiling a document in the session.
+// The context and its descendants use the results of the session's extension
+// handler batches. They refer to a copy of extensionHandlers that the session
+// owns, and not to the session itself.
+func (s *Session) NewContext(name string, node *yaml.Node, extensionHandlers *[]ExtensionHandler) *Context {
+	if extensionHandlers != nil {
+		handlers := append([]ExtensionHandler(nil), *extensionHandlers...)
+		extensionHandlers = &handlers
+		s.batches.link(extensionHandlers)
+	}
+	return NewContextWithExtensions(name, node, nil, extensionHandlers)
+}
+
+// Close releases the state of the session. Its contexts no longer use the
+// results of its extension handler batches.
+func (s *Session) Close() {
+	s.batches.unlink()
+	s.mutex.Lock()
+	s.files = make(map[string][]byte)
+	s.infos = make(map[string]*yaml.Node)
+	s.mutex.Unlock()
+}
+
+// FetchFile gets a remote document with the session's fetcher.
+func (s *Session) FetchFile(fileurl string) ([]byte, error) {
+	s.mutex.Lock()
+	bytes, ok := s.files[fileurl]
+	s.mutex.Unlock()
+	if ok {
+		return bytes, nil
+	}
+	bytes, err := s.fetcher.Fetch(fileurl)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	s.files[fileurl] = bytes
+	s.mutex.Unlock()
+	return bytes, nil
+}
+
+// ReadBytesForFile reads the bytes of a local file or a remote document.
+func (s *Session) ReadBytesForFile(filename string) ([]byte, error) {
+	if fileurl, err := url.Parse(filename); err == nil && fileurl.Scheme != "" {
+		return s.FetchFile(filename)
+	}
+	s.mutex.Lock()
+	bytes, ok := s.files[filename]
+	s.mutex.Unlock()
+	if ok {
+		return bytes, nil
+	}
+	if fileInfo, err := os.Stat(filename); err == nil {
+		if err := s.limits.CheckSize(filename, fileInfo.Size()); err != nil {
+			return nil, err
+		}
+	}
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	s.files[filename] = bytes
+	s.mutex.Unlock()
+	return bytes, nil
+}
+
+// ReadInfoFromBytes unmarshals a document as a *yaml.Node and checks it against
+// the session's limits. Documents are cached by filename.
+func (s *Session) ReadInfoFromBytes(filename string, bytes []byte) (*yaml.Node, error) {
+	s.mutex.Lock()
+	info, ok := s.infos[filename]
+	s.mutex.Unlock()
+	if ok && filename != "" {
+		return info, nil
+	}
+	if err := s.limits.CheckSize(filename, int64(len(bytes))); err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(bytes, &node); err != nil {
+		return nil, err
+	}
+	if err := s.limits.CheckNode(&node); err != nil {
+		return nil, err
+	}
+	if filename != "" {
+		s.mutex.Lock()
+		s.infos[filename] = &node
+		s.mutex.Unlock()
+	}
+	return &node, nil
+}
+
+// ReadInfoForRef reads the document that a $ref in basefile refers to and
+// returns the node at the $ref's fragment.
+func (s *Session) ReadInfoForRef(basefile string, ref string) (*yaml.Node, error) {
+	parts := strings.SplitN(ref, "#", 2)
+	filename := basefile
+	if parts[0] != "" {
+		filename = refFilename(basefile, parts[0])
+	}
+	bytes, err := s.ReadBytesForFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.ReadInfoFromBytes(filename, bytes)
+	if err != nil {
+		return nil, err
+	}
+	for info.Kind == yaml.DocumentNode && len(info.Content) > 0 {
+		info = info.Content[0]
+	}
+	if len(parts) == 2 {
+		info = nodeAtPointer(info, parts[1])
+		if info == nil {
+			return nil, NewError(nil, fmt.Sprintf("could not resolve %s", ref))
+		}
+	}
+	return info, nil
+}
+
+// CheckRefs checks the $ref chains of a document against the session's limits,
+// reading referenced documents in the session.
+func (s *Session) CheckRefs(filename string, root *yaml.Node) error {
+	return s.limits.checkRefs(filename, root, s.ReadInfoForRef)
+}
+
+// globalMutex serializes the uses of the package-global caches by sessions.
+var globalMutex sync.Mutex
+
+// WithGlobalCaches calls f with exclusive use of the package-global caches. It is
+// for calling code such as the generated ResolveReferences methods, which read
+// documents with the package-global functions. Code that only needs the targets
+// of $refs should use ReadRefs, which needs no global state. Calls
+// are serialized across all sessions in the process, and the global caches are
+// cleared before and after f is called, so no state is shared with other sessions.
+// The package-global functions fetch remote documents with http.Get, so f should
+// call LoadGlobalCaches before it reads any.
+func (s *Session) WithGlobalCaches(f func() error) error {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+	ClearCaches()
+	defer ClearCaches()
+	return f()
+}
+
+// ReadRefs reads the targets of the $refs of a document, and of the documents
+// that it refers to, with the session. It returns them keyed like the entries of
+// the package-global info cache: by $ref, and by the names of the documents that
+// they are in. References to remote documents that the session can't read are
+// errors; other references that can't be resolved are left out.
+func (s *Session) ReadRefs(filename string, root *yaml.Node) (map[string]*yaml.Node, error) {
+	f := s.newCycleFinder(filename, root)
+	if err := f.find(); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(f.sites))
+	for key := range f.sites {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	refs := make(map[string]*yaml.Node)
+	for _, key := range keys {
+		site := f.sites[key]
+		parts := strings.SplitN(site.ref, "#", 2)
+		target := site.filename
+		if parts[0] != "" {
+			target = refFilename(site.filename, parts[0])
+		}
+		info, err := s.ReadInfoForRef(site.filename, site.ref)
+		if err != nil {
+			if fileurl, _ := url.Parse(target); fileurl != nil && fileurl.Scheme != "" {
+				return nil, err
+			}
+			continue
+		}
+		if _, ok := refs[site.ref]; !ok {
+			refs[site.ref] = info
+		}
+		s.mutex.Lock()
+		document := s.infos[target]
+		s.mutex.Unlock()
+		if _, ok := refs[target]; !ok && document != nil {
+			refs[target] = document
+		}
+	}
+	return refs, nil
+}
+
+// LoadGlobalCaches adds the targets of the $refs of a document that ReadRefs
+// reads to the package-global info cache, where the package-global functions find
+// them without fetching. It must be called from a function that is passed to
+// WithGlobalCaches.
+func (s *Session) LoadGlobalCaches(filename string, root *yaml.Node) error {
+	refs, err := s.ReadRefs(filename, root)
+	if err != nil {
+		return err
+	}
+	cache := GetInfoCache()
+	for key, info := range refs {
+		if _, ok := cache[key]; !ok {
+			cache[key] = info
+		}
+	}
+	return nil
+}
+
+// ResolveReferences calls the ResolveReferences method of a compiled document with
+// WithGlobalCaches, after loading the targets of the document's $refs with
+// LoadGlobalCaches. root is the document's node and filename its name.
+func (s *Session) ResolveReferences(resolve func(root string) (*yaml.Node, error), filename string, root *yaml.Node) (*yaml.Node, error) {
+	var info *yaml.Node
+	err := s.WithGlobalCaches(func() (err error) {
+		if err = s.LoadGlobalCaches(filename, root); err != nil {
+			return err
+		}
+		info, err = resolve(filename)
+		return err
+	})
+	return info, err
+}
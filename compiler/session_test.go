@@ -0,0 +1,159 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestSessionIsolation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Each file defines Pet differently, and they are read concurrently in separate sessions.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		filename := filepath.Join(dir, fmt.Sprintf("api%d.yaml", i))
+		text := fmt.Sprintf("definitions:\n  Pet:\n    description: pet %d\n", i)
+		if err := ioutil.WriteFile(filename, []byte(text), 0644); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		wg.Add(1)
+		go func(i int, filename string) {
+			defer wg.Done()
+			session := NewSession(nil, nil)
+			defer session.Close()
+			info, err := session.ReadInfoForRef(filename, "#/definitions/Pet")
+			if err != nil {
+				t.Errorf("%+v", err)
+				return
+			}
+			description, _ := StringForScalarNode(MapValueForKey(info, "description"))
+			if description != fmt.Sprintf("pet %d", i) {
+				t.Errorf("%s: unexpected description %s", filename, description)
+			}
+		}(i, filename)
+	}
+	wg.Wait()
+}
+
+func TestSessionContexts(t *testing.T) {
+	session := NewSession(nil, nil)
+	handlers := []ExtensionHandler{{Name: "gnostic-x-test"}}
+	root := session.NewContext("$root", nil, &handlers)
+	child := NewContext("child", nil, NewContext("parent", nil, root))
+	if batchesForHandlers(child.ExtensionHandlers) != session.batches {
+		t.Errorf("the session's batches were not found from a descendant context")
+	}
+	if batchesForHandlers(&handlers) != nil {
+		t.Errorf("unexpected batches for a handler list that the session doesn't own")
+	}
+	session.Close()
+	if batchesForHandlers(child.ExtensionHandlers) != nil {
+		t.Errorf("the session's batches were found after it was closed")
+	}
+}
+
+func TestSessionCollected(t *testing.T) {
+	handlers := []ExtensionHandler{{Name: "gnostic-x-test"}}
+	// The session is dropped without being closed, and its context is kept.
+	context := NewSession(nil, nil).NewContext("$root", nil, &handlers)
+	for i := 0; i < 100 && batchesForHandlers(context.ExtensionHandlers) != nil; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if batchesForHandlers(context.ExtensionHandlers) != nil {
+		t.Errorf("the batches of a session were kept after it was collected")
+	}
+}
+
+func TestSessionWithGlobalCaches(t *testing.T) {
+	session := NewSession(FetcherFunc(func(fileurl string) ([]byte, error) {
+		if fileurl != "https://example.com/pet.yaml" {
+			return nil, &NotFoundError{URL: fileurl}
+		}
+		return []byte("Pet: {type: object}"), nil
+	}), nil)
+	defer session.Close()
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte("pet: {$ref: 'https://example.com/pet.yaml#/Pet'}"), &root); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	transport := http.DefaultClient.Transport
+	err := session.WithGlobalCaches(func() error {
+		if err := session.LoadGlobalCaches("openapi.yaml", &root); err != nil {
+			return err
+		}
+		// The global functions find the documents that the session read.
+		info, err := ReadInfoForRef("openapi.yaml", "https://example.com/pet.yaml#/Pet")
+		if err != nil {
+			return err
+		}
+		if value, _ := StringForScalarNode(MapValueForKey(info, "type")); value != "object" {
+			return fmt.Errorf("unexpected value %s", value)
+		}
+		// The HTTP client of the process is never changed.
+		if http.DefaultClient.Transport != transport {
+			return fmt.Errorf("http.DefaultClient was modified")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("%+v", err)
+	}
+	if len(GetInfoCache()) != 0 {
+		t.Errorf("the global caches were not cleared")
+	}
+
+	// Remote documents that the session can't read are errors.
+	if err := yaml.Unmarshal([]byte("pet: {$ref: 'https://example.com/missing.yaml#/Pet'}"), &root); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	err = session.WithGlobalCaches(func() error {
+		return session.LoadGlobalCaches("openapi.yaml", &root)
+	})
+	if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("unexpected error %+v", err)
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	session := NewSession(FetcherFunc(func(fileurl string) ([]byte, error) {
+		return []byte("a: b"), nil
+	}), &Limits{MaxFetches: 1, MaxDepth: 1})
+	defer session.Close()
+	_, err := session.ReadBytesForFile("https://example.com/1.yaml")
+	checkLimitError(t, "first fetch", err, "")
+	// Cached documents aren't fetched again.
+	_, err = session.ReadBytesForFile("https://example.com/1.yaml")
+	checkLimitError(t, "cached fetch", err, "")
+	_, err = session.ReadBytesForFile("https://example.com/2.yaml")
+	checkLimitError(t, "second fetch", err, LimitFetches)
+	_, err = session.ReadInfoForRef("https://example.com/1.yaml", "#/a")
+	checkLimitError(t, "depth", err, LimitDepth)
+}
@@ -0,0 +1,251 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// A Mode selects how strictly documents are compiled.
+type Mode int
+
+const (
+	// ModeDefault reports the problems that the generated compilers find as errors
+	// and accepts everything else.
+	ModeDefault Mode = iota
+	// ModeStrict also reports duplicate mapping keys, misplaced x- extensions and
+	// values that are coerced to other types as errors.
+	ModeStrict
+	// ModeLenient reports structural problems as warnings, so that a document is
+	// still produced. These are unknown and misplaced properties, duplicate
+	// mapping keys and coerced values.
+	ModeLenient
+)
+
+// StructureError is a problem in the structure of a document.
+type StructureError struct {
+	Line    int
+	Column  int
+	Pointer string
+	Message string
+	// Code is the diagnostic code of the problem.
+	Code string
+}
+
+func (e *StructureError) Error() string {
+	return fmt.Sprintf("[%d,%d] #%s %s", e.Line, e.Column, e.Pointer, e.Message)
+}
+
+func newStructureError(node *yaml.Node, pointer string, code string, message string) *StructureError {
+	return &StructureError{Line: node.Line, Column: node.Column, Pointer: pointer, Message: message, Code: code}
+}
+
+// FindDuplicateKeys returns errors for the keys that are repeated in the
+// mappings of a document. The compilers only read one of their values.
+func FindDuplicateKeys(info *yaml.Node) []error {
+	errs := make([]error, 0)
+	walkNodes(info, "", func(node *yaml.Node, pointer string) {
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		keys := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if keys[key.Value] {
+				errs = append(errs, newStructureError(key, pointer, CodeDuplicateKey, fmt.Sprintf("has duplicate key: %s", key.Value)))
+			}
+			keys[key.Value] = true
+		}
+	})
+	return errs
+}
+
+// FindCoercions compares a document with the yaml form of its compiled model
+// and returns errors for the values that were converted to strings, such as
+// numbers and timestamps in string fields.
+func FindCoercions(info *yaml.Node, compiled *yaml.Node) []error {
+	errs := make([]error, 0)
+	var compare func(in, out *yaml.Node, pointer string)
+	compare = func(in, out *yaml.Node, pointer string) {
+		in, out = unwrapNode(in), unwrapNode(out)
+		if in == nil || out == nil || in.Kind != out.Kind {
+			return
+		}
+		switch in.Kind {
+		case yaml.ScalarNode:
+			tag := in.ShortTag()
+			if tag != "!!str" && tag != "!!null" && out.ShortTag() == "!!str" {
+				errs = append(errs, newStructureError(in, pointer, CodeCoercedValue, fmt.Sprintf("has %s value %s that was converted to a string", tagName(tag), in.Value)))
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(in.Content); i += 2 {
+				key := in.Content[i].Value
+				for j := 0; j+1 < len(out.Content); j += 2 {
+					if out.Content[j].Value == key {
+						compare(in.Content[i+1], out.Content[j+1], pointer+"/"+escapePointerToken(key))
+						break
+					}
+				}
+			}
+		case yaml.SequenceNode:
+			for i := 0; i < len(in.Content) && i < len(out.Content); i++ {
+				compare(in.Content[i], out.Content[i], pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+	compare(info, compiled, "")
+	return errs
+}
+
+// FindMisplacedExtensions returns errors for x- keys in mappings of names, where
+// the compilers read them as names instead of as extensions. nameMaps are the
+// JSON pointers of these mappings. Patterns that begin with "/" match whole
+// pointers and other patterns match their ends, and "*" matches any key.
+func FindMisplacedExtensions(info *yaml.Node, nameMaps []string) []error {
+	errs := make([]error, 0)
+	walkNodes(info, "", func(node *yaml.Node, pointer string) {
+		if node.Kind != yaml.MappingNode || !matchesPointer(pointer, nameMaps) {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if strings.HasPrefix(key.Value, "x-") {
+				errs = append(errs, newStructureError(key, pointer, CodeMisplacedExtension, fmt.Sprintf("has extension %s in a map of names", key.Value)))
+			}
+		}
+	})
+	return errs
+}
+
+func matchesPointer(pointer string, patterns []string) bool {
+	tokens := strings.Split(pointer, "/")
+	for _, pattern := range patterns {
+		patternTokens := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+		if strings.HasPrefix(pattern, "/") {
+			patternTokens = append([]string{""}, patternTokens...)
+			if len(patternTokens) != len(tokens) {
+				continue
+			}
+		} else if len(patternTokens) >= len(tokens) {
+			continue
+		}
+		if ok, _ := path.Match(strings.Join(patternTokens, "/"), strings.Join(tokens[len(tokens)-len(patternTokens):], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Classify sorts the errors that were returned when a document was compiled,
+// and the structure errors that were found in it, into warnings and errors.
+// Diagnostics with severities below SeverityError are always warnings.
+func (m Mode) Classify(err error, structure []error) ([]error, error) {
+	errs := make([]error, 0)
+	warnings := make([]error, 0)
+	for _, e := range flattenErrors(err) {
+		if d, ok := e.(*Diagnostic); ok && d.Severity < SeverityError {
+			warnings = append(warnings, e)
+		} else {
+			errs = append(errs, e)
+		}
+	}
+	switch m {
+	case ModeStrict:
+		errs = append(errs, structure...)
+	case ModeLenient:
+		remaining := make([]error, 0)
+		for _, e := range errs {
+			if isInvalidPropertyError(e) {
+				warnings = append(warnings, e)
+			} else {
+				remaining = append(remaining, e)
+			}
+		}
+		errs = remaining
+		warnings = append(warnings, structure...)
+	}
+	return warnings, NewErrorGroupOrNil(errs)
+}
+
+// isInvalidPropertyError returns true for the errors that the generated compilers
+// return for unknown properties, including x- extensions where they aren't allowed.
+func isInvalidPropertyError(err error) bool {
+	e, ok := err.(*Error)
+	return ok && (strings.HasPrefix(e.Message, "has invalid property: ") || strings.HasPrefix(e.Message, "has invalid properties: "))
+}
+
+func flattenErrors(err error) []error {
+	errs := make([]error, 0)
+	if group, ok := err.(*ErrorGroup); ok {
+		for _, e := range group.Errors {
+			errs = append(errs, flattenErrors(e)...)
+		}
+	} else if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// walkNodes calls f for every node of a document with its JSON pointer.
+func walkNodes(node *yaml.Node, pointer string, f func(node *yaml.Node, pointer string)) {
+	node = unwrapNode(node)
+	if node == nil {
+		return
+	}
+	f(node, pointer)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkNodes(node.Content[i+1], pointer+"/"+escapePointerToken(node.Content[i].Value), f)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkNodes(child, pointer+"/"+strconv.Itoa(i), f)
+		}
+	}
+}
+
+// unwrapNode returns the content of document nodes and the targets of aliases.
+func unwrapNode(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+			node = node.Content[0]
+		} else if node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		} else {
+			break
+		}
+	}
+	return node
+}
+
+func tagName(tag string) string {
+	switch tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!timestamp":
+		return "timestamp"
+	}
+	return strings.TrimPrefix(tag, "!!")
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func checkErrorMessages(t *testing.T, name string, errs []error, expected []string) {
+	if len(errs) != len(expected) {
+		t.Errorf("%s: expected %d errors, found %v", name, len(expected), errs)
+		return
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("%s: expected %q, found %q", name, expected[i], err.Error())
+		}
+	}
+}
+
+func parseNode(t *testing.T, text string) *yaml.Node {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &node); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return &node
+}
+
+func TestFindDuplicateKeys(t *testing.T) {
+	info := parseNode(t, `
+a: 1
+b:
+  c: 2
+  c: 3
+a: 4
+`)
+	checkErrorMessages(t, "duplicate keys", FindDuplicateKeys(info), []string{
+		"[6,1] # has duplicate key: a",
+		"[5,3] #/b has duplicate key: c",
+	})
+}
+
+func TestFindCoercions(t *testing.T) {
+	info := parseNode(t, `
+version: 1
+date: 2020-01-01
+minimum: 1
+name: ok
+items: [1, "2"]
+`)
+	compiled := parseNode(t, `
+version: "1"
+date: "2020-01-01"
+minimum: 1.0
+name: ok
+items: ["1", "2"]
+`)
+	checkErrorMessages(t, "coercions", FindCoercions(info, compiled), []string{
+		"[2,10] #/version has integer value 1 that was converted to a string",
+		"[3,7] #/date has timestamp value 2020-01-01 that was converted to a string",
+		"[6,9] #/items/0 has integer value 1 that was converted to a string",
+	})
+}
+
+func TestFindMisplacedExtensions(t *testing.T) {
+	info := parseNode(t, `
+x-top: 1
+components:
+  schemas:
+    x-internal: {}
+    Pet:
+      x-tag: 2
+      properties:
+        x-property: {}
+`)
+	checkErrorMessages(t, "extensions", FindMisplacedExtensions(info, []string{"/components/schemas", "properties"}), []string{
+		"[5,5] #/components/schemas has extension x-internal in a map of names",
+		"[9,9] #/components/schemas/Pet/properties has extension x-property in a map of names",
+	})
+}
+
+func TestClassify(t *testing.T) {
+	compileErr := NewErrorGroupOrNil([]error{
+		NewError(nil, "has invalid property: unknown"),
+		NewErrorGroupOrNil([]error{NewError(nil, "is missing required property: name"), NewError(nil, "has invalid properties: a, b")}),
+	})
+	structure := []error{&StructureError{Line: 1, Column: 2, Pointer: "/a", Message: "has duplicate key: b"}}
+	for _, test := range []struct {
+		mode     Mode
+		errors   int
+		warnings int
+	}{
+		{ModeDefault, 3, 0},
+		{ModeStrict, 4, 0},
+		{ModeLenient, 1, 3},
+	} {
+		warnings, err := test.mode.Classify(compileErr, structure)
+		if len(warnings) != test.warnings || len(flattenErrors(err)) != test.errors {
+			t.Errorf("mode %d: unexpected warnings %v and errors %v", test.mode, warnings, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2019 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversions
+
+import (
+	"testing"
+
+	discovery "github.com/google/gnostic/discovery"
+)
+
+const uploadDiscoveryDocument = `{
+  "kind": "discovery#restDescription",
+  "discoveryVersion": "v1",
+  "name": "storage",
+  "version": "v1",
+  "title": "Cloud Storage JSON API",
+  "rootUrl": "https://storage.googleapis.com/",
+  "servicePath": "storage/v1/",
+  "basePath": "/storage/v1/",
+  "batchPath": "batch/storage/v1",
+  "auth": {
+    "oauth2": {
+      "scopes": {
+        "https://www.googleapis.com/auth/devstorage.read_only": {
+          "description": "View your data in Google Cloud Storage"
+        },
+        "https://www.googleapis.com/auth/devstorage.read_write": {
+          "description": "Manage your data in Google Cloud Storage"
+        }
+      }
+    }
+  },
+  "schemas": {
+    "Object": {
+      "id": "Object",
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"}
+      }
+    }
+  },
+  "resources": {
+    "objects": {
+      "methods": {
+        "get": {
+          "id": "storage.objects.get",
+          "path": "b/{bucket}/o/{object}",
+          "httpMethod": "GET",
+          "parameters": {
+            "bucket": {"type": "string", "required": true, "location": "path"},
+            "object": {"type": "string", "required": true, "location": "path"}
+          },
+          "response": {"$ref": "Object"},
+          "scopes": ["https://www.googleapis.com/auth/devstorage.read_only"]
+        },
+        "insert": {
+          "id": "storage.objects.insert",
+          "path": "b/{bucket}/o",
+          "httpMethod": "POST",
+          "parameters": {
+            "bucket": {"type": "string", "required": true, "location": "path"}
+          },
+          "request": {"$ref": "Object"},
+          "response": {"$ref": "Object"},
+          "scopes": ["https://www.googleapis.com/auth/devstorage.read_write"],
+          "supportsMediaUpload": true,
+          "mediaUpload": {
+            "accept": ["*/*"],
+            "protocols": {
+              "simple": {"multipart": true, "path": "/upload/storage/v1/b/{bucket}/o"},
+              "resumable": {"multipart": true, "path": "/resumable/upload/storage/v1/b/{bucket}/o"}
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func readUploadDiscoveryDocument(t *testing.T) *discovery.Document {
+	d, err := discovery.ParseDocument([]byte(uploadDiscoveryDocument))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	return d
+}
+
+func TestOpenAPIv3SecurityUploadAndBatch(t *testing.T) {
+	d, err := OpenAPIv3(readUploadDiscoveryDocument(t))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	schemes := d.Components.SecuritySchemes.AdditionalProperties
+	if len(schemes) != 1 || schemes[0].Name != "Oauth2" {
+		t.Fatalf("unexpected security schemes: %+v", schemes)
+	}
+	scopes := schemes[0].Value.GetSecurityScheme().Flows.AuthorizationCode.Scopes.AdditionalProperties
+	if len(scopes) != 2 {
+		t.Errorf("unexpected number of scopes: %d (expected 2)", len(scopes))
+	}
+	paths := make(map[string]bool)
+	for _, item := range d.Paths.Path {
+		paths[item.Name] = true
+		if item.Name == "/b/{bucket}/o/{object}" {
+			security := item.Value.Get.Security
+			if len(security) != 1 || security[0].AdditionalProperties[0].Value.Value[0] != "https://www.googleapis.com/auth/devstorage.read_only" {
+				t.Errorf("unexpected security for %s: %+v", item.Name, security)
+			}
+		}
+		if item.Name == "/upload/storage/v1/b/{bucket}/o" {
+			if len(item.Value.Servers) != 1 || item.Value.Servers[0].Url != "https://storage.googleapis.com" {
+				t.Errorf("unexpected servers for %s: %+v", item.Name, item.Value.Servers)
+			}
+			operation := item.Value.Post
+			if operation.OperationId != "storage.objects.insert.upload" {
+				t.Errorf("unexpected operation id: %s", operation.OperationId)
+			}
+			content := operation.RequestBody.GetRequestBody().Content.AdditionalProperties
+			if len(content) != 2 || content[1].Name != "multipart/related" {
+				t.Fatalf("unexpected upload content: %+v", content)
+			}
+			metadata := content[1].Value.Schema.GetSchema().Properties.AdditionalProperties[0]
+			if ref := metadata.Value.GetReference().GetXRef(); ref != "#/components/schemas/Object" {
+				t.Errorf("unexpected metadata reference: %s", ref)
+			}
+		}
+	}
+	for _, path := range []string{"/b/{bucket}/o", "/upload/storage/v1/b/{bucket}/o", "/resumable/upload/storage/v1/b/{bucket}/o", "/batch/storage/v1"} {
+		if !paths[path] {
+			t.Errorf("missing path %s", path)
+		}
+	}
+}
+
+func TestOpenAPIv2SecurityUploadAndBatch(t *testing.T) {
+	d, err := OpenAPIv2(readUploadDiscoveryDocument(t))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if d.BasePath != "/" {
+		t.Errorf("unexpected base path: %s (expected /)", d.BasePath)
+	}
+	definitions := d.SecurityDefinitions.AdditionalProperties
+	if len(definitions) != 1 || definitions[0].Value.GetOauth2AccessCodeSecurity() == nil {
+		t.Fatalf("unexpected security definitions: %+v", definitions)
+	}
+	paths := make(map[string]bool)
+	for _, item := range d.Paths.Path {
+		paths[item.Name] = true
+		if item.Name == "/upload/storage/v1/b/{bucket}/o" {
+			operation := item.Value.Post
+			if len(operation.Security) != 1 {
+				t.Errorf("unexpected security for %s: %+v", item.Name, operation.Security)
+			}
+			if len(operation.Consumes) != 2 || operation.Consumes[1] != "multipart/related" {
+				t.Errorf("unexpected consumes for %s: %+v", item.Name, operation.Consumes)
+			}
+		}
+	}
+	for _, path := range []string{"/storage/v1/b/{bucket}/o", "/storage/v1/b/{bucket}/o/{object}", "/upload/storage/v1/b/{bucket}/o", "/batch/storage/v1"} {
+		if !paths[path] {
+			t.Errorf("missing path %s", path)
+		}
+	}
+}
+
+func TestOpenAPIv2BatchWithoutUploads(t *testing.T) {
+	api := readUploadDiscoveryDocument(t)
+	for _, pair := range api.Resources.AdditionalProperties[0].Value.Methods.AdditionalProperties {
+		pair.Value.SupportsMediaUpload = false
+	}
+	d, err := OpenAPIv2(api)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	// Without uploads, the paths stay relative to the service path, and the
+	// batch path, which is relative to the rootUrl, is left out.
+	if d.BasePath != "/storage/v1" {
+		t.Errorf("unexpected base path: %s (expected /storage/v1)", d.BasePath)
+	}
+	paths := make(map[string]bool)
+	for _, item := range d.Paths.Path {
+		paths[item.Name] = true
+	}
+	if len(paths) != 2 || !paths["/b/{bucket}/o"] || !paths["/b/{bucket}/o/{object}"] {
+		t.Errorf("unexpected paths: %v", paths)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversions
+
+import (
+	"strings"
+
+	discovery "github.com/google/gnostic/discovery"
+)
+
+const (
+	// Google's OAuth2 endpoints; Discovery documents list scopes but not these URLs.
+	oauth2AuthorizationURL = "https://accounts.google.com/o/oauth2/auth"
+	oauth2TokenURL         = "https://oauth2.googleapis.com/token"
+	// Name of the security scheme generated from a document's auth.oauth2 section.
+	oauth2SecuritySchemeName = "Oauth2"
+)
+
+// uploadProtocol describes a path that accepts media uploads for a method.
+type uploadProtocol struct {
+	name        string   // suffix for the operation id
+	path        string   // path relative to the rootUrl
+	uploadTypes []string // allowed values of the uploadType query parameter
+	multipart   bool     // true if media and metadata can be sent as multipart/related
+}
+
+// scopesForDocument returns the OAuth2 scopes declared by a Discovery document.
+func scopesForDocument(api *discovery.Document) []*discovery.NamedScope {
+	if api.Auth == nil || api.Auth.Oauth2 == nil || api.Auth.Oauth2.Scopes == nil {
+		return nil
+	}
+	return api.Auth.Oauth2.Scopes.AdditionalProperties
+}
+
+// uploadProtocolsForMethod returns the upload paths of a method, merging protocols that share a path.
+func uploadProtocolsForMethod(method *discovery.Method) []*uploadProtocol {
+	if !method.SupportsMediaUpload || method.MediaUpload == nil || method.MediaUpload.Protocols == nil {
+		return nil
+	}
+	protocols := make([]*uploadProtocol, 0)
+	add := func(name, path string, uploadTypes []string, multipart bool) {
+		if path == "" {
+			return
+		}
+		path = strings.TrimPrefix(path, "/")
+		for _, p := range protocols {
+			if p.path == path {
+				p.uploadTypes = append(p.uploadTypes, uploadTypes...)
+				p.multipart = p.multipart || multipart
+				return
+			}
+		}
+		protocols = append(protocols, &uploadProtocol{name: name, path: path, uploadTypes: uploadTypes, multipart: multipart})
+	}
+	if simple := method.MediaUpload.Protocols.Simple; simple != nil {
+		uploadTypes := []string{"media"}
+		if simple.Multipart {
+			uploadTypes = append(uploadTypes, "multipart")
+		}
+		add("upload", simple.Path, uploadTypes, simple.Multipart)
+	}
+	if resumable := method.MediaUpload.Protocols.Resumable; resumable != nil {
+		add("resumableUpload", resumable.Path, []string{"resumable"}, false)
+	}
+	return protocols
+}
+
+// acceptedMediaTypes returns the media types that a method accepts for uploads.
+func acceptedMediaTypes(method *discovery.Method) []string {
+	if method.MediaUpload == nil || len(method.MediaUpload.Accept) == 0 {
+		return []string{"*/*"}
+	}
+	return method.MediaUpload.Accept
+}
+
+// hasMediaUploadPaths returns true if a document has upload paths, which are
+// relative to the rootUrl instead of the servicePath.
+func hasMediaUploadPaths(api *discovery.Document) bool {
+	if api.Methods != nil {
+		for _, pair := range api.Methods.AdditionalProperties {
+			if len(uploadProtocolsForMethod(pair.Value)) > 0 {
+				return true
+			}
+		}
+	}
+	if api.Resources != nil {
+		for _, pair := range api.Resources.AdditionalProperties {
+			if resourceHasMediaUpload(pair.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func resourceHasMediaUpload(resource *discovery.Resource) bool {
+	if resource.Methods != nil {
+		for _, pair := range resource.Methods.AdditionalProperties {
+			if len(uploadProtocolsForMethod(pair.Value)) > 0 {
+				return true
+			}
+		}
+	}
+	if resource.Resources != nil {
+		for _, pair := range resource.Resources.AdditionalProperties {
+			if resourceHasMediaUpload(pair.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// servicePath returns the path of a document's methods relative to its rootUrl.
+func servicePath(api *discovery.Document) string {
+	if api.ServicePath != "" {
+		return api.ServicePath
+	}
+	return strings.TrimPrefix(api.BasePath, "/")
+}
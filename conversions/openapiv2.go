@@ -190,9 +190,85 @@ func buildOpenAPI2OperationForMethod(method *discovery.Method) *openapi2.Operati
 		OperationId: method.Id,
 		Parameters:  parameters,
 		Responses:   responses,
+		Security:    buildOpenAPI2SecurityForMethod(method),
 	}
 }
 
+func buildOpenAPI2SecurityForMethod(method *discovery.Method) []*openapi2.SecurityRequirement {
+	if len(method.Scopes) == 0 {
+		return nil
+	}
+	return []*openapi2.SecurityRequirement{
+		&openapi2.SecurityRequirement{
+			AdditionalProperties: []*openapi2.NamedStringArray{
+				&openapi2.NamedStringArray{
+					Name:  oauth2SecuritySchemeName,
+					Value: &openapi2.StringArray{Value: method.Scopes},
+				},
+			},
+		},
+	}
+}
+
+func buildOpenAPI2OperationForUpload(method *discovery.Method, protocol *uploadProtocol) *openapi2.Operation {
+	operation := buildOpenAPI2OperationForMethod(method)
+	operation.OperationId = method.Id + "." + protocol.name
+	operation.Consumes = acceptedMediaTypes(method)
+	if protocol.multipart {
+		operation.Consumes = append(operation.Consumes, "multipart/related")
+	}
+	uploadType := &openapi2.QueryParameterSubSchema{
+		Name:        "uploadType",
+		In:          "query",
+		Description: "The type of upload request.",
+		Required:    true,
+		Type:        "string",
+	}
+	for _, value := range protocol.uploadTypes {
+		uploadType.Enum = append(uploadType.Enum, &openapi2.Any{Yaml: value})
+	}
+	// The media replaces any metadata body parameter of the method.
+	parameters := make([]*openapi2.ParametersItem, 0)
+	for _, item := range operation.Parameters {
+		if item.GetParameter().GetBodyParameter() == nil {
+			parameters = append(parameters, item)
+		}
+	}
+	parameters = append(parameters,
+		&openapi2.ParametersItem{
+			Oneof: &openapi2.ParametersItem_Parameter{
+				Parameter: &openapi2.Parameter{
+					Oneof: &openapi2.Parameter_NonBodyParameter{
+						NonBodyParameter: &openapi2.NonBodyParameter{
+							Oneof: &openapi2.NonBodyParameter_QueryParameterSubSchema{
+								QueryParameterSubSchema: uploadType,
+							},
+						},
+					},
+				},
+			},
+		},
+		&openapi2.ParametersItem{
+			Oneof: &openapi2.ParametersItem_Parameter{
+				Parameter: &openapi2.Parameter{
+					Oneof: &openapi2.Parameter_BodyParameter{
+						BodyParameter: &openapi2.BodyParameter{
+							Name:     "media",
+							In:       "body",
+							Required: true,
+							Schema: &openapi2.Schema{
+								Type:   &openapi2.TypeItem{Value: []string{"string"}},
+								Format: "binary",
+							},
+						},
+					},
+				},
+			},
+		})
+	operation.Parameters = parameters
+	return operation
+}
+
 func getOpenAPI2PathItemForPath(d *openapi2.Document, path string) *openapi2.PathItem {
 	// First, try to find a path item with the specified path. If it exists, return it.
 	for _, item := range d.Paths.Path {
@@ -211,10 +287,19 @@ func getOpenAPI2PathItemForPath(d *openapi2.Document, path string) *openapi2.Pat
 	return pathItem
 }
 
-func addOpenAPI2PathsForMethod(d *openapi2.Document, name string, method *discovery.Method) {
+func addOpenAPI2PathsForMethod(d *openapi2.Document, prefix string, name string, method *discovery.Method) {
 	operation := buildOpenAPI2OperationForMethod(method)
-	pathItem := getOpenAPI2PathItemForPath(d, pathForMethod(method.Path))
-	switch method.HttpMethod {
+	pathItem := getOpenAPI2PathItemForPath(d, pathForMethod(prefix+method.Path))
+	setOpenAPI2OperationForMethod(pathItem, method.HttpMethod, operation)
+	for _, protocol := range uploadProtocolsForMethod(method) {
+		operation := buildOpenAPI2OperationForUpload(method, protocol)
+		pathItem := getOpenAPI2PathItemForPath(d, pathForMethod(protocol.path))
+		setOpenAPI2OperationForMethod(pathItem, method.HttpMethod, operation)
+	}
+}
+
+func setOpenAPI2OperationForMethod(pathItem *openapi2.PathItem, httpMethod string, operation *openapi2.Operation) {
+	switch httpMethod {
 	case "GET":
 		pathItem.Get = operation
 	case "POST":
@@ -226,21 +311,102 @@ func addOpenAPI2PathsForMethod(d *openapi2.Document, name string, method *discov
 	case "PATCH":
 		pathItem.Patch = operation
 	default:
-		log.Printf("WARNING: Unknown HTTP method %s", method.HttpMethod)
+		log.Printf("WARNING: Unknown HTTP method %s", httpMethod)
 	}
 }
 
-func addOpenAPI2PathsForResource(d *openapi2.Document, name string, resource *discovery.Resource) {
+func addOpenAPI2PathsForResource(d *openapi2.Document, prefix string, name string, resource *discovery.Resource) {
 	//log.Printf("RESOURCE %s (%s)\n", resource.Name, resource.FullName)
 	if resource.Methods != nil {
 		for _, pair := range resource.Methods.AdditionalProperties {
-			addOpenAPI2PathsForMethod(d, pair.Name, pair.Value)
+			addOpenAPI2PathsForMethod(d, prefix, pair.Name, pair.Value)
 		}
 	}
 	if resource.Resources != nil {
 		for _, pair := range resource.Resources.AdditionalProperties {
-			addOpenAPI2PathsForResource(d, pair.Name, pair.Value)
+			addOpenAPI2PathsForResource(d, prefix, pair.Name, pair.Value)
+		}
+	}
+}
+
+func addOpenAPI2PathForBatch(d *openapi2.Document, api *discovery.Document) {
+	if api.BatchPath == "" {
+		return
+	}
+	pathItem := getOpenAPI2PathItemForPath(d, pathForMethod(api.BatchPath))
+	pathItem.Post = &openapi2.Operation{
+		Description: "Sends multiple requests in a single HTTP request.",
+		OperationId: "batch",
+		Consumes:    []string{"multipart/mixed"},
+		Produces:    []string{"multipart/mixed"},
+		Parameters: []*openapi2.ParametersItem{
+			&openapi2.ParametersItem{
+				Oneof: &openapi2.ParametersItem_Parameter{
+					Parameter: &openapi2.Parameter{
+						Oneof: &openapi2.Parameter_BodyParameter{
+							BodyParameter: &openapi2.BodyParameter{
+								Name:     "requests",
+								In:       "body",
+								Required: true,
+								Schema: &openapi2.Schema{
+									Type:   &openapi2.TypeItem{Value: []string{"string"}},
+									Format: "binary",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Responses: &openapi2.Responses{
+			ResponseCode: []*openapi2.NamedResponseValue{
+				&openapi2.NamedResponseValue{
+					Name: "default",
+					Value: &openapi2.ResponseValue{
+						Oneof: &openapi2.ResponseValue_Response{
+							Response: &openapi2.Response{
+								Description: "Successful operation",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func addOpenAPI2SecurityDefinitions(d *openapi2.Document, api *discovery.Document) {
+	scopes := scopesForDocument(api)
+	if len(scopes) == 0 {
+		return
+	}
+	scopeDescriptions := &openapi2.Oauth2Scopes{}
+	for _, pair := range scopes {
+		description := ""
+		if pair.Value != nil {
+			description = pair.Value.Description
 		}
+		scopeDescriptions.AdditionalProperties = append(scopeDescriptions.AdditionalProperties,
+			&openapi2.NamedString{Name: pair.Name, Value: description})
+	}
+	d.SecurityDefinitions = &openapi2.SecurityDefinitions{
+		AdditionalProperties: []*openapi2.NamedSecurityDefinitionsItem{
+			&openapi2.NamedSecurityDefinitionsItem{
+				Name: oauth2SecuritySchemeName,
+				Value: &openapi2.SecurityDefinitionsItem{
+					Oneof: &openapi2.SecurityDefinitionsItem_Oauth2AccessCodeSecurity{
+						Oauth2AccessCodeSecurity: &openapi2.Oauth2AccessCodeSecurity{
+							Type:             "oauth2",
+							Flow:             "accessCode",
+							Scopes:           scopeDescriptions,
+							AuthorizationUrl: oauth2AuthorizationURL,
+							TokenUrl:         oauth2TokenURL,
+							Description:      "Oauth 2.0 authentication",
+						},
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -263,6 +429,13 @@ func OpenAPIv2(api *discovery.Document) (*openapi2.Document, error) {
 	url, _ := url.Parse(api.RootUrl)
 	d.Host = url.Host
 	d.BasePath = removeTrailingSlash(api.BasePath)
+	// Upload paths are relative to the rootUrl, so when a document has any,
+	// the base path is the root and method paths get the service path.
+	prefix := ""
+	if hasMediaUploadPaths(api) {
+		d.BasePath = "/"
+		prefix = servicePath(api)
+	}
 	d.Schemes = []string{url.Scheme}
 	d.Consumes = []string{"application/json"}
 	d.Produces = []string{"application/json"}
@@ -273,15 +446,21 @@ func OpenAPIv2(api *discovery.Document) (*openapi2.Document, error) {
 			addOpenAPI2SchemaForSchema(d, pair.Name, pair.Value)
 		}
 	}
+	addOpenAPI2SecurityDefinitions(d, api)
 	if api.Methods != nil {
 		for _, pair := range api.Methods.AdditionalProperties {
-			addOpenAPI2PathsForMethod(d, pair.Name, pair.Value)
+			addOpenAPI2PathsForMethod(d, prefix, pair.Name, pair.Value)
 		}
 	}
 	if api.Resources != nil {
 		for _, pair := range api.Resources.AdditionalProperties {
-			addOpenAPI2PathsForResource(d, pair.Name, pair.Value)
+			addOpenAPI2PathsForResource(d, prefix, pair.Name, pair.Value)
 		}
 	}
+	// The batch path is relative to the rootUrl too, so it is left out when the
+	// paths are relative to the service path.
+	if d.BasePath == "" || d.BasePath == "/" {
+		addOpenAPI2PathForBatch(d, api)
+	}
 	return d, nil
 }
@@ -205,9 +205,117 @@ func buildOpenAPI3OperationForMethod(method *discovery.Method, hasDataWrapper bo
 		Parameters:  parameters,
 		Responses:   responses,
 		RequestBody: requestBodyOrReference,
+		Security:    buildOpenAPI3SecurityForMethod(method),
 	}
 }
 
+func buildOpenAPI3SecurityForMethod(method *discovery.Method) []*openapi3.SecurityRequirement {
+	if len(method.Scopes) == 0 {
+		return nil
+	}
+	return []*openapi3.SecurityRequirement{
+		&openapi3.SecurityRequirement{
+			AdditionalProperties: []*openapi3.NamedStringArray{
+				&openapi3.NamedStringArray{
+					Name:  oauth2SecuritySchemeName,
+					Value: &openapi3.StringArray{Value: method.Scopes},
+				},
+			},
+		},
+	}
+}
+
+func binaryOpenAPI3Schema() *openapi3.SchemaOrReference {
+	return &openapi3.SchemaOrReference{
+		Oneof: &openapi3.SchemaOrReference_Schema{
+			Schema: &openapi3.Schema{
+				Type:   "string",
+				Format: "binary",
+			},
+		},
+	}
+}
+
+func buildOpenAPI3RequestBodyForUpload(method *discovery.Method, protocol *uploadProtocol) *openapi3.RequestBody {
+	content := &openapi3.MediaTypes{}
+	for _, mediaType := range acceptedMediaTypes(method) {
+		content.AdditionalProperties = append(content.AdditionalProperties,
+			&openapi3.NamedMediaType{
+				Name:  mediaType,
+				Value: &openapi3.MediaType{Schema: binaryOpenAPI3Schema()},
+			})
+	}
+	if protocol.multipart {
+		properties := &openapi3.Properties{}
+		if method.Request != nil && method.Request.XRef != "" {
+			properties.AdditionalProperties = append(properties.AdditionalProperties,
+				&openapi3.NamedSchemaOrReference{
+					Name: "metadata",
+					Value: &openapi3.SchemaOrReference{
+						Oneof: &openapi3.SchemaOrReference_Reference{
+							Reference: &openapi3.Reference{
+								XRef: "#/components/schemas/" + method.Request.XRef,
+							},
+						},
+					},
+				})
+		}
+		properties.AdditionalProperties = append(properties.AdditionalProperties,
+			&openapi3.NamedSchemaOrReference{
+				Name:  "media",
+				Value: binaryOpenAPI3Schema(),
+			})
+		content.AdditionalProperties = append(content.AdditionalProperties,
+			&openapi3.NamedMediaType{
+				Name: "multipart/related",
+				Value: &openapi3.MediaType{
+					Schema: &openapi3.SchemaOrReference{
+						Oneof: &openapi3.SchemaOrReference_Schema{
+							Schema: &openapi3.Schema{
+								Type:       "object",
+								Properties: properties,
+							},
+						},
+					},
+				},
+			})
+	}
+	return &openapi3.RequestBody{
+		Required: true,
+		Content:  content,
+	}
+}
+
+func buildOpenAPI3OperationForUpload(method *discovery.Method, protocol *uploadProtocol, hasDataWrapper bool) *openapi3.Operation {
+	operation := buildOpenAPI3OperationForMethod(method, hasDataWrapper)
+	operation.OperationId = method.Id + "." + protocol.name
+	uploadType := &openapi3.Schema{Type: "string"}
+	for _, value := range protocol.uploadTypes {
+		uploadType.Enum = append(uploadType.Enum, &openapi3.Any{Yaml: value})
+	}
+	operation.Parameters = append(operation.Parameters, &openapi3.ParameterOrReference{
+		Oneof: &openapi3.ParameterOrReference_Parameter{
+			Parameter: &openapi3.Parameter{
+				Name:        "uploadType",
+				In:          "query",
+				Description: "The type of upload request.",
+				Required:    true,
+				Schema: &openapi3.SchemaOrReference{
+					Oneof: &openapi3.SchemaOrReference_Schema{
+						Schema: uploadType,
+					},
+				},
+			},
+		},
+	})
+	operation.RequestBody = &openapi3.RequestBodyOrReference{
+		Oneof: &openapi3.RequestBodyOrReference_RequestBody{
+			RequestBody: buildOpenAPI3RequestBodyForUpload(method, protocol),
+		},
+	}
+	return operation
+}
+
 func getOpenAPI3PathItemForPath(d *openapi3.Document, path string) *openapi3.PathItem {
 	// First, try to find a path item with the specified path. If it exists, return it.
 	for _, item := range d.Paths.Path {
@@ -226,10 +334,28 @@ func getOpenAPI3PathItemForPath(d *openapi3.Document, path string) *openapi3.Pat
 	return pathItem
 }
 
-func addOpenAPI3PathsForMethod(d *openapi3.Document, name string, method *discovery.Method, hasDataWrapper bool) {
+// getOpenAPI3PathItemForRootPath returns a path item for a path that is relative to the rootUrl.
+func getOpenAPI3PathItemForRootPath(d *openapi3.Document, rootURL string, path string) *openapi3.PathItem {
+	pathItem := getOpenAPI3PathItemForPath(d, pathForMethod(path))
+	if len(pathItem.Servers) == 0 {
+		pathItem.Servers = append(pathItem.Servers, &openapi3.Server{Url: removeTrailingSlash(rootURL)})
+	}
+	return pathItem
+}
+
+func addOpenAPI3PathsForMethod(d *openapi3.Document, api *discovery.Document, name string, method *discovery.Method, hasDataWrapper bool) {
 	operation := buildOpenAPI3OperationForMethod(method, hasDataWrapper)
 	pathItem := getOpenAPI3PathItemForPath(d, pathForMethod(method.Path))
-	switch method.HttpMethod {
+	setOpenAPI3OperationForMethod(pathItem, method.HttpMethod, operation)
+	for _, protocol := range uploadProtocolsForMethod(method) {
+		operation := buildOpenAPI3OperationForUpload(method, protocol, hasDataWrapper)
+		pathItem := getOpenAPI3PathItemForRootPath(d, api.RootUrl, protocol.path)
+		setOpenAPI3OperationForMethod(pathItem, method.HttpMethod, operation)
+	}
+}
+
+func setOpenAPI3OperationForMethod(pathItem *openapi3.PathItem, httpMethod string, operation *openapi3.Operation) {
+	switch httpMethod {
 	case "GET":
 		pathItem.Get = operation
 	case "POST":
@@ -241,23 +367,109 @@ func addOpenAPI3PathsForMethod(d *openapi3.Document, name string, method *discov
 	case "PATCH":
 		pathItem.Patch = operation
 	default:
-		log.Printf("WARNING: Unknown HTTP method %s", method.HttpMethod)
+		log.Printf("WARNING: Unknown HTTP method %s", httpMethod)
 	}
 }
 
-func addOpenAPI3PathsForResource(d *openapi3.Document, resource *discovery.Resource, hasDataWrapper bool) {
+func addOpenAPI3PathsForResource(d *openapi3.Document, api *discovery.Document, resource *discovery.Resource, hasDataWrapper bool) {
 	if resource.Methods != nil {
 		for _, pair := range resource.Methods.AdditionalProperties {
-			addOpenAPI3PathsForMethod(d, pair.Name, pair.Value, hasDataWrapper)
+			addOpenAPI3PathsForMethod(d, api, pair.Name, pair.Value, hasDataWrapper)
 		}
 	}
 	if resource.Resources != nil {
 		for _, pair := range resource.Resources.AdditionalProperties {
-			addOpenAPI3PathsForResource(d, pair.Value, hasDataWrapper)
+			addOpenAPI3PathsForResource(d, api, pair.Value, hasDataWrapper)
 		}
 	}
 }
 
+func addOpenAPI3PathForBatch(d *openapi3.Document, api *discovery.Document) {
+	if api.BatchPath == "" {
+		return
+	}
+	content := func() *openapi3.MediaTypes {
+		return &openapi3.MediaTypes{
+			AdditionalProperties: []*openapi3.NamedMediaType{
+				&openapi3.NamedMediaType{
+					Name:  "multipart/mixed",
+					Value: &openapi3.MediaType{Schema: binaryOpenAPI3Schema()},
+				},
+			},
+		}
+	}
+	pathItem := getOpenAPI3PathItemForRootPath(d, api.RootUrl, api.BatchPath)
+	pathItem.Post = &openapi3.Operation{
+		Description: "Sends multiple requests in a single HTTP request.",
+		OperationId: "batch",
+		RequestBody: &openapi3.RequestBodyOrReference{
+			Oneof: &openapi3.RequestBodyOrReference_RequestBody{
+				RequestBody: &openapi3.RequestBody{
+					Required: true,
+					Content:  content(),
+				},
+			},
+		},
+		Responses: &openapi3.Responses{
+			ResponseOrReference: []*openapi3.NamedResponseOrReference{
+				&openapi3.NamedResponseOrReference{
+					Name: "default",
+					Value: &openapi3.ResponseOrReference{
+						Oneof: &openapi3.ResponseOrReference_Response{
+							Response: &openapi3.Response{
+								Description: "Successful operation",
+								Content:     content(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func addOpenAPI3SecuritySchemes(d *openapi3.Document, api *discovery.Document) {
+	scopes := scopesForDocument(api)
+	if len(scopes) == 0 {
+		return
+	}
+	scopeDescriptions := &openapi3.Strings{}
+	for _, pair := range scopes {
+		description := ""
+		if pair.Value != nil {
+			description = pair.Value.Description
+		}
+		scopeDescriptions.AdditionalProperties = append(scopeDescriptions.AdditionalProperties,
+			&openapi3.NamedString{Name: pair.Name, Value: description})
+	}
+	d.Components.SecuritySchemes = &openapi3.SecuritySchemesOrReferences{
+		AdditionalProperties: []*openapi3.NamedSecuritySchemeOrReference{
+			&openapi3.NamedSecuritySchemeOrReference{
+				Name: oauth2SecuritySchemeName,
+				Value: &openapi3.SecuritySchemeOrReference{
+					Oneof: &openapi3.SecuritySchemeOrReference_SecurityScheme{
+						SecurityScheme: &openapi3.SecurityScheme{
+							Type:        "oauth2",
+							Description: "Oauth 2.0 authentication",
+							Flows: &openapi3.OauthFlows{
+								Implicit: &openapi3.OauthFlow{
+									AuthorizationUrl: oauth2AuthorizationURL,
+									Scopes:           scopeDescriptions,
+								},
+								AuthorizationCode: &openapi3.OauthFlow{
+									AuthorizationUrl: oauth2AuthorizationURL,
+									TokenUrl:         oauth2TokenURL,
+									Scopes:           scopeDescriptions,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 // OpenAPIv3 returns an OpenAPI v3 representation of a Discovery document
 func OpenAPIv3(api *discovery.Document) (*openapi3.Document, error) {
 	d := &openapi3.Document{}
@@ -292,15 +504,20 @@ func OpenAPIv3(api *discovery.Document) (*openapi3.Document, error) {
 		}
 	}
 
+	addOpenAPI3SecuritySchemes(d, api)
+
 	d.Paths = &openapi3.Paths{}
 	if api.Methods != nil {
 		for _, pair := range api.Methods.AdditionalProperties {
-			addOpenAPI3PathsForMethod(d, pair.Name, pair.Value, hasDataWrapper)
+			addOpenAPI3PathsForMethod(d, api, pair.Name, pair.Value, hasDataWrapper)
 		}
 	}
-	for _, pair := range api.Resources.AdditionalProperties {
-		addOpenAPI3PathsForResource(d, pair.Value, hasDataWrapper)
+	if api.Resources != nil {
+		for _, pair := range api.Resources.AdditionalProperties {
+			addOpenAPI3PathsForResource(d, api, pair.Value, hasDataWrapper)
+		}
 	}
+	addOpenAPI3PathForBatch(d, api)
 
 	return d, nil
 }
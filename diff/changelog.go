@@ -0,0 +1,290 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Changelog describes the changes in a sequence of versions of an API.
+type Changelog struct {
+	Name     string     `json:"name"`
+	Versions []*Release `json:"versions"`
+}
+
+// Release lists the changes that were made in one version of an API.
+type Release struct {
+	Name       string   `json:"name"`
+	Previous   string   `json:"previous"`
+	Added      []*Entry `json:"added,omitempty"`
+	Changed    []*Entry `json:"changed,omitempty"`
+	Deprecated []*Entry `json:"deprecated,omitempty"`
+	Removed    []*Entry `json:"removed,omitempty"`
+}
+
+// Entry is a single changelog entry.
+type Entry struct {
+	// Kind is "endpoint", "schema" or "field".
+	Kind string `json:"kind"`
+	// Subject names the endpoint, schema or field that changed.
+	Subject  string `json:"subject"`
+	Message  string `json:"message"`
+	Breaking bool   `json:"breaking,omitempty"`
+	Code     string `json:"code"`
+}
+
+// Sections of a release.
+const (
+	added      = "added"
+	changed    = "changed"
+	deprecated = "deprecated"
+	removed    = "removed"
+)
+
+// sectionForCode maps the code of each change to the section of a release that
+// lists it. Codes aren't sorted by their suffixes: "enum-added" restricts the
+// values of a field and "enum-removed" lifts the restriction, so both are changes.
+// Changes with codes that aren't listed are changes too.
+var sectionForCode = map[string]string{
+	"operation-added":       added,
+	"operation-deprecated":  deprecated,
+	"operation-removed":     removed,
+	"schema-added":          added,
+	"schema-removed":        removed,
+	"parameter-added":       added,
+	"parameter-deprecated":  deprecated,
+	"parameter-optional":    changed,
+	"parameter-required":    changed,
+	"parameter-removed":     removed,
+	"property-added":        added,
+	"property-deprecated":   deprecated,
+	"property-optional":     changed,
+	"property-required":     changed,
+	"property-removed":      removed,
+	"request-body-added":    added,
+	"request-body-required": changed,
+	"request-body-removed":  removed,
+	"response-added":        added,
+	"response-removed":      removed,
+	"response-body-removed": removed,
+	"security-added":        changed,
+	"security-changed":      changed,
+	"security-removed":      changed,
+	"type-changed":          changed,
+	"enum-added":            changed,
+	"enum-removed":          changed,
+	"enum-narrowed":         changed,
+	"enum-widened":          changed,
+}
+
+// kindOrder is the order that entries are listed in each changelog section.
+var kindOrder = map[string]int{"endpoint": 0, "schema": 1, "field": 2}
+
+// CompareVersions compares two version names and returns -1, 0 or 1. Runs of
+// digits are compared as numbers, so "v9" comes before "v10" and "1.9.0" before
+// "1.10.0", and the other characters are compared as text.
+func CompareVersions(a, b string) int {
+	for x, y := a, b; x != "" || y != ""; {
+		var xPart, yPart string
+		xPart, x = splitVersionPart(x)
+		yPart, y = splitVersionPart(y)
+		if c := compareVersionParts(xPart, yPart); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// splitVersionPart splits a version name after its leading run of digits or non-digits.
+func splitVersionPart(version string) (string, string) {
+	i := 1
+	for i < len(version) && isDigit(version[i]) == isDigit(version[0]) {
+		i++
+	}
+	if i > len(version) {
+		i = len(version)
+	}
+	return version[:i], version[i:]
+}
+
+func compareVersionParts(a, b string) int {
+	if a != "" && b != "" && isDigit(a[0]) && isDigit(b[0]) {
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// NewChangelog builds a changelog from a sequence of API descriptions,
+// oldest first. Each description is named by the corresponding entry in names.
+func NewChangelog(name string, names []string, documents []proto.Message) (*Changelog, error) {
+	if len(names) != len(documents) {
+		return nil, fmt.Errorf("%d version names given for %d descriptions", len(names), len(documents))
+	}
+	changelog := &Changelog{Name: name, Versions: make([]*Release, 0)}
+	for i := 1; i < len(documents); i++ {
+		report, err := Compare(documents[i-1], documents[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", names[i], err.Error())
+		}
+		changelog.Versions = append(changelog.Versions, newRelease(names[i], names[i-1], report))
+	}
+	return changelog, nil
+}
+
+func newRelease(name string, previous string, report *Report) *Release {
+	release := &Release{Name: name, Previous: previous}
+	for _, change := range report.Changes {
+		entry := &Entry{
+			Kind:     kindForChange(change),
+			Subject:  subjectForKeys(change.Keys),
+			Message:  change.Message,
+			Breaking: change.Breaking,
+			Code:     change.Code,
+		}
+		switch sectionForCode[change.Code] {
+		case added:
+			release.Added = append(release.Added, entry)
+		case deprecated:
+			release.Deprecated = append(release.Deprecated, entry)
+		case removed:
+			release.Removed = append(release.Removed, entry)
+		default:
+			release.Changed = append(release.Changed, entry)
+		}
+	}
+	for _, entries := range [][]*Entry{release.Added, release.Changed, release.Deprecated, release.Removed} {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return kindOrder[entries[i].Kind] < kindOrder[entries[j].Kind]
+		})
+	}
+	return release
+}
+
+func kindForChange(change *Change) string {
+	switch {
+	case strings.HasPrefix(change.Code, "operation-"):
+		return "endpoint"
+	case strings.HasPrefix(change.Code, "schema-"):
+		return "schema"
+	default:
+		return "field"
+	}
+}
+
+// subjectForKeys returns a readable name for the location of a change,
+// e.g. "GET /pets", "Pet.name" or "GET /pets response 200 items.name".
+func subjectForKeys(keys []string) string {
+	var parts []string
+	var fields []string
+	for i := 0; i < len(keys); i++ {
+		switch {
+		case i == 0 && keys[i] == "paths" && len(keys) > 2:
+			parts = append(parts, strings.ToUpper(keys[2])+" "+keys[1])
+			i += 2
+		case i == 0 && keys[i] == "definitions" && len(keys) > 1:
+			fields = append(fields, keys[1])
+			i++
+		case i == 0 && keys[i] == "components" && len(keys) > 2:
+			fields = append(fields, keys[2])
+			i += 2
+		case keys[i] == "parameters" && i+1 < len(keys):
+			parts = append(parts, "parameter "+keys[i+1])
+			i++
+		case keys[i] == "responses" && i+1 < len(keys):
+			parts = append(parts, "response "+keys[i+1])
+			i++
+		case keys[i] == "requestBody":
+			parts = append(parts, "request body")
+		case keys[i] == "properties" && i+1 < len(keys):
+			fields = append(fields, keys[i+1])
+			i++
+		case keys[i] == "items":
+			fields = append(fields, "items")
+		case keys[i] == "security":
+			parts = append(parts, "security")
+		}
+	}
+	if len(fields) > 0 {
+		parts = append(parts, strings.Join(fields, "."))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Markdown returns the changelog in Markdown format, newest version first.
+func (c *Changelog) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s changelog\n", c.Name)
+	for i := len(c.Versions) - 1; i >= 0; i-- {
+		release := c.Versions[i]
+		fmt.Fprintf(&b, "\n## %s\n\nChanges since %s.\n", release.Name, release.Previous)
+		sections := []struct {
+			title   string
+			entries []*Entry
+		}{
+			{"Added", release.Added},
+			{"Changed", release.Changed},
+			{"Deprecated", release.Deprecated},
+			{"Removed", release.Removed},
+		}
+		empty := true
+		for _, section := range sections {
+			if len(section.entries) == 0 {
+				continue
+			}
+			empty = false
+			fmt.Fprintf(&b, "\n### %s\n\n", section.title)
+			for _, entry := range section.entries {
+				breaking := ""
+				if entry.Breaking {
+					breaking = " **(breaking)**"
+				}
+				fmt.Fprintf(&b, "- %s `%s`: %s%s\n", capitalize(entry.Kind), entry.Subject, entry.Message, breaking)
+			}
+		}
+		if empty {
+			fmt.Fprintf(&b, "\nNo changes.\n")
+		}
+	}
+	return b.String()
+}
+
+// capitalize returns a word with its first letter in upper case.
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// JSON returns the changelog in JSON format.
+func (c *Changelog) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
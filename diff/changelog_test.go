@@ -0,0 +1,188 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+const deprecatedV3 = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 3.0.0
+paths:
+  /owners:
+    get:
+      operationId: listOwners
+      deprecated: true
+      responses:
+        "200":
+          description: Owners.
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        age:
+          type: integer
+          deprecated: true
+`
+
+func TestChangelog(t *testing.T) {
+	documents := make([]proto.Message, 0)
+	for _, text := range []string{oldV3, newV3, deprecatedV3} {
+		document, err := openapi_v3.ParseDocument([]byte(text))
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		documents = append(documents, document)
+	}
+	changelog, err := NewChangelog("pets", []string{"v1", "v2", "v3"}, documents)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if len(changelog.Versions) != 2 {
+		t.Fatalf("expected 2 versions, found %d", len(changelog.Versions))
+	}
+	v2 := changelog.Versions[0]
+	if v2.Name != "v2" || v2.Previous != "v1" {
+		t.Errorf("unexpected version names %s and %s", v2.Name, v2.Previous)
+	}
+	if v2.Added[0].Kind != "endpoint" || v2.Added[0].Subject != "GET /owners" {
+		t.Errorf("unexpected first added entry %+v", v2.Added[0])
+	}
+	v3 := changelog.Versions[1]
+	if len(v3.Deprecated) != 2 {
+		t.Errorf("expected 2 deprecations, found %d", len(v3.Deprecated))
+	}
+
+	markdown := changelog.Markdown()
+	for _, expected := range []string{
+		"# pets changelog\n",
+		"## v2\n\nChanges since v1.\n",
+		"- Endpoint `GET /owners`: operation GET /owners was added\n",
+		"- Endpoint `DELETE /pets/{petId}`: operation DELETE /pets/{petId} was removed **(breaking)**\n",
+		"- Field `Pet.tag`: property tag was removed **(breaking)**\n",
+		"- Field `GET /pets parameter limit`: type changed from integer(int32) to string **(breaking)**\n",
+		"### Deprecated\n\n- Endpoint `GET /owners`: operation GET /owners was deprecated\n- Field `Pet.age`: property age was deprecated\n",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("missing %q in changelog:\n%s", expected, markdown)
+		}
+	}
+	if strings.Index(markdown, "## v3") > strings.Index(markdown, "## v2") {
+		t.Errorf("expected newest version first:\n%s", markdown)
+	}
+
+	bytes, err := changelog.JSON()
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	var decoded Changelog
+	if err = json.Unmarshal(bytes, &decoded); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if len(decoded.Versions) != 2 || len(decoded.Versions[1].Removed) != len(v3.Removed) {
+		t.Errorf("unexpected JSON changelog %s", string(bytes))
+	}
+}
+
+func TestChangelogSections(t *testing.T) {
+	documents := make([]proto.Message, 0)
+	for _, enum := range []string{"", "\n          enum: [a, b]", ""} {
+		document, err := openapi_v3.ParseDocument([]byte(`
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "204":
+          description: Created.
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        kind:
+          type: string` + enum + "\n"))
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		documents = append(documents, document)
+	}
+	changelog, err := NewChangelog("pets", []string{"v1", "v2", "v3"}, documents)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	// Restricting values to an enum is a breaking change, not an addition.
+	v2 := changelog.Versions[0]
+	if len(v2.Added) != 0 || len(v2.Changed) != 1 || v2.Changed[0].Code != "enum-added" || !v2.Changed[0].Breaking {
+		t.Errorf("unexpected release %s", releaseEntries(v2))
+	}
+	v3 := changelog.Versions[1]
+	if len(v3.Removed) != 0 || len(v3.Changed) != 1 || v3.Changed[0].Code != "enum-removed" {
+		t.Errorf("unexpected release %s", releaseEntries(v3))
+	}
+
+	// Every code that Compare reports is assigned to a section.
+	source, err := ioutil.ReadFile("compare.go")
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	for _, match := range regexp.MustCompile(`c\.add\("([a-z-]+)"`).FindAllStringSubmatch(string(source), -1) {
+		if sectionForCode[match[1]] == "" {
+			t.Errorf("code %s has no changelog section", match[1])
+		}
+	}
+}
+
+// releaseEntries describes the entries of a release for test failures.
+func releaseEntries(release *Release) string {
+	bytes, _ := json.Marshal(release)
+	return string(bytes)
+}
+
+func TestCompareVersions(t *testing.T) {
+	versions := []string{"v10", "1.10.0", "v9", "v2beta1", "1.9.0", "v2", "v1", "1.9.10", "v02"}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) < 0
+	})
+	expected := []string{"1.9.0", "1.9.10", "1.10.0", "v1", "v02", "v2", "v2beta1", "v9", "v10"}
+	if !reflect.DeepEqual(versions, expected) {
+		t.Errorf("unexpected order %v", versions)
+	}
+}
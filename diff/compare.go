@@ -0,0 +1,339 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// direction describes how clients use a schema.
+type direction int
+
+const (
+	// request schemas are written by clients.
+	request direction = iota
+	// response schemas are read by clients.
+	response
+	// both is used for named schemas, which may be used either way.
+	both
+)
+
+// comparison accumulates the changes between two normalized API descriptions.
+type comparison struct {
+	old     *api
+	new     *api
+	changes []*Change
+}
+
+func (c *comparison) add(code string, breaking bool, keys []string, format string, args ...interface{}) {
+	c.changes = append(c.changes, &Change{
+		Code:     code,
+		Breaking: breaking,
+		Keys:     append([]string{}, keys...),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *comparison) compare() {
+	for _, key := range c.old.opList {
+		oldOp := c.old.operations[key]
+		newOp, ok := c.new.operations[key]
+		if !ok {
+			c.add("operation-removed", true, operationKeys(oldOp),
+				"operation %s %s was removed", strings.ToUpper(oldOp.method), oldOp.path)
+			continue
+		}
+		c.compareOperations(oldOp, newOp)
+	}
+	for _, key := range c.new.opList {
+		if _, ok := c.old.operations[key]; !ok {
+			newOp := c.new.operations[key]
+			c.add("operation-added", false, operationKeys(newOp),
+				"operation %s %s was added", strings.ToUpper(newOp.method), newOp.path)
+		}
+	}
+	for _, name := range c.old.schemaList {
+		keys := append(append([]string{}, c.new.schemasKey...), name)
+		newSchema, ok := c.new.schemas[name]
+		if !ok {
+			c.add("schema-removed", true, keys, "schema %s was removed", name)
+			continue
+		}
+		c.compareSchemas(keys, c.old.schemas[name], newSchema, both)
+	}
+	for _, name := range c.new.schemaList {
+		if _, ok := c.old.schemas[name]; !ok {
+			keys := append(append([]string{}, c.new.schemasKey...), name)
+			c.add("schema-added", false, keys, "schema %s was added", name)
+		}
+	}
+}
+
+func operationKeys(op *operation) []string {
+	return []string{"paths", op.path, op.method}
+}
+
+func (c *comparison) compareOperations(oldOp *operation, newOp *operation) {
+	keys := operationKeys(newOp)
+	name := strings.ToUpper(newOp.method) + " " + newOp.path
+	if !oldOp.deprecated && newOp.deprecated {
+		c.add("operation-deprecated", false, keys, "operation %s was deprecated", name)
+	}
+	c.compareParameters(keys, oldOp, newOp)
+
+	bodyKeys := append(append([]string{}, keys...), "requestBody")
+	switch {
+	case oldOp.requestBody == nil && newOp.requestBody != nil:
+		c.add("request-body-added", newOp.bodyNeeded, bodyKeys, "a request body was added to %s", name)
+	case oldOp.requestBody != nil && newOp.requestBody == nil:
+		c.add("request-body-removed", true, bodyKeys, "the request body of %s was removed", name)
+	case oldOp.requestBody != nil && newOp.requestBody != nil:
+		if !oldOp.bodyNeeded && newOp.bodyNeeded {
+			c.add("request-body-required", true, bodyKeys, "the request body of %s became required", name)
+		}
+		c.compareSchemas(bodyKeys, oldOp.requestBody, newOp.requestBody, request)
+	}
+
+	for _, code := range oldOp.codeList {
+		responseKeys := append(append([]string{}, keys...), "responses", code)
+		oldResponse := oldOp.responses[code]
+		newResponse, ok := newOp.responses[code]
+		if !ok {
+			c.add("response-removed", strings.HasPrefix(code, "2"), responseKeys,
+				"response %s of %s was removed", code, name)
+			continue
+		}
+		if oldResponse != nil && newResponse == nil {
+			c.add("response-body-removed", true, responseKeys,
+				"the body of response %s of %s was removed", code, name)
+			continue
+		}
+		if oldResponse != nil {
+			c.compareSchemas(responseKeys, oldResponse, newResponse, response)
+		}
+	}
+	for _, code := range newOp.codeList {
+		if _, ok := oldOp.responses[code]; !ok {
+			c.add("response-added", false, append(append([]string{}, keys...), "responses", code),
+				"response %s was added to %s", code, name)
+		}
+	}
+
+	c.compareSecurity(append(append([]string{}, keys...), "security"), name, oldOp.security, newOp.security)
+}
+
+// compareParameters compares the parameters of two operations.
+// Path parameters are matched by position because their names are not visible to clients.
+func (c *comparison) compareParameters(keys []string, oldOp *operation, newOp *operation) {
+	name := strings.ToUpper(newOp.method) + " " + newOp.path
+	oldPath := pathParameterPattern.FindAllString(oldOp.path, -1)
+	newPath := pathParameterPattern.FindAllString(newOp.path, -1)
+	for i := range newPath {
+		if i >= len(oldPath) {
+			break
+		}
+		oldParameter := oldOp.parameters[parameterKey("path", strings.Trim(oldPath[i], "{}"))]
+		newParameter := newOp.parameters[parameterKey("path", strings.Trim(newPath[i], "{}"))]
+		if oldParameter != nil && newParameter != nil && oldParameter.schema != nil && newParameter.schema != nil {
+			c.compareSchemas(append(append([]string{}, keys...), "parameters", newParameter.name),
+				oldParameter.schema, newParameter.schema, request)
+		}
+	}
+
+	for _, key := range oldOp.paramList {
+		oldParameter := oldOp.parameters[key]
+		if oldParameter.in == "path" {
+			continue
+		}
+		parameterKeys := append(append([]string{}, keys...), "parameters", oldParameter.name)
+		newParameter, ok := newOp.parameters[key]
+		if !ok {
+			c.add("parameter-removed", true, parameterKeys,
+				"%s parameter %s of %s was removed", oldParameter.in, oldParameter.name, name)
+			continue
+		}
+		if !oldParameter.deprecated && newParameter.deprecated {
+			c.add("parameter-deprecated", false, parameterKeys,
+				"%s parameter %s of %s was deprecated", newParameter.in, newParameter.name, name)
+		}
+		if !oldParameter.required && newParameter.required {
+			c.add("parameter-required", true, parameterKeys,
+				"%s parameter %s of %s became required", newParameter.in, newParameter.name, name)
+		} else if oldParameter.required && !newParameter.required {
+			c.add("parameter-optional", false, parameterKeys,
+				"%s parameter %s of %s became optional", newParameter.in, newParameter.name, name)
+		}
+		if oldParameter.schema != nil && newParameter.schema != nil {
+			c.compareSchemas(parameterKeys, oldParameter.schema, newParameter.schema, request)
+		}
+	}
+	for _, key := range newOp.paramList {
+		newParameter := newOp.parameters[key]
+		if _, ok := oldOp.parameters[key]; ok || newParameter.in == "path" {
+			continue
+		}
+		parameterKeys := append(append([]string{}, keys...), "parameters", newParameter.name)
+		if newParameter.required {
+			c.add("parameter-added", true, parameterKeys,
+				"required %s parameter %s was added to %s", newParameter.in, newParameter.name, name)
+		} else {
+			c.add("parameter-added", false, parameterKeys,
+				"optional %s parameter %s was added to %s", newParameter.in, newParameter.name, name)
+		}
+	}
+}
+
+// compareSecurity compares the security alternatives of two operations.
+// Clients are broken when any alternative they might use is no longer accepted.
+func (c *comparison) compareSecurity(keys []string, name string, oldSecurity []string, newSecurity []string) {
+	switch {
+	case len(oldSecurity) == 0 && len(newSecurity) == 0:
+		return
+	case len(oldSecurity) == 0:
+		c.add("security-added", true, keys, "%s now requires authorization (%s)",
+			name, strings.Join(newSecurity, " or "))
+		return
+	case len(newSecurity) == 0:
+		c.add("security-removed", false, keys, "%s no longer requires authorization", name)
+		return
+	}
+	removed := difference(oldSecurity, newSecurity)
+	added := difference(newSecurity, oldSecurity)
+	if len(removed) > 0 {
+		c.add("security-changed", true, keys, "%s no longer accepts %s",
+			name, strings.Join(removed, " or "))
+	}
+	if len(added) > 0 {
+		c.add("security-changed", false, keys, "%s now also accepts %s",
+			name, strings.Join(added, " or "))
+	}
+}
+
+func (c *comparison) compareSchemas(keys []string, oldSchema *schema, newSchema *schema, d direction) {
+	if oldSchema == nil || newSchema == nil {
+		return
+	}
+	if oldSchema.ref != "" || newSchema.ref != "" {
+		// Named schemas are compared separately.
+		if oldSchema.ref != newSchema.ref {
+			c.add("type-changed", true, keys, "type changed from %s to %s",
+				oldSchema.description(), newSchema.description())
+		}
+		return
+	}
+	if oldSchema.typ != newSchema.typ || oldSchema.format != newSchema.format {
+		if oldSchema.typ != "" || oldSchema.format != "" {
+			c.add("type-changed", true, keys, "type changed from %s to %s",
+				oldSchema.description(), newSchema.description())
+			return
+		}
+	}
+	c.compareEnums(keys, oldSchema, newSchema, d)
+
+	for _, name := range newSchema.required {
+		if d != response && !oldSchema.isRequired(name) {
+			c.add("property-required", true, append(append([]string{}, keys...), "properties", name),
+				"property %s became required", name)
+		}
+	}
+	for _, name := range oldSchema.required {
+		if d != request && !newSchema.isRequired(name) {
+			if _, ok := newSchema.properties[name]; ok {
+				c.add("property-optional", true, append(append([]string{}, keys...), "properties", name),
+					"property %s is no longer required", name)
+			}
+		}
+	}
+	for _, name := range oldSchema.propList {
+		propertyKeys := append(append([]string{}, keys...), "properties", name)
+		newProperty, ok := newSchema.properties[name]
+		if !ok {
+			c.add("property-removed", d != request, propertyKeys, "property %s was removed", name)
+			continue
+		}
+		if oldProperty := oldSchema.properties[name]; oldProperty != nil && newProperty != nil &&
+			!oldProperty.deprecated && newProperty.deprecated {
+			c.add("property-deprecated", false, propertyKeys, "property %s was deprecated", name)
+		}
+		c.compareSchemas(propertyKeys, oldSchema.properties[name], newProperty, d)
+	}
+	for _, name := range newSchema.propList {
+		if _, ok := oldSchema.properties[name]; !ok {
+			c.add("property-added", false, append(append([]string{}, keys...), "properties", name),
+				"property %s was added", name)
+		}
+	}
+	if oldSchema.items != nil && newSchema.items != nil {
+		c.compareSchemas(append(append([]string{}, keys...), "items"), oldSchema.items, newSchema.items, d)
+	}
+}
+
+// compareEnums compares enumerated values. Narrowing an enum breaks clients that send the
+// removed values, and widening it or removing it breaks clients that receive the new values.
+func (c *comparison) compareEnums(keys []string, oldSchema *schema, newSchema *schema, d direction) {
+	if len(oldSchema.enum) == 0 && len(newSchema.enum) == 0 {
+		return
+	}
+	if len(oldSchema.enum) == 0 {
+		c.add("enum-added", d != response, keys, "values were restricted to %s",
+			strings.Join(newSchema.enum, ", "))
+		return
+	}
+	if len(newSchema.enum) == 0 {
+		c.add("enum-removed", d != request, keys, "values are no longer restricted")
+		return
+	}
+	if removed := difference(oldSchema.enum, newSchema.enum); len(removed) > 0 {
+		c.add("enum-narrowed", d != response, keys, "enum values %s were removed",
+			strings.Join(removed, ", "))
+	}
+	if added := difference(newSchema.enum, oldSchema.enum); len(added) > 0 {
+		c.add("enum-widened", d != request, keys, "enum values %s were added",
+			strings.Join(added, ", "))
+	}
+}
+
+// description returns a short description of a schema's type for use in messages.
+func (s *schema) description() string {
+	switch {
+	case s.ref != "":
+		return s.ref
+	case s.typ == "" && s.format == "":
+		return "any"
+	case s.format != "":
+		return s.typ + "(" + s.format + ")"
+	default:
+		return s.typ
+	}
+}
+
+// difference returns the values in a that are not in b.
+func difference(a []string, b []string) []string {
+	values := make([]string, 0)
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, x)
+		}
+	}
+	return values
+}
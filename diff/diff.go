@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff compares two versions of an API description and reports
+// the changes between them, classifying each as breaking or non-breaking
+// for existing clients.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	plugins "github.com/google/gnostic/plugins"
+)
+
+// Change describes a single difference between two API descriptions.
+type Change struct {
+	// Code identifies the kind of change, e.g. "operation-removed".
+	Code string `json:"code"`
+	// Breaking is true if the change can break existing clients.
+	Breaking bool `json:"breaking"`
+	// Keys locate the change in the API description.
+	Keys []string `json:"keys"`
+	// Message describes the change.
+	Message string `json:"message"`
+}
+
+// Report holds the changes between two API descriptions.
+type Report struct {
+	Changes []*Change `json:"changes"`
+}
+
+// Compare compares two API descriptions. Both must be OpenAPI v2 documents
+// or both must be OpenAPI v3 documents.
+func Compare(oldDocument proto.Message, newDocument proto.Message) (*Report, error) {
+	oldModel, err := newAPIFromMessage(oldDocument)
+	if err != nil {
+		return nil, err
+	}
+	newModel, err := newAPIFromMessage(newDocument)
+	if err != nil {
+		return nil, err
+	}
+	if oldModel.schemasKey[0] != newModel.schemasKey[0] {
+		return nil, fmt.Errorf("unable to compare descriptions with different OpenAPI versions")
+	}
+	c := &comparison{old: oldModel, new: newModel}
+	c.compare()
+	return &Report{Changes: c.changes}, nil
+}
+
+func newAPIFromMessage(message proto.Message) (*api, error) {
+	switch document := message.(type) {
+	case *openapi_v2.Document:
+		return newAPIFromOpenAPI2(document), nil
+	case *openapi_v3.Document:
+		return newAPIFromOpenAPI3(document), nil
+	default:
+		return nil, fmt.Errorf("unsupported description type %T", message)
+	}
+}
+
+// HasBreakingChanges returns true if any change in the report is breaking.
+func (r *Report) HasBreakingChanges() bool {
+	return len(r.BreakingChanges()) > 0
+}
+
+// BreakingChanges returns the breaking changes in the report.
+func (r *Report) BreakingChanges() []*Change {
+	changes := make([]*Change, 0)
+	for _, change := range r.Changes {
+		if change.Breaking {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Text returns a human-readable listing of the changes, one per line.
+func (r *Report) Text() string {
+	var b strings.Builder
+	for _, change := range r.Changes {
+		label := "non-breaking"
+		if change.Breaking {
+			label = "BREAKING"
+		}
+		fmt.Fprintf(&b, "%s: %s (%s) [%s]\n", label, change.Message, change.Code, strings.Join(change.Keys, " "))
+	}
+	return b.String()
+}
+
+// JSON returns the report in JSON format.
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Messages returns the changes as plugin messages. Breaking changes are
+// reported as errors so that they can be used to gate changes in CI.
+func (r *Report) Messages() []*plugins.Message {
+	messages := make([]*plugins.Message, 0, len(r.Changes))
+	for _, change := range r.Changes {
+		level := plugins.Message_INFO
+		if change.Breaking {
+			level = plugins.Message_ERROR
+		}
+		messages = append(messages, &plugins.Message{
+			Level: level,
+			Code:  change.Code,
+			Text:  change.Message,
+			Keys:  change.Keys,
+		})
+	}
+	return messages
+}
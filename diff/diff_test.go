@@ -0,0 +1,416 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	plugins "github.com/google/gnostic/plugins"
+)
+
+const oldV3 = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+security:
+- key: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: integer
+          format: int32
+      - name: kind
+        in: query
+        schema:
+          type: string
+          enum: [cat, dog, bird]
+      responses:
+        "200":
+          description: Pets.
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: string
+      responses:
+        "200":
+          description: A pet.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+    delete:
+      operationId: deletePet
+      parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: string
+      responses:
+        "204":
+          description: Deleted.
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        tag:
+          type: string
+`
+
+const newV3 = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 2.0.0
+security:
+- key: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: string
+      - name: kind
+        in: query
+        schema:
+          type: string
+          enum: [cat, dog, fish]
+      - name: owner
+        in: query
+        required: true
+        schema:
+          type: string
+      responses:
+        "200":
+          description: Pets.
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+  /pets/{id}:
+    get:
+      operationId: getPet
+      security:
+      - oauth: [read]
+      parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+      responses:
+        "200":
+          description: A pet.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /owners:
+    get:
+      operationId: listOwners
+      responses:
+        "200":
+          description: Owners.
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        age:
+          type: integer
+`
+
+func compareV3(t *testing.T, oldText string, newText string) *Report {
+	oldDocument, err := openapi_v3.ParseDocument([]byte(oldText))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	newDocument, err := openapi_v3.ParseDocument([]byte(newText))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	report, err := Compare(oldDocument, newDocument)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	return report
+}
+
+func checkChanges(t *testing.T, report *Report, expected map[string]bool) {
+	found := make(map[string]bool)
+	for _, change := range report.Changes {
+		found[change.Code+": "+change.Message] = change.Breaking
+	}
+	for message, breaking := range expected {
+		b, ok := found[message]
+		if !ok {
+			t.Errorf("missing change %q in report:\n%s", message, report.Text())
+		} else if b != breaking {
+			t.Errorf("change %q has breaking=%t, expected %t", message, b, breaking)
+		}
+	}
+	if len(report.Changes) != len(expected) {
+		t.Errorf("expected %d changes, found %d:\n%s", len(expected), len(report.Changes), report.Text())
+	}
+}
+
+func TestCompareOpenAPIv3(t *testing.T) {
+	report := compareV3(t, oldV3, newV3)
+	checkChanges(t, report, map[string]bool{
+		"operation-removed: operation DELETE /pets/{petId} was removed":          true,
+		"operation-added: operation GET /owners was added":                       false,
+		"type-changed: type changed from integer(int32) to string":               true,
+		"enum-narrowed: enum values bird were removed":                           true,
+		"enum-widened: enum values fish were added":                              false,
+		"parameter-added: required query parameter owner was added to GET /pets": true,
+		"security-changed: GET /pets/{id} no longer accepts key[]":               true,
+		"security-changed: GET /pets/{id} now also accepts oauth[read]":          false,
+		"property-removed: property tag was removed":                             true,
+		"property-added: property age was added":                                 false,
+	})
+	if !report.HasBreakingChanges() {
+		t.Errorf("expected breaking changes")
+	}
+	if len(report.BreakingChanges()) != 6 {
+		t.Errorf("expected 6 breaking changes, found %d", len(report.BreakingChanges()))
+	}
+}
+
+// enumDocument returns a description that uses the kind and color schemas in
+// the parameters of an operation or in its response.
+func enumDocument(kind, color string, inResponse bool) string {
+	if inResponse {
+		return `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: Pets.
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  kind: ` + kind + `
+                  color: ` + color + "\n"
+	}
+	return `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - name: kind
+        in: query
+        schema: ` + kind + `
+      - name: color
+        in: query
+        schema: ` + color + `
+      responses:
+        "200":
+          description: Pets.
+`
+}
+
+func TestCompareEnumDirections(t *testing.T) {
+	for _, inResponse := range []bool{false, true} {
+		// Clients that receive values break on new ones, and clients that send them don't.
+		report := compareV3(t,
+			enumDocument("{type: string, enum: [cat, dog]}", "{type: string, enum: [black, white]}", inResponse),
+			enumDocument("{type: string, enum: [cat, dog, bird]}", "{type: string}", inResponse))
+		checkChanges(t, report, map[string]bool{
+			"enum-widened: enum values bird were added":     inResponse,
+			"enum-removed: values are no longer restricted": inResponse,
+		})
+	}
+}
+
+func TestCompareIdenticalDocuments(t *testing.T) {
+	report := compareV3(t, oldV3, oldV3)
+	if len(report.Changes) != 0 {
+		t.Errorf("expected no changes, found:\n%s", report.Text())
+	}
+}
+
+const oldV2 = `
+swagger: "2.0"
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      parameters:
+      - name: body
+        in: body
+        schema:
+          $ref: '#/definitions/Pet'
+      responses:
+        "200":
+          description: The pet.
+          schema:
+            $ref: '#/definitions/Pet'
+definitions:
+  Pet:
+    type: object
+    properties:
+      name:
+        type: string
+      status:
+        type: string
+        enum: [available, sold]
+`
+
+const newV2 = `
+swagger: "2.0"
+info:
+  title: Pets
+  version: 2.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      deprecated: true
+      parameters:
+      - name: body
+        in: body
+        required: true
+        schema:
+          $ref: '#/definitions/Pet'
+      - name: X-Request-Id
+        in: header
+        type: string
+      responses:
+        "201":
+          description: The pet.
+          schema:
+            $ref: '#/definitions/Pet'
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name:
+        type: string
+      status:
+        type: string
+        enum: [available]
+`
+
+func TestCompareOpenAPIv2(t *testing.T) {
+	oldDocument, err := openapi_v2.ParseDocument([]byte(oldV2))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	newDocument, err := openapi_v2.ParseDocument([]byte(newV2))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	report, err := Compare(oldDocument, newDocument)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	checkChanges(t, report, map[string]bool{
+		"operation-deprecated: operation POST /pets was deprecated":                       false,
+		"parameter-added: optional header parameter X-Request-Id was added to POST /pets": false,
+		"request-body-required: the request body of POST /pets became required":           true,
+		"response-removed: response 200 of POST /pets was removed":                        true,
+		"response-added: response 201 was added to POST /pets":                            false,
+		"property-required: property name became required":                                true,
+		"enum-narrowed: enum values sold were removed":                                    true,
+	})
+
+	// Descriptions with different versions can't be compared.
+	v3, err := openapi_v3.ParseDocument([]byte(oldV3))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if _, err = Compare(oldDocument, v3); err == nil {
+		t.Errorf("expected an error comparing v2 and v3 descriptions")
+	}
+}
+
+func TestReportOutputs(t *testing.T) {
+	report := compareV3(t, oldV3, newV3)
+	bytes, err := report.JSON()
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	var decoded Report
+	if err = json.Unmarshal(bytes, &decoded); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if len(decoded.Changes) != len(report.Changes) {
+		t.Errorf("JSON output has %d changes, expected %d", len(decoded.Changes), len(report.Changes))
+	}
+	messages := report.Messages()
+	errors := 0
+	for _, message := range messages {
+		if message.Level == plugins.Message_ERROR {
+			errors++
+		}
+	}
+	if errors != len(report.BreakingChanges()) {
+		t.Errorf("expected %d error messages, found %d", len(report.BreakingChanges()), errors)
+	}
+	for _, message := range messages {
+		if message.Code == "operation-removed" && message.Keys[1] != "/pets/{petId}" {
+			t.Errorf("unexpected keys for removed operation %+v", message.Keys)
+		}
+	}
+}
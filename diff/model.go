@@ -0,0 +1,191 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The types in this file are a version-independent view of the parts of an
+// API description that matter to its clients. Both OpenAPI v2 and v3
+// documents are converted to this form before they are compared.
+
+// api is a normalized API description.
+type api struct {
+	schemasKey []string // location of named schemas ("definitions" or "components/schemas")
+	schemas    map[string]*schema
+	schemaList []string // schema names in document order
+	operations map[string]*operation
+	opList     []string // operation keys in document order
+}
+
+// operation is a normalized operation.
+type operation struct {
+	method      string
+	path        string
+	id          string
+	deprecated  bool
+	parameters  map[string]*parameter
+	paramList   []string
+	requestBody *schema
+	bodyNeeded  bool
+	responses   map[string]*schema // keyed by status code; nil schemas for empty responses
+	codeList    []string
+	security    []string // normalized security alternatives; nil if unsecured
+}
+
+// parameter is a normalized parameter.
+type parameter struct {
+	name       string
+	in         string
+	required   bool
+	deprecated bool
+	schema     *schema
+}
+
+// schema is a normalized schema.
+type schema struct {
+	ref        string // name of a referenced named schema
+	typ        string
+	format     string
+	deprecated bool
+	enum       []string
+	required   []string
+	properties map[string]*schema
+	propList   []string
+	items      *schema
+}
+
+func newAPI() *api {
+	return &api{
+		schemas:    make(map[string]*schema),
+		operations: make(map[string]*operation),
+	}
+}
+
+func (a *api) addSchema(name string, s *schema) {
+	if _, ok := a.schemas[name]; !ok {
+		a.schemaList = append(a.schemaList, name)
+	}
+	a.schemas[name] = s
+}
+
+var pathParameterPattern = regexp.MustCompile(`{[^}]*}`)
+
+// operationKey identifies an operation independently of the names of its path parameters.
+func operationKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + pathParameterPattern.ReplaceAllString(path, "{}")
+}
+
+func (a *api) addOperation(op *operation) {
+	key := operationKey(op.method, op.path)
+	if _, ok := a.operations[key]; !ok {
+		a.opList = append(a.opList, key)
+	}
+	a.operations[key] = op
+}
+
+func newOperation(method string, path string) *operation {
+	return &operation{
+		method:     method,
+		path:       path,
+		parameters: make(map[string]*parameter),
+		responses:  make(map[string]*schema),
+	}
+}
+
+func parameterKey(in string, name string) string {
+	if in == "header" {
+		// header names are case-insensitive
+		name = strings.ToLower(name)
+	}
+	return in + ":" + name
+}
+
+func (op *operation) addParameter(p *parameter) {
+	key := parameterKey(p.in, p.name)
+	if _, ok := op.parameters[key]; !ok {
+		op.paramList = append(op.paramList, key)
+	}
+	op.parameters[key] = p
+}
+
+func (op *operation) addResponse(code string, s *schema) {
+	if _, ok := op.responses[code]; !ok {
+		op.codeList = append(op.codeList, code)
+	}
+	op.responses[code] = s
+}
+
+func (s *schema) addProperty(name string, property *schema) {
+	if s.properties == nil {
+		s.properties = make(map[string]*schema)
+	}
+	if _, ok := s.properties[name]; !ok {
+		s.propList = append(s.propList, name)
+	}
+	s.properties[name] = property
+}
+
+// merge adds the properties and requirements of another schema, as for allOf.
+func (s *schema) merge(other *schema) {
+	if other == nil {
+		return
+	}
+	if s.typ == "" {
+		s.typ = other.typ
+	}
+	for _, name := range other.propList {
+		s.addProperty(name, other.properties[name])
+	}
+	s.required = append(s.required, other.required...)
+}
+
+func (s *schema) isRequired(name string) bool {
+	for _, r := range s.required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// securityAlternative returns a canonical string for a security requirement.
+func securityAlternative(schemes map[string][]string) string {
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		scopes := append([]string{}, schemes[name]...)
+		sort.Strings(scopes)
+		parts = append(parts, name+"["+strings.Join(scopes, ",")+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// enumValue returns the text of a scalar enum value stored as YAML.
+func enumValue(yaml string) string {
+	text := strings.TrimSpace(yaml)
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		return unquoted
+	}
+	return strings.Trim(text, "'")
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"strings"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+)
+
+// newAPIFromOpenAPI2 builds a normalized description of an OpenAPI v2 document.
+func newAPIFromOpenAPI2(document *openapi_v2.Document) *api {
+	a := newAPI()
+	a.schemasKey = []string{"definitions"}
+	if document.Definitions != nil {
+		for _, pair := range document.Definitions.AdditionalProperties {
+			a.addSchema(pair.Name, schemaForOpenAPI2Schema(pair.Value))
+		}
+	}
+	security := securityForOpenAPI2(document.Security)
+	if document.Paths == nil {
+		return a
+	}
+	for _, pair := range document.Paths.Path {
+		path := pair.Name
+		item := pair.Value
+		for _, o := range []struct {
+			method    string
+			operation *openapi_v2.Operation
+		}{
+			{"get", item.Get},
+			{"put", item.Put},
+			{"post", item.Post},
+			{"delete", item.Delete},
+			{"options", item.Options},
+			{"head", item.Head},
+			{"patch", item.Patch},
+		} {
+			if o.operation == nil {
+				continue
+			}
+			op := newOperation(o.method, path)
+			op.id = o.operation.OperationId
+			op.deprecated = o.operation.Deprecated
+			op.security = security
+			if o.operation.Security != nil {
+				op.security = securityForOpenAPI2(o.operation.Security)
+			}
+			for _, parameters := range [][]*openapi_v2.ParametersItem{item.Parameters, o.operation.Parameters} {
+				for _, p := range parameters {
+					addOpenAPI2Parameter(document, op, p)
+				}
+			}
+			if o.operation.Responses != nil {
+				for _, response := range o.operation.Responses.ResponseCode {
+					op.addResponse(response.Name, schemaForOpenAPI2Response(document, response.Value))
+				}
+			}
+			a.addOperation(op)
+		}
+	}
+	return a
+}
+
+func securityForOpenAPI2(requirements []*openapi_v2.SecurityRequirement) []string {
+	if len(requirements) == 0 {
+		return nil
+	}
+	alternatives := make([]string, 0)
+	for _, requirement := range requirements {
+		schemes := make(map[string][]string)
+		for _, pair := range requirement.AdditionalProperties {
+			schemes[pair.Name] = pair.Value.GetValue()
+		}
+		alternatives = append(alternatives, securityAlternative(schemes))
+	}
+	return alternatives
+}
+
+func addOpenAPI2Parameter(document *openapi_v2.Document, op *operation, item *openapi_v2.ParametersItem) {
+	p := item.GetParameter()
+	if ref := item.GetJsonReference(); ref != nil && document.Parameters != nil {
+		name := strings.TrimPrefix(ref.XRef, "#/parameters/")
+		for _, pair := range document.Parameters.AdditionalProperties {
+			if pair.Name == name {
+				p = pair.Value
+			}
+		}
+	}
+	if p == nil {
+		return
+	}
+	if body := p.GetBodyParameter(); body != nil {
+		op.requestBody = schemaForOpenAPI2Schema(body.Schema)
+		op.bodyNeeded = body.Required
+		return
+	}
+	nonBody := p.GetNonBodyParameter()
+	if nonBody == nil {
+		return
+	}
+	switch {
+	case nonBody.GetQueryParameterSubSchema() != nil:
+		q := nonBody.GetQueryParameterSubSchema()
+		op.addParameter(&parameter{name: q.Name, in: "query", required: q.Required,
+			schema: schemaForOpenAPI2Primitive(q.Type, q.Format, q.Enum, q.Items)})
+	case nonBody.GetPathParameterSubSchema() != nil:
+		q := nonBody.GetPathParameterSubSchema()
+		op.addParameter(&parameter{name: q.Name, in: "path", required: true,
+			schema: schemaForOpenAPI2Primitive(q.Type, q.Format, q.Enum, q.Items)})
+	case nonBody.GetHeaderParameterSubSchema() != nil:
+		q := nonBody.GetHeaderParameterSubSchema()
+		op.addParameter(&parameter{name: q.Name, in: "header", required: q.Required,
+			schema: schemaForOpenAPI2Primitive(q.Type, q.Format, q.Enum, q.Items)})
+	case nonBody.GetFormDataParameterSubSchema() != nil:
+		q := nonBody.GetFormDataParameterSubSchema()
+		op.addParameter(&parameter{name: q.Name, in: "formData", required: q.Required,
+			schema: schemaForOpenAPI2Primitive(q.Type, q.Format, q.Enum, q.Items)})
+	}
+}
+
+func schemaForOpenAPI2Primitive(typ string, format string, enum []*openapi_v2.Any, items *openapi_v2.PrimitivesItems) *schema {
+	s := &schema{typ: typ, format: format}
+	for _, value := range enum {
+		s.enum = append(s.enum, enumValue(value.Yaml))
+	}
+	if items != nil {
+		s.items = schemaForOpenAPI2Primitive(items.Type, items.Format, items.Enum, items.Items)
+	}
+	return s
+}
+
+func schemaForOpenAPI2Response(document *openapi_v2.Document, value *openapi_v2.ResponseValue) *schema {
+	response := value.GetResponse()
+	if ref := value.GetJsonReference(); ref != nil && document.Responses != nil {
+		name := strings.TrimPrefix(ref.XRef, "#/responses/")
+		for _, pair := range document.Responses.AdditionalProperties {
+			if pair.Name == name {
+				response = pair.Value
+			}
+		}
+	}
+	if response == nil || response.Schema == nil {
+		return nil
+	}
+	if file := response.Schema.GetFileSchema(); file != nil {
+		return &schema{typ: "file", format: file.Format}
+	}
+	return schemaForOpenAPI2Schema(response.Schema.GetSchema())
+}
+
+func schemaForOpenAPI2Schema(s *openapi_v2.Schema) *schema {
+	if s == nil {
+		return nil
+	}
+	if s.XRef != "" {
+		return &schema{ref: strings.TrimPrefix(s.XRef, "#/definitions/")}
+	}
+	result := &schema{format: s.Format, required: s.Required}
+	if s.Type != nil {
+		result.typ = strings.Join(s.Type.Value, ",")
+	}
+	for _, value := range s.Enum {
+		result.enum = append(result.enum, enumValue(value.Yaml))
+	}
+	for _, member := range s.AllOf {
+		result.merge(schemaForOpenAPI2Schema(member))
+	}
+	if s.Properties != nil {
+		for _, pair := range s.Properties.AdditionalProperties {
+			result.addProperty(pair.Name, schemaForOpenAPI2Schema(pair.Value))
+		}
+	}
+	if s.Items != nil && len(s.Items.Schema) > 0 {
+		result.items = schemaForOpenAPI2Schema(s.Items.Schema[0])
+	}
+	return result
+}
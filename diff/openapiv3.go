@@ -0,0 +1,139 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"strings"
+
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+const openAPI3SchemaPrefix = "#/components/schemas/"
+
+// openAPI3Builder builds a normalized description of an OpenAPI v3 document.
+type openAPI3Builder struct {
+	document *openapi_v3.Document
+}
+
+func newAPIFromOpenAPI3(document *openapi_v3.Document) *api {
+	b := &openAPI3Builder{document: document}
+	a := newAPI()
+	a.schemasKey = []string{"components", "schemas"}
+	if document.Components != nil && document.Components.Schemas != nil {
+		for _, pair := range document.Components.Schemas.AdditionalProperties {
+			a.addSchema(pair.Name, b.schema(pair.Value))
+		}
+	}
+	security := securityForOpenAPI3(document.Security)
+	if document.Paths == nil {
+		return a
+	}
+	for _, pair := range document.Paths.Path {
+		path := pair.Name
+		item := pair.Value
+		for _, o := range []struct {
+			method    string
+			operation *openapi_v3.Operation
+		}{
+			{"get", item.Get},
+			{"put", item.Put},
+			{"post", item.Post},
+			{"delete", item.Delete},
+			{"options", item.Options},
+			{"head", item.Head},
+			{"patch", item.Patch},
+			{"trace", item.Trace},
+		} {
+			if o.operation == nil {
+				continue
+			}
+			op := newOperation(o.method, path)
+			op.id = o.operation.OperationId
+			op.deprecated = o.operation.Deprecated
+			op.security = security
+			if o.operation.Security != nil {
+				op.security = securityForOpenAPI3(o.operation.Security)
+			}
+			for _, parameters := range [][]*openapi_v3.ParameterOrReference{item.Parameters, o.operation.Parameters} {
+				for _, p := range parameters {
+					if resolved := openapi_v3.ResolveParameter(b.document, p); resolved != nil {
+						required := resolved.Required || resolved.In == "path"
+						op.addParameter(&parameter{name: resolved.Name, in: resolved.In, required: required,
+							deprecated: resolved.Deprecated, schema: b.schema(resolved.Schema)})
+					}
+				}
+			}
+			if body := openapi_v3.ResolveRequestBody(b.document, o.operation.RequestBody); body != nil {
+				op.requestBody = b.schema(openapi_v3.SchemaForContent(body.Content))
+				op.bodyNeeded = body.Required
+			}
+			if o.operation.Responses != nil {
+				for _, response := range o.operation.Responses.ResponseOrReference {
+					var s *schema
+					if r := openapi_v3.ResolveResponse(b.document, response.Value); r != nil {
+						s = b.schema(openapi_v3.SchemaForContent(r.Content))
+					}
+					op.addResponse(response.Name, s)
+				}
+			}
+			a.addOperation(op)
+		}
+	}
+	return a
+}
+
+func securityForOpenAPI3(requirements []*openapi_v3.SecurityRequirement) []string {
+	if len(requirements) == 0 {
+		return nil
+	}
+	alternatives := make([]string, 0)
+	for _, requirement := range requirements {
+		schemes := make(map[string][]string)
+		for _, pair := range requirement.AdditionalProperties {
+			schemes[pair.Name] = pair.Value.GetValue()
+		}
+		alternatives = append(alternatives, securityAlternative(schemes))
+	}
+	return alternatives
+}
+
+func (b *openAPI3Builder) schema(item *openapi_v3.SchemaOrReference) *schema {
+	if item == nil {
+		return nil
+	}
+	if reference := item.GetReference(); reference != nil {
+		return &schema{ref: strings.TrimPrefix(reference.XRef, openAPI3SchemaPrefix)}
+	}
+	s := item.GetSchema()
+	if s == nil {
+		return nil
+	}
+	result := &schema{typ: s.Type, format: s.Format, deprecated: s.Deprecated, required: s.Required}
+	for _, value := range s.Enum {
+		result.enum = append(result.enum, enumValue(value.Yaml))
+	}
+	for _, member := range s.AllOf {
+		result.merge(b.schema(member))
+	}
+	if s.Properties != nil {
+		for _, pair := range s.Properties.AdditionalProperties {
+			result.addProperty(pair.Name, b.schema(pair.Value))
+		}
+	}
+	if s.Items != nil && len(s.Items.SchemaOrReference) > 0 {
+		result.items = b.schema(s.Items.SchemaOrReference[0])
+	}
+	return result
+}
@@ -15,17 +15,24 @@
 package gnostic_extension_v1
 
 import (
-	"google.golang.org/protobuf/types/known/anypb"
+	"bufio"
+	"bytes"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 
+	"google.golang.org/protobuf/encoding/protodelim"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 type extensionHandler func(name string, yamlInput string) (bool, proto.Message, error)
 
-// Main implements the main program of an extension handler.
+// Main implements the main program of an extension handler. Handlers read a
+// request and write a response. With the --batch flag, they read a series of
+// size-delimited requests and write a size-delimited response for each, so that
+// the compiler can send all of the values of a document to one process.
 func Main(handler extensionHandler) {
 	// unpack the request
 	data, err := ioutil.ReadAll(os.Stdin)
@@ -33,6 +40,10 @@ func Main(handler extensionHandler) {
 		log.Println("File error:", err.Error())
 		os.Exit(1)
 	}
+	if len(os.Args) > 1 && os.Args[1] == "--batch" {
+		mainBatch(handler, data)
+		return
+	}
 	if len(data) == 0 {
 		log.Println("No input data.")
 		os.Exit(1)
@@ -43,6 +54,32 @@ func Main(handler extensionHandler) {
 		log.Println("Input error:", err.Error())
 		os.Exit(1)
 	}
+	responseBytes, _ := proto.Marshal(handle(handler, request))
+	os.Stdout.Write(responseBytes)
+}
+
+func mainBatch(handler extensionHandler, data []byte) {
+	reader := bytes.NewReader(data)
+	writer := bufio.NewWriter(os.Stdout)
+	for {
+		request := &ExtensionHandlerRequest{}
+		err := protodelim.UnmarshalFrom(reader, request)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Println("Input error:", err.Error())
+			os.Exit(1)
+		}
+		if _, err := protodelim.MarshalTo(writer, handle(handler, request)); err != nil {
+			log.Println("Output error:", err.Error())
+			os.Exit(1)
+		}
+	}
+	writer.Flush()
+}
+
+// handle calls the handler with a request and returns its response.
+func handle(handler extensionHandler, request *ExtensionHandlerRequest) *ExtensionHandlerResponse {
 	// call the handler
 	handled, output, err := handler(request.Wrapper.ExtensionName, request.Wrapper.Yaml)
 	// respond with the output of the handler
@@ -59,6 +96,5 @@ func Main(handler extensionHandler) {
 			response.Errors = append(response.Errors, err.Error())
 		}
 	}
-	responseBytes, _ := proto.Marshal(response)
-	os.Stdout.Write(responseBytes)
+	return response
 }
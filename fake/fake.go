@@ -0,0 +1,511 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fake generates values that conform to OpenAPI and JSON schemas,
+// for use in tests and mock servers.
+//
+// Values are generated with a seeded random source, so a generator that is
+// created with the same seed produces the same values for the same schemas.
+package fake
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// Options control the values that a generator produces.
+type Options struct {
+	// Seed initializes the random source of the generator.
+	Seed int64
+	// MaxDepth limits the nesting of generated objects and arrays. Below it,
+	// optional properties are omitted and arrays are as short as allowed.
+	// Below that, schemas that require themselves recursively generate nulls.
+	// The default is 4.
+	MaxDepth int
+	// OmitReadOnly omits read-only properties, as in request bodies.
+	OmitReadOnly bool
+	// OmitWriteOnly omits write-only properties, as in response bodies.
+	OmitWriteOnly bool
+}
+
+// Generator generates values for schemas. A generator is not safe for
+// concurrent use.
+type Generator struct {
+	rand    *rand.Rand
+	options Options
+	active  map[*schema]int // schemas that values are being generated for
+}
+
+// NewGenerator returns a generator. If options are nil, the seed is 0.
+func NewGenerator(options *Options) *Generator {
+	g := &Generator{active: make(map[*schema]int)}
+	if options != nil {
+		g.options = *options
+	}
+	if g.options.MaxDepth <= 0 {
+		g.options.MaxDepth = 4
+	}
+	g.rand = rand.New(rand.NewSource(g.options.Seed))
+	return g
+}
+
+// hardDepthLimit stops generation for schemas that are nested too deeply
+// even without recursion.
+const hardDepthLimit = 32
+
+// schema is the common form of the schemas that values are generated for.
+// Limits are pointers so that unset limits can be distinguished from zero.
+type schema struct {
+	types                []string
+	format               string
+	enum                 []interface{}
+	example              interface{}
+	hasExample           bool
+	minimum              *float64
+	maximum              *float64
+	exclusiveMinimum     bool
+	exclusiveMaximum     bool
+	multipleOf           float64
+	minLength            *int64
+	maxLength            *int64
+	pattern              string
+	items                *schema
+	minItems             *int64
+	maxItems             *int64
+	uniqueItems          bool
+	properties           []*property
+	required             []string
+	additionalProperties *schema
+	minProperties        *int64
+	readOnly             bool
+	writeOnly            bool
+	allOf                []*schema
+	anyOf                []*schema
+	oneOf                []*schema
+	ref                  string // the reference that the schema was converted for
+}
+
+// property is a named property of an object schema.
+type property struct {
+	name   string
+	schema *schema
+}
+
+// positive returns a pointer to a limit of an OpenAPI schema, where zero means unset.
+func positive(value int64) *int64 {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
+
+// nonZero returns a pointer to a bound of an OpenAPI schema, where zero means unset.
+func nonZero(value float64) *float64 {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// generate returns a value for a schema.
+func (g *Generator) generate(s *schema, depth int) interface{} {
+	if s == nil || depth > hardDepthLimit {
+		return nil
+	}
+	// References share the schemas that they refer to, so a referenced schema
+	// that is already active is a recursive reference. Required recursive
+	// properties would otherwise double the size of a value with each level.
+	if s.ref != "" {
+		if g.active[s] > 0 && depth > g.options.MaxDepth {
+			return nil
+		}
+		g.active[s]++
+		defer func() { g.active[s]-- }()
+	}
+	if s.hasExample {
+		return s.example
+	}
+	if len(s.enum) > 0 {
+		return s.enum[g.rand.Intn(len(s.enum))]
+	}
+	if len(s.allOf) > 0 {
+		merged := merge(s, s.allOf)
+		return g.generate(merged, depth)
+	}
+	if alternatives := append(append([]*schema{}, s.oneOf...), s.anyOf...); len(alternatives) > 0 {
+		chosen := alternatives[g.rand.Intn(len(alternatives))]
+		base := *s
+		base.oneOf, base.anyOf = nil, nil
+		return g.generate(merge(&base, []*schema{chosen}), depth)
+	}
+	switch g.chooseType(s) {
+	case "string":
+		return g.string(s)
+	case "integer":
+		return g.integer(s)
+	case "number":
+		return g.number(s)
+	case "boolean":
+		return g.rand.Intn(2) == 1
+	case "array":
+		return g.array(s, depth)
+	case "object":
+		return g.object(s, depth)
+	}
+	return nil
+}
+
+// chooseType returns the type of the value to generate. Values are never null
+// unless null is the only allowed type.
+func (g *Generator) chooseType(s *schema) string {
+	types := make([]string, 0)
+	for _, t := range s.types {
+		if t != "null" {
+			types = append(types, t)
+		}
+	}
+	switch {
+	case len(types) > 0:
+		return types[g.rand.Intn(len(types))]
+	case len(s.types) > 0:
+		return "null"
+	case len(s.properties) > 0 || s.additionalProperties != nil:
+		return "object"
+	case s.items != nil:
+		return "array"
+	case s.format != "" || s.pattern != "" || s.minLength != nil || s.maxLength != nil:
+		return "string"
+	case s.minimum != nil || s.maximum != nil:
+		return "number"
+	}
+	return "string"
+}
+
+// merge combines a schema with the members of an allOf or the chosen member of a oneOf or anyOf.
+func merge(base *schema, members []*schema) *schema {
+	result := *base
+	result.allOf = nil
+	result.properties = append([]*property{}, base.properties...)
+	result.required = append([]string{}, base.required...)
+	for _, member := range members {
+		if member == nil {
+			continue
+		}
+		m := member
+		if len(m.allOf) > 0 {
+			m = merge(m, m.allOf)
+		}
+		if len(result.types) == 0 {
+			result.types = m.types
+		}
+		if result.format == "" {
+			result.format = m.format
+		}
+		if len(result.enum) == 0 {
+			result.enum = m.enum
+		}
+		if !result.hasExample && m.hasExample && len(m.properties) == 0 {
+			result.example, result.hasExample = m.example, true
+		}
+		if m.minimum != nil && (result.minimum == nil || *m.minimum > *result.minimum) {
+			result.minimum, result.exclusiveMinimum = m.minimum, m.exclusiveMinimum
+		}
+		if m.maximum != nil && (result.maximum == nil || *m.maximum < *result.maximum) {
+			result.maximum, result.exclusiveMaximum = m.maximum, m.exclusiveMaximum
+		}
+		if result.multipleOf == 0 {
+			result.multipleOf = m.multipleOf
+		}
+		if m.minLength != nil && (result.minLength == nil || *m.minLength > *result.minLength) {
+			result.minLength = m.minLength
+		}
+		if m.maxLength != nil && (result.maxLength == nil || *m.maxLength < *result.maxLength) {
+			result.maxLength = m.maxLength
+		}
+		if result.pattern == "" {
+			result.pattern = m.pattern
+		}
+		if result.items == nil {
+			result.items = m.items
+		}
+		if m.minItems != nil && (result.minItems == nil || *m.minItems > *result.minItems) {
+			result.minItems = m.minItems
+		}
+		if m.maxItems != nil && (result.maxItems == nil || *m.maxItems < *result.maxItems) {
+			result.maxItems = m.maxItems
+		}
+		result.uniqueItems = result.uniqueItems || m.uniqueItems
+		for _, p := range m.properties {
+			replaced := false
+			for i, existing := range result.properties {
+				if existing.name == p.name {
+					result.properties[i] = p
+					replaced = true
+				}
+			}
+			if !replaced {
+				result.properties = append(result.properties, p)
+			}
+		}
+		for _, name := range m.required {
+			if !contains(result.required, name) {
+				result.required = append(result.required, name)
+			}
+		}
+		if result.additionalProperties == nil {
+			result.additionalProperties = m.additionalProperties
+		}
+		if result.oneOf == nil && result.anyOf == nil {
+			result.oneOf, result.anyOf = m.oneOf, m.anyOf
+		}
+	}
+	return &result
+}
+
+func (g *Generator) string(s *schema) interface{} {
+	minLength, maxLength := int64(0), int64(-1)
+	if s.minLength != nil {
+		minLength = *s.minLength
+	}
+	if s.maxLength != nil {
+		maxLength = *s.maxLength
+	}
+	var value string
+	if generate, ok := formats[s.format]; ok {
+		value = generate(g)
+	} else if s.pattern != "" {
+		// Patterns are retried a few times to find a value of an allowed length.
+		for i := 0; i < 10; i++ {
+			if value = g.pattern(s.pattern); int64(len(value)) >= minLength && (maxLength < 0 || int64(len(value)) <= maxLength) {
+				break
+			}
+		}
+		return value
+	} else {
+		value = g.words(minLength, maxLength)
+	}
+	for int64(len(value)) < minLength {
+		value += string(rune('a' + g.rand.Intn(26)))
+	}
+	if maxLength >= 0 && int64(len(value)) > maxLength {
+		value = value[:maxLength]
+	}
+	return value
+}
+
+// words returns a phrase of random words with a length between the limits.
+func (g *Generator) words(minLength, maxLength int64) string {
+	target := minLength
+	if target == 0 {
+		target = int64(1 + g.rand.Intn(3))
+	}
+	value := ""
+	for count := int64(0); count < target; count++ {
+		word := wordList[g.rand.Intn(len(wordList))]
+		next := word
+		if value != "" {
+			next = value + " " + word
+		}
+		if maxLength >= 0 && int64(len(next)) > maxLength {
+			break
+		}
+		value = next
+		if minLength > 0 && int64(len(value)) >= minLength {
+			break
+		}
+	}
+	return value
+}
+
+// bounds returns the inclusive range of a numeric schema, given a default range.
+func (s *schema) bounds(low, high, step float64) (float64, float64) {
+	switch {
+	case s.minimum != nil && s.maximum != nil:
+		low, high = *s.minimum, *s.maximum
+	case s.minimum != nil:
+		low, high = *s.minimum, *s.minimum+(high-low)
+	case s.maximum != nil:
+		low, high = *s.maximum-(high-low), *s.maximum
+	}
+	if s.exclusiveMinimum {
+		low += step
+	}
+	if s.exclusiveMaximum {
+		high -= step
+	}
+	switch s.format {
+	case "int32":
+		low, high = math.Max(low, math.MinInt32), math.Min(high, math.MaxInt32)
+	case "uint32":
+		low, high = math.Max(low, 0), math.Min(high, math.MaxUint32)
+	case "uint64":
+		low = math.Max(low, 0)
+	}
+	return low, high
+}
+
+func (g *Generator) integer(s *schema) interface{} {
+	low, high := s.bounds(1, 1000, 1)
+	low, high = math.Ceil(low), math.Floor(high)
+	if s.multipleOf > 0 {
+		low, high = math.Ceil(low/s.multipleOf), math.Floor(high/s.multipleOf)
+	}
+	value := low
+	if high > low {
+		value = low + math.Floor(g.rand.Float64()*(high-low+1))
+	}
+	if s.multipleOf > 0 {
+		value *= s.multipleOf
+	}
+	return int64(value)
+}
+
+func (g *Generator) number(s *schema) interface{} {
+	step := 0.01
+	low, high := s.bounds(0, 1000, step)
+	if s.multipleOf > 0 {
+		step = s.multipleOf
+	}
+	low, high = math.Ceil(low/step), math.Floor(high/step)
+	value := low
+	if high > low {
+		value = low + math.Floor(g.rand.Float64()*(high-low+1))
+	}
+	// Rounding keeps values like 0.1*3 from printing as 0.30000000000000004.
+	return math.Round(value*step*1e6) / 1e6
+}
+
+func (g *Generator) array(s *schema, depth int) interface{} {
+	minItems, maxItems := int64(0), int64(-1)
+	if s.minItems != nil {
+		minItems = *s.minItems
+	}
+	if s.maxItems != nil {
+		maxItems = *s.maxItems
+	}
+	count := minItems
+	if depth < g.options.MaxDepth {
+		high := minItems + 3
+		if count == 0 {
+			count = 1
+		}
+		if maxItems >= 0 && high > maxItems {
+			high = maxItems
+		}
+		if high > count {
+			count += int64(g.rand.Intn(int(high - count + 1)))
+		}
+	}
+	result := make([]interface{}, 0, count)
+	for attempts := 0; int64(len(result)) < count && attempts < int(count)*10; attempts++ {
+		value := g.generate(s.items, depth+1)
+		if s.uniqueItems && containsValue(result, value) {
+			continue
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+func (g *Generator) object(s *schema, depth int) interface{} {
+	result := make(map[string]interface{})
+	for _, p := range s.properties {
+		required := contains(s.required, p.name)
+		if p.schema != nil && ((p.schema.readOnly && g.options.OmitReadOnly) || (p.schema.writeOnly && g.options.OmitWriteOnly)) {
+			continue
+		}
+		// Optional properties are included at random above the depth limit.
+		if !required && (depth >= g.options.MaxDepth || g.rand.Intn(4) == 0) {
+			continue
+		}
+		result[p.name] = g.generate(p.schema, depth+1)
+	}
+	// Required properties without schemas are allowed to have any value.
+	for _, name := range s.required {
+		if _, ok := result[name]; !ok && s.property(name) == nil {
+			result[name] = g.words(0, -1)
+		}
+	}
+	if s.minProperties != nil {
+		for i := 0; int64(len(result)) < *s.minProperties; i++ {
+			name := wordList[g.rand.Intn(len(wordList))]
+			if _, ok := result[name]; ok {
+				name += strconv.Itoa(i)
+			}
+			if s.additionalProperties != nil {
+				result[name] = g.generate(s.additionalProperties, depth+1)
+			} else {
+				result[name] = g.words(0, -1)
+			}
+		}
+	}
+	return result
+}
+
+func (s *schema) property(name string) *property {
+	for _, p := range s.properties {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares generated values, which are built from maps, slices and scalars.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k := range x {
+			if !equal(x[k], y[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
@@ -0,0 +1,363 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/google/gnostic/jsonschema"
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	"github.com/google/gnostic/validate"
+)
+
+const documentV3 = `
+openapi: 3.0.0
+info:
+  title: Shop
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Order:
+      type: object
+      required: [id, customer, items, placed]
+      properties:
+        id:
+          type: string
+          format: uuid
+        placed:
+          type: string
+          format: date-time
+        customer:
+          $ref: '#/components/schemas/Customer'
+        items:
+          type: array
+          minItems: 1
+          maxItems: 5
+          items:
+            $ref: '#/components/schemas/Item'
+        status:
+          type: string
+          enum: [placed, shipped, delivered]
+        total:
+          type: number
+          minimum: 0.5
+          maximum: 10000
+        count:
+          type: integer
+          format: int64
+          minimum: 1
+          maximum: 9
+          exclusiveMaximum: true
+        code:
+          type: string
+          pattern: '^[A-Z]{3}-\d{4}$'
+        note:
+          type: string
+          minLength: 5
+          maxLength: 12
+        token:
+          type: string
+          writeOnly: true
+        payment:
+          oneOf:
+          - $ref: '#/components/schemas/Card'
+          - $ref: '#/components/schemas/Transfer'
+        gift:
+          allOf:
+          - $ref: '#/components/schemas/Item'
+          - type: object
+            required: [message]
+            properties:
+              message:
+                type: string
+    Customer:
+      type: object
+      required: [email, name]
+      properties:
+        email:
+          type: string
+          format: email
+        name:
+          type: string
+        referrer:
+          $ref: '#/components/schemas/Customer'
+    Item:
+      type: object
+      required: [sku, quantity]
+      properties:
+        sku:
+          type: string
+          pattern: '[a-z]+-[0-9]+'
+        quantity:
+          type: integer
+          minimum: 1
+          multipleOf: 2
+        tags:
+          type: array
+          uniqueItems: true
+          items:
+            type: string
+            enum: [red, green, blue]
+    Card:
+      type: object
+      required: [number]
+      properties:
+        number:
+          type: string
+          pattern: '^[0-9]{16}$'
+    Transfer:
+      type: object
+      required: [iban]
+      properties:
+        iban:
+          type: string
+          example: DE89370400440532013000
+`
+
+func openAPI3Schema(t *testing.T, document *openapi_v3.Document, name string) *openapi_v3.SchemaOrReference {
+	for _, pair := range document.Components.Schemas.AdditionalProperties {
+		if pair.Name == name {
+			return pair.Value
+		}
+	}
+	t.Fatalf("missing schema %s", name)
+	return nil
+}
+
+func TestOpenAPI3Conformance(t *testing.T) {
+	document, err := openapi_v3.ParseDocument([]byte(documentV3))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	order := openAPI3Schema(t, document, "Order")
+	validationSchema := validate.NewOpenAPI3Schemas(document, nil).ForOpenAPI3(order)
+	for seed := int64(0); seed < 200; seed++ {
+		g := NewGenerator(&Options{Seed: seed, OmitWriteOnly: true})
+		value := g.OpenAPI3SchemaOrReference(order, document)
+		if err := validationSchema.ValidateResponse(value); err != nil {
+			bytes, _ := json.Marshal(value)
+			t.Fatalf("seed %d: %s\n%s", seed, err.Error(), string(bytes))
+		}
+		if _, ok := value.(map[string]interface{})["token"]; ok {
+			t.Errorf("seed %d: write-only property was generated", seed)
+		}
+	}
+}
+
+func TestDeterminism(t *testing.T) {
+	document, err := openapi_v3.ParseDocument([]byte(documentV3))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	order := openAPI3Schema(t, document, "Order")
+	generate := func(seed int64) string {
+		g := NewGenerator(&Options{Seed: seed})
+		bytes, _ := json.Marshal(g.OpenAPI3SchemaOrReference(order, document))
+		return string(bytes)
+	}
+	if generate(7) != generate(7) {
+		t.Errorf("values generated with the same seed differ")
+	}
+	if generate(7) == generate(8) {
+		t.Errorf("values generated with different seeds are the same")
+	}
+}
+
+func TestGolden(t *testing.T) {
+	document, err := openapi_v3.ParseDocument([]byte(documentV3))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	g := NewGenerator(&Options{Seed: 1})
+	bytes, _ := json.MarshalIndent(g.OpenAPI3SchemaOrReference(openAPI3Schema(t, document, "Order"), document), "", "  ")
+	expected, err := ioutil.ReadFile("testdata/order.json")
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if strings.TrimSpace(string(bytes)) != strings.TrimSpace(string(expected)) {
+		t.Errorf("expected\n%s\nfound\n%s", string(expected), string(bytes))
+	}
+}
+
+const documentV2 = `
+swagger: "2.0"
+info:
+  title: Shop
+  version: 1.0.0
+paths: {}
+definitions:
+  Pet:
+    type: object
+    required: [id, name]
+    properties:
+      id:
+        type: integer
+        format: int32
+        minimum: -5
+        maximum: -1
+      name:
+        type: string
+        maxLength: 8
+      born:
+        type: string
+        format: date
+      weight:
+        type: number
+        multipleOf: 0.25
+      owner:
+        $ref: '#/definitions/Owner'
+      friends:
+        type: array
+        items:
+          $ref: '#/definitions/Pet'
+  Owner:
+    allOf:
+    - type: object
+      properties:
+        ip:
+          type: string
+          format: ipv4
+    - type: object
+      required: [host]
+      properties:
+        host:
+          type: string
+          format: hostname
+`
+
+func TestOpenAPI2Conformance(t *testing.T) {
+	document, err := openapi_v2.ParseDocument([]byte(documentV2))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	pet := &openapi_v2.Schema{XRef: "#/definitions/Pet"}
+	validationSchema := validate.NewOpenAPI2Schemas(document, nil).ForOpenAPI2(pet)
+	for seed := int64(0); seed < 200; seed++ {
+		value := NewGenerator(&Options{Seed: seed}).OpenAPI2(pet, document)
+		if err := validationSchema.Validate(value); err != nil {
+			bytes, _ := json.Marshal(value)
+			t.Fatalf("seed %d: %s\n%s", seed, err.Error(), string(bytes))
+		}
+	}
+}
+
+const schemaJSON = `{
+  "type": "object",
+  "required": ["name", "kind", "node"],
+  "properties": {
+    "name": {"type": "string", "pattern": "^(foo|bar)_[a-f0-9]{2,4}$"},
+    "kind": {"enum": ["leaf", "branch"]},
+    "size": {"type": ["integer", "null"], "minimum": 0, "maximum": 0},
+    "node": {"$ref": "#/definitions/node"}
+  },
+  "definitions": {
+    "node": {
+      "type": "object",
+      "required": ["label"],
+      "properties": {
+        "label": {"type": "string", "minLength": 3},
+        "children": {"type": "array", "items": {"$ref": "#/definitions/node"}}
+      }
+    }
+  }
+}`
+
+func TestJSONSchema(t *testing.T) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(schemaJSON), &node); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	s := jsonschema.NewSchemaFromObject(&node)
+	name := regexp.MustCompile(`^(foo|bar)_[a-f0-9]{2,4}$`)
+	for seed := int64(0); seed < 100; seed++ {
+		value := NewGenerator(&Options{Seed: seed}).JSONSchema(s).(map[string]interface{})
+		if !name.MatchString(value["name"].(string)) {
+			t.Errorf("seed %d: name %q doesn't match its pattern", seed, value["name"])
+		}
+		if kind := value["kind"]; kind != "leaf" && kind != "branch" {
+			t.Errorf("seed %d: unexpected kind %v", seed, kind)
+		}
+		if size, ok := value["size"]; ok && size != int64(0) {
+			t.Errorf("seed %d: unexpected size %v", seed, size)
+		}
+		var check func(node interface{}, depth int)
+		check = func(node interface{}, depth int) {
+			n := node.(map[string]interface{})
+			if label, ok := n["label"].(string); !ok || len(label) < 3 {
+				t.Errorf("seed %d: invalid label %v", seed, n["label"])
+			}
+			if depth > 5 {
+				t.Errorf("seed %d: nodes are nested too deeply", seed)
+				return
+			}
+			if children, ok := n["children"]; ok {
+				for _, child := range children.([]interface{}) {
+					check(child, depth+1)
+				}
+			}
+		}
+		check(value["node"], 0)
+	}
+}
+
+const recursiveDocumentV3 = `
+openapi: 3.0.0
+info:
+  title: Tree
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Node:
+      type: object
+      required: [left, right]
+      properties:
+        left:
+          $ref: '#/components/schemas/Node'
+        right:
+          $ref: '#/components/schemas/Node'
+`
+
+func TestRecursiveRequiredProperties(t *testing.T) {
+	document, err := openapi_v3.ParseDocument([]byte(recursiveDocumentV3))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	node := openAPI3Schema(t, document, "Node")
+	for _, maxDepth := range []int{0, 2, 6} {
+		g := NewGenerator(&Options{MaxDepth: maxDepth})
+		value := g.OpenAPI3SchemaOrReference(node, document)
+		var count func(value interface{}) int
+		count = func(value interface{}) int {
+			n, ok := value.(map[string]interface{})
+			if !ok {
+				return 0
+			}
+			return 1 + count(n["left"]) + count(n["right"])
+		}
+		limit := 1 << uint(g.options.MaxDepth+1)
+		if nodes := count(value); nodes == 0 || nodes >= limit {
+			t.Errorf("max depth %d: generated %d nodes, expected between 1 and %d", g.options.MaxDepth, nodes, limit-1)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var wordList = []string{
+	"alpha", "amber", "apple", "arrow", "atlas", "birch", "blue", "bright",
+	"cedar", "cloud", "coral", "delta", "dune", "eagle", "ember", "falcon",
+	"fern", "field", "glade", "granite", "harbor", "hazel", "iris", "island",
+	"jade", "juniper", "kestrel", "lake", "lemon", "maple", "meadow", "nova",
+	"oak", "ocean", "olive", "pearl", "pine", "quartz", "raven", "river",
+	"sage", "silver", "spruce", "stone", "summit", "tide", "valley", "willow",
+}
+
+var firstNames = []string{
+	"ada", "alan", "barbara", "dennis", "edsger", "frances", "grace", "john",
+	"katherine", "ken", "leslie", "linus", "margaret", "radia", "rob", "tim",
+}
+
+// Generated times fall between 2000 and 2030.
+var (
+	earliest = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	latest   = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+)
+
+func (g *Generator) time() time.Time {
+	return time.Unix(earliest+g.rand.Int63n(latest-earliest), 0).UTC()
+}
+
+// formats generate strings for the formats of OpenAPI and JSON schemas.
+var formats = map[string]func(g *Generator) string{
+	"date-time": func(g *Generator) string { return g.time().Format(time.RFC3339) },
+	"date":      func(g *Generator) string { return g.time().Format("2006-01-02") },
+	"time":      func(g *Generator) string { return g.time().Format("15:04:05") },
+	"email": func(g *Generator) string {
+		return firstNames[g.rand.Intn(len(firstNames))] + "." + wordList[g.rand.Intn(len(wordList))] + "@example.com"
+	},
+	"uuid": func(g *Generator) string {
+		b := make([]byte, 16)
+		g.rand.Read(b)
+		// Generated UUIDs are random (version 4) UUIDs.
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	},
+	"uri": func(g *Generator) string { return "https://example.com/" + wordList[g.rand.Intn(len(wordList))] },
+	"url": func(g *Generator) string { return "https://example.com/" + wordList[g.rand.Intn(len(wordList))] },
+	"hostname": func(g *Generator) string {
+		return wordList[g.rand.Intn(len(wordList))] + ".example.com"
+	},
+	"ipv4": func(g *Generator) string {
+		return fmt.Sprintf("192.0.2.%d", 1+g.rand.Intn(254))
+	},
+	"ipv6": func(g *Generator) string {
+		return fmt.Sprintf("2001:db8::%x", 1+g.rand.Intn(0xfffe))
+	},
+	"byte": func(g *Generator) string {
+		return base64.StdEncoding.EncodeToString([]byte(wordList[g.rand.Intn(len(wordList))]))
+	},
+	"password": func(g *Generator) string {
+		word := wordList[g.rand.Intn(len(wordList))]
+		return strings.ToUpper(word[:1]) + word[1:] + strconv.Itoa(100+g.rand.Intn(900)) + "!"
+	},
+	// Google APIs encode 64-bit integers as strings.
+	"int32":  func(g *Generator) string { return strconv.FormatInt(int64(g.rand.Int31()), 10) },
+	"int64":  func(g *Generator) string { return strconv.FormatInt(g.rand.Int63(), 10) },
+	"uint32": func(g *Generator) string { return strconv.FormatUint(uint64(g.rand.Uint32()), 10) },
+	"uint64": func(g *Generator) string { return strconv.FormatUint(g.rand.Uint64(), 10) },
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"strings"
+
+	"github.com/google/gnostic/jsonschema"
+)
+
+// JSONSchema returns a value for a JSON schema. Local references like
+// "#/definitions/name" are resolved in the schema itself.
+func (g *Generator) JSONSchema(s *jsonschema.Schema) interface{} {
+	b := &jsonSchemaBuilder{root: s, named: make(map[string]*schema)}
+	return g.generate(b.schema(s), 0)
+}
+
+// jsonSchemaBuilder converts JSON schemas, sharing the conversions of referenced schemas.
+type jsonSchemaBuilder struct {
+	root  *jsonschema.Schema
+	named map[string]*schema
+}
+
+// resolve returns the schema of a local reference. Pointers may step
+// through definitions and properties.
+func (b *jsonSchemaBuilder) resolve(ref string) *jsonschema.Schema {
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	s := b.root
+	parts := strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:]
+	for i := 0; i+1 < len(parts) && s != nil; i += 2 {
+		name := strings.Replace(strings.Replace(parts[i+1], "~1", "/", -1), "~0", "~", -1)
+		switch parts[i] {
+		case "definitions":
+			s = s.DefinitionWithName(name)
+		case "properties":
+			s = s.PropertyWithName(name)
+		default:
+			return nil
+		}
+	}
+	if len(parts)%2 != 0 {
+		return nil
+	}
+	return s
+}
+
+func (b *jsonSchemaBuilder) schema(s *jsonschema.Schema) *schema {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != nil {
+		if result, ok := b.named[*s.Ref]; ok {
+			return result
+		}
+		// The placeholder is filled after conversion, so recursive schemas share it.
+		result := &schema{}
+		b.named[*s.Ref] = result
+		if resolved := b.resolve(*s.Ref); resolved != nil && resolved != s {
+			if named := b.schema(resolved); named != nil {
+				*result = *named
+			}
+		}
+		result.ref = *s.Ref
+		return result
+	}
+	result := &schema{
+		minimum:     number(s.Minimum),
+		maximum:     number(s.Maximum),
+		minLength:   s.MinLength,
+		maxLength:   s.MaxLength,
+		minItems:    s.MinItems,
+		maxItems:    s.MaxItems,
+		uniqueItems: s.UniqueItems != nil && *s.UniqueItems,
+		readOnly:    s.ReadOnly != nil && *s.ReadOnly,
+		writeOnly:   s.WriteOnly != nil && *s.WriteOnly,
+	}
+	if s.Format != nil {
+		result.format = *s.Format
+	}
+	if s.Pattern != nil {
+		result.pattern = *s.Pattern
+	}
+	if s.ExclusiveMinimum != nil {
+		result.exclusiveMinimum = *s.ExclusiveMinimum
+	}
+	if s.ExclusiveMaximum != nil {
+		result.exclusiveMaximum = *s.ExclusiveMaximum
+	}
+	if m := number(s.MultipleOf); m != nil {
+		result.multipleOf = *m
+	}
+	if s.Type != nil {
+		if s.Type.String != nil {
+			result.types = []string{*s.Type.String}
+		} else if s.Type.StringArray != nil {
+			result.types = *s.Type.StringArray
+		}
+	}
+	if s.Enumeration != nil {
+		for _, value := range *s.Enumeration {
+			if value.String != nil {
+				result.enum = append(result.enum, *value.String)
+			} else if value.Bool != nil {
+				result.enum = append(result.enum, *value.Bool)
+			}
+		}
+	}
+	if s.Items != nil {
+		if s.Items.Schema != nil {
+			result.items = b.schema(s.Items.Schema)
+		} else if s.Items.SchemaArray != nil && len(*s.Items.SchemaArray) > 0 {
+			result.items = b.schema((*s.Items.SchemaArray)[0])
+		}
+	}
+	if s.Required != nil {
+		result.required = *s.Required
+	}
+	if s.Properties != nil {
+		for _, pair := range *s.Properties {
+			result.properties = append(result.properties, &property{name: pair.Name, schema: b.schema(pair.Value)})
+		}
+	}
+	if s.AdditionalProperties != nil {
+		result.additionalProperties = b.schema(s.AdditionalProperties.Schema)
+	}
+	result.minProperties = s.MinProperties
+	for _, members := range []struct {
+		schemas *[]*jsonschema.Schema
+		result  *[]*schema
+	}{
+		{s.AllOf, &result.allOf},
+		{s.AnyOf, &result.anyOf},
+		{s.OneOf, &result.oneOf},
+	} {
+		if members.schemas != nil {
+			for _, member := range *members.schemas {
+				*members.result = append(*members.result, b.schema(member))
+			}
+		}
+	}
+	return result
+}
+
+func number(n *jsonschema.SchemaNumber) *float64 {
+	switch {
+	case n == nil:
+		return nil
+	case n.Integer != nil:
+		f := float64(*n.Integer)
+		return &f
+	case n.Float != nil:
+		return n.Float
+	}
+	return nil
+}
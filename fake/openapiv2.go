@@ -0,0 +1,103 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"strings"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+)
+
+// OpenAPI2 returns a value for an OpenAPI v2 schema. References to
+// "#/definitions" are resolved in the document, which may be nil.
+func (g *Generator) OpenAPI2(s *openapi_v2.Schema, document *openapi_v2.Document) interface{} {
+	b := &openAPI2Builder{document: document, named: make(map[string]*schema)}
+	return g.generate(b.schema(s), 0)
+}
+
+// openAPI2Builder converts OpenAPI v2 schemas, sharing the conversions of named schemas.
+type openAPI2Builder struct {
+	document *openapi_v2.Document
+	named    map[string]*schema
+}
+
+func (b *openAPI2Builder) schema(s *openapi_v2.Schema) *schema {
+	if s == nil {
+		return nil
+	}
+	if s.XRef != "" {
+		name := strings.TrimPrefix(s.XRef, "#/definitions/")
+		if result, ok := b.named[name]; ok {
+			return result
+		}
+		// The placeholder is filled after conversion, so recursive schemas share it.
+		result := &schema{}
+		b.named[name] = result
+		if b.document != nil && b.document.Definitions != nil {
+			for _, pair := range b.document.Definitions.AdditionalProperties {
+				if pair.Name == name {
+					if named := b.schema(pair.Value); named != nil {
+						*result = *named
+					}
+				}
+			}
+		}
+		result.ref = name
+		return result
+	}
+	result := &schema{
+		format:           s.Format,
+		minimum:          nonZero(s.Minimum),
+		maximum:          nonZero(s.Maximum),
+		exclusiveMinimum: s.ExclusiveMinimum,
+		exclusiveMaximum: s.ExclusiveMaximum,
+		multipleOf:       s.MultipleOf,
+		minLength:        positive(s.MinLength),
+		maxLength:        positive(s.MaxLength),
+		pattern:          s.Pattern,
+		minItems:         positive(s.MinItems),
+		maxItems:         positive(s.MaxItems),
+		uniqueItems:      s.UniqueItems,
+		required:         s.Required,
+		minProperties:    positive(s.MinProperties),
+		readOnly:         s.ReadOnly,
+	}
+	if s.Type != nil {
+		result.types = s.Type.Value
+	}
+	if s.Example != nil {
+		result.example, result.hasExample = valueForYAML(s.Example.Yaml)
+	}
+	for _, value := range s.Enum {
+		if v, ok := valueForYAML(value.Yaml); ok {
+			result.enum = append(result.enum, v)
+		}
+	}
+	if s.Items != nil && len(s.Items.Schema) > 0 {
+		result.items = b.schema(s.Items.Schema[0])
+	}
+	if s.Properties != nil {
+		for _, pair := range s.Properties.AdditionalProperties {
+			result.properties = append(result.properties, &property{name: pair.Name, schema: b.schema(pair.Value)})
+		}
+	}
+	if s.AdditionalProperties != nil {
+		result.additionalProperties = b.schema(s.AdditionalProperties.GetSchema())
+	}
+	for _, member := range s.AllOf {
+		result.allOf = append(result.allOf, b.schema(member))
+	}
+	return result
+}
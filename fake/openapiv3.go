@@ -0,0 +1,135 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+// OpenAPI3 returns a value for an OpenAPI v3 schema. References to
+// "#/components/schemas" are resolved in the document, which may be nil.
+func (g *Generator) OpenAPI3(s *openapi_v3.Schema, document *openapi_v3.Document) interface{} {
+	b := &openAPI3Builder{document: document, named: make(map[string]*schema)}
+	return g.generate(b.schema(s), 0)
+}
+
+// OpenAPI3SchemaOrReference returns a value for an OpenAPI v3 schema or reference.
+func (g *Generator) OpenAPI3SchemaOrReference(item *openapi_v3.SchemaOrReference, document *openapi_v3.Document) interface{} {
+	b := &openAPI3Builder{document: document, named: make(map[string]*schema)}
+	return g.generate(b.schemaOrReference(item), 0)
+}
+
+// openAPI3Builder converts OpenAPI v3 schemas, sharing the conversions of named schemas.
+type openAPI3Builder struct {
+	document *openapi_v3.Document
+	named    map[string]*schema
+}
+
+func (b *openAPI3Builder) schemaOrReference(item *openapi_v3.SchemaOrReference) *schema {
+	if item == nil {
+		return nil
+	}
+	reference := item.GetReference()
+	if reference == nil {
+		return b.schema(item.GetSchema())
+	}
+	name := strings.TrimPrefix(reference.XRef, "#/components/schemas/")
+	if result, ok := b.named[name]; ok {
+		return result
+	}
+	// The placeholder is filled after conversion, so recursive schemas share it.
+	result := &schema{}
+	b.named[name] = result
+	if b.document != nil && b.document.Components != nil && b.document.Components.Schemas != nil {
+		for _, pair := range b.document.Components.Schemas.AdditionalProperties {
+			if pair.Name == name {
+				if named := b.schemaOrReference(pair.Value); named != nil {
+					*result = *named
+				}
+			}
+		}
+	}
+	result.ref = name
+	return result
+}
+
+func (b *openAPI3Builder) schema(s *openapi_v3.Schema) *schema {
+	if s == nil {
+		return nil
+	}
+	result := &schema{
+		format:           s.Format,
+		minimum:          nonZero(s.Minimum),
+		maximum:          nonZero(s.Maximum),
+		exclusiveMinimum: s.ExclusiveMinimum,
+		exclusiveMaximum: s.ExclusiveMaximum,
+		multipleOf:       s.MultipleOf,
+		minLength:        positive(s.MinLength),
+		maxLength:        positive(s.MaxLength),
+		pattern:          s.Pattern,
+		minItems:         positive(s.MinItems),
+		maxItems:         positive(s.MaxItems),
+		uniqueItems:      s.UniqueItems,
+		required:         s.Required,
+		minProperties:    positive(s.MinProperties),
+		readOnly:         s.ReadOnly,
+		writeOnly:        s.WriteOnly,
+	}
+	if s.Type != "" {
+		result.types = []string{s.Type}
+	}
+	if s.Example != nil {
+		result.example, result.hasExample = valueForYAML(s.Example.Yaml)
+	}
+	for _, value := range s.Enum {
+		if v, ok := valueForYAML(value.Yaml); ok {
+			result.enum = append(result.enum, v)
+		}
+	}
+	if s.Items != nil && len(s.Items.SchemaOrReference) > 0 {
+		result.items = b.schemaOrReference(s.Items.SchemaOrReference[0])
+	}
+	if s.Properties != nil {
+		for _, pair := range s.Properties.AdditionalProperties {
+			result.properties = append(result.properties, &property{name: pair.Name, schema: b.schemaOrReference(pair.Value)})
+		}
+	}
+	if s.AdditionalProperties != nil {
+		result.additionalProperties = b.schemaOrReference(s.AdditionalProperties.GetSchemaOrReference())
+	}
+	for _, member := range s.AllOf {
+		result.allOf = append(result.allOf, b.schemaOrReference(member))
+	}
+	for _, member := range s.AnyOf {
+		result.anyOf = append(result.anyOf, b.schemaOrReference(member))
+	}
+	for _, member := range s.OneOf {
+		result.oneOf = append(result.oneOf, b.schemaOrReference(member))
+	}
+	return result
+}
+
+// valueForYAML decodes the YAML text of an example or enum value.
+func valueForYAML(text string) (interface{}, bool) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(text), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"regexp/syntax"
+	"strings"
+)
+
+// maxRepeat limits the repetitions of unbounded operators like * and +.
+const maxRepeat = 3
+
+// pattern returns a string that matches a regular expression. Invalid
+// expressions produce random words.
+func (g *Generator) pattern(expression string) string {
+	re, err := syntax.Parse(expression, syntax.Perl)
+	if err != nil {
+		return g.words(0, -1)
+	}
+	var b strings.Builder
+	g.writeMatch(&b, re.Simplify())
+	return b.String()
+}
+
+func (g *Generator) writeMatch(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			b.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		b.WriteRune(g.runeInClass(re.Rune))
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		b.WriteRune(rune('a' + g.rand.Intn(26)))
+	case syntax.OpCapture:
+		g.writeMatch(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			g.writeMatch(b, sub)
+		}
+	case syntax.OpAlternate:
+		g.writeMatch(b, re.Sub[g.rand.Intn(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		low, high := 0, maxRepeat
+		switch re.Op {
+		case syntax.OpPlus:
+			low = 1
+		case syntax.OpQuest:
+			high = 1
+		case syntax.OpRepeat:
+			low, high = re.Min, re.Max
+			if high < 0 {
+				high = low + maxRepeat
+			}
+		}
+		count := low + g.rand.Intn(high-low+1)
+		for i := 0; i < count; i++ {
+			g.writeMatch(b, re.Sub[0])
+		}
+	}
+	// Anchors, word boundaries and empty matches don't produce any text.
+}
+
+// runeInClass returns a rune from a character class, preferring printable ASCII.
+func (g *Generator) runeInClass(ranges []rune) rune {
+	printable := make([]rune, 0)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		low, high := ranges[i], ranges[i+1]
+		if low < 0x21 {
+			low = 0x21
+		}
+		if high > 0x7e {
+			high = 0x7e
+		}
+		if low <= high {
+			printable = append(printable, low, high)
+		}
+	}
+	if len(printable) > 0 {
+		ranges = printable
+	}
+	if len(ranges) < 2 {
+		return 'x'
+	}
+	total := 0
+	for i := 0; i+1 < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+	n := g.rand.Intn(total)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n)
+		}
+		n -= size
+	}
+	return ranges[0]
+}
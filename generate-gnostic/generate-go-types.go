@@ -0,0 +1,606 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/imports"
+
+	"github.com/google/gnostic/jsonschema"
+)
+
+// goInitialisms are words that are written in upper case in Go names.
+var goInitialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// goName returns an exported Go name for a schema or property name.
+func goName(input string) string {
+	words := regexp.MustCompile("[^0-9A-Za-z]+").Split(input, -1)
+	out := ""
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if goInitialisms[strings.ToLower(word)] {
+			out += strings.ToUpper(word)
+			continue
+		}
+		w := []rune(word)
+		w[0] = unicode.ToUpper(w[0])
+		out += string(w)
+	}
+	if out == "" {
+		return "Value"
+	}
+	if unicode.IsDigit(rune(out[0])) {
+		return "V" + out
+	}
+	return out
+}
+
+// goDeclaration is a type declaration in generated code.
+type goDeclaration struct {
+	code string
+}
+
+// goTypesBuilder generates Go types for the schemas of a JSON Schema.
+type goTypesBuilder struct {
+	root         *jsonschema.Schema
+	rootName     string
+	declarations []*goDeclaration
+	names        map[string]bool               // names that have been used
+	named        map[*jsonschema.Schema]string // the types that have been declared for schemas
+	resolving    map[*jsonschema.Schema]bool   // definitions whose types are being resolved
+	hasUnions    bool
+}
+
+// generateGoTypes returns Go source code for the types of a JSON Schema.
+// The root schema and each of its definitions become named types. Properties
+// that are not required are pointers, enumerations become typed constants,
+// oneOf and anyOf become wrapper structs with one field for each alternative,
+// and allOf references become embedded structs.
+func generateGoTypes(schema *jsonschema.Schema, packageName string, rootName string) ([]byte, error) {
+	b := &goTypesBuilder{
+		root:      schema,
+		rootName:  rootName,
+		names:     make(map[string]bool),
+		named:     make(map[*jsonschema.Schema]string),
+		resolving: make(map[*jsonschema.Schema]bool),
+	}
+	if b.needsDeclaration(schema) {
+		b.declare(schema, rootName)
+	}
+	for _, definitions := range []*[]*jsonschema.NamedSchema{schema.Definitions, schema.Defs} {
+		if definitions == nil {
+			continue
+		}
+		for _, pair := range *definitions {
+			b.definitionType(pair.Value, goName(pair.Name))
+		}
+	}
+
+	code := License + "\n"
+	code += "// Code generated by generate-gnostic. DO NOT EDIT.\n\n"
+	code += "package " + packageName + "\n"
+	for _, declaration := range b.declarations {
+		code += "\n" + declaration.code
+	}
+	if b.hasUnions {
+		code += unmarshalStrictCode
+	}
+	imports.LocalPrefix = "github.com/google/gnostic"
+	return imports.Process(packageName+".go", []byte(code), &imports.Options{
+		TabWidth:  8,
+		TabIndent: true,
+		Comments:  true,
+		Fragment:  true,
+	})
+}
+
+const unmarshalStrictCode = `
+// unmarshalStrict decodes JSON and fails if objects contain unknown fields.
+func unmarshalStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+`
+
+// uniqueName returns a type name that hasn't been used.
+func (b *goTypesBuilder) uniqueName(name string) string {
+	result := name
+	for i := 2; b.names[result]; i++ {
+		result = name + strconv.Itoa(i)
+	}
+	b.names[result] = true
+	return result
+}
+
+// typeFor returns the Go type of a schema, declaring named types as needed.
+func (b *goTypesBuilder) typeFor(s *jsonschema.Schema, hint string) string {
+	if s == nil {
+		return "interface{}"
+	}
+	if name, ok := b.named[s]; ok {
+		return name
+	}
+	if s.Ref != nil {
+		target, name := b.resolveRef(*s.Ref)
+		if target == nil {
+			return "interface{}"
+		}
+		return b.definitionType(target, name)
+	}
+	if b.needsDeclaration(s) {
+		return b.declare(s, hint)
+	}
+	return b.expression(s, hint)
+}
+
+// resolveRef finds the schema of a reference to the root schema or one of its definitions.
+func (b *goTypesBuilder) resolveRef(ref string) (*jsonschema.Schema, string) {
+	if ref == "#" {
+		return b.root, b.rootName
+	}
+	for _, prefix := range []string{"#/definitions/", "#/$defs/"} {
+		if strings.HasPrefix(ref, prefix) {
+			name := strings.TrimPrefix(ref, prefix)
+			return b.root.DefinitionWithName(name), goName(name)
+		}
+	}
+	return nil, ""
+}
+
+// definitionType returns the named type of a definition.
+func (b *goTypesBuilder) definitionType(s *jsonschema.Schema, name string) string {
+	if declared, ok := b.named[s]; ok {
+		return declared
+	}
+	if s.Ref != nil {
+		// A definition that is a reference has the type of the schema it refers to.
+		if b.resolving[s] {
+			return "interface{}"
+		}
+		b.resolving[s] = true
+		defer delete(b.resolving, s)
+		return b.typeFor(s, name)
+	}
+	if b.needsDeclaration(s) {
+		return b.declare(s, name)
+	}
+	name = b.uniqueName(name)
+	b.named[s] = name
+	declaration := &goDeclaration{}
+	b.declarations = append(b.declarations, declaration)
+	declaration.code = comment(s.Description) + "type " + name + " " + b.expression(s, name) + "\n"
+	return name
+}
+
+// needsDeclaration returns true if a schema is represented with a named type.
+func (b *goTypesBuilder) needsDeclaration(s *jsonschema.Schema) bool {
+	if s.Ref != nil || s.Boolean != nil {
+		return false
+	}
+	if s.Enumeration != nil {
+		return enumType(s) != ""
+	}
+	if alternatives(s) != nil {
+		return true
+	}
+	return isStruct(s)
+}
+
+func isStruct(s *jsonschema.Schema) bool {
+	if s.Properties != nil || s.AllOf != nil {
+		return true
+	}
+	return s.TypeIs("object") && s.AdditionalProperties == nil && s.PatternProperties == nil
+}
+
+func alternatives(s *jsonschema.Schema) *[]*jsonschema.Schema {
+	if s.OneOf != nil {
+		return s.OneOf
+	}
+	return s.AnyOf
+}
+
+// schemaTypes returns the types of a schema other than "null".
+func schemaTypes(s *jsonschema.Schema) []string {
+	result := make([]string, 0)
+	if s.Type == nil {
+		return result
+	}
+	if s.Type.String != nil && *s.Type.String != "null" {
+		result = append(result, *s.Type.String)
+	}
+	if s.Type.StringArray != nil {
+		for _, t := range *s.Type.StringArray {
+			if t != "null" {
+				result = append(result, t)
+			}
+		}
+	}
+	return result
+}
+
+// expression returns the Go type of a schema that isn't a named type.
+func (b *goTypesBuilder) expression(s *jsonschema.Schema, hint string) string {
+	t := schemaTypes(s)
+	if len(t) != 1 {
+		return "interface{}"
+	}
+	switch t[0] {
+	case "string":
+		if s.Format != nil && *s.Format == "date-time" {
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		if s.Format != nil && *s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		if s.Items != nil && s.Items.Schema != nil {
+			return "[]" + b.typeFor(s.Items.Schema, hint+"Item")
+		}
+		return "[]interface{}"
+	case "object":
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+			return "map[string]" + b.typeFor(s.AdditionalProperties.Schema, hint+"Value")
+		}
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+// declare declares a named type for a struct, enumeration or union schema.
+func (b *goTypesBuilder) declare(s *jsonschema.Schema, hint string) string {
+	name := b.uniqueName(hint)
+	b.named[s] = name
+	// Reserve a place for the declaration so that types are declared before the types they contain.
+	declaration := &goDeclaration{}
+	b.declarations = append(b.declarations, declaration)
+	if s.Enumeration != nil {
+		declaration.code = b.enumDeclaration(s, name)
+	} else if alternatives(s) != nil {
+		declaration.code = b.unionDeclaration(s, name)
+	} else {
+		declaration.code = b.structDeclaration(s, name)
+	}
+	return name
+}
+
+// enumType returns the Go type of the values of an enumeration, or "" if they have different types.
+func enumType(s *jsonschema.Schema) string {
+	result := ""
+	for _, value := range *s.Enumeration {
+		t := ""
+		switch {
+		case value.String != nil:
+			t = "string"
+		case value.Bool != nil:
+			t = "bool"
+		case value.Node != nil && value.Node.Tag == "!!null":
+			continue
+		case value.Node != nil && value.Node.Tag == "!!int":
+			t = "int64"
+		case value.Node != nil && value.Node.Tag == "!!float":
+			t = "float64"
+		default:
+			return ""
+		}
+		if result == "" || (result == "int64" && t == "float64") {
+			result = t
+		} else if result != t && !(result == "float64" && t == "int64") {
+			return ""
+		}
+	}
+	return result
+}
+
+func (b *goTypesBuilder) enumDeclaration(s *jsonschema.Schema, name string) string {
+	code := comment(s.Description)
+	code += "type " + name + " " + enumType(s) + "\n\n"
+	code += "// Values of " + name + ".\n"
+	code += "const (\n"
+	used := make(map[string]bool)
+	for _, value := range *s.Enumeration {
+		var literal, suffix string
+		switch {
+		case value.String != nil:
+			literal, suffix = strconv.Quote(*value.String), *value.String
+			if suffix == "" {
+				suffix = "Empty"
+			}
+		case value.Bool != nil:
+			literal = strconv.FormatBool(*value.Bool)
+			suffix = literal
+		case value.Node.Tag == "!!null":
+			continue
+		default:
+			literal = value.Node.Value
+			suffix = strings.Replace(strings.Replace(literal, "-", "Minus", 1), ".", "Point", 1)
+		}
+		constant := name + strings.TrimPrefix(goName(suffix), "V")
+		for i := 2; used[constant]; i++ {
+			constant = name + goName(suffix) + strconv.Itoa(i)
+		}
+		used[constant] = true
+		code += "\t" + constant + " " + name + " = " + literal + "\n"
+	}
+	code += ")\n"
+	return code
+}
+
+func (b *goTypesBuilder) unionDeclaration(s *jsonschema.Schema, name string) string {
+	b.hasUnions = true
+	type alternative struct {
+		field string
+		t     string
+	}
+	fields := make([]alternative, 0)
+	used := make(map[string]bool)
+	for i, schema := range *alternatives(s) {
+		t := b.typeFor(schema, name+"Option"+strconv.Itoa(i+1))
+		field := ""
+		if b.named[schema] != "" || schema.Ref != nil {
+			field = strings.TrimPrefix(t, name)
+		} else if kinds := schemaTypes(schema); len(kinds) == 1 {
+			field = goName(kinds[0])
+		}
+		if field == "" {
+			field = "Option" + strconv.Itoa(i+1)
+		}
+		for j := 2; used[field]; j++ {
+			field = field + strconv.Itoa(j)
+		}
+		used[field] = true
+		if nilable(t) {
+			fields = append(fields, alternative{field: field, t: t})
+		} else {
+			fields = append(fields, alternative{field: field, t: "*" + t})
+		}
+	}
+
+	code := comment(s.Description)
+	if s.Description == nil {
+		code += "// " + name + " holds one of several alternative values.\n"
+	} else {
+		code += "// It holds one of several alternative values.\n"
+	}
+	code += "// When it is written as JSON, the first alternative that is set is written.\n"
+	code += "type " + name + " struct {\n"
+	for _, f := range fields {
+		code += "\t" + f.field + " " + f.t + "\n"
+	}
+	code += "}\n\n"
+
+	code += "// MarshalJSON writes the alternative that is set.\n"
+	code += "func (v " + name + ") MarshalJSON() ([]byte, error) {\n"
+	code += "\tswitch {\n"
+	for _, f := range fields {
+		code += "\tcase v." + f.field + " != nil:\n"
+		code += "\t\treturn json.Marshal(v." + f.field + ")\n"
+	}
+	code += "\t}\n"
+	code += "\treturn []byte(\"null\"), nil\n"
+	code += "}\n\n"
+
+	code += "// UnmarshalJSON reads the first alternative that matches a JSON value.\n"
+	code += "func (v *" + name + ") UnmarshalJSON(data []byte) error {\n"
+	code += "\t*v = " + name + "{}\n"
+	for _, f := range fields {
+		code += "\t{\n"
+		code += "\t\tvar value " + strings.TrimPrefix(f.t, "*") + "\n"
+		code += "\t\tif err := unmarshalStrict(data, &value); err == nil {\n"
+		if strings.HasPrefix(f.t, "*") {
+			code += "\t\t\tv." + f.field + " = &value\n"
+		} else {
+			code += "\t\t\tv." + f.field + " = value\n"
+		}
+		code += "\t\t\treturn nil\n"
+		code += "\t\t}\n"
+		code += "\t}\n"
+	}
+	code += "\treturn fmt.Errorf(\"%s doesn't match any of the alternatives of " + name + "\", data)\n"
+	code += "}\n"
+	return code
+}
+
+// goField is a field of a generated struct.
+type goField struct {
+	name        string
+	t           string
+	tag         string
+	description *string
+}
+
+func (b *goTypesBuilder) structDeclaration(s *jsonschema.Schema, name string) string {
+	embedded := make([]string, 0)
+	fields := make([]*goField, 0)
+	b.collectFields(s, name, &embedded, &fields)
+
+	code := comment(s.Description)
+	code += "type " + name + " struct {\n"
+	for _, e := range embedded {
+		code += "\t" + e + "\n"
+	}
+	used := make(map[string]bool)
+	for _, e := range embedded {
+		used[e] = true
+	}
+	for _, f := range fields {
+		field := f.name
+		for i := 2; used[field]; i++ {
+			field = f.name + strconv.Itoa(i)
+		}
+		used[field] = true
+		code += strings.Replace(comment(f.description), "// ", "\t// ", -1)
+		code += "\t" + field + " " + f.t + " " + f.tag + "\n"
+	}
+	code += "}\n"
+	return code
+}
+
+// collectFields finds the embedded types and fields of a struct. The properties of
+// allOf schemas are merged into the struct, and references in allOf are embedded.
+func (b *goTypesBuilder) collectFields(s *jsonschema.Schema, name string, embedded *[]string, fields *[]*goField) {
+	if s.AllOf != nil {
+		for _, part := range *s.AllOf {
+			if part.Ref != nil {
+				target, _ := b.resolveRef(*part.Ref)
+				if target != nil && isStruct(target) && alternatives(target) == nil {
+					*embedded = append(*embedded, b.typeFor(part, name))
+					continue
+				}
+			}
+			b.collectFields(part, name, embedded, fields)
+		}
+	}
+	if s.Properties == nil {
+		return
+	}
+	for _, pair := range *s.Properties {
+		property := pair.Value
+		required := false
+		if s.Required != nil {
+			for _, r := range *s.Required {
+				if r == pair.Name {
+					required = true
+				}
+			}
+		}
+		t := b.typeFor(property, name+goName(pair.Name))
+		tag := "`json:\"" + pair.Name + "\"`"
+		if !required {
+			tag = "`json:\"" + pair.Name + ",omitempty\"`"
+		}
+		if (!required || nullable(property)) && !nilable(t) {
+			t = "*" + t
+		}
+		*fields = append(*fields, &goField{name: goName(pair.Name), t: t, tag: tag, description: property.Description})
+	}
+}
+
+// nullable returns true if a schema allows null values.
+func nullable(s *jsonschema.Schema) bool {
+	return s.TypeIs("null")
+}
+
+// nilable returns true if a Go type has a nil value.
+func nilable(t string) bool {
+	return strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") || t == "interface{}"
+}
+
+// comment returns a description as a Go comment.
+func comment(description *string) string {
+	if description == nil {
+		return ""
+	}
+	code := ""
+	for _, line := range strings.Split(strings.TrimSpace(*description), "\n") {
+		code += strings.TrimRight("// "+line, " ") + "\n"
+	}
+	return code
+}
+
+func generateGoTypesFromFile(schemaFile string, outDir string, packageName string, rootName string) error {
+	schema, err := jsonschema.NewSchemaFromFile(schemaFile)
+	if err != nil {
+		return err
+	}
+	baseName := getBaseFileNameWithoutExt(schemaFile)
+	if packageName == "" {
+		packageName = strings.ToLower(regexp.MustCompile("[^0-9A-Za-z]+").ReplaceAllString(baseName, ""))
+	}
+	if rootName == "" {
+		if schema.Title != nil {
+			rootName = goName(*schema.Title)
+		} else {
+			rootName = goName(baseName)
+		}
+	}
+	code, err := generateGoTypes(schema, packageName, rootName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outDir, baseName+".go"), code, 0644)
+}
+
+func generateGoTypesMain() error {
+	outDir := ""
+	packageName := ""
+	rootName := ""
+	schemaFile := ""
+
+	paramRegex, _ := regexp.Compile("--(.+)=(.+)")
+
+	for i, arg := range os.Args {
+		if i == 0 {
+			continue // skip the tool name
+		}
+		var m [][]byte
+		if m = paramRegex.FindSubmatch([]byte(arg)); m != nil {
+			flagName := string(m[1])
+			flagValue := string(m[2])
+			switch flagName {
+			case "out_dir":
+				outDir = flagValue
+			case "package":
+				packageName = flagValue
+			case "type":
+				rootName = flagValue
+			default:
+				fmt.Printf("Unknown option: %s.\n%s\n", arg, usage())
+				os.Exit(-1)
+			}
+		} else if arg == "--go-types" {
+			continue
+		} else if arg[0] == '-' {
+			fmt.Printf("Unknown option: %s.\n%s\n", arg, usage())
+			os.Exit(-1)
+		} else {
+			schemaFile = arg
+		}
+	}
+
+	if schemaFile == "" {
+		fmt.Printf("No input json schema specified.\n%s\n", usage())
+		os.Exit(-1)
+	}
+	if outDir == "" {
+		fmt.Printf("Missing output directive.\n%s\n", usage())
+		os.Exit(-1)
+	}
+	return generateGoTypesFromFile(schemaFile, outDir, packageName, rootName)
+}
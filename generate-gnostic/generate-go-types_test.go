@@ -0,0 +1,59 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/gnostic/jsonschema"
+)
+
+func TestGoTypes(t *testing.T) {
+	schema, err := jsonschema.NewSchemaFromFile("test/go-types/petstore.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	code, err := generateGoTypes(schema, "petstore", "PetStore")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected, err := ioutil.ReadFile("test/go-types/petstore.go.golden")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if string(code) != string(expected) {
+		t.Errorf("generated code differs from test/go-types/petstore.go.golden:\n%s", code)
+	}
+
+	// The generated code must compile.
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "petstore.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	config := &types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("petstore", fset, []*ast.File{file}, nil); err != nil {
+		t.Errorf("generated code doesn't compile: %+v", err)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for input, expected := range map[string]string{
+		"pet":         "Pet",
+		"birth_date":  "BirthDate",
+		"home-url":    "HomeURL",
+		"apiKey":      "ApiKey",
+		"api_key":     "APIKey",
+		"2xx":         "V2xx",
+		"x-unit.size": "XUnitSize",
+		"":            "Value",
+	} {
+		if name := goName(input); name != expected {
+			t.Errorf("goName(%q) = %q, expected %q", input, name, expected)
+		}
+	}
+}
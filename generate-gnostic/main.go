@@ -234,12 +234,19 @@ Options:
     supported.
     EXTENSION_OPTIONS
       --out_dir=PATH: Location for writing extension models and support code.
+  --go-types SCHEMA [GO_TYPES_OPTIONS]
+    Generate Go types with JSON tags for the definitions of a json schema.
+    GO_TYPES_OPTIONS
+      --out_dir=PATH: Location for writing the generated Go file.
+      --package=NAME: Package name of the generated code.
+      --type=NAME: Name of the type of the root schema.
 `, path.Base(os.Args[0]))
 }
 
 func main() {
 	var openapiVersion = ""
 	var shouldGenerateExtensions = false
+	var shouldGenerateGoTypes = false
 
 	for i, arg := range os.Args {
 		if i == 0 {
@@ -254,6 +261,9 @@ func main() {
 		} else if arg == "--extension" {
 			shouldGenerateExtensions = true
 			break
+		} else if arg == "--go-types" {
+			shouldGenerateGoTypes = true
+			break
 		} else {
 			fmt.Printf("Unknown option: %s.\n%s\n", arg, usage())
 			os.Exit(-1)
@@ -270,6 +280,11 @@ func main() {
 		if err != nil {
 			fmt.Printf("%+v\n", err)
 		}
+	} else if shouldGenerateGoTypes {
+		err := generateGoTypesMain()
+		if err != nil {
+			fmt.Printf("%+v\n", err)
+		}
 	} else {
 		fmt.Printf("%s\n", usage())
 	}
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"io/ioutil"
 	"net/url"
 	"os"
 	"os/exec"
@@ -22,6 +23,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/gnostic/jsonwriter"
 	"github.com/google/gnostic/lib"
 )
 
@@ -206,6 +208,229 @@ func TestJSONOutput(t *testing.T) {
 	}
 }
 
+func TestCanonicalJSONOutput(t *testing.T) {
+	inputFile := "testdata/library-example-with-ext.json"
+
+	yamlFile := "sample.yaml"
+	canonicalFile := "sample.canonical.json"
+	canonicalFile2 := "sample2.canonical.json"
+
+	os.Remove(yamlFile)
+	os.Remove(canonicalFile)
+	os.Remove(canonicalFile + ".sha256")
+	os.Remove(canonicalFile2)
+	os.Remove(canonicalFile2 + ".sha256")
+
+	// Write the description as yaml and as canonical json.
+	args := []string{
+		"gnostic",
+		"--yaml-out=" + yamlFile,
+		"--canonical-json-out=" + canonicalFile,
+		inputFile}
+	if err := lib.NewGnostic(args).Main(); err != nil {
+		t.Fatalf("Compile failed for command %v: %+v", strings.Join(args, " "), err)
+	}
+
+	// The canonical json of the yaml output must be identical.
+	args = []string{
+		"gnostic",
+		"--canonical-json-out=" + canonicalFile2,
+		yamlFile}
+	if err := lib.NewGnostic(args).Main(); err != nil {
+		t.Fatalf("Compile failed for command %v: %+v", strings.Join(args, " "), err)
+	}
+	for _, file := range []string{canonicalFile, canonicalFile + ".sha256"} {
+		err := exec.Command("diff", file, strings.Replace(file, "sample", "sample2", 1)).Run()
+		if err != nil {
+			t.Fatalf("Diff failed (%s): %+v", file, err)
+		}
+	}
+
+	// The digest file must contain the digest of the canonical json.
+	canonical, err := ioutil.ReadFile(canonicalFile)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	digest, err := ioutil.ReadFile(canonicalFile + ".sha256")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if string(digest) != jsonwriter.Digest(canonical)+"\n" {
+		t.Errorf("unexpected digest %s", digest)
+	}
+
+	os.Remove(yamlFile)
+	os.Remove(canonicalFile)
+	os.Remove(canonicalFile + ".sha256")
+	os.Remove(canonicalFile2)
+	os.Remove(canonicalFile2 + ".sha256")
+}
+
+func TestOfflineReferences(t *testing.T) {
+	inputFile := "testdata/fetcher/petstore-remote.yaml"
+	textFile := "petstore-remote.text"
+	os.Remove(textFile)
+
+	// Remote references are read from the catalog.
+	args := []string{
+		"gnostic",
+		inputFile,
+		"--catalog=testdata/fetcher/catalog.yaml",
+		"--offline",
+		"--resolve-refs",
+		"--text-out=" + textFile}
+	if err := lib.NewGnostic(args).Main(); err != nil {
+		t.Fatalf("Compile failed for command %v: %+v", strings.Join(args, " "), err)
+	}
+	text, err := ioutil.ReadFile(textFile)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !strings.Contains(string(text), `format: "int64"`) {
+		t.Errorf("references were not resolved:\n%s", text)
+	}
+	os.Remove(textFile)
+
+	// Offline, references that aren't in a catalog are errors.
+	args = []string{
+		"gnostic",
+		inputFile,
+		"--offline",
+		"--resolve-refs",
+		"--errors-out=!",
+		"--text-out=!"}
+	err = lib.NewGnostic(args).Main()
+	if err == nil || !strings.Contains(err.Error(), "no local copy of https://example.com/schemas/pet.yaml") {
+		t.Errorf("unexpected error %+v", err)
+	}
+
+	// Subcommands read documents with the same fetchers.
+	for _, args := range [][]string{
+		{"gnostic", "diff", inputFile, "https://example.com/petstore.yaml", "--offline"},
+		{"gnostic", "mock", "https://example.com/petstore.yaml", "--catalog=testdata/fetcher/catalog.yaml", "--offline"},
+	} {
+		err = lib.NewGnostic(args).Main()
+		if err == nil || !strings.Contains(err.Error(), "no local copy of https://example.com/petstore.yaml") {
+			t.Errorf("unexpected error for command %v: %+v", strings.Join(args, " "), err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, test := range []struct {
+		option   string
+		expected string
+	}{
+		{"--max-aliases=1000", "alias expansions limit of 1000 exceeded"},
+		{"--max-bytes=100", "bytes limit of 100 exceeded"},
+		{"--max-depth=5", "depth limit of 5 exceeded"},
+	} {
+		args := []string{
+			"gnostic",
+			"testdata/limits/billion-laughs.yaml",
+			test.option,
+			"--errors-out=!",
+			"--text-out=!"}
+		err := lib.NewGnostic(args).Main()
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: unexpected error %+v", test.option, err)
+		}
+	}
+}
+
+func TestRefCycles(t *testing.T) {
+	for _, test := range []struct {
+		inputFile     string
+		referenceFile string
+	}{
+		{"testdata/cycles/recursive-v2.yaml", "testdata/cycles/recursive-v2.yaml.out"},
+		{"testdata/cycles/recursive-v3.yaml", "testdata/cycles/recursive-v3.yaml.out"},
+	} {
+		cyclesFile := "ref-cycles.json"
+		yamlFile := "resolved.yaml"
+		os.Remove(cyclesFile)
+		os.Remove(yamlFile)
+		// Resolution leaves the references that close cycles in place.
+		args := []string{
+			"gnostic",
+			test.inputFile,
+			"--resolve-refs",
+			"--ref-cycles-out=" + cyclesFile,
+			"--yaml-out=" + yamlFile}
+		if err := lib.NewGnostic(args).Main(); err != nil {
+			t.Fatalf("Compile failed for command %v: %+v", strings.Join(args, " "), err)
+		}
+		if err := exec.Command("diff", cyclesFile, strings.TrimSuffix(test.inputFile, ".yaml")+".ref-cycles.json").Run(); err != nil {
+			t.Errorf("Diff failed for %s cycles: %+v", test.inputFile, err)
+		}
+		if err := exec.Command("diff", yamlFile, test.referenceFile).Run(); err != nil {
+			t.Errorf("Diff failed for %s: %+v", test.inputFile, err)
+		}
+		os.Remove(cyclesFile)
+		os.Remove(yamlFile)
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	inputFile := "testdata/strict/problems.yaml"
+	errorsFile := "problems.errors"
+	for _, test := range []struct {
+		option   string
+		fails    bool
+		expected string
+	}{
+		{"", true, "testdata/strict/problems.errors"},
+		{"--strict", true, "testdata/strict/problems-strict.errors"},
+		{"--lenient", false, "testdata/strict/problems-lenient.errors"},
+	} {
+		os.Remove(errorsFile)
+		args := []string{"gnostic", inputFile, "--errors-out=" + errorsFile, "--text-out=!"}
+		if test.option != "" {
+			args = append(args, test.option)
+		}
+		err := lib.NewGnostic(args).Main()
+		if (err != nil) != test.fails {
+			t.Errorf("%s: unexpected result %+v", test.option, err)
+		}
+		if err := exec.Command("diff", errorsFile, test.expected).Run(); err != nil {
+			t.Errorf("Diff failed for %s: %+v", test.option, err)
+		}
+	}
+	os.Remove(errorsFile)
+	args := []string{"gnostic", inputFile, "--strict", "--lenient", "--text-out=!"}
+	if err := lib.NewGnostic(args).Main(); err == nil {
+		t.Errorf("--strict and --lenient were accepted together")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	inputFile := "testdata/diagnostics/checks.yaml"
+	errorsFile := "checks.errors"
+	sarifFile := "checks.sarif"
+	os.Remove(errorsFile)
+	os.Remove(sarifFile)
+	// By default, only errors are reported.
+	args := []string{"gnostic", inputFile, "--errors-out=" + errorsFile, "--text-out=!"}
+	if err := lib.NewGnostic(args).Main(); err != nil {
+		t.Fatalf("Compile failed for command %v: %+v", strings.Join(args, " "), err)
+	}
+	if _, err := os.Stat(errorsFile); err == nil {
+		t.Errorf("diagnostics were reported below the default severity")
+	}
+	args = []string{"gnostic", inputFile, "--severity=info", "--errors-out=" + errorsFile, "--sarif-out=" + sarifFile}
+	if err := lib.NewGnostic(args).Main(); err != nil {
+		t.Fatalf("Compile failed for command %v: %+v", strings.Join(args, " "), err)
+	}
+	if err := exec.Command("diff", errorsFile, "testdata/diagnostics/checks.errors").Run(); err != nil {
+		t.Errorf("Diff failed for %s: %+v", errorsFile, err)
+	}
+	if err := exec.Command("diff", sarifFile, "testdata/diagnostics/checks.sarif").Run(); err != nil {
+		t.Errorf("Diff failed for %s: %+v", sarifFile, err)
+	}
+	os.Remove(errorsFile)
+	os.Remove(sarifFile)
+}
+
 func TestYAMLOutput(t *testing.T) {
 	inputFile := "testdata/library-example-with-ext.json"
 
@@ -17,6 +17,8 @@ package jsonschema
 import (
 	"fmt"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 //
@@ -43,6 +45,9 @@ func (schema *Schema) String() string {
 // Helper: Returns a string representation of a Schema indented by a specified string.
 func (schema *Schema) describeSchema(indent string) string {
 	result := ""
+	if schema.Boolean != nil {
+		return indent + fmt.Sprintf("%+v\n", *(schema.Boolean))
+	}
 	if schema.Schema != nil {
 		result += indent + "$schema: " + *(schema.Schema) + "\n"
 	}
@@ -53,7 +58,11 @@ func (schema *Schema) describeSchema(indent string) string {
 		result += indent + fmt.Sprintf("writeOnly: %+v\n", *(schema.WriteOnly))
 	}
 	if schema.ID != nil {
-		switch strings.TrimSuffix(*schema.Schema, "#") {
+		version := ""
+		if schema.Schema != nil {
+			version = strings.TrimSuffix(*schema.Schema, "#")
+		}
+		switch version {
 		case "http://json-schema.org/draft-04/schema#":
 			fallthrough
 		case "#":
@@ -64,6 +73,24 @@ func (schema *Schema) describeSchema(indent string) string {
 			result += indent + "$id: " + *(schema.ID) + "\n"
 		}
 	}
+	if schema.Vocabulary != nil {
+		result += indent + "$vocabulary:\n"
+		for _, pair := range *(schema.Vocabulary) {
+			result += indent + "  " + fmt.Sprintf("%s: %+v\n", pair.Name, pair.Value)
+		}
+	}
+	if schema.Anchor != nil {
+		result += indent + fmt.Sprintf("$anchor: %+v\n", *(schema.Anchor))
+	}
+	if schema.DynamicAnchor != nil {
+		result += indent + fmt.Sprintf("$dynamicAnchor: %+v\n", *(schema.DynamicAnchor))
+	}
+	if schema.RecursiveAnchor != nil {
+		result += indent + fmt.Sprintf("$recursiveAnchor: %+v\n", *(schema.RecursiveAnchor))
+	}
+	if schema.Comment != nil {
+		result += indent + fmt.Sprintf("$comment: %+v\n", *(schema.Comment))
+	}
 	if schema.MultipleOf != nil {
 		result += indent + fmt.Sprintf("multipleOf: %+v\n", *(schema.MultipleOf))
 	}
@@ -79,6 +106,12 @@ func (schema *Schema) describeSchema(indent string) string {
 	if schema.ExclusiveMinimum != nil {
 		result += indent + fmt.Sprintf("exclusiveMinimum: %+v\n", *(schema.ExclusiveMinimum))
 	}
+	if schema.ExclusiveMaximumNumber != nil {
+		result += indent + fmt.Sprintf("exclusiveMaximum: %+v\n", *(schema.ExclusiveMaximumNumber))
+	}
+	if schema.ExclusiveMinimumNumber != nil {
+		result += indent + fmt.Sprintf("exclusiveMinimum: %+v\n", *(schema.ExclusiveMinimumNumber))
+	}
 	if schema.MaxLength != nil {
 		result += indent + fmt.Sprintf("maxLength: %+v\n", *(schema.MaxLength))
 	}
@@ -119,6 +152,27 @@ func (schema *Schema) describeSchema(indent string) string {
 	if schema.UniqueItems != nil {
 		result += indent + fmt.Sprintf("uniqueItems: %+v\n", *(schema.UniqueItems))
 	}
+	if schema.Contains != nil {
+		result += indent + "contains:\n"
+		result += schema.Contains.describeSchema(indent + "  ")
+	}
+	if schema.PrefixItems != nil {
+		result += indent + "prefixItems:\n"
+		for i, s := range *(schema.PrefixItems) {
+			result += indent + "  " + fmt.Sprintf("%d", i) + ":\n"
+			result += s.describeSchema(indent + "  " + "  ")
+		}
+	}
+	if schema.MaxContains != nil {
+		result += indent + fmt.Sprintf("maxContains: %+v\n", *(schema.MaxContains))
+	}
+	if schema.MinContains != nil {
+		result += indent + fmt.Sprintf("minContains: %+v\n", *(schema.MinContains))
+	}
+	if schema.UnevaluatedItems != nil {
+		result += indent + "unevaluatedItems:\n"
+		result += schema.UnevaluatedItems.describeSchema(indent + "  ")
+	}
 	if schema.MaxProperties != nil {
 		result += indent + fmt.Sprintf("maxProperties: %+v\n", *(schema.MaxProperties))
 	}
@@ -177,16 +231,47 @@ func (schema *Schema) describeSchema(indent string) string {
 
 		}
 	}
+	if schema.PropertyNames != nil {
+		result += indent + "propertyNames:\n"
+		result += schema.PropertyNames.describeSchema(indent + "  ")
+	}
+	if schema.DependentSchemas != nil {
+		result += indent + "dependentSchemas:\n"
+		for _, pair := range *(schema.DependentSchemas) {
+			name := pair.Name
+			s := pair.Value
+			result += indent + "  " + name + ":\n"
+			result += s.describeSchema(indent + "  " + "  ")
+		}
+	}
+	if schema.DependentRequired != nil {
+		result += indent + "dependentRequired:\n"
+		for _, pair := range *(schema.DependentRequired) {
+			result += indent + "  " + pair.Name + ":\n"
+			for _, s2 := range pair.Value {
+				result += indent + "  " + "  " + s2 + "\n"
+			}
+		}
+	}
+	if schema.UnevaluatedProperties != nil {
+		result += indent + "unevaluatedProperties:\n"
+		result += schema.UnevaluatedProperties.describeSchema(indent + "  ")
+	}
 	if schema.Enumeration != nil {
 		result += indent + "enumeration:\n"
 		for _, value := range *(schema.Enumeration) {
 			if value.String != nil {
 				result += indent + "  " + fmt.Sprintf("%+v\n", *value.String)
-			} else {
+			} else if value.Bool != nil {
 				result += indent + "  " + fmt.Sprintf("%+v\n", *value.Bool)
+			} else if value.Node != nil {
+				result += indent + "  " + describeNode(value.Node) + "\n"
 			}
 		}
 	}
+	if schema.Const != nil {
+		result += indent + "const: " + describeNode(schema.Const) + "\n"
+	}
 	if schema.Type != nil {
 		result += indent + fmt.Sprintf("type: %+v\n", schema.Type.Description())
 	}
@@ -215,6 +300,18 @@ func (schema *Schema) describeSchema(indent string) string {
 		result += indent + "not:\n"
 		result += schema.Not.describeSchema(indent + "  ")
 	}
+	if schema.If != nil {
+		result += indent + "if:\n"
+		result += schema.If.describeSchema(indent + "  ")
+	}
+	if schema.Then != nil {
+		result += indent + "then:\n"
+		result += schema.Then.describeSchema(indent + "  ")
+	}
+	if schema.Else != nil {
+		result += indent + "else:\n"
+		result += schema.Else.describeSchema(indent + "  ")
+	}
 	if schema.Definitions != nil {
 		result += indent + "definitions:\n"
 		for _, pair := range *(schema.Definitions) {
@@ -224,6 +321,15 @@ func (schema *Schema) describeSchema(indent string) string {
 			result += s.describeSchema(indent + "  " + "  ")
 		}
 	}
+	if schema.Defs != nil {
+		result += indent + "$defs:\n"
+		for _, pair := range *(schema.Defs) {
+			name := pair.Name
+			s := pair.Value
+			result += indent + "  " + name + ":\n"
+			result += s.describeSchema(indent + "  " + "  ")
+		}
+	}
 	if schema.Title != nil {
 		result += indent + "title: " + *(schema.Title) + "\n"
 	}
@@ -240,5 +346,19 @@ func (schema *Schema) describeSchema(indent string) string {
 	if schema.Ref != nil {
 		result += indent + "$ref: " + *(schema.Ref) + "\n"
 	}
+	if schema.DynamicRef != nil {
+		result += indent + "$dynamicRef: " + *(schema.DynamicRef) + "\n"
+	}
+	if schema.RecursiveRef != nil {
+		result += indent + "$recursiveRef: " + *(schema.RecursiveRef) + "\n"
+	}
 	return result
 }
+
+// Helper: Returns a one-line representation of a value.
+func describeNode(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	return strings.Join(strings.Fields(Render(node)), " ")
+}
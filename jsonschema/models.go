@@ -29,6 +29,20 @@ type Schema struct {
 	ReadOnly  *bool
 	WriteOnly *bool
 
+	// Core keywords of drafts 2019-09 and 2020-12
+	Vocabulary      *[]*NamedBoolean // $vocabulary
+	Anchor          *string          // $anchor
+	DynamicAnchor   *string          // $dynamicAnchor (2020-12)
+	DynamicRef      *string          // $dynamicRef (2020-12)
+	RecursiveAnchor *bool            // $recursiveAnchor (2019-09)
+	RecursiveRef    *string          // $recursiveRef (2019-09)
+	Defs            *[]*NamedSchema  // $defs
+	Comment         *string          // $comment
+
+	// Boolean schemas (draft-06 and later) accept every value (true)
+	// or no values (false). When Boolean is set, other fields are nil.
+	Boolean *bool
+
 	// http://json-schema.org/latest/json-schema-validation.html
 	// 5.1.  Validation keywords for numeric instances (number and integer)
 	MultipleOf       *SchemaNumber
@@ -37,6 +51,10 @@ type Schema struct {
 	Minimum          *SchemaNumber
 	ExclusiveMinimum *bool
 
+	// Draft-06 and later use numeric exclusive limits.
+	ExclusiveMaximumNumber *SchemaNumber
+	ExclusiveMinimumNumber *SchemaNumber
+
 	// 5.2.  Validation keywords for strings
 	MaxLength *int64
 	MinLength *int64
@@ -48,6 +66,14 @@ type Schema struct {
 	MaxItems        *int64
 	MinItems        *int64
 	UniqueItems     *bool
+	Contains        *Schema
+
+	// Array keywords of drafts 2019-09 and 2020-12. In 2020-12,
+	// prefixItems replaces the array form of items.
+	PrefixItems      *[]*Schema
+	MaxContains      *int64
+	MinContains      *int64
+	UnevaluatedItems *Schema
 
 	// 5.4.  Validation keywords for objects
 	MaxProperties        *int64
@@ -57,9 +83,17 @@ type Schema struct {
 	Properties           *[]*NamedSchema
 	PatternProperties    *[]*NamedSchema
 	Dependencies         *[]*NamedSchemaOrStringArray
+	PropertyNames        *Schema
+
+	// Object keywords of drafts 2019-09 and 2020-12, which split dependencies
+	// into dependentSchemas and dependentRequired.
+	DependentSchemas      *[]*NamedSchema
+	DependentRequired     *[]*NamedStringArray
+	UnevaluatedProperties *Schema
 
 	// 5.5.  Validation keywords for any instance type
 	Enumeration *[]SchemaEnumValue
+	Const       *yaml.Node
 	Type        *StringOrStringArray
 	AllOf       *[]*Schema
 	AnyOf       *[]*Schema
@@ -67,6 +101,11 @@ type Schema struct {
 	Not         *Schema
 	Definitions *[]*NamedSchema
 
+	// Conditional subschemas (draft-07)
+	If   *Schema
+	Then *Schema
+	Else *Schema
+
 	// 6.  Metadata keywords
 	Title       *string
 	Description *string
@@ -170,10 +209,12 @@ func NewSchemaOrSchemaArrayWithSchemaArray(a []*Schema) *SchemaOrSchemaArray {
 }
 
 // SchemaEnumValue represents a value that can be part of an
-// enumeration in a Schema.
+// enumeration in a Schema. Values that are neither strings nor
+// booleans are kept in Node.
 type SchemaEnumValue struct {
 	String *string
 	Bool   *bool
+	Node   *yaml.Node
 }
 
 // NamedSchema is a name-value pair that is used to emulate maps
@@ -188,6 +229,20 @@ func NewNamedSchema(name string, value *Schema) *NamedSchema {
 	return &NamedSchema{Name: name, Value: value}
 }
 
+// NamedStringArray is a name-value pair that is used to emulate maps
+// with ordered keys.
+type NamedStringArray struct {
+	Name  string
+	Value []string
+}
+
+// NamedBoolean is a name-value pair that is used to emulate maps
+// with ordered keys.
+type NamedBoolean struct {
+	Name  string
+	Value bool
+}
+
 // NamedSchemaOrStringArray is a name-value pair that is used
 // to emulate maps with ordered keys.
 type NamedSchemaOrStringArray struct {
@@ -220,8 +275,12 @@ func (s *Schema) PatternPropertyWithName(name string) *Schema {
 }
 
 // DefinitionWithName returns the selected element.
+// Definitions are looked up in $defs if they aren't found in definitions.
 func (s *Schema) DefinitionWithName(name string) *Schema {
-	return namedSchemaArrayElementWithName(s.Definitions, name)
+	if d := namedSchemaArrayElementWithName(s.Definitions, name); d != nil {
+		return d
+	}
+	return namedSchemaArrayElementWithName(s.Defs, name)
 }
 
 // AddProperty adds a named property.
@@ -29,11 +29,22 @@ import (
 func (schema *Schema) IsEmpty() bool {
 	return (schema.Schema == nil) &&
 		(schema.ID == nil) &&
+		(schema.Vocabulary == nil) &&
+		(schema.Anchor == nil) &&
+		(schema.DynamicAnchor == nil) &&
+		(schema.DynamicRef == nil) &&
+		(schema.RecursiveAnchor == nil) &&
+		(schema.RecursiveRef == nil) &&
+		(schema.Defs == nil) &&
+		(schema.Comment == nil) &&
+		(schema.Boolean == nil) &&
 		(schema.MultipleOf == nil) &&
 		(schema.Maximum == nil) &&
 		(schema.ExclusiveMaximum == nil) &&
 		(schema.Minimum == nil) &&
 		(schema.ExclusiveMinimum == nil) &&
+		(schema.ExclusiveMaximumNumber == nil) &&
+		(schema.ExclusiveMinimumNumber == nil) &&
 		(schema.MaxLength == nil) &&
 		(schema.MinLength == nil) &&
 		(schema.Pattern == nil) &&
@@ -42,6 +53,11 @@ func (schema *Schema) IsEmpty() bool {
 		(schema.MaxItems == nil) &&
 		(schema.MinItems == nil) &&
 		(schema.UniqueItems == nil) &&
+		(schema.Contains == nil) &&
+		(schema.PrefixItems == nil) &&
+		(schema.MaxContains == nil) &&
+		(schema.MinContains == nil) &&
+		(schema.UnevaluatedItems == nil) &&
 		(schema.MaxProperties == nil) &&
 		(schema.MinProperties == nil) &&
 		(schema.Required == nil) &&
@@ -49,12 +65,20 @@ func (schema *Schema) IsEmpty() bool {
 		(schema.Properties == nil) &&
 		(schema.PatternProperties == nil) &&
 		(schema.Dependencies == nil) &&
+		(schema.PropertyNames == nil) &&
+		(schema.DependentSchemas == nil) &&
+		(schema.DependentRequired == nil) &&
+		(schema.UnevaluatedProperties == nil) &&
 		(schema.Enumeration == nil) &&
+		(schema.Const == nil) &&
 		(schema.Type == nil) &&
 		(schema.AllOf == nil) &&
 		(schema.AnyOf == nil) &&
 		(schema.OneOf == nil) &&
 		(schema.Not == nil) &&
+		(schema.If == nil) &&
+		(schema.Then == nil) &&
+		(schema.Else == nil) &&
 		(schema.Definitions == nil) &&
 		(schema.Title == nil) &&
 		(schema.Description == nil) &&
@@ -140,12 +164,52 @@ func (schema *Schema) applyToSchemas(operation SchemaOperation, context string)
 		schema.Not.applyToSchemas(operation, "Not")
 	}
 
+	if schema.Contains != nil {
+		schema.Contains.applyToSchemas(operation, "Contains")
+	}
+	if schema.PropertyNames != nil {
+		schema.PropertyNames.applyToSchemas(operation, "PropertyNames")
+	}
+	if schema.If != nil {
+		schema.If.applyToSchemas(operation, "If")
+	}
+	if schema.Then != nil {
+		schema.Then.applyToSchemas(operation, "Then")
+	}
+	if schema.Else != nil {
+		schema.Else.applyToSchemas(operation, "Else")
+	}
+
+	if schema.PrefixItems != nil {
+		for _, s := range *(schema.PrefixItems) {
+			s.applyToSchemas(operation, "PrefixItems")
+		}
+	}
+	if schema.UnevaluatedItems != nil {
+		schema.UnevaluatedItems.applyToSchemas(operation, "UnevaluatedItems")
+	}
+	if schema.DependentSchemas != nil {
+		for _, pair := range *(schema.DependentSchemas) {
+			s := pair.Value
+			s.applyToSchemas(operation, "DependentSchemas")
+		}
+	}
+	if schema.UnevaluatedProperties != nil {
+		schema.UnevaluatedProperties.applyToSchemas(operation, "UnevaluatedProperties")
+	}
+
 	if schema.Definitions != nil {
 		for _, pair := range *(schema.Definitions) {
 			s := pair.Value
 			s.applyToSchemas(operation, "Definitions")
 		}
 	}
+	if schema.Defs != nil {
+		for _, pair := range *(schema.Defs) {
+			s := pair.Value
+			s.applyToSchemas(operation, "Defs")
+		}
+	}
 
 	operation(schema, context)
 }
@@ -158,6 +222,30 @@ func (schema *Schema) CopyProperties(source *Schema) {
 	if source.ID != nil {
 		schema.ID = source.ID
 	}
+	if source.Vocabulary != nil {
+		schema.Vocabulary = source.Vocabulary
+	}
+	if source.Anchor != nil {
+		schema.Anchor = source.Anchor
+	}
+	if source.DynamicAnchor != nil {
+		schema.DynamicAnchor = source.DynamicAnchor
+	}
+	if source.DynamicRef != nil {
+		schema.DynamicRef = source.DynamicRef
+	}
+	if source.RecursiveAnchor != nil {
+		schema.RecursiveAnchor = source.RecursiveAnchor
+	}
+	if source.RecursiveRef != nil {
+		schema.RecursiveRef = source.RecursiveRef
+	}
+	if source.Defs != nil {
+		schema.Defs = source.Defs
+	}
+	if source.Comment != nil {
+		schema.Comment = source.Comment
+	}
 	if source.MultipleOf != nil {
 		schema.MultipleOf = source.MultipleOf
 	}
@@ -173,6 +261,12 @@ func (schema *Schema) CopyProperties(source *Schema) {
 	if source.ExclusiveMinimum != nil {
 		schema.ExclusiveMinimum = source.ExclusiveMinimum
 	}
+	if source.ExclusiveMaximumNumber != nil {
+		schema.ExclusiveMaximumNumber = source.ExclusiveMaximumNumber
+	}
+	if source.ExclusiveMinimumNumber != nil {
+		schema.ExclusiveMinimumNumber = source.ExclusiveMinimumNumber
+	}
 	if source.MaxLength != nil {
 		schema.MaxLength = source.MaxLength
 	}
@@ -197,6 +291,21 @@ func (schema *Schema) CopyProperties(source *Schema) {
 	if source.UniqueItems != nil {
 		schema.UniqueItems = source.UniqueItems
 	}
+	if source.Contains != nil {
+		schema.Contains = source.Contains
+	}
+	if source.PrefixItems != nil {
+		schema.PrefixItems = source.PrefixItems
+	}
+	if source.MaxContains != nil {
+		schema.MaxContains = source.MaxContains
+	}
+	if source.MinContains != nil {
+		schema.MinContains = source.MinContains
+	}
+	if source.UnevaluatedItems != nil {
+		schema.UnevaluatedItems = source.UnevaluatedItems
+	}
 	if source.MaxProperties != nil {
 		schema.MaxProperties = source.MaxProperties
 	}
@@ -218,9 +327,24 @@ func (schema *Schema) CopyProperties(source *Schema) {
 	if source.Dependencies != nil {
 		schema.Dependencies = source.Dependencies
 	}
+	if source.PropertyNames != nil {
+		schema.PropertyNames = source.PropertyNames
+	}
+	if source.DependentSchemas != nil {
+		schema.DependentSchemas = source.DependentSchemas
+	}
+	if source.DependentRequired != nil {
+		schema.DependentRequired = source.DependentRequired
+	}
+	if source.UnevaluatedProperties != nil {
+		schema.UnevaluatedProperties = source.UnevaluatedProperties
+	}
 	if source.Enumeration != nil {
 		schema.Enumeration = source.Enumeration
 	}
+	if source.Const != nil {
+		schema.Const = source.Const
+	}
 	if source.Type != nil {
 		schema.Type = source.Type
 	}
@@ -236,6 +360,15 @@ func (schema *Schema) CopyProperties(source *Schema) {
 	if source.Not != nil {
 		schema.Not = source.Not
 	}
+	if source.If != nil {
+		schema.If = source.If
+	}
+	if source.Then != nil {
+		schema.Then = source.Then
+	}
+	if source.Else != nil {
+		schema.Else = source.Else
+	}
 	if source.Definitions != nil {
 		schema.Definitions = source.Definitions
 	}
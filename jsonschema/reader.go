@@ -64,6 +64,12 @@ func NewSchemaFromObject(jsonData *yaml.Node) *Schema {
 	switch jsonData.Kind {
 	case yaml.DocumentNode:
 		return NewSchemaFromObject(jsonData.Content[0])
+	case yaml.ScalarNode:
+		if jsonData.Tag == "!!bool" {
+			v, _ := strconv.ParseBool(jsonData.Value)
+			return &Schema{Boolean: &v}
+		}
+		fmt.Printf("schemaValue: unexpected node %+v\n", jsonData)
 	case yaml.MappingNode:
 		schema := &Schema{}
 
@@ -74,19 +80,47 @@ func NewSchemaFromObject(jsonData *yaml.Node) *Schema {
 			switch k {
 			case "$schema":
 				schema.Schema = schema.stringValue(v)
-			case "id":
+			case "id", "$id":
 				schema.ID = schema.stringValue(v)
+			case "$vocabulary":
+				schema.Vocabulary = schema.mapOfBooleansValue(v)
+			case "$anchor":
+				schema.Anchor = schema.stringValue(v)
+			case "$dynamicAnchor":
+				schema.DynamicAnchor = schema.stringValue(v)
+			case "$dynamicRef":
+				schema.DynamicRef = schema.stringValue(v)
+			case "$recursiveAnchor":
+				schema.RecursiveAnchor = schema.boolValue(v)
+			case "$recursiveRef":
+				schema.RecursiveRef = schema.stringValue(v)
+			case "$defs":
+				schema.Defs = schema.mapOfSchemasValue(v)
+			case "$comment":
+				schema.Comment = schema.stringValue(v)
+			case "readOnly":
+				schema.ReadOnly = schema.boolValue(v)
+			case "writeOnly":
+				schema.WriteOnly = schema.boolValue(v)
 
 			case "multipleOf":
 				schema.MultipleOf = schema.numberValue(v)
 			case "maximum":
 				schema.Maximum = schema.numberValue(v)
 			case "exclusiveMaximum":
-				schema.ExclusiveMaximum = schema.boolValue(v)
+				if v.Tag == "!!bool" {
+					schema.ExclusiveMaximum = schema.boolValue(v)
+				} else {
+					schema.ExclusiveMaximumNumber = schema.numberValue(v)
+				}
 			case "minimum":
 				schema.Minimum = schema.numberValue(v)
 			case "exclusiveMinimum":
-				schema.ExclusiveMinimum = schema.boolValue(v)
+				if v.Tag == "!!bool" {
+					schema.ExclusiveMinimum = schema.boolValue(v)
+				} else {
+					schema.ExclusiveMinimumNumber = schema.numberValue(v)
+				}
 
 			case "maxLength":
 				schema.MaxLength = schema.intValue(v)
@@ -105,6 +139,16 @@ func NewSchemaFromObject(jsonData *yaml.Node) *Schema {
 				schema.MinItems = schema.intValue(v)
 			case "uniqueItems":
 				schema.UniqueItems = schema.boolValue(v)
+			case "contains":
+				schema.Contains = NewSchemaFromObject(v)
+			case "prefixItems":
+				schema.PrefixItems = schema.arrayOfSchemasValue(v)
+			case "maxContains":
+				schema.MaxContains = schema.intValue(v)
+			case "minContains":
+				schema.MinContains = schema.intValue(v)
+			case "unevaluatedItems":
+				schema.UnevaluatedItems = NewSchemaFromObject(v)
 
 			case "maxProperties":
 				schema.MaxProperties = schema.intValue(v)
@@ -120,9 +164,19 @@ func NewSchemaFromObject(jsonData *yaml.Node) *Schema {
 				schema.PatternProperties = schema.mapOfSchemasValue(v)
 			case "dependencies":
 				schema.Dependencies = schema.mapOfSchemasOrStringArraysValue(v)
+			case "propertyNames":
+				schema.PropertyNames = NewSchemaFromObject(v)
+			case "dependentSchemas":
+				schema.DependentSchemas = schema.mapOfSchemasValue(v)
+			case "dependentRequired":
+				schema.DependentRequired = schema.mapOfStringArraysValue(v)
+			case "unevaluatedProperties":
+				schema.UnevaluatedProperties = NewSchemaFromObject(v)
 
 			case "enum":
 				schema.Enumeration = schema.arrayOfEnumValuesValue(v)
+			case "const":
+				schema.Const = v
 
 			case "type":
 				schema.Type = schema.stringOrStringArrayValue(v)
@@ -137,6 +191,13 @@ func NewSchemaFromObject(jsonData *yaml.Node) *Schema {
 			case "definitions":
 				schema.Definitions = schema.mapOfSchemasValue(v)
 
+			case "if":
+				schema.If = NewSchemaFromObject(v)
+			case "then":
+				schema.Then = NewSchemaFromObject(v)
+			case "else":
+				schema.Else = NewSchemaFromObject(v)
+
 			case "title":
 				schema.Title = schema.stringValue(v)
 			case "description":
@@ -149,6 +210,8 @@ func NewSchemaFromObject(jsonData *yaml.Node) *Schema {
 				schema.Format = schema.stringValue(v)
 			case "$ref":
 				schema.Ref = schema.stringValue(v)
+			case "examples":
+				// annotations that aren't modeled
 			default:
 				fmt.Printf("UNSUPPORTED (%s)\n", k)
 			}
@@ -273,7 +336,7 @@ func (schema *Schema) arrayOfSchemasValue(v *yaml.Node) *[]*Schema {
 		m := make([]*Schema, 0)
 		for _, v2 := range v.Content {
 			switch v2.Kind {
-			case yaml.MappingNode:
+			case yaml.MappingNode, yaml.ScalarNode:
 				s := NewSchemaFromObject(v2)
 				m = append(m, s)
 			default:
@@ -299,7 +362,7 @@ func (schema *Schema) schemaOrSchemaArrayValue(v *yaml.Node) *SchemaOrSchemaArra
 		m := make([]*Schema, 0)
 		for _, v2 := range v.Content {
 			switch v2.Kind {
-			case yaml.MappingNode:
+			case yaml.MappingNode, yaml.ScalarNode:
 				s := NewSchemaFromObject(v2)
 				m = append(m, s)
 			default:
@@ -307,7 +370,7 @@ func (schema *Schema) schemaOrSchemaArrayValue(v *yaml.Node) *SchemaOrSchemaArra
 			}
 		}
 		return &SchemaOrSchemaArray{SchemaArray: &m}
-	case yaml.MappingNode:
+	case yaml.MappingNode, yaml.ScalarNode:
 		s := NewSchemaFromObject(v)
 		return &SchemaOrSchemaArray{Schema: s}
 	default:
@@ -380,10 +443,10 @@ func (schema *Schema) arrayOfEnumValuesValue(v *yaml.Node) *[]SchemaEnumValue {
 					v3, _ := strconv.ParseBool(v2.Value)
 					a = append(a, SchemaEnumValue{Bool: &v3})
 				default:
-					fmt.Printf("arrayOfEnumValuesValue: unexpected type %s\n", v2.Tag)
+					a = append(a, SchemaEnumValue{Node: v2})
 				}
 			default:
-				fmt.Printf("arrayOfEnumValuesValue: unexpected node %+v\n", v2)
+				a = append(a, SchemaEnumValue{Node: v2})
 			}
 		}
 	default:
@@ -415,6 +478,11 @@ func (schema *Schema) mapOfSchemasOrStringArraysValue(v *yaml.Node) *[]*NamedSch
 				s.StringArray = &a
 				pair := &NamedSchemaOrStringArray{Name: k2, Value: s}
 				m = append(m, pair)
+			case yaml.MappingNode, yaml.ScalarNode:
+				s := &SchemaOrStringArray{}
+				s.Schema = NewSchemaFromObject(v2)
+				pair := &NamedSchemaOrStringArray{Name: k2, Value: s}
+				m = append(m, pair)
 			default:
 				fmt.Printf("mapOfSchemasOrStringArraysValue: unexpected node %+v\n", v2)
 			}
@@ -425,6 +493,40 @@ func (schema *Schema) mapOfSchemasOrStringArraysValue(v *yaml.Node) *[]*NamedSch
 	return &m
 }
 
+// Gets a map of string arrays from an interface{} value if possible.
+func (schema *Schema) mapOfStringArraysValue(v *yaml.Node) *[]*NamedStringArray {
+	m := make([]*NamedStringArray, 0)
+	switch v.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(v.Content); i += 2 {
+			a := schema.arrayOfStringsValue(v.Content[i+1])
+			if a != nil {
+				m = append(m, &NamedStringArray{Name: v.Content[i].Value, Value: *a})
+			}
+		}
+	default:
+		fmt.Printf("mapOfStringArraysValue: unexpected node %+v\n", v)
+	}
+	return &m
+}
+
+// Gets a map of booleans from an interface{} value if possible.
+func (schema *Schema) mapOfBooleansValue(v *yaml.Node) *[]*NamedBoolean {
+	m := make([]*NamedBoolean, 0)
+	switch v.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(v.Content); i += 2 {
+			b := schema.boolValue(v.Content[i+1])
+			if b != nil {
+				m = append(m, &NamedBoolean{Name: v.Content[i].Value, Value: *b})
+			}
+		}
+	default:
+		fmt.Printf("mapOfBooleansValue: unexpected node %+v\n", v)
+	}
+	return &m
+}
+
 // Gets a schema or a boolean value from an interface{} value if possible.
 func (schema *Schema) schemaOrBooleanValue(v *yaml.Node) *SchemaOrBoolean {
 	schemaOrBoolean := &SchemaOrBoolean{}
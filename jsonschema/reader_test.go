@@ -0,0 +1,109 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const schema202012 = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://example.com/tree",
+  "$vocabulary": {
+    "https://json-schema.org/draft/2020-12/vocab/core": true,
+    "https://json-schema.org/draft/2020-12/vocab/format-annotation": false
+  },
+  "$dynamicAnchor": "node",
+  "$comment": "a tree of labeled nodes",
+  "type": "object",
+  "properties": {
+    "label": {"$ref": "#/$defs/label"},
+    "children": {"type": "array", "items": {"$dynamicRef": "#node"}},
+    "position": {
+      "type": "array",
+      "prefixItems": [{"type": "number"}, {"type": "number"}],
+      "items": false,
+      "contains": {"const": 0},
+      "minContains": 1,
+      "maxContains": 2,
+      "unevaluatedItems": false
+    }
+  },
+  "dependentRequired": {"position": ["label"]},
+  "dependentSchemas": {"children": {"required": ["label"]}},
+  "unevaluatedProperties": false,
+  "$defs": {
+    "label": {"$anchor": "label", "type": "string", "minLength": 1}
+  }
+}`
+
+func TestDraft202012Keywords(t *testing.T) {
+	schema := NewSchemaFromObject(readNode(t, schema202012))
+	if schema.Vocabulary == nil || !reflect.DeepEqual(*schema.Vocabulary, []*NamedBoolean{
+		{Name: "https://json-schema.org/draft/2020-12/vocab/core", Value: true},
+		{Name: "https://json-schema.org/draft/2020-12/vocab/format-annotation", Value: false},
+	}) {
+		t.Errorf("unexpected $vocabulary %+v", schema.Vocabulary)
+	}
+	if schema.DynamicAnchor == nil || *schema.DynamicAnchor != "node" {
+		t.Errorf("unexpected $dynamicAnchor %v", schema.DynamicAnchor)
+	}
+	if ref := schema.PropertyWithName("children").Items.Schema.DynamicRef; ref == nil || *ref != "#node" {
+		t.Errorf("unexpected $dynamicRef %v", ref)
+	}
+	position := schema.PropertyWithName("position")
+	if position.PrefixItems == nil || len(*position.PrefixItems) != 2 {
+		t.Errorf("unexpected prefixItems %+v", position.PrefixItems)
+	}
+	if position.Items.Schema == nil || position.Items.Schema.Boolean == nil || *position.Items.Schema.Boolean {
+		t.Errorf("unexpected items %+v", position.Items)
+	}
+	if *position.MinContains != 1 || *position.MaxContains != 2 || position.UnevaluatedItems == nil {
+		t.Errorf("unexpected array keywords")
+	}
+	if schema.DependentRequired == nil || !reflect.DeepEqual(*schema.DependentRequired, []*NamedStringArray{
+		{Name: "position", Value: []string{"label"}},
+	}) {
+		t.Errorf("unexpected dependentRequired %+v", schema.DependentRequired)
+	}
+	if schema.DependentSchemas == nil || (*schema.DependentSchemas)[0].Value.Required == nil {
+		t.Errorf("unexpected dependentSchemas %+v", schema.DependentSchemas)
+	}
+	if schema.UnevaluatedProperties == nil || *schema.UnevaluatedProperties.Boolean {
+		t.Errorf("unexpected unevaluatedProperties %+v", schema.UnevaluatedProperties)
+	}
+	label := schema.DefinitionWithName("label")
+	if label == nil || label.Anchor == nil || *label.Anchor != "label" {
+		t.Errorf("unexpected $defs %+v", schema.Defs)
+	}
+	for _, ref := range []string{"#/$defs/label", "#label"} {
+		if resolved, err := schema.resolveRef(ref); err != nil || resolved != label {
+			t.Errorf("%s was not resolved: %v", ref, err)
+		}
+	}
+
+	// Writing and rereading the schema preserves all of its keywords.
+	bytes, err := yaml.Marshal(schema.nodeValue())
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	reread := NewSchemaFromObject(readNode(t, string(bytes)))
+	if !schema.IsEqual(reread) {
+		t.Errorf("expected\n%s\nfound\n%s", schema.String(), reread.String())
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//
+// REGISTRY
+// A Registry resolves references among a set of Schemas.
+//
+
+// A Loader returns the JSON or YAML document at a URI.
+type Loader func(uri string) ([]byte, error)
+
+// FileLoader loads documents from files, file URIs, and HTTP URLs.
+func FileLoader(uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "", "file":
+		return ioutil.ReadFile(u.Path)
+	case "http", "https":
+		response, err := http.Get(uri)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error fetching %s: %s", uri, response.Status)
+		}
+		return ioutil.ReadAll(response.Body)
+	}
+	return nil, fmt.Errorf("unsupported URI %s", uri)
+}
+
+// MapLoader returns a Loader that serves documents from memory.
+func MapLoader(documents map[string]string) Loader {
+	return func(uri string) ([]byte, error) {
+		document, ok := documents[uri]
+		if !ok {
+			return nil, fmt.Errorf("no document for %s", uri)
+		}
+		return []byte(document), nil
+	}
+}
+
+// Registry holds Schemas that can refer to each other. Schemas are indexed by the URIs
+// that they were loaded from and by their ids, and references are resolved relative to
+// the ids of the Schemas that contain them. Schemas that aren't in the registry are
+// loaded when they are first referenced.
+type Registry struct {
+	loader    Loader
+	resources map[string]*Schema   // schemas with URIs, keyed by URIs without fragments
+	anchors   map[string]*Schema   // schemas with plain name fragments, keyed by URIs with fragments
+	bases     map[*Schema]*url.URL // the base URI of every schema in the registry
+}
+
+// NewRegistry creates a registry that loads schemas with the specified loader.
+// If the loader is nil, schemas are loaded with FileLoader.
+func NewRegistry(loader Loader) *Registry {
+	if loader == nil {
+		loader = FileLoader
+	}
+	return &Registry{
+		loader:    loader,
+		resources: make(map[string]*Schema),
+		anchors:   make(map[string]*Schema),
+		bases:     make(map[*Schema]*url.URL),
+	}
+}
+
+// AddSchema adds a schema to the registry with the URI that it was read from.
+// The URI may be empty if the schema has an absolute id.
+func (r *Registry) AddSchema(uri string, schema *Schema) error {
+	base, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	base.Fragment = ""
+	r.resources[base.String()] = schema
+	r.index(schema, base)
+	return nil
+}
+
+// Load returns the schema at a URI, loading it if it isn't already in the registry.
+func (r *Registry) Load(uri string) (*Schema, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	u.Fragment = ""
+	if schema, ok := r.resources[u.String()]; ok {
+		return schema, nil
+	}
+	bytes, err := r.loader(u.String())
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(bytes, &node); err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", u.String(), err.Error())
+	}
+	if len(node.Content) == 0 {
+		return nil, fmt.Errorf("error reading %s: empty document", u.String())
+	}
+	schema := NewSchemaFromObject(&node)
+	if schema == nil {
+		return nil, fmt.Errorf("error reading %s: not a schema", u.String())
+	}
+	return schema, r.AddSchema(u.String(), schema)
+}
+
+// index records the base URIs, ids and anchors of a schema and its subschemas.
+func (r *Registry) index(schema *Schema, base *url.URL) {
+	if schema == nil {
+		return
+	}
+	if _, ok := r.bases[schema]; ok {
+		return
+	}
+	if schema.ID != nil {
+		if id, err := url.Parse(*schema.ID); err == nil {
+			u := base.ResolveReference(id)
+			if *schema.ID == "#"+id.Fragment {
+				// In drafts 04 to 07, ids that are only fragments are anchors.
+				r.anchors[u.String()] = schema
+			} else {
+				u.Fragment = ""
+				base = u
+				r.resources[base.String()] = schema
+			}
+		}
+	}
+	if schema.Anchor != nil {
+		r.anchors[base.String()+"#"+*schema.Anchor] = schema
+	}
+	if schema.DynamicAnchor != nil {
+		r.anchors[base.String()+"#"+*schema.DynamicAnchor] = schema
+	}
+	r.bases[schema] = base
+	for _, s := range schema.subschemas() {
+		r.index(s, base)
+	}
+}
+
+// Resolve returns the schema that a reference refers to. The reference is resolved
+// relative to the base URI of the schema that contains it.
+func (r *Registry) Resolve(from *Schema, ref string) (*Schema, error) {
+	base, ok := r.bases[from]
+	if !ok {
+		base = &url.URL{}
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference: %s", ref)
+	}
+	target := base.ResolveReference(u)
+	fragment := target.Fragment
+	target.Fragment = ""
+	document, ok := r.resources[target.String()]
+	if !ok {
+		if target.String() == "" {
+			return nil, fmt.Errorf("unresolved reference: %s", ref)
+		}
+		document, err = r.Load(target.String())
+		if err != nil {
+			return nil, fmt.Errorf("unresolved reference: %s (%s)", ref, err.Error())
+		}
+	}
+	var result *Schema
+	if fragment == "" {
+		result = document
+	} else if strings.HasPrefix(fragment, "/") {
+		result = document.schemaAtPointer(strings.Split(fragment[1:], "/"))
+	} else {
+		result = r.anchors[target.String()+"#"+fragment]
+	}
+	if result == nil {
+		return nil, fmt.Errorf("unresolved reference: %s", ref)
+	}
+	return result, nil
+}
+
+// Deref follows the references of a schema until it reaches a schema that is not a
+// reference. It returns an error if the references form a cycle.
+func (r *Registry) Deref(schema *Schema) (*Schema, error) {
+	visited := make(map[*Schema]bool)
+	refs := make([]string, 0)
+	for schema.Ref != nil {
+		if visited[schema] {
+			return nil, fmt.Errorf("reference cycle: %s", strings.Join(refs, " -> "))
+		}
+		visited[schema] = true
+		refs = append(refs, *schema.Ref)
+		target, err := r.Resolve(schema, *schema.Ref)
+		if err != nil {
+			return nil, err
+		}
+		schema = target
+	}
+	return schema, nil
+}
+
+// Validate checks an instance against a schema, resolving references with the registry.
+// A schema that isn't in the registry is added to it with an empty URI.
+func (r *Registry) Validate(schema *Schema, instance *yaml.Node) error {
+	if _, ok := r.bases[schema]; !ok {
+		r.AddSchema("", schema)
+	}
+	v := &validator{
+		resolve:  r.Resolve,
+		patterns: make(map[string]*regexp.Regexp),
+		refs:     make(map[refVisit]bool),
+	}
+	v.validate(schema, instance, "", "")
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+// subschemas returns the schemas that a schema contains.
+func (schema *Schema) subschemas() []*Schema {
+	result := make([]*Schema, 0)
+	add := func(schemas ...*Schema) {
+		for _, s := range schemas {
+			if s != nil {
+				result = append(result, s)
+			}
+		}
+	}
+	addArray := func(array *[]*Schema) {
+		if array != nil {
+			add(*array...)
+		}
+	}
+	addNamed := func(array *[]*NamedSchema) {
+		if array != nil {
+			for _, pair := range *array {
+				add(pair.Value)
+			}
+		}
+	}
+	if schema.Items != nil {
+		add(schema.Items.Schema)
+		addArray(schema.Items.SchemaArray)
+	}
+	if schema.AdditionalItems != nil {
+		add(schema.AdditionalItems.Schema)
+	}
+	if schema.AdditionalProperties != nil {
+		add(schema.AdditionalProperties.Schema)
+	}
+	if schema.Dependencies != nil {
+		for _, pair := range *schema.Dependencies {
+			add(pair.Value.Schema)
+		}
+	}
+	addNamed(schema.Properties)
+	addNamed(schema.PatternProperties)
+	addNamed(schema.DependentSchemas)
+	addNamed(schema.Definitions)
+	addNamed(schema.Defs)
+	addArray(schema.PrefixItems)
+	addArray(schema.AllOf)
+	addArray(schema.AnyOf)
+	addArray(schema.OneOf)
+	add(schema.Not, schema.Contains, schema.PropertyNames, schema.If, schema.Then, schema.Else,
+		schema.UnevaluatedItems, schema.UnevaluatedProperties)
+	return result
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+var documents = map[string]string{
+	"https://example.com/schemas/order.json": `{
+  "$id": "https://example.com/schemas/order.json",
+  "type": "object",
+  "properties": {
+    "customer": {"$ref": "customer.json"},
+    "total": {"$ref": "common/money.json#/definitions/amount"},
+    "currency": {"$ref": "common/money.json#currency"},
+    "shipping": {"$ref": "https://example.com/schemas/common/address.json"}
+  }
+}`,
+	"https://example.com/schemas/customer.json": `{
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "name": {"type": "string"},
+    "address": {"$ref": "common/address.json"}
+  }
+}`,
+	"https://example.com/schemas/common/money.json": `{
+  "definitions": {
+    "amount": {"type": "number", "minimum": 0},
+    "code": {"$anchor": "currency", "enum": ["EUR", "USD"]}
+  }
+}`,
+	"https://example.com/schemas/common/address.json": `{
+  "type": "object",
+  "required": ["city"],
+  "properties": {"city": {"type": "string"}}
+}`,
+	"https://example.com/schemas/a.json": `{"$ref": "b.json"}`,
+	"https://example.com/schemas/b.json": `{"$ref": "a.json"}`,
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(MapLoader(documents))
+	order, err := registry.Load("https://example.com/schemas/order.json")
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	valid := `{"customer": {"name": "Ada", "address": {"city": "London"}}, "total": 3.5, "currency": "EUR", "shipping": {"city": "Paris"}}`
+	if err := registry.Validate(order, readNode(t, valid)); err != nil {
+		t.Errorf("unexpected errors\n%s", err.Error())
+	}
+	err = registry.Validate(order, readNode(t, `{"customer": {"address": {}}, "total": -1, "currency": "GBP"}`))
+	expected := strings.Join([]string{
+		`#/customer: missing required property "name" (#/properties/customer/$ref/required)`,
+		`#/customer/address: missing required property "city" (#/properties/customer/$ref/properties/address/$ref/required)`,
+		`#/total: -1 is less than minimum 0 (#/properties/total/$ref/minimum)`,
+		`#/currency: value is not one of the allowed values (#/properties/currency/$ref/enum)`,
+	}, "\n")
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected\n%s\nfound\n%v", expected, err)
+	}
+
+	// Documents are only loaded once.
+	address, err := registry.Load("https://example.com/schemas/common/address.json")
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	shipping, err := registry.Deref(order.PropertyWithName("shipping"))
+	if err != nil || shipping != address {
+		t.Errorf("shipping address was not resolved: %v", err)
+	}
+}
+
+func TestRegistryIDs(t *testing.T) {
+	registry := NewRegistry(MapLoader(nil))
+	root := NewSchemaFromObject(readNode(t, `{
+  "$id": "https://example.com/root.json",
+  "properties": {
+    "a": {"$ref": "nested/a.json"},
+    "b": {"$ref": "nested/a.json#b"},
+    "c": {"$ref": "nested/a.json#/definitions/c"}
+  },
+  "definitions": {
+    "a": {
+      "$id": "nested/a.json",
+      "type": "object",
+      "definitions": {
+        "b": {"$id": "#b", "type": "integer"},
+        "c": {"$ref": "#b"}
+      }
+    }
+  }
+}`))
+	if err := registry.AddSchema("", root); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	a := root.DefinitionWithName("a")
+	b := a.DefinitionWithName("b")
+	for _, test := range []struct {
+		property string
+		expected *Schema
+	}{
+		{"a", a},
+		{"b", b},
+		{"c", b},
+	} {
+		resolved, err := registry.Deref(root.PropertyWithName(test.property))
+		if err != nil || resolved != test.expected {
+			t.Errorf("%s was not resolved: %v", test.property, err)
+		}
+	}
+	if s, err := registry.Load("https://example.com/nested/a.json"); err != nil || s != a {
+		t.Errorf("embedded schema was not indexed by its id: %v", err)
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	registry := NewRegistry(MapLoader(documents))
+	a, err := registry.Load("https://example.com/schemas/a.json")
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if _, err := registry.Deref(a); err == nil || err.Error() != "reference cycle: b.json -> a.json" {
+		t.Errorf("unexpected error %v", err)
+	}
+	missing := NewSchemaFromObject(readNode(t, `{"$ref": "https://example.com/missing.json"}`))
+	if _, err := registry.Deref(missing); err == nil ||
+		err.Error() != "unresolved reference: https://example.com/missing.json (no document for https://example.com/missing.json)" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,762 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+//
+// VALIDATION
+// The following methods check instances against Schemas.
+//
+
+// ValidationError describes a part of an instance that does not satisfy a schema.
+type ValidationError struct {
+	// InstanceLocation is a JSON pointer to the invalid part of the instance.
+	InstanceLocation string
+	// SchemaLocation is a JSON pointer to the keyword that failed. It is relative to
+	// the schema being validated and includes any "$ref" keywords that were followed.
+	SchemaLocation string
+	// Message describes the problem.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("#%s: %s (#%s)", e.InstanceLocation, e.Message, e.SchemaLocation)
+}
+
+// ValidationErrors is a list of validation errors.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Validate checks an instance against a schema using the keywords of JSON Schema
+// drafts 04, 06 and 07, and the prefixItems, minContains, maxContains,
+// dependentRequired and dependentSchemas keywords of drafts 2019-09 and 2020-12.
+// Other keywords of those drafts that would restrict instances, like
+// unevaluatedProperties, are reported as unsupported instead of ignored. Instances are usually read from JSON or YAML with yaml.Unmarshal.
+// References are resolved within the schema and among the schemas that have been read
+// with ids; use a Registry to resolve references to other files.
+// The returned error is nil or a ValidationErrors list.
+func (schema *Schema) Validate(instance *yaml.Node) error {
+	v := &validator{
+		resolve: func(from *Schema, ref string) (*Schema, error) {
+			return schema.resolveRef(ref)
+		},
+		patterns: make(map[string]*regexp.Regexp),
+		refs:     make(map[refVisit]bool),
+	}
+	v.validate(schema, instance, "", "")
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+type validator struct {
+	resolve  func(from *Schema, ref string) (*Schema, error)
+	patterns map[string]*regexp.Regexp
+	refs     map[refVisit]bool // references that are being followed
+	errors   ValidationErrors
+}
+
+// refVisit identifies the application of a referenced schema to a part of an instance.
+// Visiting the same pair again before the first visit has finished means that the
+// references form a cycle that doesn't consume any of the instance.
+type refVisit struct {
+	schema   *Schema
+	instance *yaml.Node
+}
+
+func (v *validator) fail(instanceLocation, schemaLocation string, format string, args ...interface{}) {
+	v.errors = append(v.errors, &ValidationError{
+		InstanceLocation: instanceLocation,
+		SchemaLocation:   schemaLocation,
+		Message:          fmt.Sprintf(format, args...),
+	})
+}
+
+// valid runs a nested validation and reports whether it succeeded without recording errors.
+func (v *validator) valid(s *Schema, instance *yaml.Node, instanceLocation, schemaLocation string) bool {
+	nested := &validator{resolve: v.resolve, patterns: v.patterns, refs: v.refs}
+	nested.validate(s, instance, instanceLocation, schemaLocation)
+	return len(nested.errors) == 0
+}
+
+func (v *validator) validate(s *Schema, instance *yaml.Node, instanceLocation, schemaLocation string) {
+	if s == nil || instance == nil {
+		return
+	}
+	switch instance.Kind {
+	case yaml.DocumentNode:
+		if len(instance.Content) == 1 {
+			v.validate(s, instance.Content[0], instanceLocation, schemaLocation)
+		}
+		return
+	case yaml.AliasNode:
+		v.validate(s, instance.Alias, instanceLocation, schemaLocation)
+		return
+	}
+	if s.Boolean != nil {
+		if !*s.Boolean {
+			v.fail(instanceLocation, schemaLocation, "no values are allowed")
+		}
+		return
+	}
+	for _, keyword := range s.unsupportedKeywords() {
+		v.fail(instanceLocation, schemaLocation+"/"+keyword, "unsupported keyword %s", keyword)
+	}
+	if s.Ref != nil {
+		// In drafts 04 to 07, keywords that are siblings of "$ref" are ignored.
+		location := schemaLocation + "/$ref"
+		target, err := v.resolve(s, *s.Ref)
+		if err != nil {
+			v.fail(instanceLocation, location, "%s", err.Error())
+			return
+		}
+		visit := refVisit{schema: target, instance: instance}
+		if v.refs[visit] {
+			v.fail(instanceLocation, location, "reference cycle at %s", *s.Ref)
+			return
+		}
+		v.refs[visit] = true
+		v.validate(target, instance, instanceLocation, location)
+		delete(v.refs, visit)
+		return
+	}
+
+	if s.Type != nil && !s.allowsType(instance) {
+		v.fail(instanceLocation, schemaLocation+"/type", "expected %s but found %s", s.Type.Description(), typeOf(instance))
+	}
+	if s.Enumeration != nil {
+		found := false
+		for _, value := range *s.Enumeration {
+			if equal(instance, value.nodeValue()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(instanceLocation, schemaLocation+"/enum", "value is not one of the allowed values")
+		}
+	}
+	if s.Const != nil && !equal(instance, s.Const) {
+		v.fail(instanceLocation, schemaLocation+"/const", "value is not %s", describeNode(s.Const))
+	}
+
+	switch typeOf(instance) {
+	case "integer", "number":
+		if x := numberForNode(instance); x != nil {
+			v.validateNumber(s, x, instanceLocation, schemaLocation)
+		}
+	case "string":
+		v.validateString(s, instance.Value, instanceLocation, schemaLocation)
+	case "array":
+		v.validateArray(s, instance, instanceLocation, schemaLocation)
+	case "object":
+		v.validateObject(s, instance, instanceLocation, schemaLocation)
+	}
+
+	if s.AllOf != nil {
+		for i, s2 := range *s.AllOf {
+			v.validate(s2, instance, instanceLocation, schemaLocation+"/allOf/"+strconv.Itoa(i))
+		}
+	}
+	if s.AnyOf != nil {
+		matched := false
+		for i, s2 := range *s.AnyOf {
+			if v.valid(s2, instance, instanceLocation, schemaLocation+"/anyOf/"+strconv.Itoa(i)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(instanceLocation, schemaLocation+"/anyOf", "value doesn't match any of the schemas")
+		}
+	}
+	if s.OneOf != nil {
+		count := 0
+		for i, s2 := range *s.OneOf {
+			if v.valid(s2, instance, instanceLocation, schemaLocation+"/oneOf/"+strconv.Itoa(i)) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(instanceLocation, schemaLocation+"/oneOf", "value matches %d of the schemas instead of one", count)
+		}
+	}
+	if s.Not != nil && v.valid(s.Not, instance, instanceLocation, schemaLocation+"/not") {
+		v.fail(instanceLocation, schemaLocation+"/not", "value matches a schema that it must not match")
+	}
+	if s.If != nil {
+		if v.valid(s.If, instance, instanceLocation, schemaLocation+"/if") {
+			v.validate(s.Then, instance, instanceLocation, schemaLocation+"/then")
+		} else {
+			v.validate(s.Else, instance, instanceLocation, schemaLocation+"/else")
+		}
+	}
+}
+
+// unsupportedKeywords returns the keywords of a schema that can't be validated.
+// Ignoring them would accept instances that the schema rejects.
+func (s *Schema) unsupportedKeywords() []string {
+	var keywords []string
+	if s.DynamicRef != nil {
+		keywords = append(keywords, "$dynamicRef")
+	}
+	if s.RecursiveRef != nil {
+		keywords = append(keywords, "$recursiveRef")
+	}
+	if s.UnevaluatedItems != nil {
+		keywords = append(keywords, "unevaluatedItems")
+	}
+	if s.UnevaluatedProperties != nil {
+		keywords = append(keywords, "unevaluatedProperties")
+	}
+	return keywords
+}
+
+func (v *validator) validateNumber(s *Schema, x *big.Rat, instanceLocation, schemaLocation string) {
+	if s.MultipleOf != nil {
+		if m := numberForSchemaNumber(s.MultipleOf); m != nil && m.Sign() > 0 {
+			if !new(big.Rat).Quo(x, m).IsInt() {
+				v.fail(instanceLocation, schemaLocation+"/multipleOf", "%s is not a multiple of %s", ratString(x), ratString(m))
+			}
+		}
+	}
+	if s.Maximum != nil {
+		if m := numberForSchemaNumber(s.Maximum); m != nil {
+			if s.ExclusiveMaximum != nil && *s.ExclusiveMaximum {
+				if x.Cmp(m) >= 0 {
+					v.fail(instanceLocation, schemaLocation+"/maximum", "%s is not less than %s", ratString(x), ratString(m))
+				}
+			} else if x.Cmp(m) > 0 {
+				v.fail(instanceLocation, schemaLocation+"/maximum", "%s is greater than maximum %s", ratString(x), ratString(m))
+			}
+		}
+	}
+	if s.ExclusiveMaximumNumber != nil {
+		if m := numberForSchemaNumber(s.ExclusiveMaximumNumber); m != nil && x.Cmp(m) >= 0 {
+			v.fail(instanceLocation, schemaLocation+"/exclusiveMaximum", "%s is not less than %s", ratString(x), ratString(m))
+		}
+	}
+	if s.Minimum != nil {
+		if m := numberForSchemaNumber(s.Minimum); m != nil {
+			if s.ExclusiveMinimum != nil && *s.ExclusiveMinimum {
+				if x.Cmp(m) <= 0 {
+					v.fail(instanceLocation, schemaLocation+"/minimum", "%s is not greater than %s", ratString(x), ratString(m))
+				}
+			} else if x.Cmp(m) < 0 {
+				v.fail(instanceLocation, schemaLocation+"/minimum", "%s is less than minimum %s", ratString(x), ratString(m))
+			}
+		}
+	}
+	if s.ExclusiveMinimumNumber != nil {
+		if m := numberForSchemaNumber(s.ExclusiveMinimumNumber); m != nil && x.Cmp(m) <= 0 {
+			v.fail(instanceLocation, schemaLocation+"/exclusiveMinimum", "%s is not greater than %s", ratString(x), ratString(m))
+		}
+	}
+}
+
+func (v *validator) validateString(s *Schema, x string, instanceLocation, schemaLocation string) {
+	// Lengths are measured in characters, not bytes.
+	length := int64(utf8.RuneCountInString(x))
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(instanceLocation, schemaLocation+"/maxLength", "string is longer than %d characters", *s.MaxLength)
+	}
+	if s.MinLength != nil && length < *s.MinLength {
+		v.fail(instanceLocation, schemaLocation+"/minLength", "string is shorter than %d characters", *s.MinLength)
+	}
+	if s.Pattern != nil {
+		if r := v.pattern(*s.Pattern); r != nil && !r.MatchString(x) {
+			v.fail(instanceLocation, schemaLocation+"/pattern", "string doesn't match pattern %s", *s.Pattern)
+		}
+	}
+	if s.Format != nil && !validFormat(*s.Format, x) {
+		v.fail(instanceLocation, schemaLocation+"/format", "%q is not a valid %s", x, *s.Format)
+	}
+}
+
+func (v *validator) validateArray(s *Schema, instance *yaml.Node, instanceLocation, schemaLocation string) {
+	items := instance.Content
+	// In 2020-12, prefixItems validates the first items and items validates the rest.
+	prefix := 0
+	if s.PrefixItems != nil {
+		prefix = len(*s.PrefixItems)
+		for i, item := range items {
+			if i >= prefix {
+				break
+			}
+			v.validate((*s.PrefixItems)[i], item, instanceLocation+"/"+strconv.Itoa(i), schemaLocation+"/prefixItems/"+strconv.Itoa(i))
+		}
+	}
+	if s.Items != nil {
+		if s.Items.Schema != nil {
+			for i := prefix; i < len(items); i++ {
+				v.validate(s.Items.Schema, items[i], instanceLocation+"/"+strconv.Itoa(i), schemaLocation+"/items")
+			}
+		} else if s.Items.SchemaArray != nil {
+			schemas := *s.Items.SchemaArray
+			for i, item := range items {
+				itemLocation := instanceLocation + "/" + strconv.Itoa(i)
+				if i < len(schemas) {
+					v.validate(schemas[i], item, itemLocation, schemaLocation+"/items/"+strconv.Itoa(i))
+				} else if s.AdditionalItems != nil {
+					if s.AdditionalItems.Boolean != nil && !*s.AdditionalItems.Boolean {
+						v.fail(itemLocation, schemaLocation+"/additionalItems", "additional items are not allowed")
+					} else {
+						v.validate(s.AdditionalItems.Schema, item, itemLocation, schemaLocation+"/additionalItems")
+					}
+				}
+			}
+		}
+	}
+	if s.MaxItems != nil && int64(len(items)) > *s.MaxItems {
+		v.fail(instanceLocation, schemaLocation+"/maxItems", "array has more than %d items", *s.MaxItems)
+	}
+	if s.MinItems != nil && int64(len(items)) < *s.MinItems {
+		v.fail(instanceLocation, schemaLocation+"/minItems", "array has fewer than %d items", *s.MinItems)
+	}
+	if s.UniqueItems != nil && *s.UniqueItems {
+	unique:
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if equal(items[i], items[j]) {
+					v.fail(instanceLocation, schemaLocation+"/uniqueItems", "items %d and %d are equal", i, j)
+					break unique
+				}
+			}
+		}
+	}
+	if s.Contains != nil {
+		count := int64(0)
+		for i, item := range items {
+			if v.valid(s.Contains, item, instanceLocation+"/"+strconv.Itoa(i), schemaLocation+"/contains") {
+				count++
+			}
+		}
+		if s.MinContains == nil && count == 0 {
+			v.fail(instanceLocation, schemaLocation+"/contains", "array doesn't contain a matching item")
+		}
+		if s.MinContains != nil && count < *s.MinContains {
+			v.fail(instanceLocation, schemaLocation+"/minContains", "array contains fewer than %d matching items", *s.MinContains)
+		}
+		if s.MaxContains != nil && count > *s.MaxContains {
+			v.fail(instanceLocation, schemaLocation+"/maxContains", "array contains more than %d matching items", *s.MaxContains)
+		}
+	}
+}
+
+func (v *validator) validateObject(s *Schema, instance *yaml.Node, instanceLocation, schemaLocation string) {
+	count := int64(len(instance.Content) / 2)
+	if s.MaxProperties != nil && count > *s.MaxProperties {
+		v.fail(instanceLocation, schemaLocation+"/maxProperties", "object has more than %d properties", *s.MaxProperties)
+	}
+	if s.MinProperties != nil && count < *s.MinProperties {
+		v.fail(instanceLocation, schemaLocation+"/minProperties", "object has fewer than %d properties", *s.MinProperties)
+	}
+	if s.Required != nil {
+		for _, name := range *s.Required {
+			if propertyNode(instance, name) == nil {
+				v.fail(instanceLocation, schemaLocation+"/required", "missing required property %q", name)
+			}
+		}
+	}
+	for i := 0; i+1 < len(instance.Content); i += 2 {
+		name := instance.Content[i].Value
+		value := instance.Content[i+1]
+		propertyLocation := instanceLocation + "/" + escapePointer(name)
+		matched := false
+		if s.Properties != nil {
+			for _, pair := range *s.Properties {
+				if pair.Name == name {
+					matched = true
+					v.validate(pair.Value, value, propertyLocation, schemaLocation+"/properties/"+escapePointer(name))
+				}
+			}
+		}
+		if s.PatternProperties != nil {
+			for _, pair := range *s.PatternProperties {
+				if r := v.pattern(pair.Name); r != nil && r.MatchString(name) {
+					matched = true
+					v.validate(pair.Value, value, propertyLocation, schemaLocation+"/patternProperties/"+escapePointer(pair.Name))
+				}
+			}
+		}
+		if !matched && s.AdditionalProperties != nil {
+			if s.AdditionalProperties.Boolean != nil && !*s.AdditionalProperties.Boolean {
+				v.fail(propertyLocation, schemaLocation+"/additionalProperties", "additional property %q is not allowed", name)
+			} else {
+				v.validate(s.AdditionalProperties.Schema, value, propertyLocation, schemaLocation+"/additionalProperties")
+			}
+		}
+		if s.PropertyNames != nil {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+			v.validate(s.PropertyNames, key, propertyLocation, schemaLocation+"/propertyNames")
+		}
+	}
+	if s.Dependencies != nil {
+		for _, pair := range *s.Dependencies {
+			if propertyNode(instance, pair.Name) == nil {
+				continue
+			}
+			location := schemaLocation + "/dependencies/" + escapePointer(pair.Name)
+			if pair.Value.StringArray != nil {
+				for _, name := range *pair.Value.StringArray {
+					if propertyNode(instance, name) == nil {
+						v.fail(instanceLocation, location, "property %q requires property %q", pair.Name, name)
+					}
+				}
+			} else {
+				v.validate(pair.Value.Schema, instance, instanceLocation, location)
+			}
+		}
+	}
+	if s.DependentRequired != nil {
+		for _, pair := range *s.DependentRequired {
+			if propertyNode(instance, pair.Name) == nil {
+				continue
+			}
+			for _, name := range pair.Value {
+				if propertyNode(instance, name) == nil {
+					v.fail(instanceLocation, schemaLocation+"/dependentRequired/"+escapePointer(pair.Name), "property %q requires property %q", pair.Name, name)
+				}
+			}
+		}
+	}
+	if s.DependentSchemas != nil {
+		for _, pair := range *s.DependentSchemas {
+			if propertyNode(instance, pair.Name) != nil {
+				v.validate(pair.Value, instance, instanceLocation, schemaLocation+"/dependentSchemas/"+escapePointer(pair.Name))
+			}
+		}
+	}
+}
+
+// pattern returns a compiled regular expression. Patterns that can't be compiled are ignored.
+func (v *validator) pattern(pattern string) *regexp.Regexp {
+	r, ok := v.patterns[pattern]
+	if !ok {
+		r, _ = regexp.Compile(pattern)
+		v.patterns[pattern] = r
+	}
+	return r
+}
+
+// resolveRef finds the schema that a reference refers to. References can point into
+// the schema, or into schemas that have been read with ids.
+func (schema *Schema) resolveRef(ref string) (*Schema, error) {
+	document := schema
+	parts := strings.SplitN(ref, "#", 2)
+	if parts[0] != "" && (schema.ID == nil || strings.TrimSuffix(*schema.ID, "#") != parts[0]) {
+		document = schemas[parts[0]]
+		if document == nil {
+			document = schemas[parts[0]+"#"]
+		}
+		if document == nil {
+			return nil, fmt.Errorf("unresolved reference: %s", ref)
+		}
+	}
+	if len(parts) == 1 || parts[1] == "" {
+		return document, nil
+	}
+	fragment, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference: %s", ref)
+	}
+	var result *Schema
+	if strings.HasPrefix(fragment, "/") {
+		result = document.schemaAtPointer(strings.Split(fragment[1:], "/"))
+	} else {
+		// A plain name fragment refers to a subschema with a matching id or anchor.
+		document.applyToSchemas(func(s *Schema, context string) {
+			if (s.ID != nil && *s.ID == "#"+fragment) ||
+				(s.Anchor != nil && *s.Anchor == fragment) ||
+				(s.DynamicAnchor != nil && *s.DynamicAnchor == fragment) {
+				result = s
+			}
+		}, "")
+	}
+	if result == nil {
+		return nil, fmt.Errorf("unresolved reference: %s", ref)
+	}
+	return result, nil
+}
+
+// schemaAtPointer follows the segments of a JSON pointer through the keywords of a schema.
+func (schema *Schema) schemaAtPointer(segments []string) *Schema {
+	if schema == nil || len(segments) == 0 {
+		return schema
+	}
+	segment := unescapePointer(segments[0])
+	rest := segments[1:]
+	named := func(array *[]*NamedSchema) *Schema {
+		if len(rest) == 0 {
+			return nil
+		}
+		return namedSchemaArrayElementWithName(array, unescapePointer(rest[0])).schemaAtPointer(rest[1:])
+	}
+	indexed := func(array *[]*Schema) *Schema {
+		if array == nil || len(rest) == 0 {
+			return nil
+		}
+		i, err := strconv.Atoi(rest[0])
+		if err != nil || i < 0 || i >= len(*array) {
+			return nil
+		}
+		return (*array)[i].schemaAtPointer(rest[1:])
+	}
+	switch segment {
+	case "definitions":
+		return named(schema.Definitions)
+	case "$defs":
+		return named(schema.Defs)
+	case "dependentSchemas":
+		return named(schema.DependentSchemas)
+	case "prefixItems":
+		return indexed(schema.PrefixItems)
+	case "unevaluatedItems":
+		return schema.UnevaluatedItems.schemaAtPointer(rest)
+	case "unevaluatedProperties":
+		return schema.UnevaluatedProperties.schemaAtPointer(rest)
+	case "properties":
+		return named(schema.Properties)
+	case "patternProperties":
+		return named(schema.PatternProperties)
+	case "dependencies":
+		if schema.Dependencies == nil || len(rest) == 0 {
+			return nil
+		}
+		for _, pair := range *schema.Dependencies {
+			if pair.Name == unescapePointer(rest[0]) {
+				return pair.Value.Schema.schemaAtPointer(rest[1:])
+			}
+		}
+	case "items":
+		if schema.Items == nil {
+			return nil
+		}
+		if schema.Items.SchemaArray != nil {
+			return indexed(schema.Items.SchemaArray)
+		}
+		return schema.Items.Schema.schemaAtPointer(rest)
+	case "additionalItems":
+		if schema.AdditionalItems != nil {
+			return schema.AdditionalItems.Schema.schemaAtPointer(rest)
+		}
+	case "additionalProperties":
+		if schema.AdditionalProperties != nil {
+			return schema.AdditionalProperties.Schema.schemaAtPointer(rest)
+		}
+	case "allOf":
+		return indexed(schema.AllOf)
+	case "anyOf":
+		return indexed(schema.AnyOf)
+	case "oneOf":
+		return indexed(schema.OneOf)
+	case "not":
+		return schema.Not.schemaAtPointer(rest)
+	case "contains":
+		return schema.Contains.schemaAtPointer(rest)
+	case "propertyNames":
+		return schema.PropertyNames.schemaAtPointer(rest)
+	case "if":
+		return schema.If.schemaAtPointer(rest)
+	case "then":
+		return schema.Then.schemaAtPointer(rest)
+	case "else":
+		return schema.Else.schemaAtPointer(rest)
+	}
+	return nil
+}
+
+func escapePointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
+
+func unescapePointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~1", "/", -1), "~0", "~", -1)
+}
+
+// allowsType returns true if the schema's type keyword allows the type of an instance.
+func (schema *Schema) allowsType(instance *yaml.Node) bool {
+	t := typeOf(instance)
+	return schema.TypeIs(t) || (t == "integer" && schema.TypeIs("number"))
+}
+
+// typeOf returns the JSON type of a value. Numbers without fractional parts are integers.
+func typeOf(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	case yaml.AliasNode:
+		return typeOf(node.Alias)
+	}
+	switch node.ShortTag() {
+	case "!!null":
+		return "null"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		if x := numberForNode(node); x != nil && x.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	return "string"
+}
+
+// numberForNode returns the exact value of a number, or nil if it has none (e.g. NaN).
+func numberForNode(node *yaml.Node) *big.Rat {
+	if x, ok := new(big.Rat).SetString(node.Value); ok {
+		return x
+	}
+	if i, err := strconv.ParseInt(node.Value, 0, 64); err == nil {
+		return new(big.Rat).SetInt64(i)
+	}
+	return nil
+}
+
+func numberForSchemaNumber(n *SchemaNumber) *big.Rat {
+	if n.Integer != nil {
+		return new(big.Rat).SetInt64(*n.Integer)
+	}
+	if n.Float != nil {
+		// Use the shortest decimal form, so that 0.1 is one tenth and not its binary approximation.
+		x, _ := new(big.Rat).SetString(strconv.FormatFloat(*n.Float, 'g', -1, 64))
+		return x
+	}
+	return nil
+}
+
+func ratString(x *big.Rat) string {
+	if x.IsInt() {
+		return x.Num().String()
+	}
+	f, _ := x.Float64()
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func propertyNode(object *yaml.Node, name string) *yaml.Node {
+	for i := 0; i+1 < len(object.Content); i += 2 {
+		if object.Content[i].Value == name {
+			return object.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// equal compares JSON values. Numbers are equal if their values are equal, so 1 and 1.0 are equal.
+func equal(a, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Kind == yaml.AliasNode {
+		return equal(a.Alias, b)
+	}
+	if b.Kind == yaml.AliasNode {
+		return equal(a, b.Alias)
+	}
+	ta, tb := typeOf(a), typeOf(b)
+	isNumber := func(t string) bool { return t == "integer" || t == "number" }
+	if isNumber(ta) && isNumber(tb) {
+		x, y := numberForNode(a), numberForNode(b)
+		return x != nil && y != nil && x.Cmp(y) == 0
+	}
+	if ta != tb {
+		return false
+	}
+	switch ta {
+	case "array":
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := range a.Content {
+			if !equal(a.Content[i], b.Content[i]) {
+				return false
+			}
+		}
+		return true
+	case "object":
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			if !equal(a.Content[i+1], propertyNode(b, a.Content[i].Value)) {
+				return false
+			}
+		}
+		return true
+	case "boolean":
+		x, _ := strconv.ParseBool(a.Value)
+		y, _ := strconv.ParseBool(b.Value)
+		return x == y
+	}
+	return a.Value == b.Value
+}
+
+var hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// validFormat checks some of the formats defined by JSON Schema. Unknown formats are allowed.
+func validFormat(format string, x string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, x)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", x)
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(x)
+		return err == nil && address.Address == x
+	case "hostname":
+		return len(x) <= 253 && hostnamePattern.MatchString(x)
+	case "ipv4":
+		ip := net.ParseIP(x)
+		return ip != nil && ip.To4() != nil && !strings.Contains(x, ":")
+	case "ipv6":
+		ip := net.ParseIP(x)
+		return ip != nil && strings.Contains(x, ":")
+	case "uri":
+		u, err := url.Parse(x)
+		return err == nil && u.IsAbs()
+	case "regex":
+		_, err := regexp.Compile(x)
+		return err == nil
+	}
+	return true
+}
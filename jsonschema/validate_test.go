@@ -0,0 +1,151 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const suite = "testdata/JSON-Schema-Test-Suite"
+
+// remoteLoader serves the suite's remote documents.
+func remoteLoader(uri string) ([]byte, error) {
+	if !strings.HasPrefix(uri, "http://localhost:1234/") {
+		return nil, fmt.Errorf("unexpected URI %s", uri)
+	}
+	return ioutil.ReadFile(filepath.Join(suite, "remotes", strings.TrimPrefix(uri, "http://localhost:1234/")))
+}
+
+func readNode(t *testing.T, text string) *yaml.Node {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &node); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	return &node
+}
+
+// TestSuite runs the test cases of the JSON Schema Test Suite.
+// Each schema is validated with a Registry so that remote references can be resolved.
+func TestSuite(t *testing.T) {
+	for _, draft := range []string{"draft4", "draft6", "draft7"} {
+		filenames, err := filepath.Glob(filepath.Join(suite, "tests", draft, "*.json"))
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		if len(filenames) == 0 {
+			t.Fatalf("no test cases found for %s", draft)
+		}
+		for _, filename := range filenames {
+			bytes, err := ioutil.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("%s", err.Error())
+			}
+			groups := readNode(t, string(bytes)).Content[0]
+			for _, group := range groups.Content {
+				description := propertyNode(group, "description").Value
+				schema := NewSchemaFromObject(propertyNode(group, "schema"))
+				registry := NewRegistry(remoteLoader)
+				for _, test := range propertyNode(group, "tests").Content {
+					name := filename + ": " + description + ": " + propertyNode(test, "description").Value
+					valid, _ := strconv.ParseBool(propertyNode(test, "valid").Value)
+					err := registry.Validate(schema, propertyNode(test, "data"))
+					if valid && err != nil {
+						t.Errorf("%s: unexpected errors\n%s", name, err.Error())
+					} else if !valid && err == nil {
+						t.Errorf("%s: expected errors", name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	schema := NewSchemaFromObject(readNode(t, `{
+  "properties": {
+    "items": {"type": "array", "items": {"$ref": "#/definitions/item"}},
+    "a/b": {"type": "string"}
+  },
+  "additionalProperties": false,
+  "definitions": {
+    "item": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer", "minimum": 1}}}
+  }
+}`))
+	err := schema.Validate(readNode(t, `{"items": [{"id": 1}, {"id": 0}, {}], "a/b": 3, "c": true}`))
+	expected := ValidationErrors{
+		{InstanceLocation: "/items/1/id", SchemaLocation: "/properties/items/items/$ref/properties/id/minimum", Message: "0 is less than minimum 1"},
+		{InstanceLocation: "/items/2", SchemaLocation: "/properties/items/items/$ref/required", Message: `missing required property "id"`},
+		{InstanceLocation: "/a~1b", SchemaLocation: "/properties/a~1b/type", Message: "expected string but found integer"},
+		{InstanceLocation: "/c", SchemaLocation: "/additionalProperties", Message: `additional property "c" is not allowed`},
+	}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("expected\n%s\nfound\n%v", expected.Error(), err)
+	}
+	if message := expected[0].Error(); message != "#/items/1/id: 0 is less than minimum 1 (#/properties/items/items/$ref/properties/id/minimum)" {
+		t.Errorf("unexpected message %s", message)
+	}
+}
+
+func TestReferenceErrors(t *testing.T) {
+	for _, test := range []struct {
+		schema   string
+		expected string
+	}{
+		{`{"$ref": "#/definitions/missing"}`, "#: unresolved reference: #/definitions/missing (#/$ref)"},
+		{`{"definitions": {"a": {"$ref": "#/definitions/b"}, "b": {"$ref": "#/definitions/a"}}, "$ref": "#/definitions/a"}`,
+			"#: reference cycle at #/definitions/a (#/$ref/$ref/$ref)"},
+	} {
+		err := NewSchemaFromObject(readNode(t, test.schema)).Validate(readNode(t, `{}`))
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("expected %s, found %v", test.expected, err)
+		}
+	}
+}
+
+func TestValidateDraft2020Keywords(t *testing.T) {
+	for _, test := range []struct {
+		schema   string
+		instance string
+		expected string
+	}{
+		{`{"prefixItems": [{"type": "number"}], "items": false}`, `[1]`, ""},
+		{`{"prefixItems": [{"type": "number"}], "items": false}`, `[1, 2]`, "#/1: no values are allowed (#/items)"},
+		{`{"prefixItems": [{"type": "number"}, {"type": "string"}]}`, `[1, 2]`, "#/1: expected string but found integer (#/prefixItems/1/type)"},
+		{`{"contains": {"type": "string"}, "minContains": 2, "maxContains": 3}`, `["a", "b", 1]`, ""},
+		{`{"contains": {"type": "string"}, "minContains": 2}`, `["a", 1]`, "#: array contains fewer than 2 matching items (#/minContains)"},
+		{`{"contains": {"type": "string"}, "maxContains": 1}`, `["a", "b"]`, "#: array contains more than 1 matching items (#/maxContains)"},
+		{`{"contains": {"type": "string"}, "minContains": 0}`, `[1]`, ""},
+		{`{"dependentRequired": {"a": ["b"]}}`, `{"b": 1}`, ""},
+		{`{"dependentRequired": {"a": ["b"]}}`, `{"a": 1}`, `#: property "a" requires property "b" (#/dependentRequired/a)`},
+		{`{"dependentSchemas": {"a": {"required": ["b"]}}}`, `{"a": 1}`, `#: missing required property "b" (#/dependentSchemas/a/required)`},
+		{`{"unevaluatedProperties": false}`, `{"a": 1}`, "#: unsupported keyword unevaluatedProperties (#/unevaluatedProperties)"},
+		{`{"items": {"unevaluatedItems": false}}`, `[[1]]`, "#/0: unsupported keyword unevaluatedItems (#/items/unevaluatedItems)"},
+	} {
+		err := NewSchemaFromObject(readNode(t, test.schema)).Validate(readNode(t, test.instance))
+		if test.expected == "" && err != nil {
+			t.Errorf("%s: unexpected errors for %s\n%s", test.schema, test.instance, err.Error())
+		} else if test.expected != "" && (err == nil || err.Error() != test.expected) {
+			t.Errorf("%s: expected %s for %s, found %v", test.schema, test.expected, test.instance, err)
+		}
+	}
+}
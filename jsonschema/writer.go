@@ -182,7 +182,7 @@ func (object *SchemaEnumValue) nodeValue() *yaml.Node {
 	} else if object.Bool != nil {
 		return nodeForBoolean(*object.Bool)
 	} else {
-		return nil
+		return object.Node
 	}
 }
 
@@ -202,6 +202,22 @@ func nodeForNamedSchemaOrStringArray(array *[]*NamedSchemaOrStringArray) *yaml.N
 	return nodeForMapping(content)
 }
 
+func nodeForNamedStringArray(array *[]*NamedStringArray) *yaml.Node {
+	content := make([]*yaml.Node, 0)
+	for _, pair := range *(array) {
+		content = appendPair(content, pair.Name, nodeForStringArray(pair.Value))
+	}
+	return nodeForMapping(content)
+}
+
+func nodeForNamedBooleanArray(array *[]*NamedBoolean) *yaml.Node {
+	content := make([]*yaml.Node, 0)
+	for _, pair := range *(array) {
+		content = appendPair(content, pair.Name, nodeForBoolean(pair.Value))
+	}
+	return nodeForMapping(content)
+}
+
 func nodeForSchemaEnumArray(array *[]SchemaEnumValue) *yaml.Node {
 	content := make([]*yaml.Node, 0)
 	for _, item := range *array {
@@ -263,13 +279,20 @@ func appendPair(nodes []*yaml.Node, name string, value *yaml.Node) []*yaml.Node
 }
 
 func (schema *Schema) nodeValue() *yaml.Node {
+	if schema.Boolean != nil {
+		return nodeForBoolean(*schema.Boolean)
+	}
 	n := &yaml.Node{Kind: yaml.MappingNode}
 	content := make([]*yaml.Node, 0)
 	if schema.Title != nil {
 		content = appendPair(content, "title", nodeForString(*schema.Title))
 	}
 	if schema.ID != nil {
-		switch strings.TrimSuffix(*schema.Schema, "#") {
+		version := ""
+		if schema.Schema != nil {
+			version = strings.TrimSuffix(*schema.Schema, "#")
+		}
+		switch version {
 		case "http://json-schema.org/draft-04/schema":
 			fallthrough
 		case "#":
@@ -283,6 +306,21 @@ func (schema *Schema) nodeValue() *yaml.Node {
 	if schema.Schema != nil {
 		content = appendPair(content, "$schema", nodeForString(*schema.Schema))
 	}
+	if schema.Vocabulary != nil {
+		content = appendPair(content, "$vocabulary", nodeForNamedBooleanArray(schema.Vocabulary))
+	}
+	if schema.Anchor != nil {
+		content = appendPair(content, "$anchor", nodeForString(*schema.Anchor))
+	}
+	if schema.DynamicAnchor != nil {
+		content = appendPair(content, "$dynamicAnchor", nodeForString(*schema.DynamicAnchor))
+	}
+	if schema.RecursiveAnchor != nil {
+		content = appendPair(content, "$recursiveAnchor", nodeForBoolean(*schema.RecursiveAnchor))
+	}
+	if schema.Comment != nil {
+		content = appendPair(content, "$comment", nodeForString(*schema.Comment))
+	}
 	if schema.ReadOnly != nil && *schema.ReadOnly {
 		content = appendPair(content, "readOnly", nodeForBoolean(*schema.ReadOnly))
 	}
@@ -292,6 +330,9 @@ func (schema *Schema) nodeValue() *yaml.Node {
 	if schema.Type != nil {
 		content = appendPair(content, "type", schema.Type.nodeValue())
 	}
+	if schema.PrefixItems != nil {
+		content = appendPair(content, "prefixItems", nodeForSchemaArray(*schema.PrefixItems))
+	}
 	if schema.Items != nil {
 		content = appendPair(content, "items", schema.Items.nodeValue())
 	}
@@ -313,9 +354,27 @@ func (schema *Schema) nodeValue() *yaml.Node {
 	if schema.Dependencies != nil {
 		content = appendPair(content, "dependencies", nodeForNamedSchemaOrStringArray(schema.Dependencies))
 	}
+	if schema.PropertyNames != nil {
+		content = appendPair(content, "propertyNames", schema.PropertyNames.nodeValue())
+	}
+	if schema.DependentSchemas != nil {
+		content = appendPair(content, "dependentSchemas", nodeForNamedSchemaArray(schema.DependentSchemas))
+	}
+	if schema.DependentRequired != nil {
+		content = appendPair(content, "dependentRequired", nodeForNamedStringArray(schema.DependentRequired))
+	}
+	if schema.UnevaluatedProperties != nil {
+		content = appendPair(content, "unevaluatedProperties", schema.UnevaluatedProperties.nodeValue())
+	}
 	if schema.Ref != nil {
 		content = appendPair(content, "$ref", nodeForString(*schema.Ref))
 	}
+	if schema.DynamicRef != nil {
+		content = appendPair(content, "$dynamicRef", nodeForString(*schema.DynamicRef))
+	}
+	if schema.RecursiveRef != nil {
+		content = appendPair(content, "$recursiveRef", nodeForString(*schema.RecursiveRef))
+	}
 	if schema.MultipleOf != nil {
 		content = appendPair(content, "multipleOf", schema.MultipleOf.nodeValue())
 	}
@@ -325,12 +384,18 @@ func (schema *Schema) nodeValue() *yaml.Node {
 	if schema.ExclusiveMaximum != nil {
 		content = appendPair(content, "exclusiveMaximum", nodeForBoolean(*schema.ExclusiveMaximum))
 	}
+	if schema.ExclusiveMaximumNumber != nil {
+		content = appendPair(content, "exclusiveMaximum", schema.ExclusiveMaximumNumber.nodeValue())
+	}
 	if schema.Minimum != nil {
 		content = appendPair(content, "minimum", schema.Minimum.nodeValue())
 	}
 	if schema.ExclusiveMinimum != nil {
 		content = appendPair(content, "exclusiveMinimum", nodeForBoolean(*schema.ExclusiveMinimum))
 	}
+	if schema.ExclusiveMinimumNumber != nil {
+		content = appendPair(content, "exclusiveMinimum", schema.ExclusiveMinimumNumber.nodeValue())
+	}
 	if schema.MaxLength != nil {
 		content = appendPair(content, "maxLength", nodeForInt64(*schema.MaxLength))
 	}
@@ -352,6 +417,18 @@ func (schema *Schema) nodeValue() *yaml.Node {
 	if schema.UniqueItems != nil {
 		content = appendPair(content, "uniqueItems", nodeForBoolean(*schema.UniqueItems))
 	}
+	if schema.Contains != nil {
+		content = appendPair(content, "contains", schema.Contains.nodeValue())
+	}
+	if schema.MaxContains != nil {
+		content = appendPair(content, "maxContains", nodeForInt64(*schema.MaxContains))
+	}
+	if schema.MinContains != nil {
+		content = appendPair(content, "minContains", nodeForInt64(*schema.MinContains))
+	}
+	if schema.UnevaluatedItems != nil {
+		content = appendPair(content, "unevaluatedItems", schema.UnevaluatedItems.nodeValue())
+	}
 	if schema.MaxProperties != nil {
 		content = appendPair(content, "maxProperties", nodeForInt64(*schema.MaxProperties))
 	}
@@ -361,6 +438,9 @@ func (schema *Schema) nodeValue() *yaml.Node {
 	if schema.Enumeration != nil {
 		content = appendPair(content, "enum", nodeForSchemaEnumArray(schema.Enumeration))
 	}
+	if schema.Const != nil {
+		content = appendPair(content, "const", schema.Const)
+	}
 	if schema.AllOf != nil {
 		content = appendPair(content, "allOf", nodeForSchemaArray(*schema.AllOf))
 	}
@@ -373,9 +453,21 @@ func (schema *Schema) nodeValue() *yaml.Node {
 	if schema.Not != nil {
 		content = appendPair(content, "not", schema.Not.nodeValue())
 	}
+	if schema.If != nil {
+		content = appendPair(content, "if", schema.If.nodeValue())
+	}
+	if schema.Then != nil {
+		content = appendPair(content, "then", schema.Then.nodeValue())
+	}
+	if schema.Else != nil {
+		content = appendPair(content, "else", schema.Else.nodeValue())
+	}
 	if schema.Definitions != nil {
 		content = appendPair(content, "definitions", nodeForNamedSchemaArray(schema.Definitions))
 	}
+	if schema.Defs != nil {
+		content = appendPair(content, "$defs", nodeForNamedSchemaArray(schema.Defs))
+	}
 	if schema.Default != nil {
 		// m = append(m, yaml.MapItem{Key: "default", Value: *schema.Default})
 	}
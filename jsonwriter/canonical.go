@@ -0,0 +1,200 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonwriter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalCanonical writes a yaml.Node as JSON in the form defined by the JSON
+// Canonicalization Scheme (RFC 8785). The output has no insignificant whitespace,
+// the keys of maps are sorted by their UTF-16 code units, and numbers are written
+// as ECMAScript writes IEEE 754 doubles, so equal documents have identical bytes.
+// It is an error for a map to have duplicate keys or for a number to be infinite
+// or NaN.
+func MarshalCanonical(in *yaml.Node) ([]byte, error) {
+	var w canonicalWriter
+	if err := w.writeValue(in); err != nil {
+		return nil, err
+	}
+	return w.b.Bytes(), nil
+}
+
+// Digest returns the SHA-256 digest of canonical JSON in the form "sha256:HEX".
+func Digest(canonical []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(canonical))
+}
+
+type canonicalWriter struct {
+	b bytes.Buffer
+}
+
+func (w *canonicalWriter) writeValue(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return errors.New("empty document")
+		}
+		return w.writeValue(node.Content[0])
+	case yaml.AliasNode:
+		if node.Alias == nil {
+			return errors.New("invalid alias node")
+		}
+		return w.writeValue(node.Alias)
+	case yaml.MappingNode:
+		return w.writeMap(node)
+	case yaml.SequenceNode:
+		w.b.WriteString("[")
+		for i, value := range node.Content {
+			if i > 0 {
+				w.b.WriteString(",")
+			}
+			if err := w.writeValue(value); err != nil {
+				return err
+			}
+		}
+		w.b.WriteString("]")
+		return nil
+	case yaml.ScalarNode:
+		return w.writeScalar(node)
+	}
+	return fmt.Errorf("invalid node: %+v", node)
+}
+
+func (w *canonicalWriter) writeMap(node *yaml.Node) error {
+	type pair struct {
+		key   []uint16
+		index int
+	}
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{key: utf16.Encode([]rune(node.Content[i].Value)), index: i})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return lessUTF16(pairs[i].key, pairs[j].key)
+	})
+	w.b.WriteString("{")
+	for n, p := range pairs {
+		key := node.Content[p.index].Value
+		if n > 0 {
+			if key == node.Content[pairs[n-1].index].Value {
+				return fmt.Errorf("duplicate key %q", key)
+			}
+			w.b.WriteString(",")
+		}
+		w.writeQuoted(key)
+		w.b.WriteString(":")
+		if err := w.writeValue(node.Content[p.index+1]); err != nil {
+			return err
+		}
+	}
+	w.b.WriteString("}")
+	return nil
+}
+
+func lessUTF16(a, b []uint16) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func (w *canonicalWriter) writeScalar(node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!null":
+		w.b.WriteString(null)
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return err
+		}
+		w.b.WriteString(strconv.FormatBool(b))
+	case "!!int", "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return err
+		}
+		s, err := formatNumber(f)
+		if err != nil {
+			return err
+		}
+		w.b.WriteString(s)
+	default:
+		w.writeQuoted(node.Value)
+	}
+	return nil
+}
+
+// formatNumber formats a number as ECMAScript's Number.prototype.toString does.
+func formatNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("invalid number %v", f)
+	}
+	if f == 0 {
+		// Negative zero is written as 0.
+		return "0", nil
+	}
+	abs := math.Abs(f)
+	if abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	// ECMAScript doesn't pad exponents with zeros.
+	if n := len(s); s[n-4] == 'e' && s[n-2] == '0' {
+		s = s[:n-2] + s[n-1:]
+	}
+	return s, nil
+}
+
+// writeQuoted writes a string with the minimal escaping that RFC 8785 requires.
+func (w *canonicalWriter) writeQuoted(s string) {
+	w.b.WriteString(`"`)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == '"' || r == '\\':
+			w.b.WriteByte('\\')
+			w.b.WriteRune(r)
+		case r == '\b':
+			w.b.WriteString(`\b`)
+		case r == '\t':
+			w.b.WriteString(`\t`)
+		case r == '\n':
+			w.b.WriteString(`\n`)
+		case r == '\f':
+			w.b.WriteString(`\f`)
+		case r == '\r':
+			w.b.WriteString(`\r`)
+		case r < 0x20:
+			fmt.Fprintf(&w.b, `\u%04x`, r)
+		default:
+			w.b.WriteRune(r)
+		}
+	}
+	w.b.WriteString(`"`)
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonwriter_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/gnostic/compiler"
+	"github.com/google/gnostic/jsonwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshalCanonical(t *testing.T) {
+	t.Parallel()
+	// This is the example in section 3.2.2 of RFC 8785.
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"/",
+  "literals": [null, true, false]
+}`), &node)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	b, err := jsonwriter.MarshalCanonical(&node)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	if string(b) != expected {
+		t.Errorf("expected %s to equal %s", string(b), expected)
+	}
+	if digest := jsonwriter.Digest(b); len(digest) != len("sha256:")+64 {
+		t.Errorf("unexpected digest %s", digest)
+	}
+}
+
+func TestCanonicalKeyOrder(t *testing.T) {
+	t.Parallel()
+	// Keys are sorted by UTF-16 code units, as in section 3.2.3 of RFC 8785.
+	node := compiler.NewMappingNode()
+	for _, key := range []string{"\u20ac", "\r", "\ufb33", "1", "\U0001F600", "\u0080", "\u00f6"} {
+		node.Content = append(node.Content, compiler.NewScalarNodeForString(key), compiler.NewScalarNodeForString(""))
+	}
+	b, err := jsonwriter.MarshalCanonical(node)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := "{\"\\r\":\"\",\"1\":\"\",\"\u0080\":\"\",\"\u00f6\":\"\",\"\u20ac\":\"\",\"\U0001F600\":\"\",\"\ufb33\":\"\"}"
+	if string(b) != expected {
+		t.Errorf("expected %s to equal %s", string(b), expected)
+	}
+}
+
+func TestCanonicalNumbers(t *testing.T) {
+	t.Parallel()
+	// These are based on the examples in appendix B of RFC 8785.
+	for bits, expected := range map[uint64]string{
+		0x0000000000000000: "0",
+		0x8000000000000000: "0",
+		0x0000000000000001: "5e-324",
+		0x8000000000000001: "-5e-324",
+		0x7fefffffffffffff: "1.7976931348623157e+308",
+		0x4340000000000000: "9007199254740992",
+		0xc340000000000000: "-9007199254740992",
+		0x4430000000000000: "295147905179352830000",
+		0x44b52d02c7e14af5: "9.999999999999997e+22",
+		0x44b52d02c7e14af6: "1e+23",
+		0x44b52d02c7e14af7: "1.0000000000000001e+23",
+		0x444b1ae4d6e2ef4e: "999999999999999700000",
+		0x444b1ae4d6e2ef4f: "999999999999999900000",
+		0x444b1ae4d6e2ef50: "1e+21",
+		0x3eb0c6f7a0b5ed8c: "9.999999999999997e-7",
+		0x3eb0c6f7a0b5ed8d: "0.000001",
+		0x41b3de4355555553: "333333333.3333332",
+		0x41b3de4355555556: "333333333.3333334",
+	} {
+		b, err := jsonwriter.MarshalCanonical(compiler.NewScalarNodeForFloat(math.Float64frombits(bits)))
+		if err != nil {
+			t.Errorf("%x: %v", bits, err)
+		} else if string(b) != expected {
+			t.Errorf("%x: expected %s to equal %s", bits, string(b), expected)
+		}
+	}
+}
+
+func TestCanonicalErrors(t *testing.T) {
+	t.Parallel()
+	duplicates := compiler.NewMappingNode()
+	duplicates.Content = append(duplicates.Content,
+		compiler.NewScalarNodeForString("a"), compiler.NewScalarNodeForInt(1),
+		compiler.NewScalarNodeForString("a"), compiler.NewScalarNodeForInt(2))
+	for _, node := range []*yaml.Node{
+		duplicates,
+		{Kind: yaml.ScalarNode, Tag: "!!float", Value: ".inf"},
+		{Kind: yaml.ScalarNode, Tag: "!!float", Value: ".nan"},
+	} {
+		if _, err := jsonwriter.MarshalCanonical(node); err == nil {
+			t.Errorf("expected error for %+v", node)
+		}
+	}
+}
@@ -15,130 +15,247 @@
 package jsonwriter
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
-	"strconv"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
 
 const (
-	indentation = "  "
-	null        = "null"
+	defaultIndent = 2
+	null          = "null"
 )
 
-type writer struct {
-	b bytes.Buffer
+// Options control the formatting of JSON output.
+type Options struct {
+	// Indent is the number of spaces used for each level of nesting.
+	// If it is zero, two spaces are used.
+	Indent int
+	// Compact writes JSON without any insignificant whitespace.
+	Compact bool
+	// SortKeys writes the keys of maps in sorted order instead of document order.
+	SortKeys bool
+	// EscapeHTML escapes <, >, and & in strings so that JSON can be safely embedded in HTML.
+	EscapeHTML bool
 }
 
-func (w *writer) bytes() []byte {
-	return w.b.Bytes()
+// An Encoder writes yaml.Nodes as JSON to an output stream.
+type Encoder struct {
+	w       *bufio.Writer
+	options Options
+	indent  string
+	err     error
 }
 
-func (w *writer) writeString(s string) {
-	w.b.Write([]byte(s))
+// NewEncoder returns an encoder that writes to w. If options is nil,
+// output is indented with two spaces.
+func NewEncoder(w io.Writer, options *Options) *Encoder {
+	e := &Encoder{w: bufio.NewWriter(w)}
+	if options != nil {
+		e.options = *options
+	}
+	if !e.options.Compact {
+		indent := e.options.Indent
+		if indent <= 0 {
+			indent = defaultIndent
+		}
+		e.indent = strings.Repeat(" ", indent)
+	}
+	return e
 }
 
-func (w *writer) writeMap(node *yaml.Node, indent string) {
-	if node.Kind == yaml.DocumentNode {
-		w.writeMap(node.Content[0], indent)
-		return
-	}
-	if node.Kind != yaml.MappingNode {
-		w.writeString(fmt.Sprintf("invalid node for map: %+v", node))
-		return
-	}
-	w.writeString("{\n")
-	innerIndent := indent + indentation
-	for i := 0; i < len(node.Content); i += 2 {
-		// first print the key
-		key := node.Content[i].Value
-		w.writeString(fmt.Sprintf("%s\"%+v\": ", innerIndent, key))
-		// then the value
-		value := node.Content[i+1]
-		switch value.Kind {
-		case yaml.MappingNode:
-			w.writeMap(value, innerIndent)
-		case yaml.SequenceNode:
-			w.writeSequence(value, innerIndent)
-		case yaml.ScalarNode:
-			w.writeScalar(value, innerIndent)
+// Encode writes a yaml.Node as JSON followed by a newline.
+func (e *Encoder) Encode(in *yaml.Node) error {
+	for in.Kind == yaml.DocumentNode {
+		if len(in.Content) == 0 {
+			return errors.New("empty document passed to Encode")
 		}
-		if i < len(node.Content)-2 {
-			w.writeString(",")
-		}
-		w.writeString("\n")
+		in = in.Content[0]
+	}
+	if in.Kind == yaml.AliasNode {
+		return errors.New("invalid type passed to Encode")
+	}
+	e.writeValue(in, "")
+	e.writeString("\n")
+	if e.err != nil {
+		return e.err
 	}
-	w.writeString(indent)
-	w.writeString("}")
+	return e.w.Flush()
 }
 
-func (w *writer) writeScalar(node *yaml.Node, indent string) {
-	if node.Kind != yaml.ScalarNode {
-		w.writeString(fmt.Sprintf("invalid node for scalar: %+v", node))
-		return
-	}
-	switch node.Tag {
-	case "!!str":
-		w.writeString(strconv.Quote(node.Value))
-	case "!!int":
-		w.writeString(node.Value)
-	case "!!float":
-		w.writeString(node.Value)
-	case "!!bool":
-		w.writeString(node.Value)
-	case "!!null":
-		w.writeString(null)
-	}
+// Marshal writes a yaml.Node as JSON
+func Marshal(in *yaml.Node) (out []byte, err error) {
+	return MarshalWithOptions(in, nil)
 }
 
-func (w *writer) writeSequence(node *yaml.Node, indent string) {
-	if node.Kind != yaml.SequenceNode {
-		w.writeString(fmt.Sprintf("invalid node for sequence: %+v", node))
-		return
+// MarshalWithOptions writes a yaml.Node as JSON formatted with the specified options.
+func MarshalWithOptions(in *yaml.Node, options *Options) ([]byte, error) {
+	var b bytes.Buffer
+	if err := NewEncoder(&b, options).Encode(in); err != nil {
+		return nil, err
 	}
-	w.writeString("[\n")
-	innerIndent := indent + indentation
-	for i, value := range node.Content {
-		w.writeString(innerIndent)
-		switch value.Kind {
-		case yaml.MappingNode:
-			w.writeMap(value, innerIndent)
-		case yaml.SequenceNode:
-			w.writeSequence(value, innerIndent)
-		case yaml.ScalarNode:
-			w.writeScalar(value, innerIndent)
-		}
-		if i < len(node.Content)-1 {
-			w.writeString(",")
-		}
-		w.writeString("\n")
+	return b.Bytes(), nil
+}
+
+func (e *Encoder) writeString(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
 	}
-	w.writeString(indent)
-	w.writeString("]")
 }
 
-// Marshal writes a yaml.Node as JSON
-func Marshal(in *yaml.Node) (out []byte, err error) {
-	var w writer
+// newline starts a new line at the specified indentation.
+func (e *Encoder) newline(indent string) {
+	if !e.options.Compact {
+		e.writeString("\n")
+		e.writeString(indent)
+	}
+}
 
-	switch in.Kind {
+func (e *Encoder) writeValue(node *yaml.Node, indent string) {
+	switch node.Kind {
 	case yaml.DocumentNode:
-		w.writeMap(in.Content[0], "")
-		w.writeString("\n")
+		if len(node.Content) > 0 {
+			e.writeValue(node.Content[0], indent)
+		}
 	case yaml.MappingNode:
-		w.writeMap(in, "")
-		w.writeString("\n")
+		e.writeMap(node, indent)
 	case yaml.SequenceNode:
-		w.writeSequence(in, "")
-		w.writeString("\n")
+		e.writeSequence(node, indent)
 	case yaml.ScalarNode:
-		w.writeScalar(in, "")
-		w.writeString("\n")
+		e.writeScalar(node)
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			e.writeValue(node.Alias, indent)
+		} else {
+			e.writeString(null)
+		}
+	}
+}
+
+func (e *Encoder) writeMap(node *yaml.Node, indent string) {
+	e.writeString("{")
+	if len(node.Content) == 0 && !e.options.Compact {
+		// Empty maps are written on two lines as they always have been.
+		e.writeString("\n" + indent)
+	}
+	pairs := make([]int, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, i)
+	}
+	if e.options.SortKeys {
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return node.Content[pairs[i]].Value < node.Content[pairs[j]].Value
+		})
+	}
+	innerIndent := indent + e.indent
+	for n, i := range pairs {
+		if n > 0 {
+			e.writeString(",")
+		}
+		e.newline(innerIndent)
+		e.writeQuoted(node.Content[i].Value)
+		if e.options.Compact {
+			e.writeString(":")
+		} else {
+			e.writeString(": ")
+		}
+		e.writeValue(node.Content[i+1], innerIndent)
+	}
+	if len(pairs) > 0 {
+		e.newline(indent)
+	}
+	e.writeString("}")
+}
+
+func (e *Encoder) writeSequence(node *yaml.Node, indent string) {
+	e.writeString("[")
+	if len(node.Content) == 0 && !e.options.Compact {
+		e.writeString("\n" + indent)
+	}
+	innerIndent := indent + e.indent
+	for i, value := range node.Content {
+		if i > 0 {
+			e.writeString(",")
+		}
+		e.newline(innerIndent)
+		e.writeValue(value, innerIndent)
+	}
+	if len(node.Content) > 0 {
+		e.newline(indent)
+	}
+	e.writeString("]")
+}
+
+func (e *Encoder) writeScalar(node *yaml.Node) {
+	switch node.ShortTag() {
+	case "!!int", "!!float", "!!bool":
+		e.writeString(node.Value)
+	case "!!null":
+		e.writeString(null)
 	default:
-		return nil, errors.New("invalid type passed to Marshal")
+		e.writeQuoted(node.Value)
 	}
+}
 
-	return w.bytes(), err
+const hex = "0123456789abcdef"
+
+// writeQuoted writes a string as a quoted JSON string.
+func (e *Encoder) writeQuoted(s string) {
+	e.writeString(`"`)
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && !(e.options.EscapeHTML && (c == '<' || c == '>' || c == '&')) {
+				i++
+				continue
+			}
+			e.writeString(s[start:i])
+			switch c {
+			case '"', '\\':
+				e.writeString(`\` + string(c))
+			case '\n':
+				e.writeString(`\n`)
+			case '\r':
+				e.writeString(`\r`)
+			case '\t':
+				e.writeString(`\t`)
+			case '\b':
+				e.writeString(`\b`)
+			case '\f':
+				e.writeString(`\f`)
+			default:
+				e.writeString(`\u00` + string(hex[c>>4]) + string(hex[c&0xF]))
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			// Invalid UTF-8 is replaced with the replacement character.
+			e.writeString(s[start:i])
+			e.writeString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			// These are valid in JSON but not in JavaScript strings.
+			e.writeString(s[start:i])
+			e.writeString(fmt.Sprintf(`\u%04x`, r))
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	e.writeString(s[start:])
+	e.writeString(`"`)
 }
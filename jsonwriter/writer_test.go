@@ -15,6 +15,7 @@
 package jsonwriter_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/google/gnostic/compiler"
@@ -215,3 +216,80 @@ func aliasNodeTestCase() *MarshalTestCase {
 		Err:  true,
 	}
 }
+
+func optionsTestNode() *yaml.Node {
+	var node yaml.Node
+	_ = yaml.Unmarshal([]byte(`{"b": [1, 2.5, true, null], "a": {"html": "<a href='x'>&</a>"}, "c": [], "d": {}}`), &node)
+	return &node
+}
+
+func TestMarshalWithOptions(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		Name     string
+		Options  *jsonwriter.Options
+		Expected string
+	}{
+		{
+			Name:     "default",
+			Expected: "{\n  \"b\": [\n    1,\n    2.5,\n    true,\n    null\n  ],\n  \"a\": {\n    \"html\": \"<a href='x'>&</a>\"\n  },\n  \"c\": [\n  ],\n  \"d\": {\n  }\n}\n",
+		},
+		{
+			Name:     "indent",
+			Options:  &jsonwriter.Options{Indent: 4},
+			Expected: "{\n    \"b\": [\n        1,\n        2.5,\n        true,\n        null\n    ],\n    \"a\": {\n        \"html\": \"<a href='x'>&</a>\"\n    },\n    \"c\": [\n    ],\n    \"d\": {\n    }\n}\n",
+		},
+		{
+			Name:     "compact",
+			Options:  &jsonwriter.Options{Compact: true},
+			Expected: "{\"b\":[1,2.5,true,null],\"a\":{\"html\":\"<a href='x'>&</a>\"},\"c\":[],\"d\":{}}\n",
+		},
+		{
+			Name:     "sorted keys",
+			Options:  &jsonwriter.Options{Compact: true, SortKeys: true},
+			Expected: "{\"a\":{\"html\":\"<a href='x'>&</a>\"},\"b\":[1,2.5,true,null],\"c\":[],\"d\":{}}\n",
+		},
+		{
+			Name:     "escaped html",
+			Options:  &jsonwriter.Options{Compact: true, EscapeHTML: true},
+			Expected: "{\"b\":[1,2.5,true,null],\"a\":{\"html\":\"\\u003ca href='x'\\u003e\\u0026\\u003c/a\\u003e\"},\"c\":[],\"d\":{}}\n",
+		},
+	} {
+		b, err := jsonwriter.MarshalWithOptions(optionsTestNode(), test.Options)
+		if err != nil {
+			t.Errorf("%s: %v", test.Name, err)
+		}
+		if string(b) != test.Expected {
+			t.Errorf("%s: expected %v to equal %v", test.Name, string(b), test.Expected)
+		}
+	}
+}
+
+func TestStringEscaping(t *testing.T) {
+	t.Parallel()
+	m := compiler.NewMappingNode()
+	m.Content = append(m.Content, compiler.NewScalarNodeForString("a\"key\""))
+	m.Content = append(m.Content, compiler.NewScalarNodeForString("\x00\t\u00e9\u2028\xff"))
+	b, err := jsonwriter.MarshalWithOptions(m, &jsonwriter.Options{Compact: true})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := "{\"a\\\"key\\\"\":\"\\u0000\\t\u00e9\\u2028\\ufffd\"}\n"
+	if string(b) != expected {
+		t.Errorf("expected %v to equal %v", string(b), expected)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	encoder := jsonwriter.NewEncoder(&b, &jsonwriter.Options{Compact: true})
+	for _, s := range []string{"a", "b"} {
+		if err := encoder.Encode(compiler.NewSequenceNodeForStringArray([]string{s})); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if b.String() != "[\"a\"]\n[\"b\"]\n" {
+		t.Errorf("unexpected output %s", b.String())
+	}
+}
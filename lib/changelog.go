@@ -0,0 +1,144 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/google/gnostic/diff"
+)
+
+const changelogUsage = `
+Usage: gnostic changelog DIRECTORY [OPTIONS]
+  DIRECTORY contains one subdirectory for each version of an API.
+  Each subdirectory must contain one OpenAPI v2 or v3 description.
+  Versions are ordered by the names of their subdirectories, with
+  numbers compared by value, so v9 comes before v10.
+Options:
+  --json              Write the changelog in JSON format.
+` + fetcherUsage + `  --help              Print usage information and exit.
+`
+
+// gatherVersions returns the names and description files of the versions in a
+// directory, ordered with diff.CompareVersions.
+func gatherVersions(directory string) ([]string, []string, error) {
+	entries, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return diff.CompareVersions(entries[i].Name(), entries[j].Name()) < 0
+	})
+	names := make([]string, 0)
+	files := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		file := ""
+		err = filepath.Walk(filepath.Join(directory, entry.Name()),
+			func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				switch strings.ToLower(filepath.Ext(path)) {
+				case ".json", ".yaml", ".pb":
+					if file == "" && !info.IsDir() {
+						file = path
+					}
+				}
+				return nil
+			})
+		if err != nil {
+			return nil, nil, err
+		}
+		if file != "" {
+			names = append(names, entry.Name())
+			files = append(files, file)
+		}
+	}
+	return names, files, nil
+}
+
+// changelogMain writes a changelog for the versions of an API in a directory.
+func (g *Gnostic) changelogMain() error {
+	g.usage = changelogUsage
+	jsonOutput := false
+	directory := ""
+	for _, arg := range g.args[2:] {
+		if ok, err := g.readFetcherOption(arg); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+		switch {
+		case arg == "--help":
+			fmt.Printf("%s", changelogUsage)
+			return nil
+		case arg == "--json":
+			jsonOutput = true
+		case strings.HasPrefix(arg, "-"):
+			return NewUsageError(fmt.Sprintf("unknown option: %s", arg))
+		default:
+			directory = arg
+		}
+	}
+	if directory == "" {
+		return NewUsageError("no directory specified")
+	}
+	if err := g.startSession(); err != nil {
+		return err
+	}
+	defer g.session.Close()
+
+	names, files, err := gatherVersions(directory)
+	if err != nil {
+		return err
+	}
+	if len(files) < 2 {
+		return fmt.Errorf("%s must contain at least two versions", directory)
+	}
+	documents := make([]proto.Message, 0, len(files))
+	for _, file := range files {
+		document, err := g.readDescription(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s", g.errorBytes(err))
+			return err
+		}
+		documents = append(documents, document)
+	}
+	changelog, err := diff.NewChangelog(filepath.Base(filepath.Clean(directory)), names, documents)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		bytes, err := changelog.JSON()
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(append(bytes, '\n'))
+	} else {
+		fmt.Printf("%s", changelog.Markdown())
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/google/gnostic/diff"
+	plugins "github.com/google/gnostic/plugins"
+)
+
+const diffUsage = `
+Usage: gnostic diff OLD NEW [OPTIONS]
+  OLD and NEW are filenames or URLs of OpenAPI v2 or v3 descriptions.
+  Changes are written to stdout and the command fails if any are breaking.
+Options:
+  --json              Write changes in JSON format.
+  --messages-out=PATH Write changes as plugin messages to the specified
+                      location. Breaking changes have level ERROR.
+` + fetcherUsage + `  --help              Print usage information and exit.
+`
+
+// readDescription reads and compiles the named API description.
+func (g *Gnostic) readDescription(name string) (proto.Message, error) {
+	g.sourceName = name
+	bytes, err := g.compilerSession().ReadBytesForFile(name)
+	if err != nil {
+		return nil, err
+	}
+	extension := strings.ToLower(filepath.Ext(name))
+	switch extension {
+	case ".json", ".yaml":
+		return g.readOpenAPIText(bytes)
+	case ".pb":
+		return g.readOpenAPIBinary(bytes)
+	default:
+		return nil, errors.New("unknown file extension. 'json', 'yaml', and 'pb' are accepted")
+	}
+}
+
+// diffMain compares two API descriptions and reports their differences.
+func (g *Gnostic) diffMain() error {
+	g.usage = diffUsage
+	jsonOutput := false
+	messageOutputPath := ""
+	sources := make([]string, 0)
+	for _, arg := range g.args[2:] {
+		if ok, err := g.readFetcherOption(arg); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+		switch {
+		case arg == "--help":
+			fmt.Printf("%s", diffUsage)
+			return nil
+		case arg == "--json":
+			jsonOutput = true
+		case strings.HasPrefix(arg, "--messages-out="):
+			messageOutputPath = strings.TrimPrefix(arg, "--messages-out=")
+		case strings.HasPrefix(arg, "-"):
+			return NewUsageError(fmt.Sprintf("unknown option: %s", arg))
+		default:
+			sources = append(sources, arg)
+		}
+	}
+	if len(sources) != 2 {
+		return NewUsageError("diff requires two API descriptions")
+	}
+	if err := g.startSession(); err != nil {
+		return err
+	}
+	defer g.session.Close()
+
+	documents := make([]proto.Message, 0, 2)
+	for _, source := range sources {
+		document, err := g.readDescription(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s", g.errorBytes(err))
+			return err
+		}
+		documents = append(documents, document)
+	}
+	report, err := diff.Compare(documents[0], documents[1])
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		bytes, err := report.JSON()
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(append(bytes, '\n'))
+	} else {
+		fmt.Printf("%s", report.Text())
+	}
+	if messageOutputPath != "" {
+		bytes, err := proto.Marshal(&plugins.Messages{Messages: report.Messages()})
+		if err != nil {
+			return err
+		}
+		writeFile(messageOutputPath, bytes, sources[1], "messages.pb")
+	}
+	if report.HasBreakingChanges() {
+		return fmt.Errorf("%d breaking changes found", len(report.BreakingChanges()))
+	}
+	return nil
+}
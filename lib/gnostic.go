@@ -16,15 +16,18 @@ package lib
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -106,7 +109,7 @@ type pluginCall struct {
 }
 
 // Invokes a plugin.
-func (p *pluginCall) perform(document proto.Message, sourceFormat int, sourceName string, timePlugins bool, excludeSurface bool) ([]*plugins.Message, error) {
+func (p *pluginCall) perform(document proto.Message, sourceFormat int, sourceName string, timePlugins bool, excludeSurface bool, session *compiler.Session, diagnostics []*compiler.Diagnostic) ([]*plugins.Message, error) {
 	if p.Name != "" {
 		request := &plugins.Request{}
 
@@ -162,7 +165,8 @@ func (p *pluginCall) perform(document proto.Message, sourceFormat int, sourceNam
 			request.AddModel("openapi.v2.Document", document)
 			if !excludeSurface {
 				// include experimental API surface model
-				surfaceModel, err := surface.NewModelFromOpenAPI2(document.(*openapi_v2.Document), sourceName)
+				// (its symbolic references are found with the documents that the session reads)
+				surfaceModel, err := surface.NewModelFromOpenAPI2WithSession(document.(*openapi_v2.Document), sourceName, session)
 				if err == nil {
 					request.AddModel("surface.v1.Model", surfaceModel)
 				}
@@ -171,15 +175,26 @@ func (p *pluginCall) perform(document proto.Message, sourceFormat int, sourceNam
 			request.AddModel("openapi.v3.Document", document)
 			if !excludeSurface {
 				// include experimental API surface model
-				surfaceModel, err := surface.NewModelFromOpenAPI3(document.(*openapi_v3.Document), sourceName)
+				// (its symbolic references are found with the documents that the session reads)
+				surfaceModel, err := surface.NewModelFromOpenAPI3WithSession(document.(*openapi_v3.Document), sourceName, session)
 				if err == nil {
 					request.AddModel("surface.v1.Model", surfaceModel)
 				}
 			}
 		case SourceFormatDiscovery:
 			request.AddModel("discovery.v1.Document", document)
+			if !excludeSurface {
+				// include experimental API surface model
+				surfaceModel, err := surface.NewModelFromDiscovery(document.(*discovery_v1.Document))
+				if err == nil {
+					request.AddModel("surface.v1.Model", surfaceModel)
+				}
+			}
 		default:
 		}
+		if len(diagnostics) > 0 {
+			request.AddModel("gnostic.plugin.v1.Messages", pluginMessagesForDiagnostics(diagnostics))
+		}
 
 		requestBytes, _ := proto.Marshal(request)
 
@@ -247,6 +262,15 @@ func isURL(path string) bool {
 // If a directory name is given, the file is written there with
 // a name derived from the source and extension arguments.
 func writeFile(name string, bytes []byte, source string, extension string) {
+	writeOutput(name, source, extension, func(writer io.Writer) error {
+		_, err := writer.Write(bytes)
+		return err
+	})
+}
+
+// writeOutput calls write with the output that writeFile would write to,
+// allowing large outputs to be streamed instead of buffered.
+func writeOutput(name string, source string, extension string, write func(io.Writer) error) {
 	var writer io.Writer
 	if name == "!" {
 		return
@@ -283,7 +307,9 @@ func writeFile(name string, bytes []byte, source string, extension string) {
 		defer file.Close()
 		writer = file
 	}
-	writer.Write(bytes)
+	if err := write(writer); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s output %s\n", extension, err.Error())
+	}
 }
 
 // The Gnostic structure holds global state information for gnostic.
@@ -295,29 +321,73 @@ type Gnostic struct {
 	textOutputPath    string
 	yamlOutputPath    string
 	jsonOutputPath    string
+	canonicalJSONPath string
 	errorOutputPath   string
 	messageOutputPath string
+	refCyclesPath     string
 	resolveReferences bool
+	mode              compiler.Mode
+	severity          compiler.Severity
+	diagnostics       []*compiler.Diagnostic
+	sarifPath         string
 	pluginCalls       []*pluginCall
 	extensionHandlers []compiler.ExtensionHandler
 	sourceFormat      int
 	timePlugins       bool
 	excludeSurface    bool
+	offline           bool
+	catalogPaths      []string
+	vendorDirectories []string
+	httpHeaders       map[string]http.Header
+	limits            compiler.Limits
+	session           *compiler.Session
+	info              *yaml.Node
 }
 
+// fetcherUsage describes the options that configure the fetcher for remote documents.
+const fetcherUsage = `  --catalog=PATH      Read remote documents from the local files listed in a
+                      catalog, a YAML or JSON file that maps URLs to files.
+                      URLs that end with "/" map to directories.
+  --vendor-dir=PATH   Read remote documents from vendored copies stored in
+                      PATH/HOST/PATH.
+  --http-header=URL=NAME:VALUE
+                      Add a header to HTTP requests for remote documents
+                      whose URLs begin with URL. The header is not sent to
+                      any other URL.
+  --offline           Don't make HTTP requests. Remote documents that aren't
+                      in a catalog or vendored directory are errors.
+`
+
 // NewGnostic initializes a structure to store global application state.
 func NewGnostic(args []string) *Gnostic {
 	g := &Gnostic{args: args}
 	// Option fields initialize to their default values.
 	g.usage = `
 Usage: gnostic SOURCE [OPTIONS]
+       gnostic diff OLD NEW [OPTIONS]
+       gnostic changelog DIRECTORY [OPTIONS]
+       gnostic mock SOURCE [OPTIONS]
   SOURCE is the filename or URL of an API description.
+  Use "gnostic diff --help" for information about comparing descriptions,
+  "gnostic changelog --help" for information about changelogs and
+  "gnostic mock --help" for information about mock servers.
 Options:
   --pb-out=PATH       Write a binary proto to the specified location.
   --text-out=PATH     Write a text proto to the specified location.
   --json-out=PATH     Write a json API description to the specified location.
   --yaml-out=PATH     Write a yaml API description to the specified location.
+  --canonical-json-out=PATH
+                      Write a json API description in the canonical form of
+                      RFC 8785 to the specified location. Its SHA-256 digest
+                      is written to a file with the same name and a .sha256
+                      suffix, or to stderr if the description is written to
+                      stdout.
   --errors-out=PATH   Write compilation errors to the specified location.
+  --sarif-out=PATH    Write compilation errors and diagnostics to the
+                      specified location in the SARIF format.
+  --severity=LEVEL    Report diagnostics of at least LEVEL (error, warning or
+                      info) with errors. The default is error, or warning
+                      with --lenient. Plugins receive all diagnostics.
   --messages-out=PATH Write messages generated by plugins to the specified
                       location. Messages from all plugin invocations are
                       written to a single common file.
@@ -328,15 +398,31 @@ Options:
                       PLUGIN must not match any other gnostic option.
   --x-EXTENSION       Use the extension named gnostic-x-EXTENSION
                       to process OpenAPI specification extensions.
-  --resolve-refs      Explicitly resolve $ref references.
-                      This could have problems with recursive definitions.
+  --resolve-refs      Explicitly resolve $ref references. References that
+                      close cycles are left in place.
+  --ref-cycles-out=PATH
+                      Write the $ref cycles of the description to the
+                      specified location as a json list of cycles, each a
+                      list of the JSON pointers of its references.
+  --strict            Fail on duplicate keys, x- extensions in maps of names
+                      and values that are converted to other types, such as
+                      numbers in string fields.
+  --lenient           Report unknown properties, misplaced extensions,
+                      duplicate keys and converted values as warnings and
+                      continue with the compiled description.
   --time-plugins      Report plugin runtimes.
   --no-surface        Exclude surface model from calls to plugins.
+` + fetcherUsage + `  --max-bytes=N       Limit the size of documents to N bytes.
+  --max-depth=N       Limit the nesting depth of documents to N levels.
+  --max-aliases=N     Limit the number of YAML aliases that are expanded to N.
+  --max-ref-hops=N    Limit the length of chains of $refs to N.
+  --max-fetches=N     Limit the number of remote documents that are fetched to N.
   --help              Print usage information and exit.
 `
 	// Initialize internal structures.
 	g.pluginCalls = make([]*pluginCall, 0)
 	g.extensionHandlers = make([]compiler.ExtensionHandler, 0)
+	g.httpHeaders = make(map[string]http.Header)
 	return g
 }
 
@@ -353,12 +439,42 @@ func (g *Gnostic) readOptions() error {
 	// extension processing matches patterns of the form "--x-EXTENSION"
 	extensionRegex := regexp.MustCompile("--x-(.+)")
 
+	// limit options match patterns of the form "--max-LIMIT=N"
+	limitRegex := regexp.MustCompile("^--max-(bytes|depth|aliases|ref-hops|fetches)=([0-9]+)$")
+
 	for i, arg := range g.args {
 		if i == 0 {
 			continue // skip the tool name
 		}
 		var m [][]byte
-		if m = pluginRegex.FindSubmatch([]byte(arg)); m != nil {
+		if m = limitRegex.FindSubmatch([]byte(arg)); m != nil {
+			value, err := strconv.ParseInt(string(m[2]), 10, 64)
+			if err != nil {
+				return NewUsageError(fmt.Sprintf("invalid limit: %s", arg))
+			}
+			switch string(m[1]) {
+			case "bytes":
+				g.limits.MaxBytes = value
+			case "depth":
+				g.limits.MaxDepth = int(value)
+			case "aliases":
+				g.limits.MaxAliasExpansions = value
+			case "ref-hops":
+				g.limits.MaxRefHops = int(value)
+			case "fetches":
+				g.limits.MaxFetches = int(value)
+			}
+		} else if ok, err := g.readFetcherOption(arg); ok || err != nil {
+			if err != nil {
+				return err
+			}
+		} else if strings.HasPrefix(arg, "--severity=") {
+			severity, err := compiler.ParseSeverity(strings.TrimPrefix(arg, "--severity="))
+			if err != nil {
+				return NewUsageError(err.Error())
+			}
+			g.severity = severity
+		} else if m = pluginRegex.FindSubmatch([]byte(arg)); m != nil {
 			pluginName := string(m[1])
 			invocation := string(m[2])
 			switch pluginName {
@@ -370,10 +486,16 @@ func (g *Gnostic) readOptions() error {
 				g.jsonOutputPath = invocation
 			case "yaml":
 				g.yamlOutputPath = invocation
+			case "canonical-json":
+				g.canonicalJSONPath = invocation
 			case "errors":
 				g.errorOutputPath = invocation
+			case "sarif":
+				g.sarifPath = invocation
 			case "messages":
 				g.messageOutputPath = invocation
+			case "ref-cycles":
+				g.refCyclesPath = invocation
 			default:
 				p := &pluginCall{Name: pluginName, Invocation: invocation}
 				g.pluginCalls = append(g.pluginCalls, p)
@@ -384,6 +506,15 @@ func (g *Gnostic) readOptions() error {
 			g.extensionHandlers = append(g.extensionHandlers, extensionHandler)
 		} else if arg == "--resolve-refs" {
 			g.resolveReferences = true
+		} else if arg == "--strict" || arg == "--lenient" {
+			mode := compiler.ModeStrict
+			if arg == "--lenient" {
+				mode = compiler.ModeLenient
+			}
+			if g.mode != compiler.ModeDefault && g.mode != mode {
+				return NewUsageError("--strict and --lenient can't be used together")
+			}
+			g.mode = mode
 		} else if arg == "--time-plugins" {
 			g.timePlugins = true
 		} else if arg == "--no-surface" {
@@ -402,14 +533,57 @@ func (g *Gnostic) readOptions() error {
 	return nil
 }
 
+// fetcher options match patterns of the form "--OPTION=VALUE"
+var fetcherRegex = regexp.MustCompile("^--(catalog|vendor-dir|http-header)=(.+)$")
+
+// Parse an option that configures the fetcher for remote documents. These
+// options are accepted by gnostic and all of its subcommands.
+func (g *Gnostic) readFetcherOption(arg string) (bool, error) {
+	if arg == "--offline" {
+		g.offline = true
+		return true, nil
+	}
+	m := fetcherRegex.FindSubmatch([]byte(arg))
+	if m == nil {
+		return false, nil
+	}
+	value := string(m[2])
+	switch string(m[1]) {
+	case "catalog":
+		g.catalogPaths = append(g.catalogPaths, value)
+	case "vendor-dir":
+		g.vendorDirectories = append(g.vendorDirectories, value)
+	case "http-header":
+		scope := strings.SplitN(value, "=", 2)
+		if len(scope) != 2 {
+			return true, NewUsageError(fmt.Sprintf("invalid header, expected URL=NAME:VALUE: %s", value))
+		}
+		if u, err := url.Parse(scope[0]); err != nil || u.Scheme == "" || u.Host == "" {
+			return true, NewUsageError(fmt.Sprintf("invalid header URL: %s", scope[0]))
+		}
+		parts := strings.SplitN(scope[1], ":", 2)
+		if len(parts) != 2 {
+			return true, NewUsageError(fmt.Sprintf("invalid header: %s", value))
+		}
+		if g.httpHeaders[scope[0]] == nil {
+			g.httpHeaders[scope[0]] = make(http.Header)
+		}
+		g.httpHeaders[scope[0]].Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+	return true, nil
+}
+
 // Validate command-line options.
 func (g *Gnostic) validateOptions() error {
 	if g.binaryOutputPath == "" &&
 		g.textOutputPath == "" &&
 		g.yamlOutputPath == "" &&
 		g.jsonOutputPath == "" &&
+		g.canonicalJSONPath == "" &&
 		g.errorOutputPath == "" &&
 		g.messageOutputPath == "" &&
+		g.refCyclesPath == "" &&
+		g.sarifPath == "" &&
 		len(g.pluginCalls) == 0 {
 		return NewUsageError("missing output directives")
 	}
@@ -420,49 +594,217 @@ func (g *Gnostic) validateOptions() error {
 	if g.errorOutputPath == "" {
 		g.errorOutputPath = "="
 	}
+	if g.severity == 0 {
+		g.severity = compiler.SeverityError
+		if g.mode == compiler.ModeLenient {
+			g.severity = compiler.SeverityWarning
+		}
+	}
+	return nil
+}
+
+// Build the fetcher for remote documents, or return nil if the default fetcher should be used.
+func (g *Gnostic) fetcher() (compiler.Fetcher, error) {
+	if !g.offline && len(g.catalogPaths) == 0 && len(g.vendorDirectories) == 0 && len(g.httpHeaders) == 0 {
+		return nil, nil
+	}
+	fetchers := compiler.Fetchers{}
+	for _, path := range g.catalogPaths {
+		catalog, err := compiler.ReadCatalog(path)
+		if err != nil {
+			return nil, err
+		}
+		fetchers = append(fetchers, catalog)
+	}
+	for _, directory := range g.vendorDirectories {
+		fetchers = append(fetchers, &compiler.VendorFetcher{Directory: directory})
+	}
+	if !g.offline {
+		fetchers = append(fetchers, &compiler.HTTPFetcher{Headers: g.httpHeaders, MaxBytes: g.limits.MaxBytes})
+	}
+	return fetchers, nil
+}
+
+// Start a compilation session with the fetcher and limits of the options.
+func (g *Gnostic) startSession() error {
+	fetcher, err := g.fetcher()
+	if err != nil {
+		return err
+	}
+	g.session = compiler.NewSession(fetcher, &g.limits)
 	return nil
 }
 
+// Return the compilation session, creating one if necessary.
+func (g *Gnostic) compilerSession() *compiler.Session {
+	if g.session == nil {
+		g.session = compiler.NewSession(nil, &g.limits)
+	}
+	return g.session
+}
+
 // Generate an error message to be written to stderr or a file.
 func (g *Gnostic) errorBytes(err error) []byte {
 	return []byte("Errors reading " + g.sourceName + "\n" + err.Error())
 }
 
+// Write errors and the diagnostics that are at least as severe as the reported
+// severity to the error output and the SARIF output.
+func (g *Gnostic) writeDiagnostics(err error) {
+	diagnostics := make([]*compiler.Diagnostic, 0)
+	var text []byte
+	if err != nil {
+		diagnostics = append(diagnostics, compiler.DiagnosticsForError(err, compiler.SeverityError)...)
+		text = g.errorBytes(err)
+	}
+	reported := make([]string, 0)
+	for _, d := range g.diagnostics {
+		if d.Severity >= g.severity {
+			diagnostics = append(diagnostics, d)
+			reported = append(reported, d.String())
+		}
+	}
+	if len(reported) > 0 {
+		if text != nil {
+			text = append(text, '\n')
+		}
+		text = append(text, []byte("Diagnostics reading "+g.sourceName+"\n"+strings.Join(reported, "\n")+"\n")...)
+	}
+	if text != nil {
+		writeFile(g.errorOutputPath, text, g.sourceName, "errors")
+	}
+	if g.sarifPath != "" {
+		bytes, err := marshalSARIF(g.sourceName, diagnostics)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating sarif output %s\n", err.Error())
+			return
+		}
+		writeFile(g.sarifPath, bytes, g.sourceName, "sarif")
+	}
+}
+
+// Convert diagnostics to plugin messages.
+func pluginMessagesForDiagnostics(diagnostics []*compiler.Diagnostic) *plugins.Messages {
+	levels := map[compiler.Severity]plugins.Message_Level{
+		compiler.SeverityInfo:    plugins.Message_INFO,
+		compiler.SeverityWarning: plugins.Message_WARNING,
+		compiler.SeverityError:   plugins.Message_ERROR,
+	}
+	messages := &plugins.Messages{}
+	for _, d := range diagnostics {
+		keys := []string{d.Location}
+		if strings.HasPrefix(d.Location, "#/") {
+			// JSON pointers are split into the keys of their paths.
+			keys = strings.Split(d.Location[2:], "/")
+			for i, key := range keys {
+				keys[i] = strings.Replace(strings.Replace(key, "~1", "/", -1), "~0", "~", -1)
+			}
+		}
+		messages.Messages = append(messages.Messages, &plugins.Message{
+			Level: levels[d.Severity],
+			Code:  d.Code,
+			Text:  d.Error(),
+			Keys:  keys,
+		})
+	}
+	return messages
+}
+
 // Read an OpenAPI description from YAML or JSON.
 func (g *Gnostic) readOpenAPIText(bytes []byte) (message proto.Message, err error) {
-	info, err := compiler.ReadInfoFromBytes(g.sourceName, bytes)
+	session := g.compilerSession()
+	info, err := session.ReadInfoFromBytes(g.sourceName, bytes)
 	if err != nil {
 		return nil, err
 	}
+	g.info = info
 	// Determine the OpenAPI version.
 	g.sourceFormat = getOpenAPIVersionFromInfo(info)
 	if g.sourceFormat == SourceFormatUnknown {
 		return nil, errors.New("unable to identify OpenAPI version")
 	}
 	// Compile to the proto model.
+	root := info.Content[0]
+	if len(g.extensionHandlers) > 0 {
+		session.RunExtensionHandlers(root, g.extensionHandlers)
+	}
+	context := session.NewContext("$root", root, &g.extensionHandlers)
+	var compiled interface{ ToRawInfo() *yaml.Node }
 	if g.sourceFormat == SourceFormatOpenAPI2 {
-		root := info.Content[0]
-		document, err := openapi_v2.NewDocument(root, compiler.NewContextWithExtensions("$root", root, nil, &g.extensionHandlers))
-		if err != nil {
-			return nil, err
+		var document *openapi_v2.Document
+		document, err = openapi_v2.NewDocument(root, context)
+		if document != nil {
+			message, compiled = document, document
 		}
-		message = document
 	} else if g.sourceFormat == SourceFormatOpenAPI3 {
-		root := info.Content[0]
-		document, err := openapi_v3.NewDocument(root, compiler.NewContextWithExtensions("$root", root, nil, &g.extensionHandlers))
-		if err != nil {
-			return nil, err
+		var document *openapi_v3.Document
+		document, err = openapi_v3.NewDocument(root, context)
+		if document != nil {
+			message, compiled = document, document
 		}
-		message = document
 	} else {
-		root := info.Content[0]
-		document, err := discovery_v1.NewDocument(root, compiler.NewContextWithExtensions("$root", root, nil, &g.extensionHandlers))
-		if err != nil {
-			return nil, err
+		var document *discovery_v1.Document
+		document, err = discovery_v1.NewDocument(root, context)
+		if document != nil {
+			message, compiled = document, document
+		}
+	}
+	structure := make([]error, 0)
+	if g.mode != compiler.ModeDefault {
+		// Check for the structural problems that the compilers accept.
+		structure = compiler.FindDuplicateKeys(info)
+		structure = append(structure, compiler.FindMisplacedExtensions(info, nameMaps[g.sourceFormat])...)
+		if compiled != nil {
+			structure = append(structure, compiler.FindCoercions(info, compiled.ToRawInfo())...)
 		}
-		message = document
 	}
-	return message, err
+	warnings, err := g.mode.Classify(err, structure)
+	g.diagnostics = compiler.DiagnosticsForError(compiler.NewErrorGroupOrNil(warnings), compiler.SeverityWarning)
+	if g.sourceFormat != SourceFormatDiscovery {
+		g.diagnostics = append(g.diagnostics, compiler.CheckDocument(info, nameMaps[g.sourceFormat])...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, errors.New("unable to compile " + g.sourceName)
+	}
+	return message, nil
+}
+
+// The JSON pointers of the maps of names in OpenAPI documents, where x- keys are
+// read as names and not as extensions. Patterns that begin with "/" match whole
+// pointers and others match their ends.
+var nameMaps = map[int][]string{
+	SourceFormatOpenAPI2: {
+		"/definitions",
+		"/parameters",
+		"/responses",
+		"/securityDefinitions",
+		"/securityDefinitions/*/scopes",
+		"properties",
+		"responses/*/headers",
+	},
+	SourceFormatOpenAPI3: {
+		"/components/schemas",
+		"/components/responses",
+		"/components/parameters",
+		"/components/examples",
+		"/components/requestBodies",
+		"/components/headers",
+		"/components/securitySchemes",
+		"/components/links",
+		"/components/callbacks",
+		"properties",
+		"content",
+		"encoding",
+		"headers",
+		"examples",
+		"links",
+		"scopes",
+		"mapping",
+		"servers/*/variables",
+	},
 }
 
 func (g *Gnostic) ReadOpenAPIText(bytes []byte) (message proto.Message, err error) {
@@ -514,19 +856,12 @@ func (g *Gnostic) writeTextOutput(message proto.Message) {
 
 // Write JSON/YAML OpenAPI representations.
 func (g *Gnostic) writeJSONYAMLOutput(message proto.Message) {
-	// Convert the OpenAPI document into an exportable MapSlice.
-	var rawInfo *yaml.Node
-	if g.sourceFormat == SourceFormatOpenAPI2 {
-		document := message.(*openapi_v2.Document)
-		rawInfo = document.ToRawInfo()
-	} else if g.sourceFormat == SourceFormatOpenAPI3 {
-		document := message.(*openapi_v3.Document)
-		rawInfo = document.ToRawInfo()
-	} else if g.sourceFormat == SourceFormatDiscovery {
-		document := message.(*discovery_v1.Document)
-		rawInfo = document.ToRawInfo()
+	// Convert the document into yaml, writing the messages of handled extension values.
+	rawInfo, err := compiler.ToRawInfo(proto.MessageV2(message), g.extensionHandlers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing extension values %s\n", err.Error())
 	}
-	if rawInfo.Kind != yaml.DocumentNode {
+	if rawInfo != nil && rawInfo.Kind != yaml.DocumentNode {
 		rawInfo = &yaml.Node{
 			Kind:    yaml.DocumentNode,
 			Content: []*yaml.Node{rawInfo},
@@ -552,15 +887,79 @@ func (g *Gnostic) writeJSONYAMLOutput(message proto.Message) {
 				Kind:    yaml.DocumentNode,
 				Content: []*yaml.Node{rawInfo},
 			}
-			bytes, err := jsonwriter.Marshal(rawInfo)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error generating json output %s\n", err.Error())
-			}
-			writeFile(g.jsonOutputPath, bytes, g.sourceName, "json")
+			writeOutput(g.jsonOutputPath, g.sourceName, "json", func(writer io.Writer) error {
+				return jsonwriter.NewEncoder(writer, nil).Encode(rawInfo)
+			})
 		} else {
 			fmt.Fprintf(os.Stderr, "No json output available.\n")
 		}
 	}
+	// Optionally write description in canonical json format with its digest.
+	if g.canonicalJSONPath != "" {
+		bytes, err := jsonwriter.MarshalCanonical(rawInfo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating canonical json output %s\n", err.Error())
+			return
+		}
+		writeFile(g.canonicalJSONPath, bytes, g.sourceName, "canonical.json")
+		digestPath := g.canonicalJSONPath
+		if digestPath == "-" {
+			digestPath = "="
+		} else if digestPath != "=" && digestPath != "!" && !isDirectory(digestPath) {
+			digestPath += ".sha256"
+		}
+		writeFile(digestPath, []byte(jsonwriter.Digest(bytes)+"\n"), g.sourceName, "canonical.json.sha256")
+	}
+}
+
+// Return the yaml form of an OpenAPI document, or nil for other formats.
+func (g *Gnostic) openAPIInfo(message proto.Message) *yaml.Node {
+	if g.sourceFormat != SourceFormatOpenAPI2 && g.sourceFormat != SourceFormatOpenAPI3 {
+		return nil
+	}
+	if g.info != nil {
+		return g.info
+	}
+	// Documents read from binary protos are converted back to yaml.
+	if document, ok := message.(interface{ ToRawInfo() *yaml.Node }); ok {
+		return document.ToRawInfo()
+	}
+	return nil
+}
+
+// Resolve the internal references of an OpenAPI document. The ResolveReferences
+// methods read the targets of references that the compiler session has read with
+// its fetcher. They would never finish on documents with reference cycles, so those are
+// dereferenced by the compiler session, which leaves the references that close
+// cycles in place, and compiled again.
+func (g *Gnostic) resolveRefs(message proto.Message, info *yaml.Node, hasCycles bool) (proto.Message, error) {
+	session := g.compilerSession()
+	if !hasCycles {
+		var err error
+		if g.sourceFormat == SourceFormatOpenAPI2 {
+			document := message.(*openapi_v2.Document)
+			_, err = session.ResolveReferences(document.ResolveReferences, g.sourceName, info)
+		} else if g.sourceFormat == SourceFormatOpenAPI3 {
+			document := message.(*openapi_v3.Document)
+			_, err = session.ResolveReferences(document.ResolveReferences, g.sourceName, info)
+		}
+		return message, err
+	}
+	info, _, err := session.Dereference(g.sourceName, info)
+	if err != nil {
+		return nil, err
+	}
+	root := info
+	if root.Kind == yaml.DocumentNode {
+		root = root.Content[0]
+	}
+	if len(g.extensionHandlers) > 0 {
+		session.RunExtensionHandlers(root, g.extensionHandlers)
+	}
+	if g.sourceFormat == SourceFormatOpenAPI2 {
+		return openapi_v2.NewDocument(root, session.NewContext("$root", root, &g.extensionHandlers))
+	}
+	return openapi_v3.NewDocument(root, session.NewContext("$root", root, &g.extensionHandlers))
 }
 
 // Write messages.
@@ -576,17 +975,34 @@ func (g *Gnostic) writeMessagesOutput(message proto.Message) error {
 
 // Perform all actions specified in the command-line options.
 func (g *Gnostic) performActions(message proto.Message) (err error) {
-	// Optionally resolve internal references.
-	if g.resolveReferences {
-		if g.sourceFormat == SourceFormatOpenAPI2 {
-			document := message.(*openapi_v2.Document)
-			_, err = document.ResolveReferences(g.sourceName)
-		} else if g.sourceFormat == SourceFormatOpenAPI3 {
-			document := message.(*openapi_v3.Document)
-			_, err = document.ResolveReferences(g.sourceName)
+	// Optionally find reference cycles and resolve internal references.
+	if g.resolveReferences || g.refCyclesPath != "" {
+		if g.info != nil {
+			err = g.compilerSession().CheckRefs(g.sourceName, g.info)
+			if err != nil {
+				return err
+			}
 		}
-		if err != nil {
-			return err
+		info := g.openAPIInfo(message)
+		cycles := make([]compiler.RefCycle, 0)
+		if info != nil {
+			cycles, err = g.compilerSession().FindRefCycles(g.sourceName, info)
+			if err != nil {
+				return err
+			}
+		}
+		if g.refCyclesPath != "" {
+			bytes, err := json.MarshalIndent(cycles, "", "  ")
+			if err != nil {
+				return err
+			}
+			writeFile(g.refCyclesPath, append(bytes, '\n'), g.sourceName, "ref-cycles.json")
+		}
+		if g.resolveReferences {
+			message, err = g.resolveRefs(message, info, len(cycles) > 0)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	// Optionally write proto in binary format.
@@ -601,14 +1017,14 @@ func (g *Gnostic) performActions(message proto.Message) (err error) {
 		g.writeTextOutput(message)
 	}
 	// Optionally write document in yaml and/or json formats.
-	if g.yamlOutputPath != "" || g.jsonOutputPath != "" {
+	if g.yamlOutputPath != "" || g.jsonOutputPath != "" || g.canonicalJSONPath != "" {
 		g.writeJSONYAMLOutput(message)
 	}
 	// Call all specified plugins.
 	messages := make([]*plugins.Message, 0)
 	errors := make([]error, 0)
 	for _, p := range g.pluginCalls {
-		pluginMessages, err := p.perform(message, g.sourceFormat, g.sourceName, g.timePlugins, g.excludeSurface)
+		pluginMessages, err := p.perform(message, g.sourceFormat, g.sourceName, g.timePlugins, g.excludeSurface, g.compilerSession(), g.diagnostics)
 		if err != nil {
 			// we don't exit or fail here so that we run all plugins even when some have errors
 			errors = append(errors, err)
@@ -633,6 +1049,16 @@ func (g *Gnostic) performActions(message proto.Message) (err error) {
 
 // Main is the main program for Gnostic.
 func (g *Gnostic) Main() error {
+	if len(g.args) > 1 {
+		switch g.args[1] {
+		case "diff":
+			return g.diffMain()
+		case "changelog":
+			return g.changelogMain()
+		case "mock":
+			return g.mockMain()
+		}
+	}
 	// if help is requested, print usage and immediately exit
 	for _, arg := range g.args {
 		if arg == "--help" {
@@ -641,8 +1067,6 @@ func (g *Gnostic) Main() error {
 		}
 	}
 
-	compiler.ClearCaches()
-
 	var err error
 	err = g.readOptions()
 	if err != nil {
@@ -652,10 +1076,17 @@ func (g *Gnostic) Main() error {
 	if err != nil {
 		return err
 	}
+	// Compile in a session of its own, so that no state is shared with other compilations.
+	err = g.startSession()
+	if err != nil {
+		g.writeDiagnostics(err)
+		return err
+	}
+	defer g.session.Close()
 	// Read the OpenAPI source.
-	bytes, err := compiler.ReadBytesForFile(g.sourceName)
+	bytes, err := g.session.ReadBytesForFile(g.sourceName)
 	if err != nil {
-		writeFile(g.errorOutputPath, g.errorBytes(err), g.sourceName, "errors")
+		g.writeDiagnostics(err)
 		return err
 	}
 	extension := strings.ToLower(filepath.Ext(g.sourceName))
@@ -664,26 +1095,23 @@ func (g *Gnostic) Main() error {
 		// Try to read the source as JSON/YAML.
 		message, err = g.readOpenAPIText(bytes)
 		if err != nil {
-			writeFile(g.errorOutputPath, g.errorBytes(err), g.sourceName, "errors")
+			g.writeDiagnostics(err)
 			return err
 		}
 	} else if extension == ".pb" {
 		// Try to read the source as a binary protocol buffer.
 		message, err = g.readOpenAPIBinary(bytes)
 		if err != nil {
-			writeFile(g.errorOutputPath, g.errorBytes(err), g.sourceName, "errors")
+			g.writeDiagnostics(err)
 			return err
 		}
 	} else {
 		err = errors.New("unknown file extension. 'json', 'yaml', and 'pb' are accepted")
-		writeFile(g.errorOutputPath, g.errorBytes(err), g.sourceName, "errors")
+		g.writeDiagnostics(err)
 		return err
 	}
 	// Perform actions specified by command options.
 	err = g.performActions(message)
-	if err != nil {
-		writeFile(g.errorOutputPath, g.errorBytes(err), g.sourceName, "errors")
-		return err
-	}
-	return nil
+	g.writeDiagnostics(err)
+	return err
 }
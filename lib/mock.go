@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/gnostic/mock"
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+const mockUsage = `
+Usage: gnostic mock SOURCE [OPTIONS]
+  SOURCE is the filename or URL of an OpenAPI v2 or v3 description.
+  Every operation is served with responses built from the examples of the
+  description or, when there are none, from its schemas. Requests are
+  validated against the description.
+  Clients can select responses with the Prefer header:
+    Prefer: code=404         Respond with the response for a status code.
+    Prefer: example=NAME     Respond with a named example.
+Options:
+  --addr=ADDRESS      Listen on the specified address (default ":8080").
+` + fetcherUsage + `  --help              Print usage information and exit.
+`
+
+// mockMain serves an API description with a mock server.
+func (g *Gnostic) mockMain() error {
+	g.usage = mockUsage
+	address := ":8080"
+	sources := make([]string, 0)
+	for _, arg := range g.args[2:] {
+		if ok, err := g.readFetcherOption(arg); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+		switch {
+		case arg == "--help":
+			fmt.Printf("%s", mockUsage)
+			return nil
+		case strings.HasPrefix(arg, "--addr="):
+			address = strings.TrimPrefix(arg, "--addr=")
+		case strings.HasPrefix(arg, "-"):
+			return NewUsageError(fmt.Sprintf("unknown option: %s", arg))
+		default:
+			sources = append(sources, arg)
+		}
+	}
+	if len(sources) != 1 {
+		return NewUsageError("mock requires one API description")
+	}
+	if err := g.startSession(); err != nil {
+		return err
+	}
+	defer g.session.Close()
+
+	document, err := g.readDescription(sources[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s", g.errorBytes(err))
+		return err
+	}
+	var server *mock.Server
+	switch d := document.(type) {
+	case *openapi_v2.Document:
+		server = mock.NewOpenAPI2Server(d, g.info)
+	case *openapi_v3.Document:
+		server = mock.NewServer(d, g.info)
+	default:
+		return fmt.Errorf("unsupported description: %s", sources[0])
+	}
+	fmt.Fprintf(os.Stderr, "Serving %s on %s\n", sources[0], address)
+	return http.ListenAndServe(address, server)
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// gnostic-lint-examples is a tool for analyzing OpenAPI descriptions.
+//
+// It checks every example in an API description against its schema
+// and returns a message for each example that doesn't conform.
+package main
+
+import (
+	"github.com/golang/protobuf/proto"
+	"gopkg.in/yaml.v3"
+
+	"github.com/google/gnostic/compiler"
+	openapiv2 "github.com/google/gnostic/openapiv2"
+	openapiv3 "github.com/google/gnostic/openapiv3"
+	plugins "github.com/google/gnostic/plugins"
+	"github.com/google/gnostic/validate"
+)
+
+// sourceInfo reads the yaml of the source document, which holds the limits
+// with zero values that the compiled models leave out. It returns nil if the
+// source can't be read.
+func sourceInfo(sourceName string) *yaml.Node {
+	bytes, err := compiler.ReadBytesForFile(sourceName)
+	if err != nil {
+		return nil
+	}
+	info, err := compiler.ReadInfoFromBytes(sourceName, bytes)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// This is the main function for the plugin.
+func main() {
+	env, err := plugins.NewEnvironment()
+	env.RespondAndExitIfError(err)
+
+	for _, model := range env.Request.Models {
+		switch model.TypeUrl {
+		case "openapi.v2.Document":
+			documentv2 := &openapiv2.Document{}
+			err = proto.Unmarshal(model.Value, documentv2)
+			if err == nil {
+				env.Response.Messages = validate.OpenAPI2Examples(documentv2, sourceInfo(env.Request.SourceName))
+			}
+		case "openapi.v3.Document":
+			documentv3 := &openapiv3.Document{}
+			err = proto.Unmarshal(model.Value, documentv3)
+			if err == nil {
+				env.Response.Messages = validate.OpenAPI3Examples(documentv3, sourceInfo(env.Request.SourceName))
+			}
+		}
+	}
+
+	env.RespondAndExit()
+}
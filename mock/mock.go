@@ -0,0 +1,254 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mock serves the operations of an OpenAPI v2 or v3 description with
+// responses built from the examples and schemas of the description.
+//
+// Requests are validated against the description before they are answered.
+// Clients can select a response with a Prefer header: "Prefer: code=404"
+// selects the response for a status code and "Prefer: example=name" selects
+// a named example.
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/google/gnostic/validate/middleware"
+)
+
+// example is a named example value of a response body.
+type example struct {
+	name  string
+	value interface{}
+}
+
+// content is a media type of a response.
+type content struct {
+	mediaType  string
+	examples   []*example
+	synthesize func() interface{} // nil if the media type has no schema
+}
+
+// response is a response of an operation.
+type response struct {
+	code    string // status code, range ("2XX") or "default"
+	content []*content
+}
+
+// operation holds the responses of an operation.
+type operation struct {
+	responses []*response
+}
+
+// Server is an http.Handler that answers requests with mock responses.
+type Server struct {
+	handler    http.Handler
+	operations map[string]*operation // keyed by method and path template
+}
+
+func newServer() *Server {
+	return &Server{operations: make(map[string]*operation)}
+}
+
+func operationKey(method string, template string) string {
+	return method + " " + template
+}
+
+// ServeHTTP validates a request and writes a mock response.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.handler.ServeHTTP(w, req)
+}
+
+// respond writes the mock response of a request that has been matched and validated.
+func (s *Server) respond(w http.ResponseWriter, req *http.Request) {
+	m := middleware.MatchFromContext(req.Context())
+	if m == nil {
+		middleware.WriteProblem(w, &middleware.Problem{Status: http.StatusNotFound})
+		return
+	}
+	op := s.operations[operationKey(m.Method, m.Template)]
+	preferences := parsePrefer(req.Header["Prefer"])
+	r, status, err := op.selectResponse(preferences["code"])
+	if err != nil {
+		middleware.WriteProblem(w, &middleware.Problem{Status: http.StatusBadRequest, Detail: err.Error()})
+		return
+	}
+	if len(r.content) == 0 {
+		w.WriteHeader(status)
+		return
+	}
+	c := selectContent(r.content, req.Header.Get("Accept"))
+	if c == nil {
+		middleware.WriteProblem(w, &middleware.Problem{Status: http.StatusNotAcceptable,
+			Detail: fmt.Sprintf("no response of %s %s matches %q", m.Method, m.Template, req.Header.Get("Accept"))})
+		return
+	}
+	w.Header().Set("Content-Type", c.mediaType)
+	if len(c.examples) == 0 && c.synthesize == nil {
+		w.WriteHeader(status)
+		return
+	}
+	value, err := c.value(preferences["example"])
+	if err != nil {
+		w.Header().Del("Content-Type")
+		middleware.WriteProblem(w, &middleware.Problem{Status: http.StatusBadRequest, Detail: err.Error()})
+		return
+	}
+	var body []byte
+	if text, ok := value.(string); ok && !isJSON(c.mediaType) {
+		body = []byte(text)
+	} else if body, err = json.Marshal(value); err != nil {
+		middleware.WriteProblem(w, &middleware.Problem{Status: http.StatusInternalServerError, Detail: err.Error()})
+		return
+	}
+	w.WriteHeader(status)
+	if req.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// parsePrefer returns the preferences of Prefer headers, e.g. "code=404, example=missing".
+func parsePrefer(headers []string) map[string]string {
+	preferences := make(map[string]string)
+	for _, header := range headers {
+		for _, preference := range strings.Split(header, ",") {
+			parts := strings.SplitN(strings.TrimSpace(preference), "=", 2)
+			if len(parts) == 2 {
+				preferences[strings.ToLower(parts[0])] = strings.Trim(parts[1], `"`)
+			}
+		}
+	}
+	return preferences
+}
+
+// selectResponse returns the response for a requested status code and the
+// status to send. Without a request, the lowest successful status is chosen.
+func (op *operation) selectResponse(requested string) (*response, int, error) {
+	find := func(code string) *response {
+		for _, r := range op.responses {
+			if r.code == code {
+				return r
+			}
+		}
+		return nil
+	}
+	if requested != "" {
+		status, err := strconv.Atoi(requested)
+		if err != nil || status < 100 || status > 599 {
+			return nil, 0, fmt.Errorf("invalid status code %q", requested)
+		}
+		for _, code := range []string{requested, requested[:1] + "XX", "default"} {
+			if r := find(code); r != nil {
+				return r, status, nil
+			}
+		}
+		return nil, 0, fmt.Errorf("no response is defined for status %s", requested)
+	}
+	codes := make([]int, 0)
+	for _, r := range op.responses {
+		if status, err := strconv.Atoi(r.code); err == nil {
+			codes = append(codes, status)
+		}
+	}
+	sort.Ints(codes)
+	for _, status := range codes {
+		if status >= 200 && status < 300 {
+			return find(strconv.Itoa(status)), status, nil
+		}
+	}
+	if r := find("2XX"); r != nil {
+		return r, http.StatusOK, nil
+	}
+	if r := find("default"); r != nil {
+		return r, http.StatusOK, nil
+	}
+	if len(codes) > 0 {
+		return find(strconv.Itoa(codes[0])), codes[0], nil
+	}
+	return &response{}, http.StatusNoContent, nil
+}
+
+// selectContent returns the media type of a response that is accepted by
+// a client, preferring JSON.
+func selectContent(contents []*content, accept string) *content {
+	accepted := make([]string, 0)
+	for _, item := range strings.Split(accept, ",") {
+		if name, _, err := mime.ParseMediaType(strings.TrimSpace(item)); err == nil {
+			accepted = append(accepted, name)
+		}
+	}
+	matches := func(c *content) bool {
+		if len(accepted) == 0 {
+			return true
+		}
+		for _, name := range accepted {
+			if name == "*/*" || name == c.mediaType ||
+				(strings.HasSuffix(name, "/*") && strings.HasPrefix(c.mediaType, strings.TrimSuffix(name, "*"))) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, c := range contents {
+		if isJSON(c.mediaType) && matches(c) {
+			return c
+		}
+	}
+	for _, c := range contents {
+		if matches(c) {
+			return c
+		}
+	}
+	return nil
+}
+
+// value returns the named example, the first example or a synthesized value.
+func (c *content) value(name string) (interface{}, error) {
+	if name != "" {
+		for _, e := range c.examples {
+			if e.name == name {
+				return e.value, nil
+			}
+		}
+		return nil, fmt.Errorf("no example is named %q", name)
+	}
+	if len(c.examples) > 0 {
+		return c.examples[0].value, nil
+	}
+	if c.synthesize != nil {
+		return c.synthesize(), nil
+	}
+	return nil, nil
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// valueForYAML decodes the YAML text of an example.
+func valueForYAML(text string) (interface{}, bool) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(text), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
@@ -0,0 +1,249 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+const petstoreV3 = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+- url: /v1
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: integer
+      responses:
+        "200":
+          description: Pets.
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "201":
+          description: Created.
+  /pets/{petId}:
+    get:
+      parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: integer
+      responses:
+        "200":
+          description: A pet.
+          content:
+            application/json:
+              examples:
+                fluffy:
+                  value: {id: 1, name: Fluffy}
+                rex:
+                  value: {id: 2, name: Rex}
+            text/plain:
+              example: Fluffy
+        "404":
+          description: Not found.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Error'
+        default:
+          description: Error.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Error'
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+          minimum: 1
+        name:
+          type: string
+          minLength: 10
+        tag:
+          type: string
+          enum: [cat, dog]
+        born:
+          type: string
+          format: date
+        secret:
+          type: string
+          writeOnly: true
+        parent:
+          $ref: '#/components/schemas/Pet'
+    Error:
+      type: object
+      properties:
+        code:
+          type: integer
+          format: int32
+        message:
+          type: string
+          example: not found
+`
+
+const petstoreV2 = `
+swagger: "2.0"
+info:
+  title: Pets
+  version: 1.0.0
+basePath: /v1
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: Pets.
+          schema:
+            type: array
+            items:
+              $ref: '#/definitions/Pet'
+  /pets/{petId}:
+    get:
+      parameters:
+      - name: petId
+        in: path
+        required: true
+        type: integer
+      responses:
+        "200":
+          description: A pet.
+          schema:
+            $ref: '#/definitions/Pet'
+          examples:
+            application/json: {id: 1, name: Fluffy}
+definitions:
+  Pet:
+    type: object
+    properties:
+      id:
+        type: integer
+        format: int64
+      name:
+        type: string
+      weight:
+        type: number
+        minimum: 2.5
+        exclusiveMinimum: true
+`
+
+func get(t *testing.T, server http.Handler, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestOpenAPI3Server(t *testing.T) {
+	document, err := openapi_v3.ParseDocument([]byte(petstoreV3))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	server := NewServer(document, nil)
+	for _, test := range []struct {
+		target      string
+		headers     map[string]string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"/v1/pets", nil, 200, "application/json",
+			`[{"id":368,"name":"nova birch"}]`},
+		{"/v1/pets/1", nil, 200, "application/json", `{"id":1,"name":"Fluffy"}`},
+		{"/v1/pets/1", map[string]string{"Prefer": "example=rex"}, 200, "application/json", `{"id":2,"name":"Rex"}`},
+		{"/v1/pets/1", map[string]string{"Accept": "text/plain"}, 200, "text/plain", `Fluffy`},
+		{"/v1/pets/1", map[string]string{"Prefer": "code=404"}, 404, "application/json", `{"code":55,"message":"not found"}`},
+		{"/v1/pets/1", map[string]string{"Prefer": "code=503"}, 503, "application/json", `{"code":55,"message":"not found"}`},
+		{"/v1/pets/1", map[string]string{"Prefer": "code=200, example=missing"}, 400, "application/problem+json", ""},
+		{"/v1/pets/1", map[string]string{"Accept": "application/xml"}, 406, "application/problem+json", ""},
+		{"/v1/pets/fluffy", nil, 400, "application/problem+json", ""},
+		{"/v1/owners", nil, 404, "application/problem+json", ""},
+	} {
+		w := get(t, server, test.target, test.headers)
+		if w.Code != test.status {
+			t.Errorf("%s %v: expected status %d, found %d: %s", test.target, test.headers, test.status, w.Code, w.Body.String())
+			continue
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != test.contentType {
+			t.Errorf("%s %v: expected content type %s, found %s", test.target, test.headers, test.contentType, contentType)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s %v: expected body\n%s\nfound\n%s", test.target, test.headers, test.body, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/v1/pets", strings.NewReader(`{"id": 1, "name": "Fluffy the cat"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != 201 || w.Body.Len() != 0 {
+		t.Errorf("expected an empty 201 response, found %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOpenAPI2Server(t *testing.T) {
+	document, err := openapi_v2.ParseDocument([]byte(petstoreV2))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	server := NewOpenAPI2Server(document, nil)
+	for target, body := range map[string]string{
+		"/v1/pets":   `[{"id":290,"name":"alpha"}]`,
+		"/v1/pets/1": `{"id":1,"name":"Fluffy"}`,
+	} {
+		w := get(t, server, target, nil)
+		if w.Code != 200 || w.Body.String() != body {
+			t.Errorf("%s: expected %s, found %d: %s", target, body, w.Code, w.Body.String())
+		}
+	}
+	if w := get(t, server, "/v1/pets/fluffy", nil); w.Code != 400 {
+		t.Errorf("expected an invalid request, found %d: %s", w.Code, w.Body.String())
+	}
+	if w := get(t, server, "/v1/pets", map[string]string{"Prefer": "code=500"}); w.Code != 400 {
+		t.Errorf("expected an undefined status, found %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/google/gnostic/fake"
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	"github.com/google/gnostic/validate/middleware"
+)
+
+// NewOpenAPI2Server returns a mock server for an OpenAPI v2 description.
+// info is the yaml that the description was compiled from, or nil.
+func NewOpenAPI2Server(document *openapi_v2.Document, info *yaml.Node) *Server {
+	s := newServer()
+	s.handler = middleware.NewOpenAPI2Handler(document, http.HandlerFunc(s.respond), &middleware.Options{Source: info})
+	if document.Paths == nil {
+		return s
+	}
+	for _, pair := range document.Paths.Path {
+		item := pair.Value
+		for _, o := range []struct {
+			method    string
+			operation *openapi_v2.Operation
+		}{
+			{http.MethodGet, item.Get},
+			{http.MethodPut, item.Put},
+			{http.MethodPost, item.Post},
+			{http.MethodDelete, item.Delete},
+			{http.MethodOptions, item.Options},
+			{http.MethodHead, item.Head},
+			{http.MethodPatch, item.Patch},
+		} {
+			if o.operation == nil {
+				continue
+			}
+			produces := o.operation.Produces
+			if len(produces) == 0 {
+				produces = document.Produces
+			}
+			if len(produces) == 0 {
+				produces = []string{"application/json"}
+			}
+			op := &operation{}
+			if o.operation.Responses != nil {
+				for _, r := range o.operation.Responses.ResponseCode {
+					op.responses = append(op.responses,
+						newOpenAPI2Response(document, strings.ToUpper(r.Name), r.Value, produces))
+				}
+			}
+			s.operations[operationKey(o.method, pair.Name)] = op
+		}
+	}
+	return s
+}
+
+func newOpenAPI2Response(document *openapi_v2.Document, code string, item *openapi_v2.ResponseValue, produces []string) *response {
+	result := &response{code: code}
+	r := item.GetResponse()
+	if reference := item.GetJsonReference(); reference != nil && document.Responses != nil {
+		name := strings.TrimPrefix(reference.XRef, "#/responses/")
+		for _, pair := range document.Responses.AdditionalProperties {
+			if pair.Name == name {
+				r = pair.Value
+			}
+		}
+	}
+	if r == nil {
+		return result
+	}
+	schema := r.Schema.GetSchema()
+	for _, mediaType := range produces {
+		c := &content{mediaType: mediaType}
+		// Examples are keyed by MIME type.
+		if r.Examples != nil {
+			for _, pair := range r.Examples.AdditionalProperties {
+				if pair.Name != mediaType || pair.Value == nil {
+					continue
+				}
+				if v, ok := valueForYAML(pair.Value.Yaml); ok {
+					c.examples = append(c.examples, &example{name: pair.Name, value: v})
+				}
+			}
+		}
+		if schema != nil {
+			// Generators are not shared, so requests can be served concurrently, and
+			// each generator has the same seed, so repeated requests get the same response.
+			c.synthesize = func() interface{} {
+				return fake.NewGenerator(&fake.Options{OmitWriteOnly: true}).OpenAPI2(schema, document)
+			}
+		}
+		if len(c.examples) > 0 || c.synthesize != nil {
+			result.content = append(result.content, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/google/gnostic/fake"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	"github.com/google/gnostic/validate/middleware"
+)
+
+// NewServer returns a mock server for an OpenAPI v3 description.
+// info is the yaml that the description was compiled from, or nil.
+func NewServer(document *openapi_v3.Document, info *yaml.Node) *Server {
+	s := newServer()
+	s.handler = middleware.NewHandler(document, http.HandlerFunc(s.respond), &middleware.Options{Source: info})
+	if document.Paths == nil {
+		return s
+	}
+	for _, pair := range document.Paths.Path {
+		item := pair.Value
+		for _, o := range []struct {
+			method    string
+			operation *openapi_v3.Operation
+		}{
+			{http.MethodGet, item.Get},
+			{http.MethodPut, item.Put},
+			{http.MethodPost, item.Post},
+			{http.MethodDelete, item.Delete},
+			{http.MethodOptions, item.Options},
+			{http.MethodHead, item.Head},
+			{http.MethodPatch, item.Patch},
+			{http.MethodTrace, item.Trace},
+		} {
+			if o.operation == nil {
+				continue
+			}
+			op := &operation{}
+			if responses := o.operation.Responses; responses != nil {
+				for _, r := range responses.ResponseOrReference {
+					op.responses = append(op.responses,
+						newOpenAPI3Response(document, strings.ToUpper(r.Name), r.Value))
+				}
+				if responses.Default != nil {
+					op.responses = append(op.responses,
+						newOpenAPI3Response(document, "default", responses.Default))
+				}
+			}
+			s.operations[operationKey(o.method, pair.Name)] = op
+		}
+	}
+	return s
+}
+
+func newOpenAPI3Response(document *openapi_v3.Document, code string, item *openapi_v3.ResponseOrReference) *response {
+	result := &response{code: code}
+	r := item.GetResponse()
+	if reference := item.GetReference(); reference != nil && document.Components != nil && document.Components.Responses != nil {
+		name := strings.TrimPrefix(reference.XRef, "#/components/responses/")
+		for _, pair := range document.Components.Responses.AdditionalProperties {
+			if pair.Name == name {
+				r = pair.Value.GetResponse()
+			}
+		}
+	}
+	if r == nil || r.Content == nil {
+		return result
+	}
+	for _, pair := range r.Content.AdditionalProperties {
+		c := &content{mediaType: pair.Name}
+		mediaType := pair.Value
+		if mediaType.Example != nil {
+			if v, ok := valueForYAML(mediaType.Example.Yaml); ok {
+				c.examples = append(c.examples, &example{name: "example", value: v})
+			}
+		}
+		if mediaType.Examples != nil {
+			for _, named := range mediaType.Examples.AdditionalProperties {
+				e := openAPI3Example(document, named.Value)
+				if e == nil || e.Value == nil {
+					continue
+				}
+				if v, ok := valueForYAML(e.Value.Yaml); ok {
+					c.examples = append(c.examples, &example{name: named.Name, value: v})
+				}
+			}
+		}
+		if schema := mediaType.Schema; schema != nil {
+			// Generators are not shared, so requests can be served concurrently, and
+			// each generator has the same seed, so repeated requests get the same response.
+			c.synthesize = func() interface{} {
+				return fake.NewGenerator(&fake.Options{OmitWriteOnly: true}).OpenAPI3SchemaOrReference(schema, document)
+			}
+		}
+		result.content = append(result.content, c)
+	}
+	return result
+}
+
+func openAPI3Example(document *openapi_v3.Document, item *openapi_v3.ExampleOrReference) *openapi_v3.Example {
+	if e := item.GetExample(); e != nil {
+		return e
+	}
+	name := strings.TrimPrefix(item.GetReference().GetXRef(), "#/components/examples/")
+	if document.Components == nil || document.Components.Examples == nil {
+		return nil
+	}
+	for _, pair := range document.Components.Examples.AdditionalProperties {
+		if pair.Name == name {
+			return pair.Value.GetExample()
+		}
+	}
+	return nil
+}
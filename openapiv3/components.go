@@ -0,0 +1,97 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi_v3
+
+import "strings"
+
+// componentName returns the name of the component that a reference refers to,
+// or "" if it doesn't refer to a component with the prefix.
+func componentName(ref string, prefix string) string {
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(ref, prefix)
+}
+
+// ResolveParameter returns a parameter, or the parameter in the components of
+// a document that it refers to. References to other documents resolve to nil.
+func ResolveParameter(document *Document, item *ParameterOrReference) *Parameter {
+	if parameter := item.GetParameter(); parameter != nil {
+		return parameter
+	}
+	name := componentName(item.GetReference().GetXRef(), "#/components/parameters/")
+	if name == "" || document.GetComponents().GetParameters() == nil {
+		return nil
+	}
+	for _, pair := range document.Components.Parameters.AdditionalProperties {
+		if pair.Name == name {
+			return pair.Value.GetParameter()
+		}
+	}
+	return nil
+}
+
+// ResolveRequestBody returns a request body, or the request body in the
+// components of a document that it refers to.
+func ResolveRequestBody(document *Document, item *RequestBodyOrReference) *RequestBody {
+	if body := item.GetRequestBody(); body != nil {
+		return body
+	}
+	name := componentName(item.GetReference().GetXRef(), "#/components/requestBodies/")
+	if name == "" || document.GetComponents().GetRequestBodies() == nil {
+		return nil
+	}
+	for _, pair := range document.Components.RequestBodies.AdditionalProperties {
+		if pair.Name == name {
+			return pair.Value.GetRequestBody()
+		}
+	}
+	return nil
+}
+
+// ResolveResponse returns a response, or the response in the components of a
+// document that it refers to.
+func ResolveResponse(document *Document, item *ResponseOrReference) *Response {
+	if response := item.GetResponse(); response != nil {
+		return response
+	}
+	name := componentName(item.GetReference().GetXRef(), "#/components/responses/")
+	if name == "" || document.GetComponents().GetResponses() == nil {
+		return nil
+	}
+	for _, pair := range document.Components.Responses.AdditionalProperties {
+		if pair.Name == name {
+			return pair.Value.GetResponse()
+		}
+	}
+	return nil
+}
+
+// SchemaForContent returns the schema of the JSON media type of a request or
+// response body, or of its first media type.
+func SchemaForContent(content *MediaTypes) *SchemaOrReference {
+	if content == nil || len(content.AdditionalProperties) == 0 {
+		return nil
+	}
+	for _, pair := range content.AdditionalProperties {
+		if pair.Name == "application/json" && pair.Value != nil {
+			return pair.Value.Schema
+		}
+	}
+	if value := content.AdditionalProperties[0].Value; value != nil {
+		return value.Schema
+	}
+	return nil
+}
@@ -61,3 +61,51 @@ func TestParseDocument_Empty(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveComponents(t *testing.T) {
+	d, err := ParseDocument([]byte(`
+openapi: 3.0.0
+info:
+  title: Components
+  version: 1.0.0
+paths: {}
+components:
+  parameters:
+    limit:
+      name: limit
+      in: query
+  requestBodies:
+    pet:
+      content:
+        application/xml:
+          schema:
+            type: string
+        application/json:
+          schema:
+            type: object
+  responses:
+    empty:
+      description: Nothing.
+`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	parameter := ResolveParameter(d, &ParameterOrReference{Oneof: &ParameterOrReference_Reference{Reference: &Reference{XRef: "#/components/parameters/limit"}}})
+	if parameter == nil || parameter.Name != "limit" {
+		t.Errorf("unexpected parameter %+v", parameter)
+	}
+	body := ResolveRequestBody(d, &RequestBodyOrReference{Oneof: &RequestBodyOrReference_Reference{Reference: &Reference{XRef: "#/components/requestBodies/pet"}}})
+	if schema := SchemaForContent(body.GetContent()).GetSchema(); schema == nil || schema.Type != "object" {
+		t.Errorf("unexpected request body schema %+v", schema)
+	}
+	response := ResolveResponse(d, &ResponseOrReference{Oneof: &ResponseOrReference_Reference{Reference: &Reference{XRef: "#/components/responses/empty"}}})
+	if response == nil || response.Description != "Nothing." {
+		t.Errorf("unexpected response %+v", response)
+	}
+	// References to other documents and other kinds of components aren't resolved.
+	for _, ref := range []string{"other.yaml#/components/responses/empty", "#/components/parameters/limit"} {
+		if response := ResolveResponse(d, &ResponseOrReference{Oneof: &ResponseOrReference_Reference{Reference: &Reference{XRef: ref}}}); response != nil {
+			t.Errorf("unexpected response for %s %+v", ref, response)
+		}
+	}
+}
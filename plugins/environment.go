@@ -133,6 +133,11 @@ When the -plugin option is specified, these flags are ignored.`)
 		err = proto.Unmarshal(apiData, discoveryDocument)
 		if err == nil {
 			env.Request.AddModel("discovery.v1.Document", discoveryDocument)
+			// include experimental API surface model
+			surfaceModel, err := surface.NewModelFromDiscovery(discoveryDocument)
+			if err == nil {
+				env.Request.AddModel("surface.v1.Model", surfaceModel)
+			}
 			return env, err
 		}
 		// If we get here, we don't know what we got
@@ -204,6 +209,20 @@ func (request *Request) AddModel(modelType string, model proto.Message) error {
 	return err
 }
 
+// Diagnostics returns the diagnostics that gnostic found when it compiled the
+// API description. They are sent to plugins as a Messages model.
+func (request *Request) Diagnostics() []*Message {
+	for _, model := range request.Models {
+		if model.TypeUrl == "gnostic.plugin.v1.Messages" {
+			messages := &Messages{}
+			if err := proto.Unmarshal(model.Value, messages); err == nil {
+				return messages.Messages
+			}
+		}
+	}
+	return nil
+}
+
 func isFile(path string) bool {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
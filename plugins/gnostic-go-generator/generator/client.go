@@ -0,0 +1,363 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/gnostic/printer"
+	surface "github.com/google/gnostic/surface"
+)
+
+var pathVariable = regexp.MustCompile(`{([^}]+)}`)
+
+// credentialFields returns the names of the fields of the client that hold
+// the credentials of a security scheme.
+func credentialFields(s *surface.SecurityScheme) []string {
+	name := goName(s.Name)
+	switch {
+	case s.Type == "apiKey":
+		if !strings.HasSuffix(name, "Key") {
+			name += "Key"
+		}
+		return []string{name}
+	case s.Type == "http" && strings.ToLower(s.Scheme) == "basic":
+		return []string{name + "Username", name + "Password"}
+	default:
+		if !strings.HasSuffix(name, "Token") {
+			name += "Token"
+		}
+		return []string{name}
+	}
+}
+
+// securitySchemes returns the security schemes of the model that a method can be authorized with.
+func (g *Generator) securitySchemes(m *surface.Method) []*surface.SecurityScheme {
+	schemes := make([]*surface.SecurityScheme, 0)
+	used := make(map[string]bool)
+	for _, requirement := range m.Security {
+		for _, schemeRequirement := range requirement.Schemes {
+			if used[schemeRequirement.Name] {
+				continue
+			}
+			used[schemeRequirement.Name] = true
+			for _, s := range g.model.SecuritySchemes {
+				if s.Name == schemeRequirement.Name {
+					schemes = append(schemes, s)
+				}
+			}
+		}
+	}
+	return schemes
+}
+
+// fieldsWithPosition returns the fields of a type with a position.
+func fieldsWithPosition(t *surface.Type, position surface.Position) []*surface.Field {
+	fields := make([]*surface.Field, 0)
+	if t == nil {
+		return fields
+	}
+	for _, f := range t.Fields {
+		if f.Position == position {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// stringValue returns an expression that converts a value of a field to a string.
+func (g *Generator) stringValue(f *surface.Field, value string, imports map[string]bool) string {
+	switch {
+	case f.NativeType == "string":
+		return value
+	case g.underlyingType(f) == "string":
+		return "string(" + value + ")"
+	}
+	imports["fmt"] = true
+	return "fmt.Sprint(" + value + ")"
+}
+
+// printSetValues prints code that sets the values of fields with a set
+// function if they aren't zero values. Arrays set a value for each item.
+func (g *Generator) printSetValues(code *printer.Code, imports map[string]bool, fields []*surface.Field, set string) {
+	for _, f := range fields {
+		value := f.ParameterName
+		switch underlying := g.underlyingType(f); {
+		case strings.HasPrefix(underlying, "[]"):
+			code.Print("for _, value := range %s {", value)
+			if underlying == "[]string" {
+				code.Print("%s(%q, value)", strings.Replace(set, ".Set", ".Add", 1), f.Name)
+			} else {
+				imports["fmt"] = true
+				code.Print("%s(%q, fmt.Sprint(value))", strings.Replace(set, ".Set", ".Add", 1), f.Name)
+			}
+			code.Print("}")
+			continue
+		case underlying == "string":
+			code.Print("if %s != \"\" {", value)
+		case underlying == "bool":
+			code.Print("if %s {", value)
+		case underlying == "interface{}" || strings.HasPrefix(underlying, "map[") || strings.HasPrefix(f.NativeType, "*"):
+			code.Print("if %s != nil {", value)
+		default:
+			code.Print("if %s != 0 {", value)
+		}
+		code.Print("%s(%q, %s)", set, f.Name, g.stringValue(f, value, imports))
+		code.Print("}")
+	}
+}
+
+// renderClient writes a client with a method for each method of the model.
+func (g *Generator) renderClient(code *printer.Code, imports map[string]bool) {
+	imports["context"] = true
+	imports["net/http"] = true
+	imports["strings"] = true
+	if len(g.model.Servers) > 0 {
+		code.Print()
+		code.Print("// Servers are the URLs of the servers of the API.")
+		code.Print("var Servers = []string{")
+		for _, server := range g.model.Servers {
+			if server.Description != "" {
+				code.Print("%q, // %s", server.Url, server.Description)
+			} else {
+				code.Print("%q,", server.Url)
+			}
+		}
+		code.Print("}")
+	}
+	code.Print()
+	code.Print("// Client calls the methods of the %s API.", g.model.Name)
+	code.Print("type Client struct {")
+	code.Print("service string")
+	code.Print("// HTTPClient sends the requests of the client.")
+	code.Print("HTTPClient *http.Client")
+	for _, s := range g.model.SecuritySchemes {
+		code.Print("// Credentials of the %q security scheme, which are sent if they are set.", s.Name)
+		code.Print("%s string", strings.Join(credentialFields(s), ", "))
+	}
+	code.Print("}")
+	code.Print()
+	code.Print("// NewClient returns a client for the API at a URL, like one of the Servers.")
+	code.Print("// If httpClient is nil, http.DefaultClient is used.")
+	code.Print("func NewClient(service string, httpClient *http.Client) *Client {")
+	code.Print("if httpClient == nil {")
+	code.Print("httpClient = http.DefaultClient")
+	code.Print("}")
+	code.Print("return &Client{service: strings.TrimSuffix(service, \"/\"), HTTPClient: httpClient}")
+	code.Print("}")
+	for _, s := range g.model.SecuritySchemes {
+		g.renderAuthorize(code, s)
+	}
+	for _, m := range g.model.Methods {
+		g.renderClientMethod(code, imports, m)
+	}
+}
+
+// renderAuthorize writes a method that adds the credentials of a security scheme to requests.
+func (g *Generator) renderAuthorize(code *printer.Code, s *surface.SecurityScheme) {
+	fields := credentialFields(s)
+	code.Print()
+	code.Print("// authorize%s adds the credentials of the %q security scheme to a request.", goName(s.Name), s.Name)
+	code.Print("func (client *Client) authorize%s(request *http.Request) {", goName(s.Name))
+	switch {
+	case s.Type == "apiKey":
+		code.Print("if client.%s == \"\" {", fields[0])
+		code.Print("return")
+		code.Print("}")
+		switch s.In {
+		case "query":
+			code.Print("values := request.URL.Query()")
+			code.Print("values.Set(%q, client.%s)", s.ParameterName, fields[0])
+			code.Print("request.URL.RawQuery = values.Encode()")
+		case "cookie":
+			code.Print("request.AddCookie(&http.Cookie{Name: %q, Value: client.%s})", s.ParameterName, fields[0])
+		default:
+			code.Print("request.Header.Set(%q, client.%s)", s.ParameterName, fields[0])
+		}
+	case len(fields) == 2:
+		code.Print("if client.%s != \"\" || client.%s != \"\" {", fields[0], fields[1])
+		code.Print("request.SetBasicAuth(client.%s, client.%s)", fields[0], fields[1])
+		code.Print("}")
+	default:
+		scheme := "Bearer"
+		if s.Type == "http" && s.Scheme != "" {
+			scheme = strings.ToUpper(s.Scheme[:1]) + s.Scheme[1:]
+		}
+		code.Print("if client.%s != \"\" {", fields[0])
+		code.Print("request.Header.Set(\"Authorization\", \"%s \"+client.%s)", scheme, fields[0])
+		code.Print("}")
+	}
+	code.Print("}")
+}
+
+// renderClientMethod writes a method of the client that calls a method of the
+// API. Parameters of the method are parameters of the client method, and
+// responses that aren't successful are returned as errors unless the method
+// has a default response.
+func (g *Generator) renderClientMethod(code *printer.Code, imports map[string]bool, m *surface.Method) {
+	parameters := g.types[m.ParametersTypeName]
+	responses := g.types[m.ResponsesTypeName]
+	arguments := []string{"ctx context.Context"}
+	if parameters != nil {
+		for _, f := range parameters.Fields {
+			arguments = append(arguments, f.ParameterName+" "+f.NativeType)
+		}
+	}
+	results, failure := "error", "return err"
+	if responses != nil {
+		results, failure = "(*"+responses.TypeName+", error)", "return nil, err"
+	}
+	code.Print()
+	code.Print("// %s calls %s %s.", m.ClientName, m.Method, m.Path)
+	if m.Description != "" {
+		code.Print("//")
+		for _, line := range strings.Split(strings.TrimSpace(m.Description), "\n") {
+			code.Print("// %s", strings.TrimRight(line, " \t"))
+		}
+	}
+	code.Print("func (client *Client) %s(%s) %s {", m.ClientName, strings.Join(arguments, ", "), results)
+
+	// The path with the values of path parameters.
+	pathParameters := make(map[string]*surface.Field)
+	for _, f := range fieldsWithPosition(parameters, surface.Position_PATH) {
+		pathParameters[f.Name] = f
+	}
+	path := []string{"client.service"}
+	last := 0
+	for _, match := range pathVariable.FindAllStringSubmatchIndex(m.Path, -1) {
+		f := pathParameters[m.Path[match[2]:match[3]]]
+		if f == nil {
+			continue
+		}
+		imports["net/url"] = true
+		if match[0] > last {
+			path = append(path, strconv.Quote(m.Path[last:match[0]]))
+		}
+		path = append(path, "url.PathEscape("+g.stringValue(f, f.ParameterName, imports)+")")
+		last = match[1]
+	}
+	if last < len(m.Path) {
+		path = append(path, strconv.Quote(m.Path[last:]))
+	}
+	code.Print("path := %s", strings.Join(path, " + "))
+	if query := fieldsWithPosition(parameters, surface.Position_QUERY); len(query) > 0 {
+		imports["net/url"] = true
+		code.Print("values := url.Values{}")
+		g.printSetValues(code, imports, query, "values.Set")
+		code.Print("if len(values) > 0 {")
+		code.Print("path += \"?\" + values.Encode()")
+		code.Print("}")
+	}
+
+	// The body of the request, which is JSON or form data.
+	body, contentType := "nil", ""
+	form := fieldsWithPosition(parameters, surface.Position_FORMDATA)
+	if bodyFields := fieldsWithPosition(parameters, surface.Position_BODY); len(bodyFields) > 0 {
+		f := bodyFields[0]
+		imports["io"], imports["bytes"], imports["encoding/json"] = true, true, true
+		body, contentType = "body", "application/json"
+		code.Print("var body io.Reader")
+		nullable := strings.HasPrefix(f.NativeType, "*") || strings.HasPrefix(f.NativeType, "[]") || strings.HasPrefix(f.NativeType, "map[") || f.NativeType == "interface{}"
+		if nullable {
+			code.Print("if %s != nil {", f.ParameterName)
+		}
+		code.Print("data, err := json.Marshal(%s)", f.ParameterName)
+		code.Print("if err != nil {")
+		code.Print(failure)
+		code.Print("}")
+		code.Print("body = bytes.NewReader(data)")
+		if nullable {
+			code.Print("}")
+		}
+	} else if len(form) > 0 {
+		imports["net/url"] = true
+		body, contentType = "strings.NewReader(form.Encode())", "application/x-www-form-urlencoded"
+		code.Print("form := url.Values{}")
+		g.printSetValues(code, imports, form, "form.Set")
+	}
+	code.Print("request, err := http.NewRequest(%q, path, %s)", m.Method, body)
+	code.Print("if err != nil {")
+	code.Print(failure)
+	code.Print("}")
+	code.Print("request = request.WithContext(ctx)")
+	if contentType != "" {
+		code.Print("request.Header.Set(\"Content-Type\", %q)", contentType)
+	}
+	g.printSetValues(code, imports, fieldsWithPosition(parameters, surface.Position_HEADER), "request.Header.Set")
+	for _, s := range g.securitySchemes(m) {
+		code.Print("client.authorize%s(request)", goName(s.Name))
+	}
+	code.Print("response, err := client.HTTPClient.Do(request)")
+	code.Print("if err != nil {")
+	code.Print(failure)
+	code.Print("}")
+	code.Print("defer response.Body.Close()")
+	imports["fmt"] = true
+	if responses == nil {
+		code.Print("if response.StatusCode/100 != 2 {")
+		code.Print("return fmt.Errorf(\"%%s %%s: %%s\", request.Method, path, response.Status)")
+		code.Print("}")
+		code.Print("return nil")
+		code.Print("}")
+		return
+	}
+
+	// The responses, which are read for their status codes.
+	code.Print("responses := &%s{StatusCode: response.StatusCode}", responses.TypeName)
+	code.Print("switch {")
+	var defaultResponse *surface.Field
+	hasSuccessRange := false
+	for _, f := range responses.Fields {
+		if f.StatusCode == "default" {
+			defaultResponse = f
+			continue
+		}
+		if strings.HasSuffix(strings.ToUpper(f.StatusCode), "XX") {
+			hasSuccessRange = hasSuccessRange || f.StatusCode[0] == '2'
+			code.Print("case response.StatusCode/100 == %c:", f.StatusCode[0])
+		} else {
+			code.Print("case response.StatusCode == %s:", f.StatusCode)
+		}
+		g.printDecodeResponse(code, imports, f)
+	}
+	if !hasSuccessRange {
+		code.Print("// Successful responses without values have no fields.")
+		code.Print("case response.StatusCode/100 == 2:")
+	}
+	code.Print("default:")
+	if defaultResponse != nil {
+		g.printDecodeResponse(code, imports, defaultResponse)
+	} else {
+		code.Print("return nil, fmt.Errorf(\"%%s %%s: %%s\", request.Method, path, response.Status)")
+	}
+	code.Print("}")
+	code.Print("return responses, nil")
+	code.Print("}")
+}
+
+// printDecodeResponse prints code that reads the value of a response.
+// Responses without a body are set to true.
+func (g *Generator) printDecodeResponse(code *printer.Code, imports map[string]bool, f *surface.Field) {
+	if !hasBody(f) {
+		code.Print("responses.%s = true", f.FieldName)
+		return
+	}
+	imports["encoding/json"] = true
+	code.Print("if err := json.NewDecoder(response.Body).Decode(&responses.%s); err != nil {", f.FieldName)
+	code.Print("return nil, err")
+	code.Print("}")
+}
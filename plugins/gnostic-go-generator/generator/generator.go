@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package generator converts surface models of APIs into Go packages with
+// types, a client, and net/http server handlers.
+package generator
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	plugins "github.com/google/gnostic/plugins"
+	"github.com/google/gnostic/printer"
+	surface "github.com/google/gnostic/surface"
+)
+
+// Generator builds Go files from a surface model.
+type Generator struct {
+	model       *surface.Model
+	packageName string
+	types       map[string]*surface.Type // types of the model by name
+}
+
+// NewGenerator creates a generator for a model. If packageName is empty, a
+// package name is derived from the name of the model. The generator fills the
+// language-specific names and types of the model.
+func NewGenerator(model *surface.Model, packageName string) *Generator {
+	if packageName == "" {
+		packageName = defaultPackageName(model)
+	}
+	g := &Generator{model: model, packageName: packageName}
+	g.prepare()
+	return g
+}
+
+// defaultPackageName derives a package name from the name of a model.
+func defaultPackageName(model *surface.Model) string {
+	name := ""
+	for _, r := range strings.ToLower(model.Name) {
+		if unicode.IsLetter(r) || (unicode.IsDigit(r) && name != "") {
+			name += string(r)
+		}
+	}
+	if name == "" {
+		return "api"
+	}
+	return name
+}
+
+// Generate returns the generated files: types.go, client.go and server.go.
+func (g *Generator) Generate() ([]*plugins.File, error) {
+	files := make([]*plugins.File, 0)
+	for _, file := range []struct {
+		name   string
+		render func(code *printer.Code, imports map[string]bool)
+	}{
+		{"types.go", g.renderTypes},
+		{"client.go", g.renderClient},
+		{"server.go", g.renderServer},
+	} {
+		code := &printer.Code{}
+		imports := make(map[string]bool)
+		file.render(code, imports)
+		data, err := g.format(code, imports)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate %s: %v", file.name, err)
+		}
+		files = append(files, &plugins.File{Name: file.name, Data: data})
+	}
+	return files, nil
+}
+
+// format adds the header and imports to the code of a file and formats it.
+func (g *Generator) format(body *printer.Code, imports map[string]bool) ([]byte, error) {
+	code := &printer.Code{}
+	code.Print("// Code generated by gnostic-go-generator. DO NOT EDIT.")
+	code.Print()
+	code.Print("package %s", g.packageName)
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		code.Print()
+		code.Print("import (")
+		for _, path := range paths {
+			code.Print("%q", path)
+		}
+		code.Print(")")
+	}
+	return format.Source([]byte(code.String() + body.String()))
+}
+
+// printComment prints text as a comment, which starts with a name if the text doesn't.
+func printComment(code *printer.Code, name, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if !strings.HasPrefix(text, name+" ") {
+		text = name + ": " + text
+	}
+	for _, line := range strings.Split(text, "\n") {
+		code.Print("// %s", strings.TrimRight(line, " \t"))
+	}
+}
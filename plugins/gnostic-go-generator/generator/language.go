@@ -0,0 +1,356 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"go/token"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/proto"
+
+	surface "github.com/google/gnostic/surface"
+)
+
+// Words that are written in upper case in Go names.
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// Names that can't be used for parameters because the generated code uses them.
+var reservedNames = map[string]bool{
+	"body": true, "client": true, "ctx": true, "data": true, "err": true, "form": true, "path": true,
+	"request": true, "response": true, "responses": true, "result": true, "values": true,
+}
+
+// splitWords splits a name into words at non-alphanumeric characters and at
+// changes of case.
+func splitWords(name string) []string {
+	words := make([]string, 0)
+	runes := []rune(name)
+	word := make([]rune, 0)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			previous := word[len(word)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// goName returns an exported Go name for a name from an API description.
+func goName(name string) string {
+	result := ""
+	for _, word := range splitWords(name) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			result += upper
+		} else {
+			runes := []rune(word)
+			result += string(unicode.ToUpper(runes[0])) + string(runes[1:])
+		}
+	}
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
+
+// parameterName returns an unexported Go name for a parameter of a function.
+func parameterName(name string) string {
+	words := splitWords(goName(name))
+	first := words[0]
+	if initialisms[first] {
+		first = strings.ToLower(first)
+	} else {
+		runes := []rune(first)
+		first = string(unicode.ToLower(runes[0])) + string(runes[1:])
+	}
+	result := first + strings.Join(words[1:], "")
+	if token.Lookup(result).IsKeyword() || reservedNames[result] {
+		result += "Parameter"
+	}
+	return result
+}
+
+// statusName returns a Go name for a response with a status code, like "OK"
+// for "200" and "Default" for "default".
+func statusName(statusCode string) string {
+	if statusCode == "default" {
+		return "Default"
+	}
+	code := 0
+	for _, c := range statusCode {
+		if c < '0' || c > '9' {
+			return "Status" + strings.ToUpper(statusCode)
+		}
+		code = code*10 + int(c-'0')
+	}
+	if text := http.StatusText(code); text != "" {
+		return goName(text)
+	}
+	return "Status" + statusCode
+}
+
+// isJSON returns true if a media type is a JSON media type. Fields without
+// media types are JSON values.
+func isJSON(mediaType string) bool {
+	return mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isValueType returns true if a type is represented with the type of its only
+// field. The surface model builds such types for enums and for schemas that
+// are scalars, arrays or references.
+func isValueType(t *surface.Type) bool {
+	if t.Kind == surface.TypeKind_ENUM {
+		return true
+	}
+	return t.Kind == surface.TypeKind_STRUCT && len(t.Fields) == 1 && t.Fields[0].Name == "value"
+}
+
+// isMapType returns true if a type is represented with a map.
+func isMapType(t *surface.Type) bool {
+	if t.Kind == surface.TypeKind_OBJECT {
+		return true
+	}
+	return t.Kind == surface.TypeKind_STRUCT && len(t.Fields) == 1 && t.Fields[0].Kind == surface.FieldKind_MAP
+}
+
+// scalarType returns the Go type of a scalar with a type and format.
+func scalarType(typeName, format string) string {
+	switch typeName {
+	case "integer":
+		if format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "string":
+		return "string"
+	// Formats name the values of maps.
+	case "int32", "int64":
+		return typeName
+	case "float":
+		return "float32"
+	case "double":
+		return "float64"
+	case "byte", "binary", "date", "date-time", "password":
+		return "string"
+	}
+	return "interface{}"
+}
+
+// prepare fills the language-specific names and types of the model and
+// rewrites the parts of the model that the generated code represents
+// differently.
+func (g *Generator) prepare() {
+	for _, t := range g.model.Types {
+		t.TypeName = goName(t.Name)
+	}
+	g.types = make(map[string]*surface.Type)
+	for _, t := range g.model.Types {
+		g.types[t.Name] = t
+	}
+	removed := make(map[*surface.Type]bool)
+	for _, m := range g.model.Methods {
+		if parameters := g.types[m.ParametersTypeName]; parameters != nil {
+			g.prepareParameters(parameters, removed)
+		}
+		if responses := g.types[m.ResponsesTypeName]; responses != nil {
+			g.prepareResponses(responses)
+		}
+		m.ProcessorName = goName(m.Name)
+		m.ClientName = goName(m.Name)
+		m.HandlerName = "Handle" + goName(m.Name)
+	}
+	types := make([]*surface.Type, 0)
+	for _, t := range g.model.Types {
+		if !removed[t] {
+			types = append(types, t)
+		}
+	}
+	g.model.Types = types
+	for _, t := range g.model.Types {
+		methodType := g.isMethodType(t)
+		for _, f := range t.Fields {
+			f.FieldName = goName(f.Name)
+			if f.StatusCode != "" && methodType {
+				f.FieldName = statusName(f.StatusCode)
+			}
+			f.ParameterName = parameterName(f.Name)
+			// Responses and alternatives of unions are nil if they aren't set.
+			f.NativeType = g.nativeType(f, (f.StatusCode != "" && methodType) || t.Kind == surface.TypeKind_UNION)
+			if f.StatusCode != "" && methodType && !hasBody(f) {
+				f.NativeType = "bool"
+			}
+			f.Serialize = !methodType && !(f.Kind == surface.FieldKind_MAP && len(t.Fields) > 1) && !g.isUnionField(f)
+		}
+	}
+}
+
+// prepareParameters replaces request bodies with their JSON values and
+// references to parameter definitions with the parameters that they define.
+func (g *Generator) prepareParameters(parameters *surface.Type, removed map[*surface.Type]bool) {
+	fields := make([]*surface.Field, 0)
+	for _, f := range parameters.Fields {
+		target := g.types[f.Type]
+		if f.Kind != surface.FieldKind_REFERENCE || target == nil {
+			fields = append(fields, f)
+			continue
+		}
+		if f.Name == "request_body" && len(target.Fields) > 0 && target.Fields[0].MediaType != "" {
+			// The request body of an OpenAPI v3 operation has a field for each media type.
+			removed[target] = true
+			for _, alternative := range target.Fields {
+				if isJSON(alternative.MediaType) {
+					body := proto.Clone(alternative).(*surface.Field)
+					body.Name, body.Position, body.MediaType = f.Name, surface.Position_BODY, ""
+					fields = append(fields, body)
+					break
+				}
+			}
+			continue
+		}
+		if f.Position != surface.Position_BODY && len(target.Fields) == 1 && target.Fields[0].Position == f.Position {
+			parameter := proto.Clone(target.Fields[0]).(*surface.Field)
+			fields = append(fields, parameter)
+			continue
+		}
+		fields = append(fields, f)
+	}
+	parameters.Fields = fields
+}
+
+// prepareResponses keeps the first JSON response for each status code.
+// Responses without a body are kept too.
+func (g *Generator) prepareResponses(responses *surface.Type) {
+	fields := make([]*surface.Field, 0)
+	statusCodes := make(map[string]bool)
+	for _, f := range responses.Fields {
+		if f.StatusCode == "" || !isJSON(f.MediaType) || statusCodes[f.StatusCode] {
+			continue
+		}
+		statusCodes[f.StatusCode] = true
+		fields = append(fields, f)
+	}
+	responses.Fields = fields
+}
+
+// hasBody returns true if a response has a body. The surface model describes
+// responses without a body with fields without a type.
+func hasBody(f *surface.Field) bool {
+	return f.Type != ""
+}
+
+// isResponsesType returns true if a type holds the responses of a method.
+func (g *Generator) isResponsesType(t *surface.Type) bool {
+	for _, m := range g.model.Methods {
+		if m.ResponsesTypeName == t.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isMethodType returns true if a type holds the parameters or responses of a method.
+func (g *Generator) isMethodType(t *surface.Type) bool {
+	for _, m := range g.model.Methods {
+		if m.ParametersTypeName == t.Name || m.ResponsesTypeName == t.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnionField returns true if a field refers to the alternatives of a schema
+// that also has properties, which are written with the properties.
+func (g *Generator) isUnionField(f *surface.Field) bool {
+	t := g.types[f.Type]
+	return f.Name == "one_of" && f.Kind == surface.FieldKind_REFERENCE && t != nil && t.Kind == surface.TypeKind_UNION
+}
+
+// nativeType returns the Go type of a field. Structs are referred to with
+// pointers, and so are all optional values.
+func (g *Generator) nativeType(f *surface.Field, optional bool) string {
+	switch f.Kind {
+	case surface.FieldKind_ARRAY:
+		return "[]" + g.elementType(f.Type, f.Format)
+	case surface.FieldKind_MAP:
+		valueType := strings.TrimPrefix(f.Type, "map[string]")
+		if strings.HasPrefix(valueType, "[]") {
+			return "map[string][]" + g.elementType(strings.TrimPrefix(valueType, "[]"), "")
+		}
+		return "map[string]" + g.elementType(valueType, "")
+	case surface.FieldKind_ANY:
+		return "interface{}"
+	case surface.FieldKind_REFERENCE:
+		t := g.types[f.Type]
+		if t == nil {
+			return "interface{}"
+		}
+		if isMapType(t) || (isValueType(t) && strings.HasPrefix(g.nativeType(t.Fields[0], false), "[]")) {
+			return t.TypeName
+		}
+		if isValueType(t) && !optional {
+			return t.TypeName
+		}
+		return "*" + t.TypeName
+	}
+	native := scalarType(f.Type, f.Format)
+	if optional && native != "interface{}" {
+		return "*" + native
+	}
+	return native
+}
+
+// elementType returns the Go type of the values of arrays and maps, which are
+// types of the model or scalars.
+func (g *Generator) elementType(typeName, format string) string {
+	if t := g.types[typeName]; t != nil {
+		return t.TypeName
+	}
+	return scalarType(typeName, format)
+}
+
+// underlyingType returns the Go type of the values of a field without the
+// types of the model that represent them.
+func (g *Generator) underlyingType(f *surface.Field) string {
+	if t := g.types[f.Type]; t != nil && f.Kind == surface.FieldKind_REFERENCE && isValueType(t) {
+		return g.underlyingType(t.Fields[0])
+	}
+	return strings.TrimPrefix(f.NativeType, "*")
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import "testing"
+
+func TestGoName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"pet":          "Pet",
+		"petId":        "PetID",
+		"showPetById":  "ShowPetByID",
+		"api_key":      "APIKey",
+		"X-Rate-Limit": "XRateLimit",
+		"HTTPServer":   "HTTPServer",
+		"2fa":          "X2fa",
+		"":             "X",
+	} {
+		if got := goName(name); got != expected {
+			t.Errorf("goName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestParameterName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"petId":   "petID",
+		"ID":      "id",
+		"api_key": "apiKey",
+		"type":    "typeParameter",
+		"body":    "bodyParameter",
+	} {
+		if got := parameterName(name); got != expected {
+			t.Errorf("parameterName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestStatusName(t *testing.T) {
+	for statusCode, expected := range map[string]string{
+		"200":     "OK",
+		"201":     "Created",
+		"404":     "NotFound",
+		"default": "Default",
+		"2XX":     "Status2XX",
+		"299":     "Status299",
+	} {
+		if got := statusName(statusCode); got != expected {
+			t.Errorf("statusName(%q) = %q, expected %q", statusCode, got, expected)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/gnostic/printer"
+	surface "github.com/google/gnostic/surface"
+)
+
+// renderServer writes a service interface with a method for each method of
+// the model and an http.Handler that calls a service.
+func (g *Generator) renderServer(code *printer.Code, imports map[string]bool) {
+	imports["context"] = true
+	imports["encoding/json"] = true
+	imports["net/http"] = true
+	imports["net/url"] = true
+	imports["strings"] = true
+	code.Print()
+	code.Print("// Service implements the methods of the %s API.", g.model.Name)
+	code.Print("type Service interface {")
+	for _, m := range g.model.Methods {
+		code.Print("// %s handles %s %s.", m.ProcessorName, m.Method, m.Path)
+		code.Print("%s(%s) %s", m.ProcessorName, g.serviceArguments(m), g.serviceResults(m))
+	}
+	code.Print("}")
+	code.Print()
+	code.Print("// Handler serves the methods of the %s API with a service.", g.model.Name)
+	code.Print("// Paths are relative to the URL of a server of the API.")
+	code.Print("type Handler struct {")
+	code.Print("Service Service")
+	code.Print("}")
+	code.Print()
+	code.Print("// NewHandler returns a handler that serves the API with a service.")
+	code.Print("func NewHandler(service Service) http.Handler {")
+	code.Print("return &Handler{Service: service}")
+	code.Print("}")
+
+	// Paths with fewer variables are matched first, so that literal
+	// segments take precedence over variables.
+	methods := append([]*surface.Method{}, g.model.Methods...)
+	sort.SliceStable(methods, func(i, j int) bool {
+		return len(pathVariable.FindAllString(methods[i].Path, -1)) < len(pathVariable.FindAllString(methods[j].Path, -1))
+	})
+	code.Print()
+	code.Print("// ServeHTTP calls the handler of the method that matches a request.")
+	code.Print("func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {")
+	code.Print("switch {")
+	for _, m := range methods {
+		code.Print("case matchPath(r, %q, %q) != nil:", m.Method, m.Path)
+		code.Print("h.%s(w, r)", m.HandlerName)
+	}
+	code.Print("default:")
+	code.Print("http.NotFound(w, r)")
+	code.Print("}")
+	code.Print("}")
+	for _, m := range g.model.Methods {
+		g.renderHandler(code, imports, m)
+	}
+	code.Print()
+	code.Print("// matchPath returns the values of the variables of a path pattern if a request")
+	code.Print("// has a method and a path that match the pattern, and nil otherwise.")
+	code.Print("func matchPath(r *http.Request, method, pattern string) map[string]string {")
+	code.Print("if r.Method != method {")
+	code.Print("return nil")
+	code.Print("}")
+	code.Print("segments := strings.Split(strings.Trim(r.URL.EscapedPath(), \"/\"), \"/\")")
+	code.Print("parts := strings.Split(strings.Trim(pattern, \"/\"), \"/\")")
+	code.Print("if len(segments) != len(parts) {")
+	code.Print("return nil")
+	code.Print("}")
+	code.Print("variables := make(map[string]string)")
+	code.Print("for i, part := range parts {")
+	code.Print("segment, err := url.PathUnescape(segments[i])")
+	code.Print("if err != nil {")
+	code.Print("return nil")
+	code.Print("}")
+	code.Print("if strings.HasPrefix(part, \"{\") && strings.HasSuffix(part, \"}\") {")
+	code.Print("variables[part[1:len(part)-1]] = segment")
+	code.Print("} else if part != segment {")
+	code.Print("return nil")
+	code.Print("}")
+	code.Print("}")
+	code.Print("return variables")
+	code.Print("}")
+	code.Print()
+	code.Print("// responseStatus returns the status code of a response, or the declared")
+	code.Print("// status code if the response doesn't have one.")
+	code.Print("func responseStatus(statusCode, declared int) int {")
+	code.Print("if statusCode != 0 {")
+	code.Print("return statusCode")
+	code.Print("}")
+	code.Print("return declared")
+	code.Print("}")
+	code.Print()
+	code.Print("// writeJSON writes a value as the JSON body of a response.")
+	code.Print("func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {")
+	code.Print("data, err := json.Marshal(value)")
+	code.Print("if err != nil {")
+	code.Print("http.Error(w, err.Error(), http.StatusInternalServerError)")
+	code.Print("return")
+	code.Print("}")
+	code.Print("w.Header().Set(\"Content-Type\", \"application/json\")")
+	code.Print("w.WriteHeader(statusCode)")
+	code.Print("w.Write(data)")
+	code.Print("}")
+}
+
+// serviceArguments returns the arguments of the method of the service interface for a method.
+func (g *Generator) serviceArguments(m *surface.Method) string {
+	if parameters := g.types[m.ParametersTypeName]; parameters != nil {
+		return "ctx context.Context, parameters *" + parameters.TypeName
+	}
+	return "ctx context.Context"
+}
+
+// serviceResults returns the results of the method of the service interface for a method.
+func (g *Generator) serviceResults(m *surface.Method) string {
+	if responses := g.types[m.ResponsesTypeName]; responses != nil {
+		return "(*" + responses.TypeName + ", error)"
+	}
+	return "error"
+}
+
+// renderHandler writes a handler that reads the parameters of a method from
+// a request, calls the service and writes the response that the service
+// returns. Responses are written as JSON with the first value that is set,
+// and responses without a body are written if they are true.
+func (g *Generator) renderHandler(code *printer.Code, imports map[string]bool, m *surface.Method) {
+	parameters := g.types[m.ParametersTypeName]
+	responses := g.types[m.ResponsesTypeName]
+	code.Print()
+	code.Print("// %s handles %s %s with the %s method of the service.", m.HandlerName, m.Method, m.Path, m.ProcessorName)
+	code.Print("func (h *Handler) %s(w http.ResponseWriter, r *http.Request) {", m.HandlerName)
+	arguments := "r.Context()"
+	if parameters != nil {
+		arguments += ", parameters"
+		code.Print("parameters := &%s{}", parameters.TypeName)
+		if fields := fieldsWithPosition(parameters, surface.Position_PATH); len(fields) > 0 {
+			code.Print("variables := matchPath(r, %q, %q)", m.Method, m.Path)
+			for _, f := range fields {
+				g.printReadParameter(code, imports, f, "variables[%q]", "")
+			}
+		}
+		if fields := fieldsWithPosition(parameters, surface.Position_QUERY); len(fields) > 0 {
+			code.Print("query := r.URL.Query()")
+			for _, f := range fields {
+				g.printReadParameter(code, imports, f, "query.Get(%q)", "query[%q]")
+			}
+		}
+		for _, f := range fieldsWithPosition(parameters, surface.Position_HEADER) {
+			g.printReadParameter(code, imports, f, "r.Header.Get(%q)", "r.Header[http.CanonicalHeaderKey(%q)]")
+		}
+		if fields := fieldsWithPosition(parameters, surface.Position_FORMDATA); len(fields) > 0 {
+			code.Print("if err := r.ParseForm(); err != nil {")
+			code.Print("http.Error(w, err.Error(), http.StatusBadRequest)")
+			code.Print("return")
+			code.Print("}")
+			for _, f := range fields {
+				g.printReadParameter(code, imports, f, "r.PostForm.Get(%q)", "r.PostForm[%q]")
+			}
+		}
+		for _, f := range fieldsWithPosition(parameters, surface.Position_BODY) {
+			imports["io"] = true
+			code.Print("if err := json.NewDecoder(r.Body).Decode(&parameters.%s); err != nil && err != io.EOF {", f.FieldName)
+			code.Print("http.Error(w, err.Error(), http.StatusBadRequest)")
+			code.Print("return")
+			code.Print("}")
+		}
+	}
+	if responses == nil {
+		code.Print("if err := h.Service.%s(%s); err != nil {", m.ProcessorName, arguments)
+		code.Print("http.Error(w, err.Error(), http.StatusInternalServerError)")
+		code.Print("return")
+		code.Print("}")
+		code.Print("w.WriteHeader(http.StatusNoContent)")
+		code.Print("}")
+		return
+	}
+	code.Print("responses, err := h.Service.%s(%s)", m.ProcessorName, arguments)
+	code.Print("if err != nil {")
+	code.Print("http.Error(w, err.Error(), http.StatusInternalServerError)")
+	code.Print("return")
+	code.Print("}")
+	code.Print("switch {")
+	code.Print("case responses == nil:")
+	code.Print("w.WriteHeader(http.StatusNoContent)")
+	for _, f := range responses.Fields {
+		if !hasBody(f) {
+			code.Print("case responses.%s:", f.FieldName)
+			code.Print("w.WriteHeader(responseStatus(responses.StatusCode, %s))", statusCodeValue(f.StatusCode))
+			continue
+		}
+		code.Print("case responses.%s != nil:", f.FieldName)
+		code.Print("writeJSON(w, responseStatus(responses.StatusCode, %s), responses.%s)", statusCodeValue(f.StatusCode), f.FieldName)
+	}
+	code.Print("default:")
+	code.Print("w.WriteHeader(responseStatus(responses.StatusCode, http.StatusNoContent))")
+	code.Print("}")
+	code.Print("}")
+}
+
+// statusCodeValue returns the status code that is written for a response
+// when its StatusCode isn't set. Ranges of status codes are written with
+// their first code and default responses are written as server errors.
+func statusCodeValue(statusCode string) string {
+	switch {
+	case statusCode == "default":
+		return "http.StatusInternalServerError"
+	case strings.HasSuffix(strings.ToUpper(statusCode), "XX"):
+		return statusCode[:1] + "00"
+	}
+	return statusCode
+}
+
+// printReadParameter prints code that reads a parameter from a request.
+// Single values are read with the get expression and arrays with the list
+// expression, which are formats for the name of the parameter.
+func (g *Generator) printReadParameter(code *printer.Code, imports map[string]bool, f *surface.Field, get, list string) {
+	fail := func() {
+		code.Print("if err != nil {")
+		code.Print("http.Error(w, err.Error(), http.StatusBadRequest)")
+		code.Print("return")
+		code.Print("}")
+	}
+	if strings.HasPrefix(f.NativeType, "[]") && list != "" {
+		elementType := strings.TrimPrefix(f.NativeType, "[]")
+		code.Print("for _, value := range "+list+" {", f.Name)
+		g.printParseValue(code, imports, elementType, g.elementUnderlyingType(f), fail, func(value string) {
+			code.Print("parameters.%s = append(parameters.%s, %s)", f.FieldName, f.FieldName, value)
+		})
+		code.Print("}")
+		return
+	}
+	code.Print("if value := "+get+"; value != \"\" {", f.Name)
+	g.printParseValue(code, imports, f.NativeType, g.underlyingType(f), fail, func(value string) {
+		code.Print("parameters.%s = %s", f.FieldName, value)
+	})
+	code.Print("}")
+}
+
+// elementUnderlyingType returns the Go type of the values of the items of an array.
+func (g *Generator) elementUnderlyingType(f *surface.Field) string {
+	if t := g.types[f.Type]; t != nil && isValueType(t) {
+		return g.underlyingType(t.Fields[0])
+	}
+	return scalarType(f.Type, f.Format)
+}
+
+// printParseValue prints code that converts a string named value to a Go
+// type and passes an expression for the result to assign. Values that
+// aren't scalars are read as JSON.
+func (g *Generator) printParseValue(code *printer.Code, imports map[string]bool, nativeType, underlying string, fail func(), assign func(value string)) {
+	convert := func(value string) string {
+		if nativeType == underlying {
+			return value
+		}
+		return nativeType + "(" + value + ")"
+	}
+	switch underlying {
+	case "string":
+		assign(convert("value"))
+		return
+	case "interface{}":
+		if nativeType == underlying {
+			assign("value")
+			return
+		}
+	case "bool":
+		imports["strconv"] = true
+		code.Print("v, err := strconv.ParseBool(value)")
+		fail()
+		assign(convert("v"))
+		return
+	case "int32", "int64":
+		imports["strconv"] = true
+		code.Print("v, err := strconv.ParseInt(value, 10, %s)", underlying[3:])
+		fail()
+		if underlying == "int64" && nativeType == underlying {
+			assign("v")
+		} else {
+			assign(nativeType + "(v)")
+		}
+		return
+	case "float32", "float64":
+		imports["strconv"] = true
+		code.Print("v, err := strconv.ParseFloat(value, %s)", underlying[5:])
+		fail()
+		if underlying == "float64" && nativeType == underlying {
+			assign("v")
+		} else {
+			assign(nativeType + "(v)")
+		}
+		return
+	}
+	code.Print("var v %s", nativeType)
+	code.Print("err := json.Unmarshal([]byte(value), &v)")
+	fail()
+	assign("v")
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/gnostic/printer"
+	surface "github.com/google/gnostic/surface"
+)
+
+// renderTypes writes a Go type for each type of the model.
+func (g *Generator) renderTypes(code *printer.Code, imports map[string]bool) {
+	hasUnions := false
+	for _, t := range g.model.Types {
+		code.Print()
+		description := t.Description
+		if strings.HasPrefix(description, t.Name+" ") {
+			description = t.TypeName + strings.TrimPrefix(description, t.Name)
+		}
+		printComment(code, t.TypeName, description)
+		switch {
+		case t.Kind == surface.TypeKind_UNION:
+			g.renderUnion(code, imports, t)
+			hasUnions = true
+		case isMapType(t):
+			if t.Kind == surface.TypeKind_OBJECT {
+				code.Print("type %s map[string]interface{}", t.TypeName)
+			} else {
+				code.Print("type %s %s", t.TypeName, t.Fields[0].NativeType)
+			}
+		case isValueType(t):
+			code.Print("type %s %s", t.TypeName, strings.TrimPrefix(t.Fields[0].NativeType, "*"))
+			if t.Kind == surface.TypeKind_ENUM {
+				g.renderEnumValues(code, t)
+			}
+		default:
+			g.renderStruct(code, t)
+		}
+	}
+	if hasUnions {
+		code.Print()
+		code.Print("// unmarshalStrict reads a JSON value that mustn't have fields that a Go value doesn't have.")
+		code.Print("func unmarshalStrict(data []byte, value interface{}) error {")
+		code.Print("decoder := json.NewDecoder(bytes.NewReader(data))")
+		code.Print("decoder.DisallowUnknownFields()")
+		code.Print("return decoder.Decode(value)")
+		code.Print("}")
+		imports["bytes"] = true
+	}
+}
+
+// renderStruct writes a struct with a field for each field of a type.
+// Parameters and responses of methods aren't written as JSON, so their fields
+// have no tags, and responses also have the status code of the response.
+func (g *Generator) renderStruct(code *printer.Code, t *surface.Type) {
+	methodType := g.isMethodType(t)
+	code.Print("type %s struct {", t.TypeName)
+	for _, f := range t.Fields {
+		switch {
+		case methodType:
+			code.Print("%s %s", f.FieldName, f.NativeType)
+		case f.Serialize:
+			code.Print("%s %s `json:\"%s,omitempty\"`", f.FieldName, f.NativeType, f.Name)
+		default:
+			code.Print("%s %s `json:\"-\"`", f.FieldName, f.NativeType)
+		}
+	}
+	if g.isResponsesType(t) {
+		code.Print("// StatusCode is the status code of the response. Clients set it, and")
+		code.Print("// servers write it if it isn't zero instead of the declared status code,")
+		code.Print("// which is 500 for default responses and the first code of ranges.")
+		code.Print("StatusCode int")
+	}
+	code.Print("}")
+}
+
+// renderEnumValues writes a constant for each value of an enum.
+func (g *Generator) renderEnumValues(code *printer.Code, t *surface.Type) {
+	f := t.Fields[0]
+	code.Print()
+	code.Print("// Values of %s.", t.TypeName)
+	code.Print("const (")
+	for _, value := range f.EnumValues {
+		value = unquote(value)
+		literal := value
+		if g.underlyingType(f) == "string" {
+			literal = strconv.Quote(value)
+		}
+		code.Print("%s%s %s = %s", t.TypeName, goName(value), t.TypeName, literal)
+	}
+	code.Print(")")
+}
+
+// unquote removes the quotes of a yaml string.
+func unquote(value string) string {
+	if strings.HasPrefix(value, "\"") {
+		if s, err := strconv.Unquote(value); err == nil {
+			return s
+		}
+	}
+	if len(value) > 1 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return strings.Replace(value[1:len(value)-1], "''", "'", -1)
+	}
+	return value
+}
+
+// renderUnion writes a struct with a field for each alternative of a union,
+// which is written and read as the alternative that is set.
+func (g *Generator) renderUnion(code *printer.Code, imports map[string]bool, t *surface.Type) {
+	code.Print("type %s struct {", t.TypeName)
+	for _, f := range t.Fields {
+		code.Print("%s %s", f.FieldName, f.NativeType)
+	}
+	code.Print("}")
+	code.Print()
+	code.Print("// MarshalJSON writes the alternative that is set.")
+	code.Print("func (u %s) MarshalJSON() ([]byte, error) {", t.TypeName)
+	for _, f := range t.Fields {
+		code.Print("if u.%s != nil {", f.FieldName)
+		code.Print("return json.Marshal(u.%s)", f.FieldName)
+		code.Print("}")
+	}
+	code.Print("return []byte(\"null\"), nil")
+	code.Print("}")
+	code.Print()
+	code.Print("// UnmarshalJSON reads the first alternative that a value is valid for.")
+	code.Print("func (u *%s) UnmarshalJSON(data []byte) error {", t.TypeName)
+	for _, f := range t.Fields {
+		code.Print("var %s %s", f.ParameterName, f.NativeType)
+		code.Print("if err := unmarshalStrict(data, &%s); err == nil {", f.ParameterName)
+		code.Print("*u = %s{%s: %s}", t.TypeName, f.FieldName, f.ParameterName)
+		code.Print("return nil")
+		code.Print("}")
+	}
+	code.Print("return fmt.Errorf(\"unable to read a %s from %%s\", data)", t.TypeName)
+	code.Print("}")
+	imports["encoding/json"] = true
+	imports["fmt"] = true
+}
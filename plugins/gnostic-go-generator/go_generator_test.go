@@ -0,0 +1,201 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testPlugin(t *testing.T, plugin string, inputFile string, outputFile string, referenceFile string) {
+	// remove any preexisting output files
+	os.Remove(outputFile)
+	// run the compiler
+	var err error
+	output, err := exec.Command(
+		"gnostic",
+		"--"+plugin+"-out=-",
+		inputFile).Output()
+	if err != nil {
+		t.Logf("Compile failed: %+v", err)
+		t.FailNow()
+	}
+	_ = ioutil.WriteFile(outputFile, output, 0644)
+	err = exec.Command("diff", outputFile, referenceFile).Run()
+	if err != nil {
+		t.Logf("Diff failed: %s vs %s %+v", outputFile, referenceFile, err)
+		t.FailNow()
+	} else {
+		// if the test succeeded, clean up
+		os.Remove(outputFile)
+	}
+}
+
+// testBuild generates a package for an API description and checks that it compiles.
+func testBuild(t *testing.T, inputFile string) {
+	testCommand(t, inputFile, nil, "vet", "./...")
+}
+
+// testCommand generates a package for an API description, adds files to it
+// and runs a go command in its directory.
+func testCommand(t *testing.T, inputFile string, files map[string]string, args ...string) {
+	dir, err := ioutil.TempDir("", "gnostic-go-generator")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(dir)
+	output, err := exec.Command(
+		"gnostic",
+		"--go-generator-out=package=api:"+dir,
+		inputFile).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Compile failed: %+v\n%s", err, output)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/api\n\ngo 1.12\n"), 0644)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for name, text := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	command := exec.Command("go", args...)
+	command.Dir = dir
+	command.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+	output, err = command.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go %s failed for %s: %+v\n%s", strings.Join(args, " "), inputFile, err, output)
+	}
+}
+
+func TestGoGeneratorPluginWithPetstoreV2(t *testing.T) {
+	testPlugin(t,
+		"go-generator",
+		"../../examples/v2.0/yaml/petstore.yaml",
+		"go-generator-petstore-v2.out",
+		"../../testdata/v2.0/yaml/go-generator-petstore.out")
+}
+
+func TestGoGeneratorPluginWithPetstoreV3(t *testing.T) {
+	testPlugin(t,
+		"go-generator",
+		"../../examples/v3.0/yaml/petstore.yaml",
+		"go-generator-petstore-v3.out",
+		"../../testdata/v3.0/yaml/go-generator-petstore.out")
+}
+
+func TestGoGeneratorPluginWithStoreV2(t *testing.T) {
+	testPlugin(t,
+		"go-generator",
+		"../../surface/testdata/v2.0/store.json",
+		"go-generator-store-v2.out",
+		"../../testdata/v2.0/json/go-generator-store.out")
+}
+
+func TestGoGeneratorPluginWithStoreV3(t *testing.T) {
+	testPlugin(t,
+		"go-generator",
+		"../../surface/testdata/v3.0/store.json",
+		"go-generator-store-v3.out",
+		"../../testdata/v3.0/json/go-generator-store.out")
+}
+
+func TestGoGeneratorPluginWithBodilessResponses(t *testing.T) {
+	testPlugin(t,
+		"go-generator",
+		"../../testdata/go-generator/responses.yaml",
+		"go-generator-responses.out",
+		"../../testdata/go-generator/responses.out")
+}
+
+// responsesTest checks the status codes that a generated handler writes and
+// that a generated client reads.
+const responsesTest = `package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type service struct {
+	create *CreateItemResponses
+	get    *GetItemResponses
+}
+
+func (s *service) CreateItem(ctx context.Context, parameters *CreateItemParameters) (*CreateItemResponses, error) {
+	return s.create, nil
+}
+
+func (s *service) GetItem(ctx context.Context, parameters *GetItemParameters) (*GetItemResponses, error) {
+	return s.get, nil
+}
+
+func (s *service) DeleteItem(ctx context.Context, parameters *DeleteItemParameters) (*DeleteItemResponses, error) {
+	return &DeleteItemResponses{Default: true}, nil
+}
+
+func TestResponses(t *testing.T) {
+	s := &service{}
+	server := httptest.NewServer(NewHandler(s))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+	ctx := context.Background()
+
+	s.create = &CreateItemResponses{Created: true}
+	if r, err := client.CreateItem(ctx, &Item{}); err != nil || !r.Created || r.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected response %+v %+v", r, err)
+	}
+	s.create = &CreateItemResponses{Conflict: true}
+	if r, err := client.CreateItem(ctx, &Item{}); err != nil || !r.Conflict || r.StatusCode != http.StatusConflict {
+		t.Errorf("unexpected response %+v %+v", r, err)
+	}
+	s.create = &CreateItemResponses{Default: &Error{Message: "unavailable"}, StatusCode: http.StatusServiceUnavailable}
+	if r, err := client.CreateItem(ctx, &Item{}); err != nil || r.Default == nil || r.Default.Message != "unavailable" || r.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected response %+v %+v", r, err)
+	}
+	s.get = &GetItemResponses{NotFound: true}
+	if r, err := client.GetItem(ctx, "a"); err != nil || !r.NotFound || r.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected response %+v %+v", r, err)
+	}
+	if r, err := client.DeleteItem(ctx, "a"); err != nil || !r.Default || r.StatusCode != http.StatusInternalServerError {
+		t.Errorf("unexpected response %+v %+v", r, err)
+	}
+}
+`
+
+func TestGoGeneratorPluginOutputStatusCodes(t *testing.T) {
+	testCommand(t, "../../testdata/go-generator/responses.yaml", map[string]string{"responses_test.go": responsesTest}, "test", "./...")
+}
+
+func TestGoGeneratorPluginOutputBuilds(t *testing.T) {
+	for _, inputFile := range []string{
+		"../../examples/v2.0/yaml/petstore.yaml",
+		"../../examples/v3.0/yaml/petstore.yaml",
+		"../../surface/testdata/v2.0/store.json",
+		"../../surface/testdata/v3.0/store.json",
+		"../../testdata/go-generator/responses.yaml",
+	} {
+		t.Run(inputFile, func(t *testing.T) {
+			testBuild(t, inputFile)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// gnostic-go-generator is a plugin that generates a Go package with types,
+// a client and net/http server handlers from the surface model of an API.
+package main
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+
+	plugins "github.com/google/gnostic/plugins"
+	"github.com/google/gnostic/plugins/gnostic-go-generator/generator"
+	surface "github.com/google/gnostic/surface"
+)
+
+// This is the main function for the plugin.
+func main() {
+	env, err := plugins.NewEnvironment()
+	env.RespondAndExitIfError(err)
+
+	packageName := ""
+	for _, parameter := range env.Request.Parameters {
+		if parameter.Name == "package" {
+			packageName = parameter.Value
+		}
+	}
+
+	handled := false
+	for _, model := range env.Request.Models {
+		if model.TypeUrl != "surface.v1.Model" {
+			continue
+		}
+		surfaceModel := &surface.Model{}
+		err = proto.Unmarshal(model.Value, surfaceModel)
+		env.RespondAndExitIfError(err)
+		files, err := generator.NewGenerator(surfaceModel, packageName).Generate()
+		env.RespondAndExitIfError(err)
+		env.Response.Files = append(env.Response.Files, files...)
+		handled = true
+	}
+	if !handled {
+		env.RespondAndExitIfError(errors.New("gnostic-go-generator requires a surface model"))
+	}
+	env.RespondAndExit()
+}
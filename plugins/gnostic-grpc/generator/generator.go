@@ -0,0 +1,403 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package generator converts OpenAPI v3 descriptions into protocol buffer
+// service definitions with google.api.http annotations.
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stoewer/go-strcase"
+
+	openapiv3 "github.com/google/gnostic/openapiv3"
+	"github.com/google/gnostic/printer"
+)
+
+const (
+	importAnnotations   = "google/api/annotations.proto"
+	importFieldBehavior = "google/api/field_behavior.proto"
+	importEmpty         = "google/protobuf/empty.proto"
+	importStruct        = "google/protobuf/struct.proto"
+	importTimestamp     = "google/protobuf/timestamp.proto"
+)
+
+// A message is a protocol buffer message under construction.
+type message struct {
+	name        string
+	description string
+	fields      []*field
+	messages    []*message
+	enums       []*enum
+}
+
+// addField adds a field to a message, renaming it if its name is in use.
+func (m *message) addField(f *field) {
+	name := f.name
+	for i := 2; m.hasField(f.name); i++ {
+		f.name = fmt.Sprintf("%s_%d", name, i)
+	}
+	m.fields = append(m.fields, f)
+}
+
+func (m *message) hasField(name string) bool {
+	for _, f := range m.fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedTypeName returns a name for a nested type that is not yet used in a message.
+func (m *message) nestedTypeName(name string) string {
+	used := make(map[string]bool)
+	for _, nested := range m.messages {
+		used[nested.name] = true
+	}
+	for _, e := range m.enums {
+		used[e.name] = true
+	}
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	return candidate
+}
+
+// A field is a field of a message.
+type field struct {
+	name        string
+	typeName    string
+	description string
+	repeated    bool
+	required    bool
+	oneof       bool // true if the field is an alternative of the message's oneof
+}
+
+// An enum is a protocol buffer enum.
+type enum struct {
+	name        string
+	description string
+	values      []string
+}
+
+// An rpc is a method of the generated service.
+type rpc struct {
+	name         string
+	description  string
+	requestType  string
+	responseType string
+	verb         string
+	path         string
+	body         string
+}
+
+// Generator builds a .proto file from an OpenAPI v3 document.
+type Generator struct {
+	document    *openapiv3.Document
+	packageName string
+	schemas     map[string]*openapiv3.Schema
+	// names of the types generated for component schemas
+	componentTypes map[string]string
+	imports        map[string]bool
+	messages       []*message
+	enums          []*enum
+	rpcs           []*rpc
+	names          map[string]bool // top-level type names in use
+	rpcNames       map[string]bool // rpc names in use
+}
+
+// NewGenerator creates a generator for a document. If packageName is empty,
+// a package name is derived from the document title.
+func NewGenerator(document *openapiv3.Document, packageName string) *Generator {
+	if packageName == "" {
+		packageName = defaultPackageName(document)
+	}
+	g := &Generator{
+		document:       document,
+		packageName:    packageName,
+		schemas:        make(map[string]*openapiv3.Schema),
+		componentTypes: make(map[string]string),
+		imports:        make(map[string]bool),
+		names:          make(map[string]bool),
+		rpcNames:       make(map[string]bool),
+	}
+	if document.Components != nil && document.Components.Schemas != nil {
+		for _, pair := range document.Components.Schemas.AdditionalProperties {
+			if schema := pair.Value.GetSchema(); schema != nil {
+				g.schemas[pair.Name] = schema
+			}
+		}
+	}
+	return g
+}
+
+// FileName returns the name of the generated .proto file.
+func (g *Generator) FileName() string {
+	return strings.Replace(g.packageName, ".", "_", -1) + ".proto"
+}
+
+// Generate returns the text of the .proto file.
+func (g *Generator) Generate() ([]byte, error) {
+	g.nameComponentTypes()
+	if g.document.Components != nil && g.document.Components.Schemas != nil {
+		for _, pair := range g.document.Components.Schemas.AdditionalProperties {
+			if err := g.addTypeForComponentSchema(pair.Name, pair.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if g.document.Paths != nil {
+		for _, pair := range g.document.Paths.Path {
+			if err := g.addRPCsForPathItem(pair.Name, pair.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return []byte(g.render()), nil
+}
+
+// GenerateProto returns a .proto file describing an OpenAPI v3 document.
+func GenerateProto(document *openapiv3.Document, packageName string) ([]byte, error) {
+	return NewGenerator(document, packageName).Generate()
+}
+
+func (g *Generator) use(importPath string) {
+	g.imports[importPath] = true
+}
+
+// uniqueName returns a top-level type name that is not yet in use.
+func (g *Generator) uniqueName(name string) string {
+	candidate := name
+	for i := 2; g.names[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	g.names[candidate] = true
+	return candidate
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// typeName converts a name from an API description into a message or enum name.
+func typeName(name string) string {
+	name = strcase.UpperCamelCase(nonIdentifier.ReplaceAllString(name, " "))
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "T" + name
+	}
+	return name
+}
+
+// fieldName converts a name from an API description into a field name.
+func fieldName(name string) string {
+	name = strcase.SnakeCase(nonIdentifier.ReplaceAllString(name, " "))
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "field_" + name
+	}
+	return name
+}
+
+// enumValueName converts an enum value into a prefixed enum value name.
+func enumValueName(enumName string, value string) string {
+	name := strcase.UpperSnakeCase(nonIdentifier.ReplaceAllString(value, " "))
+	if name == "" {
+		name = "EMPTY"
+	}
+	return strcase.UpperSnakeCase(enumName) + "_" + name
+}
+
+func defaultPackageName(document *openapiv3.Document) string {
+	if document.Info == nil || document.Info.Title == "" {
+		return "api"
+	}
+	return strings.Replace(fieldName(document.Info.Title), "_", "", -1)
+}
+
+// render prints the .proto file.
+func (g *Generator) render() string {
+	code := &printer.Code{}
+	code.Print("// Code generated by gnostic-grpc. DO NOT EDIT.")
+	code.Print()
+	code.Print("syntax = \"proto3\";")
+	code.Print()
+	code.Print("package %s;", g.packageName)
+	if len(g.rpcs) > 0 {
+		g.use(importAnnotations)
+	}
+	if len(g.imports) > 0 {
+		code.Print()
+		imports := make([]string, 0, len(g.imports))
+		for importPath := range g.imports {
+			imports = append(imports, importPath)
+		}
+		sort.Strings(imports)
+		for _, importPath := range imports {
+			code.Print("import \"%s\";", importPath)
+		}
+	}
+	if len(g.rpcs) > 0 {
+		code.Print()
+		printComment(code, serviceDescription(g.document))
+		code.Print("service %s {", g.serviceName())
+		code.Indent()
+		for i, r := range g.rpcs {
+			if i > 0 {
+				code.Print()
+			}
+			printComment(code, r.description)
+			code.Print("rpc %s(%s) returns (%s) {", r.name, r.requestType, r.responseType)
+			code.Indent()
+			code.Print("option (google.api.http) = {")
+			code.Indent()
+			switch r.verb {
+			case "get", "put", "post", "delete", "patch":
+				code.Print("%s: \"%s\"", r.verb, r.path)
+			default:
+				code.Print("custom: {")
+				code.Indent()
+				code.Print("kind: \"%s\"", strings.ToUpper(r.verb))
+				code.Print("path: \"%s\"", r.path)
+				code.Outdent()
+				code.Print("}")
+			}
+			code.PrintIf(r.body != "", "body: \"%s\"", r.body)
+			code.Outdent()
+			code.Print("};")
+			code.Outdent()
+			code.Print("}")
+		}
+		code.Outdent()
+		code.Print("}")
+	}
+	for _, m := range g.messages {
+		code.Print()
+		printMessage(code, m)
+	}
+	// Enum values are scoped by the parent of their enum, so they are unique
+	// across the enums of a scope.
+	values := make(map[string]bool)
+	for _, e := range g.enums {
+		code.Print()
+		printEnum(code, e, values)
+	}
+	return code.String()
+}
+
+func (g *Generator) serviceName() string {
+	if g.document.Info == nil || g.document.Info.Title == "" {
+		return "Service"
+	}
+	return typeName(g.document.Info.Title) + "Service"
+}
+
+func serviceDescription(document *openapiv3.Document) string {
+	if document.Info == nil {
+		return ""
+	}
+	return document.Info.Description
+}
+
+func printComment(code *printer.Code, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		code.Print(strings.TrimRight("// "+line, " "))
+	}
+}
+
+func printMessage(code *printer.Code, m *message) {
+	printComment(code, m.description)
+	code.Print("message %s {", m.name)
+	code.Indent()
+	for i, nested := range m.messages {
+		if i > 0 {
+			code.Print()
+		}
+		printMessage(code, nested)
+	}
+	values := make(map[string]bool)
+	for i, e := range m.enums {
+		if i > 0 || len(m.messages) > 0 {
+			code.Print()
+		}
+		printEnum(code, e, values)
+	}
+	if len(m.fields) > 0 && (len(m.messages) > 0 || len(m.enums) > 0) {
+		code.Print()
+	}
+	// The alternatives of all compositions of a message share one oneof,
+	// which follows the other fields.
+	fields := make([]*field, 0, len(m.fields))
+	alternatives := make([]*field, 0)
+	for _, f := range m.fields {
+		if f.oneof {
+			alternatives = append(alternatives, f)
+		} else {
+			fields = append(fields, f)
+		}
+	}
+	for i, f := range append(fields, alternatives...) {
+		if f.oneof && i == len(fields) {
+			oneof := "value"
+			for j := 2; m.hasField(oneof); j++ {
+				oneof = fmt.Sprintf("value_%d", j)
+			}
+			code.Print("oneof %s {", oneof)
+			code.Indent()
+		}
+		printComment(code, f.description)
+		label := ""
+		if f.repeated {
+			label = "repeated "
+		}
+		options := ""
+		if f.required {
+			options = " [(google.api.field_behavior) = REQUIRED]"
+		}
+		code.Print("%s%s %s = %d%s;", label, f.typeName, f.name, i+1, options)
+	}
+	if len(alternatives) > 0 {
+		code.Outdent()
+		code.Print("}")
+	}
+	code.Outdent()
+	code.Print("}")
+}
+
+// printEnum prints an enum. Values are renamed if their names are in use,
+// including the name of the zero value.
+func printEnum(code *printer.Code, e *enum, used map[string]bool) {
+	printComment(code, e.description)
+	code.Print("enum %s {", e.name)
+	code.Indent()
+	unique := func(name string) string {
+		candidate := name
+		for i := 2; used[candidate]; i++ {
+			candidate = fmt.Sprintf("%s_%d", name, i)
+		}
+		used[candidate] = true
+		return candidate
+	}
+	code.Print("%s = 0;", unique(enumValueName(e.name, "unspecified")))
+	for i, value := range e.values {
+		code.Print("%s = %d;", unique(enumValueName(e.name, value)), i+1)
+	}
+	code.Outdent()
+	code.Print("}")
+}
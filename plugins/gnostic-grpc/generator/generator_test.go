@@ -0,0 +1,146 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	openapiv3 "github.com/google/gnostic/openapiv3"
+)
+
+const typesDocument = `
+openapi: 3.0.0
+info:
+  title: Library
+  version: 1.0.0
+paths:
+  /shelves/{shelfId}/books:
+    post:
+      operationId: createBook
+      parameters:
+      - name: shelfId
+        in: path
+        required: true
+        schema:
+          type: integer
+          format: int64
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Book'
+      responses:
+        "200":
+          description: The created book.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Book'
+    head:
+      operationId: checkBooks
+      responses:
+        "204":
+          description: No content.
+components:
+  schemas:
+    Genre:
+      type: string
+      enum:
+      - science fiction
+      - mystery
+    Book:
+      type: object
+      required:
+      - title
+      properties:
+        title:
+          type: string
+        genre:
+          $ref: '#/components/schemas/Genre'
+        published:
+          type: string
+          format: date-time
+        format:
+          type: string
+          enum:
+          - hardcover
+          - paperback
+        metadata:
+          type: object
+        labels:
+          type: object
+          additionalProperties:
+            type: string
+        author:
+          type: object
+          properties:
+            name:
+              type: string
+        cover:
+          oneOf:
+          - $ref: '#/components/schemas/Image'
+          - type: string
+    Image:
+      properties:
+        url:
+          type: string
+`
+
+func TestGenerateProto(t *testing.T) {
+	document, err := openapiv3.ParseDocument([]byte(typesDocument))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	g := NewGenerator(document, "")
+	if g.FileName() != "library.proto" {
+		t.Errorf("unexpected file name %s", g.FileName())
+	}
+	bytes, err := g.Generate()
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	proto := string(bytes)
+	for _, expected := range []string{
+		`import "google/protobuf/struct.proto";`,
+		`import "google/protobuf/timestamp.proto";`,
+		`service LibraryService {`,
+		`rpc CreateBook(CreateBookRequest) returns (Book) {`,
+		`post: "/shelves/{shelf_id}/books"`,
+		`body: "body"`,
+		`rpc CheckBooks(google.protobuf.Empty) returns (google.protobuf.Empty) {`,
+		`kind: "HEAD"`,
+		`string title = 1 [(google.api.field_behavior) = REQUIRED];`,
+		`Genre genre = 2;`,
+		`google.protobuf.Timestamp published = 3;`,
+		`Format format = 4;`,
+		`google.protobuf.Struct metadata = 5;`,
+		`map<string, string> labels = 6;`,
+		`Author author = 7;`,
+		`Cover cover = 8;`,
+		`oneof value {`,
+		`Image image = 1;`,
+		`string option_2 = 2;`,
+		`FORMAT_PAPERBACK = 2;`,
+		`GENRE_SCIENCE_FICTION = 1;`,
+		`int64 shelf_id = 1 [(google.api.field_behavior) = REQUIRED];`,
+		`Book body = 2 [(google.api.field_behavior) = REQUIRED];`,
+	} {
+		if !strings.Contains(proto, expected) {
+			t.Errorf("missing %q in generated proto:\n%s", expected, proto)
+		}
+	}
+}
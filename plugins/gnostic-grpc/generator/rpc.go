@@ -0,0 +1,176 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	openapiv3 "github.com/google/gnostic/openapiv3"
+)
+
+var pathParameter = regexp.MustCompile(`{([^}]+)}`)
+
+// addRPCsForPathItem adds an rpc for each operation of a path item.
+func (g *Generator) addRPCsForPathItem(path string, pathItem *openapiv3.PathItem) error {
+	operations := []struct {
+		verb      string
+		operation *openapiv3.Operation
+	}{
+		{"get", pathItem.Get},
+		{"put", pathItem.Put},
+		{"post", pathItem.Post},
+		{"delete", pathItem.Delete},
+		{"options", pathItem.Options},
+		{"head", pathItem.Head},
+		{"patch", pathItem.Patch},
+		{"trace", pathItem.Trace},
+	}
+	for _, o := range operations {
+		if o.operation == nil {
+			continue
+		}
+		if err := g.addRPCForOperation(path, o.verb, pathItem.Parameters, o.operation); err != nil {
+			return fmt.Errorf("%s %s: %s", strings.ToUpper(o.verb), path, err.Error())
+		}
+	}
+	return nil
+}
+
+func (g *Generator) addRPCForOperation(path string, verb string, common []*openapiv3.ParameterOrReference, operation *openapiv3.Operation) error {
+	name := operation.OperationId
+	if name == "" {
+		name = verb + " " + path
+	}
+	rpcName := typeName(name)
+	for i := 2; g.rpcNames[rpcName]; i++ {
+		rpcName = fmt.Sprintf("%s%d", typeName(name), i)
+	}
+	g.rpcNames[rpcName] = true
+	r := &rpc{
+		name:        rpcName,
+		description: operation.Summary,
+		verb:        verb,
+	}
+	if r.description == "" {
+		r.description = operation.Description
+	}
+
+	request := &message{name: g.uniqueName(r.name + "Request")}
+	pathFields := make(map[string]string) // names of the fields of path parameters
+	parameters := append(append([]*openapiv3.ParameterOrReference{}, common...), operation.Parameters...)
+	for _, item := range parameters {
+		parameter := openapiv3.ResolveParameter(g.document, item)
+		if parameter == nil || (parameter.In != "path" && parameter.In != "query") || parameter.Schema == nil {
+			// Only path and query parameters can be mapped by google.api.http.
+			continue
+		}
+		f, err := g.fieldForSchemaOrReference(request, parameter.Name, parameter.Schema)
+		if err != nil {
+			return err
+		}
+		f.description = parameter.Description
+		if parameter.Required {
+			f.required = true
+			g.use(importFieldBehavior)
+		}
+		request.addField(f)
+		if parameter.In == "path" {
+			pathFields[parameter.Name] = f.name
+		}
+	}
+	r.path = pathParameter.ReplaceAllStringFunc(path, func(p string) string {
+		if name, ok := pathFields[p[1:len(p)-1]]; ok {
+			return "{" + name + "}"
+		}
+		return "{" + fieldName(p[1:len(p)-1]) + "}"
+	})
+	// google.api.http doesn't allow bodies for GET and DELETE, so their
+	// request bodies are ignored.
+	if body := openapiv3.ResolveRequestBody(g.document, operation.RequestBody); body != nil && verb != "get" && verb != "delete" {
+		if schema := openapiv3.SchemaForContent(body.Content); schema != nil {
+			f, err := g.fieldForSchemaOrReference(request, "body", schema)
+			if err != nil {
+				return err
+			}
+			f.description = body.Description
+			f.required = body.Required
+			if f.required {
+				g.use(importFieldBehavior)
+			}
+			request.addField(f)
+			r.body = f.name
+		}
+	}
+	if len(request.fields) > 0 {
+		g.messages = append(g.messages, request)
+		r.requestType = request.name
+	} else {
+		g.use(importEmpty)
+		r.requestType = "google.protobuf.Empty"
+	}
+
+	responseType, err := g.responseTypeForOperation(r.name, operation)
+	if err != nil {
+		return err
+	}
+	r.responseType = responseType
+	g.rpcs = append(g.rpcs, r)
+	return nil
+}
+
+// responseTypeForOperation returns the message type of the first successful response.
+func (g *Generator) responseTypeForOperation(rpcName string, operation *openapiv3.Operation) (string, error) {
+	if operation.Responses != nil {
+		for _, pair := range operation.Responses.ResponseOrReference {
+			if !strings.HasPrefix(pair.Name, "2") {
+				continue
+			}
+			response := openapiv3.ResolveResponse(g.document, pair.Value)
+			if response == nil {
+				continue
+			}
+			schema := openapiv3.SchemaForContent(response.Content)
+			if schema == nil {
+				break
+			}
+			if reference := schema.GetReference(); reference != nil {
+				target := strings.TrimPrefix(reference.XRef, componentSchemaPrefix)
+				if typeName, ok := g.componentTypes[target]; ok {
+					if s := g.schemas[target]; s == nil || !isEnum(s) {
+						return typeName, nil
+					}
+				}
+			}
+			m := &message{name: g.uniqueName(rpcName + "Response"), description: response.Description}
+			if s := schema.GetSchema(); s != nil && isMessage(s) {
+				if err := g.addFieldsForSchema(m, s); err != nil {
+					return "", err
+				}
+			} else {
+				f, err := g.fieldForSchemaOrReference(m, "value", schema)
+				if err != nil {
+					return "", err
+				}
+				m.addField(f)
+			}
+			g.messages = append(g.messages, m)
+			return m.name, nil
+		}
+	}
+	g.use(importEmpty)
+	return "google.protobuf.Empty", nil
+}
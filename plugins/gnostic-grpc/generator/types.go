@@ -0,0 +1,288 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	openapiv3 "github.com/google/gnostic/openapiv3"
+)
+
+const componentSchemaPrefix = "#/components/schemas/"
+
+// nameComponentTypes assigns type names to the component schemas that need
+// them, so that references can be resolved in any order.
+func (g *Generator) nameComponentTypes() {
+	if g.document.Components == nil || g.document.Components.Schemas == nil {
+		return
+	}
+	for _, pair := range g.document.Components.Schemas.AdditionalProperties {
+		schema := pair.Value.GetSchema()
+		if schema != nil && (isEnum(schema) || isMessage(schema) || schema.Type == "array") {
+			g.componentTypes[pair.Name] = g.uniqueName(typeName(pair.Name))
+		}
+	}
+}
+
+// addTypeForComponentSchema adds the message or enum for a named schema.
+// Scalar schemas produce no type; references to them use the scalar directly.
+func (g *Generator) addTypeForComponentSchema(name string, schemaOrReference *openapiv3.SchemaOrReference) error {
+	schema := schemaOrReference.GetSchema()
+	if schema == nil {
+		// A component that aliases another component needs no type of its own.
+		return nil
+	}
+	switch {
+	case isEnum(schema):
+		g.enums = append(g.enums, newEnum(g.componentTypes[name], schema))
+	case isMessage(schema):
+		m := &message{name: g.componentTypes[name], description: schema.Description}
+		if err := g.addFieldsForSchema(m, schema); err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+		g.messages = append(g.messages, m)
+	case schema.Type == "array":
+		// Top-level arrays are wrapped in a message with a single repeated field.
+		m := &message{name: g.componentTypes[name], description: schema.Description}
+		f, err := g.fieldForSchema(m, "items", schema, false)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+		m.addField(f)
+		g.messages = append(g.messages, m)
+	}
+	return nil
+}
+
+func isEnum(schema *openapiv3.Schema) bool {
+	return len(schema.Enum) > 0 && (schema.Type == "" || schema.Type == "string")
+}
+
+// isMessage returns true if a schema is represented by a message.
+func isMessage(schema *openapiv3.Schema) bool {
+	if len(schema.AllOf) > 0 || len(schema.OneOf) > 0 || len(schema.AnyOf) > 0 {
+		return true
+	}
+	return schema.Properties != nil && len(schema.Properties.AdditionalProperties) > 0
+}
+
+func newEnum(name string, schema *openapiv3.Schema) *enum {
+	e := &enum{name: name, description: schema.Description}
+	for _, value := range schema.Enum {
+		e.values = append(e.values, anyString(value))
+	}
+	return e
+}
+
+// anyString returns the text of a scalar stored in an Any.
+func anyString(value *openapiv3.Any) string {
+	text := strings.TrimSpace(value.Yaml)
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		return unquoted
+	}
+	return strings.Trim(text, "'")
+}
+
+// resolveSchema returns the schema of a component reference or an inline schema.
+func (g *Generator) resolveSchema(schemaOrReference *openapiv3.SchemaOrReference) (*openapiv3.Schema, string) {
+	if reference := schemaOrReference.GetReference(); reference != nil {
+		name := strings.TrimPrefix(reference.XRef, componentSchemaPrefix)
+		return g.schemas[name], name
+	}
+	return schemaOrReference.GetSchema(), ""
+}
+
+// addFieldsForSchema adds fields for the properties and compositions of a schema.
+func (g *Generator) addFieldsForSchema(m *message, schema *openapiv3.Schema) error {
+	for _, item := range schema.AllOf {
+		s, _ := g.resolveSchema(item)
+		if s == nil {
+			continue
+		}
+		if err := g.addFieldsForSchema(m, s); err != nil {
+			return err
+		}
+	}
+	if schema.Properties != nil {
+		for _, pair := range schema.Properties.AdditionalProperties {
+			f, err := g.fieldForSchemaOrReference(m, pair.Name, pair.Value)
+			if err != nil {
+				return err
+			}
+			for _, required := range schema.Required {
+				if required == pair.Name {
+					f.required = true
+					g.use(importFieldBehavior)
+				}
+			}
+			m.addField(f)
+		}
+	}
+	alternatives := schema.OneOf
+	if len(alternatives) == 0 {
+		alternatives = schema.AnyOf
+	}
+	for i, item := range alternatives {
+		name := fmt.Sprintf("option_%d", i+1)
+		if reference := item.GetReference(); reference != nil {
+			name = strings.TrimPrefix(reference.XRef, componentSchemaPrefix)
+		}
+		f, err := g.fieldForSchemaOrReference(m, name, item)
+		if err != nil {
+			return err
+		}
+		if f.repeated || strings.HasPrefix(f.typeName, "map<") {
+			// oneof fields can't be repeated or maps.
+			g.use(importStruct)
+			f.typeName = "google.protobuf.Value"
+			f.repeated = false
+		}
+		f.oneof = true
+		m.addField(f)
+	}
+	return nil
+}
+
+// fieldForSchemaOrReference builds a field of a message.
+func (g *Generator) fieldForSchemaOrReference(m *message, name string, schemaOrReference *openapiv3.SchemaOrReference) (*field, error) {
+	if reference := schemaOrReference.GetReference(); reference != nil {
+		if !strings.HasPrefix(reference.XRef, componentSchemaPrefix) {
+			return nil, fmt.Errorf("unsupported reference %s", reference.XRef)
+		}
+		target := strings.TrimPrefix(reference.XRef, componentSchemaPrefix)
+		schema, ok := g.schemas[target]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", reference.XRef)
+		}
+		switch {
+		case isEnum(schema), isMessage(schema):
+			return &field{name: fieldName(name), typeName: g.componentTypes[target]}, nil
+		default:
+			// Scalars, arrays and maps are inlined.
+			f, err := g.fieldForSchema(m, name, schema, true)
+			if err != nil {
+				return nil, err
+			}
+			f.description = ""
+			return f, nil
+		}
+	}
+	schema := schemaOrReference.GetSchema()
+	if schema == nil {
+		return nil, fmt.Errorf("invalid schema for %s", name)
+	}
+	return g.fieldForSchema(m, name, schema, false)
+}
+
+// fieldForSchema builds a field for an inline schema. Inline objects and
+// enums become nested types of the containing message.
+func (g *Generator) fieldForSchema(m *message, name string, schema *openapiv3.Schema, shared bool) (*field, error) {
+	f := &field{name: fieldName(name), description: schema.Description}
+	switch {
+	case isEnum(schema):
+		if shared {
+			f.typeName = "string"
+			return f, nil
+		}
+		e := newEnum(m.nestedTypeName(typeName(name)), schema)
+		e.description = ""
+		m.enums = append(m.enums, e)
+		f.typeName = e.name
+	case isMessage(schema):
+		nested := &message{name: m.nestedTypeName(typeName(name))}
+		if err := g.addFieldsForSchema(nested, schema); err != nil {
+			return nil, err
+		}
+		m.messages = append(m.messages, nested)
+		f.typeName = nested.name
+	case schema.Type == "array":
+		if schema.Items == nil || len(schema.Items.SchemaOrReference) == 0 {
+			g.use(importStruct)
+			f.typeName = "google.protobuf.ListValue"
+			return f, nil
+		}
+		item, err := g.fieldForSchemaOrReference(m, name, schema.Items.SchemaOrReference[0])
+		if err != nil {
+			return nil, err
+		}
+		f.typeName = item.typeName
+		f.repeated = true
+		if item.repeated || strings.HasPrefix(item.typeName, "map<") {
+			g.use(importStruct)
+			f.typeName = "google.protobuf.ListValue"
+			f.repeated = false
+		}
+	case schema.Type == "object" || schema.Type == "":
+		f.typeName = g.typeForObject(m, name, schema)
+	default:
+		f.typeName = g.scalarType(schema)
+	}
+	return f, nil
+}
+
+// typeForObject returns the type of an object without properties.
+func (g *Generator) typeForObject(m *message, name string, schema *openapiv3.Schema) string {
+	if additional := schema.AdditionalProperties.GetSchemaOrReference(); additional != nil {
+		value, err := g.fieldForSchemaOrReference(m, name+"_value", additional)
+		if err == nil && !value.repeated && !strings.HasPrefix(value.typeName, "map<") {
+			return "map<string, " + value.typeName + ">"
+		}
+	}
+	g.use(importStruct)
+	if schema.Type == "" {
+		// Untyped schemas accept any JSON value.
+		return "google.protobuf.Value"
+	}
+	return "google.protobuf.Struct"
+}
+
+// scalarType returns the protobuf type of a scalar schema.
+func (g *Generator) scalarType(schema *openapiv3.Schema) string {
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			g.use(importTimestamp)
+			return "google.protobuf.Timestamp"
+		case "byte", "binary":
+			return "bytes"
+		default:
+			return "string"
+		}
+	case "integer":
+		switch schema.Format {
+		case "int32":
+			return "int32"
+		case "uint32":
+			return "uint32"
+		case "uint64":
+			return "uint64"
+		default:
+			return "int64"
+		}
+	case "number":
+		if schema.Format == "float" {
+			return "float"
+		}
+		return "double"
+	case "boolean":
+		return "bool"
+	default:
+		g.use(importStruct)
+		return "google.protobuf.Value"
+	}
+}
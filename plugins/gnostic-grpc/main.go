@@ -0,0 +1,59 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// gnostic-grpc is a plugin that generates a .proto file with a gRPC service
+// definition from an OpenAPI v3 description.
+package main
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+
+	openapiv3 "github.com/google/gnostic/openapiv3"
+	plugins "github.com/google/gnostic/plugins"
+	"github.com/google/gnostic/plugins/gnostic-grpc/generator"
+)
+
+// This is the main function for the plugin.
+func main() {
+	env, err := plugins.NewEnvironment()
+	env.RespondAndExitIfError(err)
+
+	packageName := ""
+	for _, parameter := range env.Request.Parameters {
+		if parameter.Name == "package" {
+			packageName = parameter.Value
+		}
+	}
+
+	handled := false
+	for _, model := range env.Request.Models {
+		if model.TypeUrl != "openapi.v3.Document" {
+			continue
+		}
+		document := &openapiv3.Document{}
+		err = proto.Unmarshal(model.Value, document)
+		env.RespondAndExitIfError(err)
+		g := generator.NewGenerator(document, packageName)
+		bytes, err := g.Generate()
+		env.RespondAndExitIfError(err)
+		env.Response.Files = append(env.Response.Files, &plugins.File{Name: g.FileName(), Data: bytes})
+		handled = true
+	}
+	if !handled {
+		env.RespondAndExitIfError(errors.New("gnostic-grpc requires an OpenAPI v3 description"))
+	}
+	env.RespondAndExit()
+}
@@ -54,6 +54,10 @@ func main() {
 				if err == nil {
 					log.Printf("%+v", document)
 				}
+			case "gnostic.plugin.v1.Messages":
+				for _, message := range env.Request.Diagnostics() {
+					log.Printf("%+v", message)
+				}
 			}
 		}
 	}
@@ -19,6 +19,8 @@ import (
 	"os"
 	"os/exec"
 	"testing"
+
+	"github.com/golang/protobuf/proto"
 )
 
 func testPlugin(t *testing.T, plugin string, inputFile string, outputFile string, referenceFile string) {
@@ -111,3 +113,43 @@ func TestValidPluginInvocations(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestPluginDiagnostics(t *testing.T) {
+	outputDirectory := "plugin-diagnostics"
+	os.RemoveAll(outputDirectory)
+	err := exec.Command(
+		"gnostic",
+		"../testdata/diagnostics/checks.yaml",
+		"--plugin-request-out="+outputDirectory,
+	).Run()
+	if err != nil {
+		t.Fatalf("Compile failed: %+v", err)
+	}
+	defer os.RemoveAll(outputDirectory)
+	data, err := ioutil.ReadFile(outputDirectory + "/plugin-request.pb")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	request := &Request{}
+	if err := proto.Unmarshal(data, request); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// Plugins receive all diagnostics, whatever severity is reported.
+	codes := make([]string, 0)
+	for _, message := range request.Diagnostics() {
+		codes = append(codes, message.Level.String()+" "+message.Code)
+	}
+	expected := []string{"INFO deprecated", "WARNING ignored-value", "WARNING format-type-mismatch", "INFO unknown-format"}
+	if len(codes) != len(expected) {
+		t.Fatalf("unexpected diagnostics %v", codes)
+	}
+	for i := range codes {
+		if codes[i] != expected[i] {
+			t.Errorf("expected %s, found %s", expected[i], codes[i])
+		}
+	}
+	keys := request.Diagnostics()[0].Keys
+	if len(keys) != 3 || keys[1] != "/pets" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
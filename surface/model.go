@@ -19,8 +19,13 @@ import (
 	nethttp "net/http"
 	"net/url"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/google/gnostic/compiler"
 )
 
 // The structure to transport information during the recursive calls inside model_openapiv2.go
@@ -33,6 +38,9 @@ type FieldInfo struct {
 	fieldPosition Position
 	fieldName     string
 	enumValues    []string
+	// For request bodies and responses
+	statusCode string
+	mediaType  string
 }
 
 func (m *Model) addType(t *Type) {
@@ -91,10 +99,53 @@ func makeFieldAndAppendToType(info *FieldInfo, schemaType *Type, fieldName strin
 			f.Name = fieldName
 		}
 		f.Type, f.Kind, f.Format, f.Position, f.EnumValues = info.fieldType, info.fieldKind, info.fieldFormat, info.fieldPosition, info.enumValues
+		f.StatusCode, f.MediaType = info.statusCode, info.mediaType
 		schemaType.Fields = append(schemaType.Fields, f)
 	}
 }
 
+// Helper method to build a surface model Type with a single field named "value" for a schema that no Type was built
+// for (e.g.: the schema is a scalar, an array or a reference). Types of scalar schemas with enum values are enums.
+func makeValueType(name string, info *FieldInfo) *Type {
+	t := makeType(name)
+	makeFieldAndAppendToType(info, t, "value")
+	if info != nil && info.fieldKind == FieldKind_SCALAR && len(info.enumValues) > 0 {
+		t.Kind = TypeKind_ENUM
+	}
+	return t
+}
+
+// Returns the media types of the fields of a Type without duplicates, in the order of the fields.
+func mediaTypesOfFields(t *Type) (mediaTypes []string) {
+	if t == nil {
+		return nil
+	}
+	for _, f := range t.Fields {
+		mediaTypes = appendUnique(mediaTypes, f.MediaType)
+	}
+	return mediaTypes
+}
+
+// Appends non-empty values to a list of strings that don't contain them yet.
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		found := false
+		for _, v := range list {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
 // Helper method to determine the type of the value property for a map.
 func determineMapValueType(fInfo FieldInfo) (mapValueType string) {
 	if fInfo.fieldKind == FieldKind_ARRAY {
@@ -140,6 +191,25 @@ func isSymbolicReference(s string) bool {
 	return true
 }
 
+// symbolicReferences returns the symbolic references of a document, which are read with a compiler session.
+func symbolicReferences(session *compiler.Session, sourceName string, root *yaml.Node) ([]string, error) {
+	if sourceName == "" {
+		return nil, nil
+	}
+	refs, err := session.ReadRefs(sourceName, root)
+	if err != nil {
+		return nil, err
+	}
+	var symbolicReferences []string
+	for ref := range refs {
+		if isSymbolicReference(ref) {
+			symbolicReferences = append(symbolicReferences, ref)
+		}
+	}
+	sort.Strings(symbolicReferences)
+	return symbolicReferences, nil
+}
+
 // Replace encoded URLS with actual characters
 func validTypeForRef(XRef string) string {
 	t, _ := url.QueryUnescape(typeForRef(XRef))
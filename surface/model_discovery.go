@@ -0,0 +1,266 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package surface_v1
+
+import (
+	"log"
+	"net/url"
+	"path"
+	"strings"
+
+	discovery_v1 "github.com/google/gnostic/discovery"
+)
+
+type DiscoveryBuilder struct {
+	model    *Model
+	document *discovery_v1.Document
+}
+
+// NewModelFromDiscovery builds a model of an API service for use in code generation.
+func NewModelFromDiscovery(document *discovery_v1.Document) (*Model, error) {
+	return newDiscoveryBuilder(document).buildModel(document)
+}
+
+func newDiscoveryBuilder(document *discovery_v1.Document) *DiscoveryBuilder {
+	return &DiscoveryBuilder{model: &Model{}, document: document}
+}
+
+// Fills the surface model with information from a parsed Discovery document. Schemas become Types, and the methods
+// of the document and of its (nested) resources become Methods with "Parameters" and "Responses" Types, like the
+// operations of OpenAPI descriptions. Discovery schemas nest anonymous objects, so the Types that are built for them
+// are named with the names of the Types that contain them.
+func (b *DiscoveryBuilder) buildModel(document *discovery_v1.Document) (*Model, error) {
+	b.model.Types = make([]*Type, 0)
+	b.model.Methods = make([]*Method, 0)
+	// Set model properties from passed-in document.
+	b.model.Name = document.Title
+	if b.model.Name == "" {
+		b.model.Name = document.Name
+	}
+	// Paths of methods start with the service path, so the server is the root URL.
+	if document.RootUrl != "" {
+		b.model.Servers = []*Server{{Url: strings.TrimSuffix(document.RootUrl, "/")}}
+	}
+	// Discovery documents describe OAuth 2.0 scopes, which methods require.
+	if document.GetAuth().GetOauth2() != nil {
+		b.model.SecuritySchemes = []*SecurityScheme{{Name: "oauth2", Type: "oauth2"}}
+	}
+	b.buildFromSchemas(document.Schemas)
+	b.buildFromMethods(document.Methods)
+	b.buildFromResources(document.Resources)
+	return b.model, nil
+}
+
+// Build surface Types from Discovery schemas
+func (b *DiscoveryBuilder) buildFromSchemas(schemas *discovery_v1.Schemas) {
+	for _, namedSchema := range schemas.GetAdditionalProperties() {
+		fInfo := b.buildFromSchema(namedSchema.Name, namedSchema.Value)
+		// No type is created for schemas that are scalars, arrays or references, so we create one with a single field.
+		if t := findType(b.model.Types, namedSchema.Name); t == nil {
+			t = makeValueType(namedSchema.Name, fInfo)
+			t.Description = namedSchema.Value.Description
+			b.model.addType(t)
+		}
+	}
+}
+
+// Build Methods and their Types from the methods of resources and their nested resources
+func (b *DiscoveryBuilder) buildFromResources(resources *discovery_v1.Resources) {
+	for _, namedResource := range resources.GetAdditionalProperties() {
+		b.buildFromMethods(namedResource.Value.GetMethods())
+		b.buildFromResources(namedResource.Value.GetResources())
+	}
+}
+
+// Build Methods and their Types from Discovery methods
+func (b *DiscoveryBuilder) buildFromMethods(methods *discovery_v1.Methods) {
+	for _, namedMethod := range methods.GetAdditionalProperties() {
+		b.buildFromNamedMethod(namedMethod.Value)
+	}
+}
+
+// servicePath returns the path of the document's methods relative to its rootUrl. Older Discovery
+// documents have no servicePath, so it is taken from the basePath or the baseUrl, which include the
+// path of the rootUrl.
+func (b *DiscoveryBuilder) servicePath() string {
+	if b.document.ServicePath != "" {
+		return b.document.ServicePath
+	}
+	basePath := b.document.BasePath
+	if basePath == "" {
+		if baseUrl, err := url.Parse(b.document.BaseUrl); err == nil {
+			basePath = baseUrl.Path
+		}
+	}
+	rootPath := "/"
+	if rootUrl, err := url.Parse(b.document.RootUrl); err == nil && rootUrl.Path != "" {
+		rootPath = strings.TrimSuffix(rootUrl.Path, "/") + "/"
+	}
+	return strings.TrimPrefix(basePath, rootPath)
+}
+
+// Builds a Method and adds it to the surface model
+func (b *DiscoveryBuilder) buildFromNamedMethod(method *discovery_v1.Method) {
+	// Paths with reserved expansions like {+name} are written as {name}, so their variables are named by parameters.
+	methodPath := strings.Replace(method.Path, "{+", "{", -1)
+	m := &Method{
+		Operation:   method.Id,
+		Path:        path.Join("/", b.servicePath(), methodPath),
+		Method:      strings.ToUpper(method.HttpMethod),
+		Name:        sanitizeOperationName(method.Id),
+		Description: method.Description,
+	}
+	if m.Name == "" {
+		m.Name = generateOperationName(m.Method, m.Path)
+	}
+	m.ParametersTypeName, m.ResponsesTypeName = b.buildFromMethod(m.Name, method)
+	if method.Request != nil {
+		m.RequestMediaTypes = []string{"application/json"}
+	}
+	m.ResponseMediaTypes = mediaTypesOfFields(findType(b.model.Types, m.ResponsesTypeName))
+	if len(method.Scopes) > 0 && len(b.model.SecuritySchemes) > 0 {
+		m.Security = []*SecurityRequirement{{Schemes: []*SchemeRequirement{{Name: "oauth2", Scopes: method.Scopes}}}}
+	}
+	b.model.addMethod(m)
+}
+
+// Builds the "Parameters" and "Responses" types for a method, adds them to the model, and returns the names of the types.
+// If no such Type is added to the model an empty string is returned.
+func (b *DiscoveryBuilder) buildFromMethod(name string, method *discovery_v1.Method) (parametersTypeName string, responseTypeName string) {
+	// At first, we build the method's input parameters. This includes parameters (like PATH or QUERY parameters) and
+	// the request body.
+	operationParameters := makeType(name + "Parameters")
+	operationParameters.Description = operationParameters.Name + " holds parameters to " + name
+	for _, namedParameter := range method.GetParameters().GetAdditionalProperties() {
+		fieldInfo := b.buildFromParameter(name+strings.Title(namedParameter.Name), namedParameter.Name, namedParameter.Value)
+		// For parameters the name of the field is contained inside fieldInfo. That is why we pass "" as fieldName
+		makeFieldAndAppendToType(fieldInfo, operationParameters, "")
+	}
+	if request := method.Request; request != nil && request.XRef != "" {
+		fieldName := request.ParameterName
+		if fieldName == "" {
+			fieldName = "request_body"
+		}
+		fieldInfo := &FieldInfo{fieldKind: FieldKind_REFERENCE, fieldType: validTypeForRef(request.XRef), fieldPosition: Position_BODY}
+		makeFieldAndAppendToType(fieldInfo, operationParameters, fieldName)
+	}
+	if len(operationParameters.Fields) > 0 {
+		b.model.addType(operationParameters)
+		parametersTypeName = operationParameters.Name
+	}
+
+	// Secondly, we build the response value for the method. Discovery methods have one response, which is JSON.
+	if response := method.Response; response != nil && response.XRef != "" {
+		operationResponses := makeType(name + "Responses")
+		operationResponses.Description = operationResponses.Name + " holds responses of " + name
+		fieldInfo := &FieldInfo{fieldKind: FieldKind_REFERENCE, fieldType: validTypeForRef(response.XRef), statusCode: "200", mediaType: "application/json"}
+		makeFieldAndAppendToType(fieldInfo, operationResponses, "200 application/json")
+		b.model.addType(operationResponses)
+		responseTypeName = operationResponses.Name
+	}
+	return parametersTypeName, responseTypeName
+}
+
+// Returns information on how to represent a method parameter as field. Parameters have the same properties as
+// schemas, and their location is their position.
+func (b *DiscoveryBuilder) buildFromParameter(typeName string, name string, parameter *discovery_v1.Parameter) (fInfo *FieldInfo) {
+	if parameter.XRef != "" {
+		fInfo = &FieldInfo{fieldKind: FieldKind_REFERENCE, fieldType: validTypeForRef(parameter.XRef)}
+	} else {
+		fInfo = b.buildFromSchema(typeName, &discovery_v1.Schema{
+			Type:                 parameter.Type,
+			Format:               parameter.Format,
+			Enum:                 parameter.Enum,
+			Properties:           parameter.Properties,
+			AdditionalProperties: parameter.AdditionalProperties,
+			Items:                parameter.Items,
+		})
+	}
+	if fInfo == nil {
+		return nil
+	}
+	if parameter.Repeated && fInfo.fieldKind != FieldKind_ARRAY {
+		fInfo.fieldKind = FieldKind_ARRAY
+	}
+	fInfo.fieldName = name
+	switch parameter.Location {
+	case "path":
+		fInfo.fieldPosition = Position_PATH
+	case "query":
+		fInfo.fieldPosition = Position_QUERY
+	case "header":
+		fInfo.fieldPosition = Position_HEADER
+	}
+	return fInfo
+}
+
+// Given a Discovery schema there are three possibilities:
+//  1. The schema is a reference: We return information on how to use the referenced Type as field.
+//  2. The schema is an object/array: We create a type for the object, recursively call according methods for child
+//     schemas, and then return information on how to use the created Type as field.
+//  3. The schema has a scalar type: We return information on how to represent a scalar schema as Field. This
+//     possibility can be considered as the "base condition" for the recursive approach.
+func (b *DiscoveryBuilder) buildFromSchema(name string, schema *discovery_v1.Schema) (fInfo *FieldInfo) {
+	fInfo = &FieldInfo{}
+	if schema.XRef != "" {
+		fInfo.fieldKind, fInfo.fieldType = FieldKind_REFERENCE, validTypeForRef(schema.XRef)
+		return fInfo
+	}
+	// Data types according to: https://developers.google.com/discovery/v1/type-format
+	switch schema.Type {
+	case "", "object":
+		schemaType := makeType(name)
+		schemaType.Description = schema.Description
+		for _, namedSchema := range schema.GetProperties().GetAdditionalProperties() {
+			fieldInfo := b.buildFromSchema(name+strings.Title(namedSchema.Name), namedSchema.Value)
+			makeFieldAndAppendToType(fieldInfo, schemaType, namedSchema.Name)
+		}
+		if additionalProperties := schema.AdditionalProperties; additionalProperties != nil {
+			// AdditionalProperties are represented as map
+			fieldInfo := b.buildFromSchema(name+"AdditionalProperties", additionalProperties)
+			if fieldInfo != nil {
+				mapValueType := determineMapValueType(*fieldInfo)
+				fieldInfo.fieldKind, fieldInfo.fieldType, fieldInfo.fieldFormat = FieldKind_MAP, "map[string]"+mapValueType, ""
+				makeFieldAndAppendToType(fieldInfo, schemaType, "additional_properties")
+			}
+		}
+		if len(schemaType.Fields) == 0 {
+			schemaType.Kind = TypeKind_OBJECT
+			schemaType.ContentType = "interface{}"
+		}
+		if t := findType(b.model.Types, schemaType.Name); t == nil {
+			b.model.addType(schemaType)
+		}
+		fInfo.fieldKind, fInfo.fieldType = FieldKind_REFERENCE, schemaType.Name
+		return fInfo
+	case "array":
+		if schema.Items != nil {
+			arrayFieldInfo := b.buildFromSchema(name+"Item", schema.Items)
+			if arrayFieldInfo != nil {
+				fInfo.fieldKind, fInfo.fieldType, fInfo.fieldFormat, fInfo.enumValues = FieldKind_ARRAY, arrayFieldInfo.fieldType, arrayFieldInfo.fieldFormat, arrayFieldInfo.enumValues
+				return fInfo
+			}
+		}
+	case "any":
+		fInfo.fieldKind, fInfo.fieldType = FieldKind_ANY, "any"
+		return fInfo
+	default:
+		// We got a scalar value
+		fInfo.fieldKind, fInfo.fieldType, fInfo.fieldFormat, fInfo.enumValues = FieldKind_SCALAR, schema.Type, schema.Format, schema.Enum
+		return fInfo
+	}
+	log.Printf("Unimplemented: could not find field info for schema with name: '%v' and properties: %v", name, schema)
+	return nil
+}
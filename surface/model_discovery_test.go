@@ -0,0 +1,87 @@
+package surface_v1
+
+import (
+	"os"
+	"testing"
+
+	discovery_v1 "github.com/google/gnostic/discovery"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestModelDiscovery(t *testing.T) {
+	refFile := "testdata/discovery/library.json"
+	modelFile := "testdata/discovery/library.model.json"
+
+	bFile, err := os.ReadFile(refFile)
+	if err != nil {
+		t.Logf("Failed to read file: %+v", err)
+		t.FailNow()
+	}
+	bModel, err := os.ReadFile(modelFile)
+	if err != nil {
+		t.Logf("Failed to read file: %+v", err)
+		t.FailNow()
+	}
+
+	document, err := discovery_v1.ParseDocument(bFile)
+	if err != nil {
+		t.Logf("Failed to parse document: %+v", err)
+		t.FailNow()
+	}
+
+	m, err := NewModelFromDiscovery(document)
+	if err != nil {
+		t.Logf("Failed to create model: %+v", err)
+		t.FailNow()
+	}
+
+	var model Model
+	if err := protojson.Unmarshal(bModel, &model); err != nil {
+		t.Logf("Failed to unmarshal model: %+v", err)
+		t.FailNow()
+	}
+
+	cmpOpts := []cmp.Option{
+		protocmp.Transform(),
+	}
+	if diff := cmp.Diff(&model, m, cmpOpts...); diff != "" {
+		t.Errorf("Model mismatch (-want +got):\n%s", diff)
+	}
+	x, _ := protojson.Marshal(m)
+	t.Logf("Model: %s", x)
+}
+
+func TestModelDiscoveryWithoutServicePath(t *testing.T) {
+	for _, test := range []struct {
+		document string
+		path     string
+	}{
+		{`"rootUrl": "https://example.com/", "servicePath": "library/v1/", "basePath": "/ignored/"`, "/library/v1/shelves"},
+		{`"rootUrl": "https://example.com/", "basePath": "/library/v1/"`, "/library/v1/shelves"},
+		{`"rootUrl": "https://example.com/api/", "basePath": "/api/library/v1/"`, "/library/v1/shelves"},
+		{`"rootUrl": "https://example.com/", "baseUrl": "https://example.com/library/v1/"`, "/library/v1/shelves"},
+		{`"basePath": "/library/v1/"`, "/library/v1/shelves"},
+	} {
+		document, err := discovery_v1.ParseDocument([]byte(`{
+  "kind": "discovery#restDescription",
+  "discoveryVersion": "v1",
+  "name": "library",
+  "version": "v1",
+  ` + test.document + `,
+  "methods": {"list": {"id": "library.shelves.list", "path": "shelves", "httpMethod": "GET"}}
+}`))
+		if err != nil {
+			t.Fatalf("Failed to parse document: %+v", err)
+		}
+		m, err := NewModelFromDiscovery(document)
+		if err != nil {
+			t.Fatalf("Failed to create model: %+v", err)
+		}
+		if len(m.Methods) != 1 || m.Methods[0].Path != test.path {
+			t.Errorf("%s: unexpected methods %+v", test.document, m.Methods)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package surface_v1
 import (
 	"log"
 	"strconv"
+	"strings"
 
 	"github.com/google/gnostic/compiler"
 	openapiv2 "github.com/google/gnostic/openapiv2"
@@ -25,6 +26,7 @@ import (
 type OpenAPI2Builder struct {
 	model    *Model
 	document *openapiv2.Document
+	session  *compiler.Session
 }
 
 // NewModelFromOpenAPI2 builds a model of an API service for use in code generation.
@@ -32,6 +34,15 @@ func NewModelFromOpenAPI2(document *openapiv2.Document, sourceName string) (*Mod
 	return newOpenAPI2Builder(document).buildModel(document, sourceName)
 }
 
+// NewModelFromOpenAPI2WithSession builds a model like NewModelFromOpenAPI2, but
+// finds symbolic references with a compiler session instead of the global caches,
+// so it can be called concurrently and doesn't change the document.
+func NewModelFromOpenAPI2WithSession(document *openapiv2.Document, sourceName string, session *compiler.Session) (*Model, error) {
+	b := newOpenAPI2Builder(document)
+	b.session = session
+	return b.buildModel(document, sourceName)
+}
+
 func newOpenAPI2Builder(document *openapiv2.Document) *OpenAPI2Builder {
 	return &OpenAPI2Builder{model: &Model{}, document: document}
 }
@@ -56,8 +67,10 @@ func (b *OpenAPI2Builder) buildModel(document *openapiv2.Document, sourceName st
 	return b.model, nil
 }
 
-// Builds Types from definitions; builds Types and Methods from paths
+// Builds servers and security schemes; builds Types from definitions; builds Types and Methods from paths
 func (b *OpenAPI2Builder) buildFromDocument(document *openapiv2.Document) {
+	b.model.Servers = b.buildServers(document.Schemes)
+	b.buildFromSecurityDefinitions(document.SecurityDefinitions)
 	b.buildFromDefinitions(document.Definitions)
 	b.buildFromParameterDefinitions(document.Parameters)
 	b.buildFromResponseDefinitions(document.Responses)
@@ -76,14 +89,65 @@ func (b *OpenAPI2Builder) buildFromDefinitions(definitions *openapiv2.Definition
 			// In certain cases no type will be created during the recursion: e.g.: the schema is of type scalar, array
 			// or an reference. So we check whether the surface model Type already exists, and if not then we create it.
 			if t := findType(b.model.Types, namedSchema.Name); t == nil {
-				t = makeType(namedSchema.Name)
-				makeFieldAndAppendToType(fInfo, t, "value")
-				b.model.addType(t)
+				b.model.addType(makeValueType(namedSchema.Name, fInfo))
 			}
 		}
 	}
 }
 
+// Builds surface Servers from the host and base path of the document, one for each scheme. The scheme that was used to
+// access the description isn't known, so "https" is used if there are no schemes.
+func (b *OpenAPI2Builder) buildServers(schemes []string) (servers []*Server) {
+	if b.document.Host == "" {
+		if b.document.BasePath != "" {
+			servers = append(servers, &Server{Url: b.document.BasePath})
+		}
+		return servers
+	}
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	for _, scheme := range schemes {
+		servers = append(servers, &Server{Url: scheme + "://" + b.document.Host + b.document.BasePath})
+	}
+	return servers
+}
+
+// Builds the security schemes of the model from OpenAPI security definitions. Basic authentication is represented
+// like the "http" schemes of OpenAPI v3.
+func (b *OpenAPI2Builder) buildFromSecurityDefinitions(securityDefinitions *openapiv2.SecurityDefinitions) {
+	for _, namedDefinition := range securityDefinitions.GetAdditionalProperties() {
+		s := &SecurityScheme{Name: namedDefinition.Name}
+		definition := namedDefinition.Value
+		if basic := definition.GetBasicAuthenticationSecurity(); basic != nil {
+			s.Type, s.Scheme, s.Description = "http", "basic", basic.Description
+		} else if apiKey := definition.GetApiKeySecurity(); apiKey != nil {
+			s.Type, s.In, s.ParameterName, s.Description = "apiKey", apiKey.In, apiKey.Name, apiKey.Description
+		} else if oauth2 := definition.GetOauth2ImplicitSecurity(); oauth2 != nil {
+			s.Type, s.Description = "oauth2", oauth2.Description
+		} else if oauth2 := definition.GetOauth2PasswordSecurity(); oauth2 != nil {
+			s.Type, s.Description = "oauth2", oauth2.Description
+		} else if oauth2 := definition.GetOauth2ApplicationSecurity(); oauth2 != nil {
+			s.Type, s.Description = "oauth2", oauth2.Description
+		} else if oauth2 := definition.GetOauth2AccessCodeSecurity(); oauth2 != nil {
+			s.Type, s.Description = "oauth2", oauth2.Description
+		}
+		b.model.SecuritySchemes = append(b.model.SecuritySchemes, s)
+	}
+}
+
+// Builds the security requirements of a method from OpenAPI security requirements
+func (b *OpenAPI2Builder) buildFromSecurityRequirements(requirements []*openapiv2.SecurityRequirement) (result []*SecurityRequirement) {
+	for _, requirement := range requirements {
+		r := &SecurityRequirement{}
+		for _, namedScopes := range requirement.AdditionalProperties {
+			r.Schemes = append(r.Schemes, &SchemeRequirement{Name: namedScopes.Name, Scopes: namedScopes.GetValue().GetValue()})
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
 // Build surface model Types from OpenAPI parameter definitions
 func (b *OpenAPI2Builder) buildFromParameterDefinitions(parameters *openapiv2.ParameterDefinitions) {
 	if parameters == nil {
@@ -115,9 +179,7 @@ func (b *OpenAPI2Builder) buildFromResponseDefinitions(responses *openapiv2.Resp
 			// In certain cases no type will be created during the recursion: e.g.: the schema is of type scalar, array
 			// or an reference. So we check whether the surface model Type already exists, and if not then we create it.
 			if t := findType(b.model.Types, namedResponse.Name); t == nil {
-				t = makeType(namedResponse.Name)
-				makeFieldAndAppendToType(fInfo, t, "value")
-				b.model.addType(t)
+				b.model.addType(makeValueType(namedResponse.Name, fInfo))
 			}
 		}
 	}
@@ -126,6 +188,10 @@ func (b *OpenAPI2Builder) buildFromResponseDefinitions(responses *openapiv2.Resp
 // Builds all symbolic references. A symbolic reference is an URL to another OpenAPI description. We call "document.ResolveReferences"
 // inside that method. This has the same effect like: "gnostic --resolve-refs"
 func (b *OpenAPI2Builder) buildSymbolicReferences(document *openapiv2.Document, sourceName string) (err error) {
+	if b.session != nil {
+		b.model.SymbolicReferences, err = symbolicReferences(b.session, sourceName, document.ToRawInfo())
+		return err
+	}
 	cache := compiler.GetInfoCache()
 	if len(cache) == 0 && sourceName != "" {
 		// Fills the compiler cache with all kind of references.
@@ -185,6 +251,23 @@ func (b *OpenAPI2Builder) buildFromNamedPath(name string, pathItem *openapiv2.Pa
 				m.Name = generateOperationName(method, name)
 			}
 			m.ParametersTypeName, m.ResponsesTypeName = b.buildFromNamedOperation(m.Name, op)
+			// Operations consume media types with body and form data parameters.
+			if parameters := findType(b.model.Types, m.ParametersTypeName); parameters.HasFieldWithPosition(Position_BODY) || parameters.HasFieldWithPosition(Position_FORMDATA) {
+				m.RequestMediaTypes = b.document.Consumes
+				if op.Consumes != nil {
+					m.RequestMediaTypes = op.Consumes
+				}
+			}
+			m.ResponseMediaTypes = mediaTypesOfFields(findType(b.model.Types, m.ResponsesTypeName))
+			// Operations without security requirements have the requirements of the document.
+			security := op.Security
+			if security == nil {
+				security = b.document.Security
+			}
+			m.Security = b.buildFromSecurityRequirements(security)
+			if op.Schemes != nil {
+				m.Servers = b.buildServers(op.Schemes)
+			}
 			b.model.addMethod(m)
 		}
 	}
@@ -212,12 +295,18 @@ func (b *OpenAPI2Builder) buildFromNamedOperation(name string, operation *openap
 		operationResponses.Description = operationResponses.Name + " holds responses of " + name
 		for _, namedResponse := range responses.ResponseCode {
 			fieldInfo := b.buildFromResponseOrRef(operation.OperationId+convertStatusCodeToText(namedResponse.Name), namedResponse.Value)
+			if fieldInfo == nil {
+				// Responses without a body are fields without a type.
+				makeFieldAndAppendToType(&FieldInfo{statusCode: namedResponse.Name}, operationResponses, namedResponse.Name)
+				continue
+			}
 			produces := b.document.Produces
 			if operation.Produces != nil {
 				produces = operation.Produces
 			}
 			for _, contentType := range produces {
 				name := namedResponse.Name + " " + contentType
+				fieldInfo.statusCode, fieldInfo.mediaType = namedResponse.Name, contentType
 				makeFieldAndAppendToType(fieldInfo, operationResponses, name)
 			}
 		}
@@ -274,21 +363,25 @@ func (b *OpenAPI2Builder) buildFromNonBodyParameter(nonBodyParameter *openapiv2.
 	if headerParameter != nil {
 		fInfo.fieldName, fInfo.fieldPosition, fInfo.fieldFormat = headerParameter.Name, Position_HEADER, headerParameter.Format
 		b.adaptFieldKindAndFieldType(fInfo, headerParameter.Type, headerParameter.Items)
+		fInfo.enumValues = enumValues(headerParameter.Enum)
 	}
 	formDataParameter := nonBodyParameter.GetFormDataParameterSubSchema()
 	if formDataParameter != nil {
 		fInfo.fieldName, fInfo.fieldPosition, fInfo.fieldFormat = formDataParameter.Name, Position_FORMDATA, formDataParameter.Format
 		b.adaptFieldKindAndFieldType(fInfo, formDataParameter.Type, formDataParameter.Items)
+		fInfo.enumValues = enumValues(formDataParameter.Enum)
 	}
 	queryParameter := nonBodyParameter.GetQueryParameterSubSchema()
 	if queryParameter != nil {
 		fInfo.fieldName, fInfo.fieldPosition, fInfo.fieldFormat = queryParameter.Name, Position_QUERY, queryParameter.Format
 		b.adaptFieldKindAndFieldType(fInfo, queryParameter.Type, queryParameter.Items)
+		fInfo.enumValues = enumValues(queryParameter.Enum)
 	}
 	pathParameter := nonBodyParameter.GetPathParameterSubSchema()
 	if pathParameter != nil {
 		fInfo.fieldName, fInfo.fieldPosition, fInfo.fieldFormat = pathParameter.Name, Position_PATH, pathParameter.Format
 		b.adaptFieldKindAndFieldType(fInfo, pathParameter.Type, pathParameter.Items)
+		fInfo.enumValues = enumValues(pathParameter.Enum)
 	}
 	return fInfo
 }
@@ -380,6 +473,10 @@ func (b *OpenAPI2Builder) buildFromSchema(name string, schema *openapiv2.Schema)
 	if schema.Type != nil && len(schema.Type.Value) == 1 && schema.Type.Value[0] != "null" {
 		t = schema.Type.Value[0]
 	}
+	if t == "" && schema.Enum != nil && schema.Properties == nil {
+		// Schemas that only have enum values are scalars. Their values are written as strings.
+		t = "string"
+	}
 	switch t {
 	case "":
 		fallthrough
@@ -413,13 +510,6 @@ func (b *OpenAPI2Builder) buildFromSchema(name string, schema *openapiv2.Schema)
 			}
 		}
 
-		if schema.Enum != nil {
-			// TODO: It is not defined how enums should be represented inside the surface model
-			fieldInfo := &FieldInfo{}
-			fieldInfo.fieldKind, fieldInfo.fieldType, fieldInfo.fieldName = FieldKind_ANY, "string", "enum"
-			makeFieldAndAppendToType(fieldInfo, schemaType, fieldInfo.fieldName)
-		}
-
 		if len(schemaType.Fields) == 0 {
 			schemaType.Kind = TypeKind_OBJECT
 			schemaType.ContentType = "interface{}"
@@ -434,15 +524,23 @@ func (b *OpenAPI2Builder) buildFromSchema(name string, schema *openapiv2.Schema)
 		for _, s := range schema.Items.Schema {
 			arrayFieldInfo := b.buildFromSchemaOrReference(name, s)
 			if arrayFieldInfo != nil {
-				fInfo.fieldKind, fInfo.fieldType, fInfo.fieldFormat = FieldKind_ARRAY, arrayFieldInfo.fieldType, arrayFieldInfo.fieldFormat
+				fInfo.fieldKind, fInfo.fieldType, fInfo.fieldFormat, fInfo.enumValues = FieldKind_ARRAY, arrayFieldInfo.fieldType, arrayFieldInfo.fieldFormat, arrayFieldInfo.enumValues
 				return fInfo
 			}
 		}
 	default:
 		// We got a scalar value
-		fInfo.fieldKind, fInfo.fieldType, fInfo.fieldFormat = FieldKind_SCALAR, t, schema.Format
+		fInfo.fieldKind, fInfo.fieldType, fInfo.fieldFormat, fInfo.enumValues = FieldKind_SCALAR, t, schema.Format, enumValues(schema.Enum)
 		return fInfo
 	}
 	log.Printf("Unimplemented: could not find field info for schema with name: '%v' and properties: %v", name, schema)
 	return nil
 }
+
+// Returns enum values as they are written in the API description.
+func enumValues(enum []*openapiv2.Any) (values []string) {
+	for _, value := range enum {
+		values = append(values, strings.TrimSuffix(value.Yaml, "\n"))
+	}
+	return values
+}
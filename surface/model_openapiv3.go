@@ -16,6 +16,7 @@ package surface_v1
 
 import (
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/google/gnostic/compiler"
@@ -25,6 +26,7 @@ import (
 type OpenAPI3Builder struct {
 	model    *Model
 	document *openapiv3.Document
+	session  *compiler.Session
 }
 
 // NewModelFromOpenAPIv3 builds a model of an API service for use in code generation.
@@ -32,6 +34,15 @@ func NewModelFromOpenAPI3(document *openapiv3.Document, sourceName string) (*Mod
 	return newOpenAPI3Builder(document).buildModel(document, sourceName)
 }
 
+// NewModelFromOpenAPI3WithSession builds a model like NewModelFromOpenAPI3, but
+// finds symbolic references with a compiler session instead of the global caches,
+// so it can be called concurrently and doesn't change the document.
+func NewModelFromOpenAPI3WithSession(document *openapiv3.Document, sourceName string, session *compiler.Session) (*Model, error) {
+	b := newOpenAPI3Builder(document)
+	b.session = session
+	return b.buildModel(document, sourceName)
+}
+
 func newOpenAPI3Builder(document *openapiv3.Document) *OpenAPI3Builder {
 	return &OpenAPI3Builder{model: &Model{}, document: document}
 }
@@ -56,12 +67,57 @@ func (b *OpenAPI3Builder) buildModel(document *openapiv3.Document, sourceName st
 	return b.model, nil
 }
 
-// Builds Types from the component section; builds Types and methods from paths;
+// Builds servers and security schemes; builds Types from the component section; builds Types and methods from paths;
 func (b *OpenAPI3Builder) buildFromDocument(document *openapiv3.Document) {
+	b.model.Servers = b.buildFromServers(document.Servers)
+	b.buildFromSecuritySchemes(document.GetComponents().GetSecuritySchemes())
 	b.buildFromComponents(document.Components)
 	b.buildFromPaths(document.Paths)
 }
 
+// Builds surface Servers from OpenAPI servers. Variables in URLs are replaced with their default values.
+func (b *OpenAPI3Builder) buildFromServers(servers []*openapiv3.Server) (result []*Server) {
+	for _, server := range servers {
+		url := server.Url
+		for _, namedVariable := range server.GetVariables().GetAdditionalProperties() {
+			url = strings.Replace(url, "{"+namedVariable.Name+"}", namedVariable.GetValue().GetDefault(), -1)
+		}
+		result = append(result, &Server{Url: url, Description: server.Description})
+	}
+	return result
+}
+
+// Builds the security schemes of the model from OpenAPI security schemes
+func (b *OpenAPI3Builder) buildFromSecuritySchemes(securitySchemes *openapiv3.SecuritySchemesOrReferences) {
+	for _, namedScheme := range securitySchemes.GetAdditionalProperties() {
+		scheme := namedScheme.GetValue().GetSecurityScheme()
+		if scheme == nil {
+			log.Printf("Unimplemented: could not build security scheme from reference: %v", namedScheme.GetValue().GetReference())
+			continue
+		}
+		b.model.SecuritySchemes = append(b.model.SecuritySchemes, &SecurityScheme{
+			Name:          namedScheme.Name,
+			Type:          scheme.Type,
+			Description:   scheme.Description,
+			Scheme:        scheme.Scheme,
+			In:            scheme.In,
+			ParameterName: scheme.Name,
+		})
+	}
+}
+
+// Builds the security requirements of a method from OpenAPI security requirements
+func (b *OpenAPI3Builder) buildFromSecurityRequirements(requirements []*openapiv3.SecurityRequirement) (result []*SecurityRequirement) {
+	for _, requirement := range requirements {
+		r := &SecurityRequirement{}
+		for _, namedScopes := range requirement.AdditionalProperties {
+			r.Schemes = append(r.Schemes, &SchemeRequirement{Name: namedScopes.Name, Scopes: namedScopes.GetValue().GetValue()})
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
 // Builds all Types from an "OpenAPI component" section
 func (b *OpenAPI3Builder) buildFromComponents(components *openapiv3.Components) {
 	if components == nil {
@@ -108,6 +164,10 @@ func (b *OpenAPI3Builder) buildFromPaths(paths *openapiv3.Paths) {
 // Builds all symbolic references. A symbolic reference is an URL to another OpenAPI description. We call "document.ResolveReferences"
 // inside that method. This has the same effect like: "gnostic --resolve-refs"
 func (b *OpenAPI3Builder) buildSymbolicReferences(document *openapiv3.Document, sourceName string) (err error) {
+	if b.session != nil {
+		b.model.SymbolicReferences, err = symbolicReferences(b.session, sourceName, document.ToRawInfo())
+		return err
+	}
 	cache := compiler.GetInfoCache()
 	if len(cache) == 0 && sourceName != "" {
 		// Fills the compiler cache with all kind of references.
@@ -162,6 +222,22 @@ func (b *OpenAPI3Builder) buildFromNamedPath(name string, pathItem *openapiv3.Pa
 				m.Name = generateOperationName(method, name)
 			}
 			m.ParametersTypeName, m.ResponsesTypeName = b.buildFromNamedOperation(m.Name, op)
+			// Request bodies are fields of the "Parameters" type that refer to Types with a field for each media type.
+			if requestBody := findType(b.model.Types, m.ParametersTypeName).FieldWithName("request_body"); requestBody != nil {
+				m.RequestMediaTypes = mediaTypesOfFields(findType(b.model.Types, requestBody.Type))
+			}
+			m.ResponseMediaTypes = mediaTypesOfFields(findType(b.model.Types, m.ResponsesTypeName))
+			// Operations without security requirements have the requirements of the document.
+			security := op.Security
+			if security == nil {
+				security = b.document.Security
+			}
+			m.Security = b.buildFromSecurityRequirements(security)
+			servers := op.Servers
+			if servers == nil {
+				servers = pathItem.Servers
+			}
+			m.Servers = b.buildFromServers(servers)
 			b.model.addMethod(m)
 		}
 	}
@@ -195,14 +271,23 @@ func (b *OpenAPI3Builder) buildFromNamedOperation(name string, operation *openap
 		operationResponses.Description = operationResponses.Name + " holds responses of " + name
 		for _, namedResponse := range responses.ResponseOrReference {
 			fieldInfos := b.buildFromResponseOrRef(namedResponse.Name, namedResponse.Value)
+			if len(fieldInfos) == 0 {
+				// Responses without a body are fields without a type.
+				fieldInfos = []*FieldInfo{{fieldName: namedResponse.Name}}
+			}
 			for _, fieldInfo := range fieldInfos {
+				fieldInfo.statusCode = namedResponse.Name
 				// For responses the name of the field is contained inside fieldInfo. That is why we pass "" as fieldName.
 				makeFieldAndAppendToType(fieldInfo, operationResponses, "")
 			}
 		}
 		if responses.Default != nil {
 			fieldInfos := b.buildFromResponseOrRef(operation.OperationId+"Default", responses.Default)
+			if len(fieldInfos) == 0 {
+				fieldInfos = []*FieldInfo{{}}
+			}
 			for _, fieldInfo := range fieldInfos {
+				fieldInfo.statusCode = "default"
 				makeFieldAndAppendToType(fieldInfo, operationResponses, "default")
 			}
 		}
@@ -277,6 +362,9 @@ func (b *OpenAPI3Builder) buildFromRequestBody(name string, reqBody *openapiv3.R
 		schemaType := makeType(name)
 		for _, namedMediaType := range reqBody.Content.AdditionalProperties {
 			fieldInfo := b.buildFromSchemaOrReference(name+namedMediaType.Name, namedMediaType.GetValue().GetSchema())
+			if fieldInfo != nil {
+				fieldInfo.mediaType = namedMediaType.Name
+			}
 			makeFieldAndAppendToType(fieldInfo, schemaType, namedMediaType.Name)
 		}
 		b.model.addType(schemaType)
@@ -305,7 +393,7 @@ func (b *OpenAPI3Builder) buildFromResponse(name string, response *openapiv3.Res
 		for _, namedMediaType := range response.Content.AdditionalProperties {
 			name := name + " " + namedMediaType.Name
 			fieldInfo := b.buildFromSchemaOrReference(name, namedMediaType.GetValue().GetSchema())
-			fieldInfo.fieldName = name
+			fieldInfo.fieldName, fieldInfo.mediaType = name, namedMediaType.Name
 			fInfos = append(fInfos, fieldInfo)
 		}
 	}
@@ -356,15 +444,15 @@ func (b *OpenAPI3Builder) buildFromSchema(name string, schema *openapiv3.Schema)
 		}
 
 		for _, schemaOrRef := range schema.AnyOf {
-			b.buildFromOneOfAnyOfAndAllOf(schemaOrRef, schemaType)
+			b.buildFromAnyOfAndAllOf(schemaOrRef, schemaType)
 		}
 
-		for _, schemaOrRef := range schema.OneOf {
-			b.buildFromOneOfAnyOfAndAllOf(schemaOrRef, schemaType)
+		if len(schema.OneOf) > 0 {
+			b.buildFromOneOf(name, schema, schemaType)
 		}
 
 		for _, schemaOrRef := range schema.AllOf {
-			b.buildFromOneOfAnyOfAndAllOf(schemaOrRef, schemaType)
+			b.buildFromAnyOfAndAllOf(schemaOrRef, schemaType)
 		}
 
 		if schema.Items != nil {
@@ -407,8 +495,32 @@ func (b *OpenAPI3Builder) buildFromSchema(name string, schema *openapiv3.Schema)
 	return nil
 }
 
-// buildFromOneOfAnyOfAndAllOf adds appropriate fields to the 'schemaType' given a new 'schemaOrRef'.
-func (b *OpenAPI3Builder) buildFromOneOfAnyOfAndAllOf(schemaOrRef *openapiv3.SchemaOrReference, schemaType *Type) {
+// buildFromOneOf adds the alternatives of a oneOf schema as fields to a union Type. If the schema has no other
+// properties, 'schemaType' is the union. Otherwise the union is a new Type that is added to 'schemaType' as "one_of"
+// field. Alternatives that are references are named like the referenced Types.
+func (b *OpenAPI3Builder) buildFromOneOf(name string, schema *openapiv3.Schema, schemaType *Type) {
+	union := schemaType
+	if len(schema.GetProperties().GetAdditionalProperties()) > 0 || schema.AdditionalProperties.GetSchemaOrReference() != nil ||
+		len(schema.AnyOf) > 0 || len(schema.AllOf) > 0 || schema.Items != nil {
+		union = makeType(name + "OneOf")
+	}
+	union.Kind = TypeKind_UNION
+	for idx, schemaOrRef := range schema.OneOf {
+		fieldName := "option_" + strconv.Itoa(idx+1)
+		if ref := schemaOrRef.GetReference(); ref != nil {
+			fieldName = validTypeForRef(ref.XRef)
+		}
+		fieldInfo := b.buildFromSchemaOrReference(union.Name+"Option"+strconv.Itoa(idx+1), schemaOrRef)
+		makeFieldAndAppendToType(fieldInfo, union, fieldName)
+	}
+	if union != schemaType {
+		b.model.addType(union)
+		makeFieldAndAppendToType(&FieldInfo{fieldKind: FieldKind_REFERENCE, fieldType: union.Name}, schemaType, "one_of")
+	}
+}
+
+// buildFromAnyOfAndAllOf adds appropriate fields to the 'schemaType' given a new 'schemaOrRef'.
+func (b *OpenAPI3Builder) buildFromAnyOfAndAllOf(schemaOrRef *openapiv3.SchemaOrReference, schemaType *Type) {
 	// Related: https://github.com/google/gnostic-grpc/issues/22
 	if schema := schemaOrRef.GetSchema(); schema != nil {
 		// Build a temporary type that has the required fields; add the fields to the current schema; remove the
@@ -457,8 +569,6 @@ func (b *OpenAPI3Builder) removeType(toRemove *Type) {
 func (b *OpenAPI3Builder) checkForExistence(name string, fInfo *FieldInfo) {
 	// In certain cases no type will be created during the recursion. (e.g.: the schema is a primitive schema)
 	if t := findType(b.model.Types, name); t == nil {
-		t = makeType(name)
-		makeFieldAndAppendToType(fInfo, t, "value")
-		b.model.addType(t)
+		b.model.addType(makeValueType(name, fInfo))
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/google/gnostic/compiler"
 	openapiv3 "github.com/google/gnostic/openapiv3"
 
 	"github.com/google/go-cmp/cmp"
@@ -12,44 +13,65 @@ import (
 )
 
 func TestModelOpenAPIV3(t *testing.T) {
-	refFile := "testdata/v3.0/petstore.json"
-	modelFile := "testdata/v3.0/petstore.model.json"
+	for _, name := range []string{"petstore", "store"} {
+		t.Run(name, func(t *testing.T) {
+			refFile := "testdata/v3.0/" + name + ".json"
+			modelFile := "testdata/v3.0/" + name + ".model.json"
 
-	bFile, err := os.ReadFile(refFile)
-	if err != nil {
-		t.Logf("Failed to read file: %+v", err)
-		t.FailNow()
-	}
-	bModel, err := os.ReadFile(modelFile)
-	if err != nil {
-		t.Logf("Failed to read file: %+v", err)
-		t.FailNow()
-	}
+			bFile, err := os.ReadFile(refFile)
+			if err != nil {
+				t.Logf("Failed to read file: %+v", err)
+				t.FailNow()
+			}
+			bModel, err := os.ReadFile(modelFile)
+			if err != nil {
+				t.Logf("Failed to read file: %+v", err)
+				t.FailNow()
+			}
 
-	docv3, err := openapiv3.ParseDocument(bFile)
-	if err != nil {
-		t.Logf("Failed to parse document: %+v", err)
-		t.FailNow()
-	}
+			docv3, err := openapiv3.ParseDocument(bFile)
+			if err != nil {
+				t.Logf("Failed to parse document: %+v", err)
+				t.FailNow()
+			}
 
-	m, err := NewModelFromOpenAPI3(docv3, refFile)
-	if err != nil {
-		t.Logf("Failed to create model: %+v", err)
-		t.FailNow()
-	}
+			m, err := NewModelFromOpenAPI3(docv3, refFile)
+			if err != nil {
+				t.Logf("Failed to create model: %+v", err)
+				t.FailNow()
+			}
 
-	var model Model
-	if err := protojson.Unmarshal(bModel, &model); err != nil {
-		t.Logf("Failed to unmarshal model: %+v", err)
-		t.FailNow()
-	}
+			var model Model
+			if err := protojson.Unmarshal(bModel, &model); err != nil {
+				t.Logf("Failed to unmarshal model: %+v", err)
+				t.FailNow()
+			}
 
-	cmpOpts := []cmp.Option{
-		protocmp.Transform(),
-	}
-	if diff := cmp.Diff(&model, m, cmpOpts...); diff != "" {
-		t.Errorf("Model mismatch (-want +got):\n%s", diff)
+			cmpOpts := []cmp.Option{
+				protocmp.Transform(),
+			}
+			if diff := cmp.Diff(&model, m, cmpOpts...); diff != "" {
+				t.Errorf("Model mismatch (-want +got):\n%s", diff)
+			}
+
+			// NewModelFromOpenAPI3 resolved the references of docv3, so the document is parsed again.
+			docv3, err = openapiv3.ParseDocument(bFile)
+			if err != nil {
+				t.Logf("Failed to parse document: %+v", err)
+				t.FailNow()
+			}
+			session := compiler.NewSession(nil, nil)
+			defer session.Close()
+			m, err = NewModelFromOpenAPI3WithSession(docv3, refFile, session)
+			if err != nil {
+				t.Logf("Failed to create model with a session: %+v", err)
+				t.FailNow()
+			}
+			if diff := cmp.Diff(&model, m, cmpOpts...); diff != "" {
+				t.Errorf("Model mismatch with a session (-want +got):\n%s", diff)
+			}
+			x, _ := protojson.Marshal(m)
+			t.Logf("Model: %s", x)
+		})
 	}
-	x, _ := protojson.Marshal(m)
-	t.Logf("Model: %s", x)
 }
@@ -0,0 +1,377 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	plugins "github.com/google/gnostic/plugins"
+)
+
+// InvalidExampleCode is the code of messages that report invalid examples.
+const InvalidExampleCode = "INVALID_EXAMPLE"
+
+// exampleChecker collects messages about examples that don't match their schemas.
+type exampleChecker struct {
+	schemas  *Schemas
+	v3       *openapi_v3.Document
+	messages []*plugins.Message
+}
+
+func keysWith(keys []string, more ...string) []string {
+	return append(append([]string{}, keys...), more...)
+}
+
+// checkYAML validates an example, given as the YAML text of an Any message, against a schema.
+func (c *exampleChecker) checkYAML(keys []string, text string, schema *Schema) {
+	if schema == nil || text == "" {
+		return
+	}
+	value, err := valueForYAML(text)
+	if err != nil {
+		c.add(keys, fmt.Sprintf("Example can't be read: %s", err.Error()))
+		return
+	}
+	if err = schema.Validate(value); err != nil {
+		for _, e := range err.(Errors) {
+			if e.Pointer == "" {
+				c.add(keys, fmt.Sprintf("Example does not match its schema: %s.", e.Message))
+			} else {
+				c.add(keys, fmt.Sprintf("Example does not match its schema at %s: %s.", e.Pointer, e.Message))
+			}
+		}
+	}
+}
+
+func (c *exampleChecker) add(keys []string, text string) {
+	c.messages = append(c.messages, &plugins.Message{
+		Level: plugins.Message_ERROR,
+		Code:  InvalidExampleCode,
+		Text:  text,
+		Keys:  keys,
+	})
+}
+
+// OpenAPI3Examples checks every example in an OpenAPI v3 document against its schema.
+// Each problem is reported in a message with the keys of the offending example.
+// info is the yaml that the document was compiled from, or nil; see NewOpenAPI3Schemas.
+func OpenAPI3Examples(document *openapi_v3.Document, info *yaml.Node) []*plugins.Message {
+	c := &exampleChecker{schemas: NewOpenAPI3Schemas(document, info), v3: document, messages: make([]*plugins.Message, 0)}
+	if components := document.Components; components != nil {
+		keys := []string{"components"}
+		if components.Schemas != nil {
+			for _, pair := range components.Schemas.AdditionalProperties {
+				c.schemaV3(keysWith(keys, "schemas", pair.Name), pair.Value)
+			}
+		}
+		if components.Parameters != nil {
+			for _, pair := range components.Parameters.AdditionalProperties {
+				c.parameterV3(keysWith(keys, "parameters", pair.Name), pair.Value.GetParameter())
+			}
+		}
+		if components.RequestBodies != nil {
+			for _, pair := range components.RequestBodies.AdditionalProperties {
+				if body := pair.Value.GetRequestBody(); body != nil {
+					c.contentV3(keysWith(keys, "requestBodies", pair.Name, "content"), body.Content)
+				}
+			}
+		}
+		if components.Responses != nil {
+			for _, pair := range components.Responses.AdditionalProperties {
+				c.responseV3(keysWith(keys, "responses", pair.Name), pair.Value.GetResponse())
+			}
+		}
+		if components.Headers != nil {
+			for _, pair := range components.Headers.AdditionalProperties {
+				c.headerV3(keysWith(keys, "headers", pair.Name), pair.Value.GetHeader())
+			}
+		}
+	}
+	if document.Paths != nil {
+		for _, pair := range document.Paths.Path {
+			keys := []string{"paths", pair.Name}
+			item := pair.Value
+			for i, p := range item.Parameters {
+				c.parameterV3(keysWith(keys, "parameters", fmt.Sprintf("%d", i)), p.GetParameter())
+			}
+			for _, o := range []struct {
+				method    string
+				operation *openapi_v3.Operation
+			}{
+				{"get", item.Get},
+				{"put", item.Put},
+				{"post", item.Post},
+				{"delete", item.Delete},
+				{"options", item.Options},
+				{"head", item.Head},
+				{"patch", item.Patch},
+				{"trace", item.Trace},
+			} {
+				if o.operation != nil {
+					c.operationV3(keysWith(keys, o.method), o.operation)
+				}
+			}
+		}
+	}
+	return c.messages
+}
+
+func (c *exampleChecker) operationV3(keys []string, operation *openapi_v3.Operation) {
+	for i, p := range operation.Parameters {
+		c.parameterV3(keysWith(keys, "parameters", fmt.Sprintf("%d", i)), p.GetParameter())
+	}
+	if body := operation.RequestBody.GetRequestBody(); body != nil {
+		c.contentV3(keysWith(keys, "requestBody", "content"), body.Content)
+	}
+	if operation.Responses != nil {
+		if operation.Responses.Default != nil {
+			c.responseV3(keysWith(keys, "responses", "default"), operation.Responses.Default.GetResponse())
+		}
+		for _, pair := range operation.Responses.ResponseOrReference {
+			c.responseV3(keysWith(keys, "responses", pair.Name), pair.Value.GetResponse())
+		}
+	}
+}
+
+func (c *exampleChecker) parameterV3(keys []string, parameter *openapi_v3.Parameter) {
+	if parameter == nil {
+		return
+	}
+	schema := c.schemas.ForOpenAPI3(parameter.Schema)
+	if parameter.Example != nil {
+		c.checkYAML(keysWith(keys, "example"), parameter.Example.Yaml, schema)
+	}
+	c.examplesV3(keysWith(keys, "examples"), parameter.Examples, schema)
+	c.contentV3(keysWith(keys, "content"), parameter.Content)
+}
+
+func (c *exampleChecker) headerV3(keys []string, header *openapi_v3.Header) {
+	if header == nil {
+		return
+	}
+	schema := c.schemas.ForOpenAPI3(header.Schema)
+	if header.Example != nil {
+		c.checkYAML(keysWith(keys, "example"), header.Example.Yaml, schema)
+	}
+	c.examplesV3(keysWith(keys, "examples"), header.Examples, schema)
+	c.contentV3(keysWith(keys, "content"), header.Content)
+}
+
+func (c *exampleChecker) responseV3(keys []string, response *openapi_v3.Response) {
+	if response == nil {
+		return
+	}
+	if response.Headers != nil {
+		for _, pair := range response.Headers.AdditionalProperties {
+			c.headerV3(keysWith(keys, "headers", pair.Name), pair.Value.GetHeader())
+		}
+	}
+	c.contentV3(keysWith(keys, "content"), response.Content)
+}
+
+func (c *exampleChecker) contentV3(keys []string, content *openapi_v3.MediaTypes) {
+	if content == nil {
+		return
+	}
+	for _, pair := range content.AdditionalProperties {
+		mediaType := pair.Value
+		if mediaType == nil {
+			continue
+		}
+		mediaKeys := keysWith(keys, pair.Name)
+		schema := c.schemas.ForOpenAPI3(mediaType.Schema)
+		if mediaType.Example != nil {
+			c.checkYAML(keysWith(mediaKeys, "example"), mediaType.Example.Yaml, schema)
+		}
+		c.examplesV3(keysWith(mediaKeys, "examples"), mediaType.Examples, schema)
+		if mediaType.Schema.GetSchema() != nil {
+			c.schemaV3(keysWith(mediaKeys, "schema"), mediaType.Schema)
+		}
+	}
+}
+
+// examplesV3 checks named examples, resolving references to components.
+func (c *exampleChecker) examplesV3(keys []string, examples *openapi_v3.ExamplesOrReferences, schema *Schema) {
+	if examples == nil {
+		return
+	}
+	for _, pair := range examples.AdditionalProperties {
+		example := pair.Value.GetExample()
+		if reference := pair.Value.GetReference(); reference != nil {
+			example = c.exampleV3(reference.XRef)
+		}
+		if example != nil && example.Value != nil {
+			c.checkYAML(keysWith(keys, pair.Name, "value"), example.Value.Yaml, schema)
+		}
+	}
+}
+
+func (c *exampleChecker) exampleV3(ref string) *openapi_v3.Example {
+	name := strings.TrimPrefix(ref, "#/components/examples/")
+	if c.v3.Components == nil || c.v3.Components.Examples == nil {
+		return nil
+	}
+	for _, pair := range c.v3.Components.Examples.AdditionalProperties {
+		if pair.Name == name {
+			return pair.Value.GetExample()
+		}
+	}
+	return nil
+}
+
+// schemaV3 checks the examples of a schema and of the schemas that it contains.
+func (c *exampleChecker) schemaV3(keys []string, item *openapi_v3.SchemaOrReference) {
+	s := item.GetSchema()
+	if s == nil {
+		return
+	}
+	if s.Example != nil {
+		c.checkYAML(keysWith(keys, "example"), s.Example.Yaml, c.schemas.ForOpenAPI3(item))
+	}
+	if s.Properties != nil {
+		for _, pair := range s.Properties.AdditionalProperties {
+			c.schemaV3(keysWith(keys, "properties", pair.Name), pair.Value)
+		}
+	}
+	if s.Items != nil {
+		for _, items := range s.Items.SchemaOrReference {
+			c.schemaV3(keysWith(keys, "items"), items)
+		}
+	}
+	if s.AdditionalProperties != nil {
+		c.schemaV3(keysWith(keys, "additionalProperties"), s.AdditionalProperties.GetSchemaOrReference())
+	}
+	for _, group := range []struct {
+		name    string
+		members []*openapi_v3.SchemaOrReference
+	}{
+		{"allOf", s.AllOf},
+		{"anyOf", s.AnyOf},
+		{"oneOf", s.OneOf},
+	} {
+		for i, member := range group.members {
+			c.schemaV3(keysWith(keys, group.name, fmt.Sprintf("%d", i)), member)
+		}
+	}
+}
+
+// OpenAPI2Examples checks every example in an OpenAPI v2 document against its schema.
+// Each problem is reported in a message with the keys of the offending example.
+// info is the yaml that the document was compiled from, or nil; see NewOpenAPI2Schemas.
+func OpenAPI2Examples(document *openapi_v2.Document, info *yaml.Node) []*plugins.Message {
+	c := &exampleChecker{schemas: NewOpenAPI2Schemas(document, info), messages: make([]*plugins.Message, 0)}
+	if document.Definitions != nil {
+		for _, pair := range document.Definitions.AdditionalProperties {
+			c.schemaV2([]string{"definitions", pair.Name}, pair.Value)
+		}
+	}
+	if document.Parameters != nil {
+		for _, pair := range document.Parameters.AdditionalProperties {
+			if body := pair.Value.GetBodyParameter(); body != nil {
+				c.schemaV2([]string{"parameters", pair.Name, "schema"}, body.Schema)
+			}
+		}
+	}
+	if document.Responses != nil {
+		for _, pair := range document.Responses.AdditionalProperties {
+			c.responseV2([]string{"responses", pair.Name}, pair.Value)
+		}
+	}
+	if document.Paths != nil {
+		for _, pair := range document.Paths.Path {
+			keys := []string{"paths", pair.Name}
+			item := pair.Value
+			for _, o := range []struct {
+				method    string
+				operation *openapi_v2.Operation
+			}{
+				{"get", item.Get},
+				{"put", item.Put},
+				{"post", item.Post},
+				{"delete", item.Delete},
+				{"options", item.Options},
+				{"head", item.Head},
+				{"patch", item.Patch},
+			} {
+				if o.operation == nil {
+					continue
+				}
+				operationKeys := keysWith(keys, o.method)
+				for i, p := range o.operation.Parameters {
+					if body := p.GetParameter().GetBodyParameter(); body != nil {
+						c.schemaV2(keysWith(operationKeys, "parameters", fmt.Sprintf("%d", i), "schema"), body.Schema)
+					}
+				}
+				if o.operation.Responses != nil {
+					for _, response := range o.operation.Responses.ResponseCode {
+						c.responseV2(keysWith(operationKeys, "responses", response.Name), response.Value.GetResponse())
+					}
+				}
+			}
+		}
+	}
+	return c.messages
+}
+
+func (c *exampleChecker) responseV2(keys []string, response *openapi_v2.Response) {
+	if response == nil || response.Schema == nil {
+		return
+	}
+	schema := response.Schema.GetSchema()
+	if schema == nil {
+		return
+	}
+	c.schemaV2(keysWith(keys, "schema"), schema)
+	if response.Examples != nil {
+		validationSchema := c.schemas.ForOpenAPI2(schema)
+		for _, pair := range response.Examples.AdditionalProperties {
+			// Examples are keyed by MIME type; only JSON examples can be checked.
+			if strings.Contains(pair.Name, "json") && pair.Value != nil {
+				c.checkYAML(keysWith(keys, "examples", pair.Name), pair.Value.Yaml, validationSchema)
+			}
+		}
+	}
+}
+
+// schemaV2 checks the examples of a schema and of the schemas that it contains.
+func (c *exampleChecker) schemaV2(keys []string, s *openapi_v2.Schema) {
+	if s == nil || s.XRef != "" {
+		return
+	}
+	if s.Example != nil {
+		c.checkYAML(keysWith(keys, "example"), s.Example.Yaml, c.schemas.ForOpenAPI2(s))
+	}
+	if s.Properties != nil {
+		for _, pair := range s.Properties.AdditionalProperties {
+			c.schemaV2(keysWith(keys, "properties", pair.Name), pair.Value)
+		}
+	}
+	if s.Items != nil {
+		for _, items := range s.Items.Schema {
+			c.schemaV2(keysWith(keys, "items"), items)
+		}
+	}
+	if s.AdditionalProperties != nil {
+		c.schemaV2(keysWith(keys, "additionalProperties"), s.AdditionalProperties.GetSchema())
+	}
+	for i, member := range s.AllOf {
+		c.schemaV2(keysWith(keys, "allOf", fmt.Sprintf("%d", i)), member)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	plugins "github.com/google/gnostic/plugins"
+)
+
+const examplesV3 = `
+openapi: 3.0.0
+info:
+  title: Examples
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: integer
+          maximum: 100
+        example: 1000
+      - name: kind
+        in: query
+        schema:
+          type: string
+          enum: [cat, dog]
+        examples:
+          good:
+            value: cat
+          bad:
+            value: cow
+      responses:
+        "200":
+          description: Pets.
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+              examples:
+                list:
+                  $ref: '#/components/examples/Pets'
+components:
+  examples:
+    Pets:
+      value:
+      - name: Fluffy
+        id: 7
+      - id: 8
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        id:
+          type: string
+          format: uuid
+          example: not-a-uuid
+        name:
+          type: string
+      example:
+        name: Fluffy
+`
+
+func messageStrings(messages []*plugins.Message) string {
+	lines := make([]string, 0)
+	for _, m := range messages {
+		if m.Level != plugins.Message_ERROR || m.Code != InvalidExampleCode {
+			lines = append(lines, "unexpected level or code")
+		}
+		lines = append(lines, strings.Join(m.Keys, " ")+": "+m.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestOpenAPI3Examples(t *testing.T) {
+	document, err := openapi_v3.ParseDocument([]byte(examplesV3))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	expected := strings.Join([]string{
+		`components schemas Pet properties id example: Example does not match its schema: "not-a-uuid" is not a valid uuid.`,
+		`paths /pets get parameters 0 example: Example does not match its schema: 1000 is greater than maximum 100.`,
+		`paths /pets get parameters 1 examples bad value: Example does not match its schema: "cow" is not one of the allowed values.`,
+		`paths /pets get responses 200 content application/json examples list value: Example does not match its schema at /0/id: expected string but found integer.`,
+		`paths /pets get responses 200 content application/json examples list value: Example does not match its schema at /1: missing required property "name".`,
+		`paths /pets get responses 200 content application/json examples list value: Example does not match its schema at /1/id: expected string but found integer.`,
+	}, "\n")
+	if found := messageStrings(OpenAPI3Examples(document, nil)); found != expected {
+		t.Errorf("expected\n%s\nfound\n%s", expected, found)
+	}
+}
+
+const examplesV2 = `
+swagger: "2.0"
+info:
+  title: Examples
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: Pets.
+          schema:
+            $ref: '#/definitions/Pet'
+          examples:
+            application/json:
+              name: 7
+            text/plain: anything
+definitions:
+  Pet:
+    type: object
+    properties:
+      name:
+        type: string
+      born:
+        type: string
+        format: date-time
+        example: "2020-02-30T10:00:00Z"
+`
+
+func TestOpenAPI2Examples(t *testing.T) {
+	document, err := openapi_v2.ParseDocument([]byte(examplesV2))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	expected := strings.Join([]string{
+		`definitions Pet properties born example: Example does not match its schema: "2020-02-30T10:00:00Z" is not a valid date-time.`,
+		`paths /pets get responses 200 examples application/json: Example does not match its schema at /name: expected string but found integer.`,
+	}, "\n")
+	if found := messageStrings(OpenAPI2Examples(document, nil)); found != expected {
+		t.Errorf("expected\n%s\nfound\n%s", expected, found)
+	}
+}
+
+const zeroLimitsV3 = `
+openapi: 3.0.0
+info:
+  title: Limits
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: offset
+          in: query
+          schema:
+            type: integer
+            minimum: 0
+          example: -1
+      responses:
+        "200":
+          description: No pets.
+          content:
+            application/json:
+              schema:
+                type: array
+                maxItems: 0
+              example: [1]
+components:
+  schemas:
+    Count:
+      type: integer
+      minimum: 1
+      maximum: 0
+      example: 1
+`
+
+const zeroLimitsV2 = `
+swagger: "2.0"
+info:
+  title: Limits
+  version: 1.0.0
+paths: {}
+definitions:
+  Empty:
+    type: string
+    maxLength: 0
+    example: "a"
+  Fields:
+    type: object
+    maxProperties: 0
+    properties:
+      offset:
+        type: number
+        minimum: 0
+        example: -0.5
+    example:
+      offset: 1
+`
+
+func TestZeroLimits(t *testing.T) {
+	var info yaml.Node
+	if err := yaml.Unmarshal([]byte(zeroLimitsV3), &info); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	document, err := openapi_v3.ParseDocument([]byte(zeroLimitsV3))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	expected := strings.Join([]string{
+		`components schemas Count example: Example does not match its schema: 1 is greater than maximum 0.`,
+		`paths /pets get parameters 0 example: Example does not match its schema: -1 is less than minimum 0.`,
+		`paths /pets get responses 200 content application/json example: Example does not match its schema: 1 items is more than maxItems 0.`,
+	}, "\n")
+	if found := messageStrings(OpenAPI3Examples(document, &info)); found != expected {
+		t.Errorf("expected\n%s\nfound\n%s", expected, found)
+	}
+	// Without the source, limits with zero values aren't enforced.
+	if found := messageStrings(OpenAPI3Examples(document, nil)); found != "" {
+		t.Errorf("unexpected messages\n%s", found)
+	}
+
+	info = yaml.Node{}
+	if err := yaml.Unmarshal([]byte(zeroLimitsV2), &info); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	documentV2, err := openapi_v2.ParseDocument([]byte(zeroLimitsV2))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	expected = strings.Join([]string{
+		`definitions Empty example: Example does not match its schema: length 1 is greater than maxLength 0.`,
+		`definitions Fields example: Example does not match its schema: 1 properties is more than maxProperties 0.`,
+		`definitions Fields properties offset example: Example does not match its schema: -0.5 is less than minimum 0.`,
+	}, "\n")
+	if found := messageStrings(OpenAPI2Examples(documentV2, &info)); found != expected {
+		t.Errorf("expected\n%s\nfound\n%s", expected, found)
+	}
+}
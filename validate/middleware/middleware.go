@@ -0,0 +1,365 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package middleware provides an http.Handler that validates requests and
+// responses against the operations of an OpenAPI v2 or v3 description.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/google/gnostic/validate"
+)
+
+// Options control the behavior of the validating handler.
+type Options struct {
+	// ValidateResponses enables validation of responses. Responses are
+	// buffered, and invalid responses are replaced with a problem report.
+	ValidateResponses bool
+	// IgnoreUnknownPaths passes requests that don't match any path of the
+	// description to the wrapped handler instead of rejecting them.
+	IgnoreUnknownPaths bool
+	// MaxBodySize limits the size of request bodies that are read for
+	// validation. Larger bodies are rejected. The default is 10 MB.
+	MaxBodySize int64
+	// Source is the yaml that the description was compiled from. Limits with
+	// zero values, like "minimum: 0", are read from it, and without it, they
+	// aren't enforced.
+	Source *yaml.Node
+}
+
+// source returns the source of the description, which is nil without options.
+func (o *Options) source() *yaml.Node {
+	if o == nil {
+		return nil
+	}
+	return o.Source
+}
+
+// defaultMaxBodySize is the default limit of the size of request bodies.
+const defaultMaxBodySize = 10 << 20
+
+// parameter is a parameter of an operation.
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	schema   *validate.Schema
+}
+
+// mediaType is an accepted media type of a request or response body.
+type mediaType struct {
+	name   string
+	schema *validate.Schema
+}
+
+// operation holds the validation information of an operation.
+type operation struct {
+	parameters   []*parameter
+	body         []*mediaType
+	bodyRequired bool
+	responses    map[string][]*mediaType // keyed by status code, range ("2XX") or "default"
+}
+
+// Handler validates requests and responses against an API description.
+type Handler struct {
+	handler http.Handler
+	options Options
+	router  *router
+}
+
+func newHandler(r *router, handler http.Handler, options *Options) *Handler {
+	h := &Handler{handler: handler, router: r}
+	if options != nil {
+		h.options = *options
+	}
+	return h
+}
+
+// Match describes the operation that matched a request.
+type Match struct {
+	Method         string
+	Template       string            // path template of the operation, e.g. "/pets/{petId}"
+	PathParameters map[string]string // values of path parameters, keyed by name
+}
+
+type matchKey struct{}
+
+// MatchFromContext returns the operation that the handler matched to a
+// request, or nil if the request did not pass through a handler.
+func MatchFromContext(ctx context.Context) *Match {
+	m, _ := ctx.Value(matchKey{}).(*Match)
+	return m
+}
+
+// ServeHTTP validates a request and, if it is valid, passes it to the wrapped handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Paths are matched before they are unescaped, so that escaped slashes
+	// stay in the values of path parameters.
+	r, pathParameters := h.router.find(req.URL.EscapedPath())
+	if r == nil {
+		if h.options.IgnoreUnknownPaths {
+			h.handler.ServeHTTP(w, req)
+			return
+		}
+		WriteProblem(w, &Problem{Status: http.StatusNotFound, Detail: fmt.Sprintf("no operation matches %s", req.URL.Path)})
+		return
+	}
+	op := r.operations[req.Method]
+	if op == nil {
+		methods := make([]string, 0, len(r.operations))
+		for method := range r.operations {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		WriteProblem(w, &Problem{Status: http.StatusMethodNotAllowed,
+			Detail: fmt.Sprintf("%s is not allowed for %s", req.Method, r.template)})
+		return
+	}
+	var data []byte
+	if op.body != nil {
+		var problem *Problem
+		if data, problem = h.readBody(w, req); problem != nil {
+			WriteProblem(w, problem)
+			return
+		}
+	}
+	if errors := h.validateRequest(req, op, pathParameters, data); len(errors) > 0 {
+		WriteProblem(w, &Problem{Status: http.StatusBadRequest, Detail: "the request is invalid", Errors: errors})
+		return
+	}
+	req = req.WithContext(context.WithValue(req.Context(), matchKey{},
+		&Match{Method: req.Method, Template: r.template, PathParameters: pathParameters}))
+	if !h.options.ValidateResponses {
+		h.handler.ServeHTTP(w, req)
+		return
+	}
+	recorder := newResponseRecorder()
+	h.handler.ServeHTTP(recorder, req)
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	if errors := validateResponse(recorder, op); len(errors) > 0 {
+		WriteProblem(w, &Problem{Status: http.StatusInternalServerError, Detail: "the response is invalid", Errors: errors})
+		return
+	}
+	recorder.writeTo(w)
+}
+
+// readBody reads the body of a request and replaces it, so that the wrapped
+// handler can read it again.
+func (h *Handler) readBody(w http.ResponseWriter, req *http.Request) ([]byte, *Problem) {
+	limit := h.options.MaxBodySize
+	if limit <= 0 {
+		limit = defaultMaxBodySize
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, limit))
+	if err != nil {
+		if int64(len(data)) >= limit {
+			return nil, &Problem{Status: http.StatusRequestEntityTooLarge,
+				Detail: fmt.Sprintf("the request body is larger than %d bytes", limit)}
+		}
+		return nil, &Problem{Status: http.StatusBadRequest, Detail: "the request is invalid",
+			Errors: []*ProblemError{{Location: "body", Message: err.Error()}}}
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// validateRequest checks the parameters and body of a request.
+func (h *Handler) validateRequest(req *http.Request, op *operation, pathParameters map[string]string, data []byte) []*ProblemError {
+	errors := make([]*ProblemError, 0)
+	query := req.URL.Query()
+	var form url.Values
+	for _, p := range op.parameters {
+		var values []string
+		switch p.in {
+		case "path":
+			if value, ok := pathParameters[p.name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[p.name]
+		case "header":
+			values = req.Header[http.CanonicalHeaderKey(p.name)]
+		case "cookie":
+			if cookie, err := req.Cookie(p.name); err == nil {
+				values = []string{cookie.Value}
+			}
+		case "formData":
+			if form == nil {
+				form = formValues(req.Header.Get("Content-Type"), data)
+			}
+			values = form[p.name]
+		}
+		location := p.in + ":" + p.name
+		if len(values) == 0 {
+			if p.required {
+				errors = append(errors, &ProblemError{Location: location, Message: "required parameter is missing"})
+			}
+			continue
+		}
+		if p.schema == nil {
+			continue
+		}
+		if err := p.schema.ValidateRequest(p.schema.ParseStrings(values)); err != nil {
+			errors = append(errors, problemErrors(location, err)...)
+		}
+	}
+
+	if op.body == nil {
+		return errors
+	}
+	if len(data) == 0 {
+		if op.bodyRequired {
+			errors = append(errors, &ProblemError{Location: "body", Message: "request body is required"})
+		}
+		return errors
+	}
+	m := matchMediaType(op.body, req.Header.Get("Content-Type"))
+	if m == nil {
+		return append(errors, &ProblemError{Location: "body",
+			Message: fmt.Sprintf("unsupported content type %q", req.Header.Get("Content-Type"))})
+	}
+	if isJSON(m.name) && m.schema != nil {
+		value, err := decodeJSON(data)
+		if err != nil {
+			return append(errors, &ProblemError{Location: "body", Message: err.Error()})
+		}
+		if err := m.schema.ValidateRequest(value); err != nil {
+			errors = append(errors, problemErrors("body", err)...)
+		}
+	}
+	return errors
+}
+
+// formValues returns the values of a form body. Uploaded files are
+// represented by their file names.
+func formValues(contentType string, data []byte) url.Values {
+	values := make(url.Values)
+	name, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return values
+	}
+	switch name {
+	case "application/x-www-form-urlencoded":
+		if parsed, err := url.ParseQuery(string(data)); err == nil {
+			values = parsed
+		}
+	case "multipart/form-data":
+		form, err := multipart.NewReader(bytes.NewReader(data), params["boundary"]).ReadForm(32 << 20)
+		if err != nil {
+			return values
+		}
+		defer form.RemoveAll()
+		for key, v := range form.Value {
+			values[key] = v
+		}
+		for key, files := range form.File {
+			for _, file := range files {
+				values[key] = append(values[key], file.Filename)
+			}
+		}
+	}
+	return values
+}
+
+// validateResponse checks the status code and body of a recorded response.
+func validateResponse(recorder *responseRecorder, op *operation) []*ProblemError {
+	if len(op.responses) == 0 {
+		return nil
+	}
+	code := fmt.Sprintf("%d", recorder.status)
+	mediaTypes, ok := op.responses[code]
+	if !ok {
+		mediaTypes, ok = op.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		mediaTypes, ok = op.responses["default"]
+	}
+	if !ok {
+		return []*ProblemError{{Location: "status", Message: fmt.Sprintf("status %s is not a documented response", code)}}
+	}
+	if recorder.body.Len() == 0 || len(mediaTypes) == 0 {
+		return nil
+	}
+	m := matchMediaType(mediaTypes, recorder.Header().Get("Content-Type"))
+	if m == nil {
+		return []*ProblemError{{Location: "body",
+			Message: fmt.Sprintf("unexpected content type %q", recorder.Header().Get("Content-Type"))}}
+	}
+	if !isJSON(m.name) || m.schema == nil {
+		return nil
+	}
+	value, err := decodeJSON(recorder.body.Bytes())
+	if err != nil {
+		return []*ProblemError{{Location: "body", Message: err.Error()}}
+	}
+	if err := m.schema.ValidateResponse(value); err != nil {
+		return problemErrors("body", err)
+	}
+	return nil
+}
+
+// matchMediaType returns the media type that matches a content type, allowing wildcards.
+func matchMediaType(mediaTypes []*mediaType, contentType string) *mediaType {
+	name, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		if len(mediaTypes) == 1 && contentType == "" {
+			return mediaTypes[0]
+		}
+		return nil
+	}
+	for _, pattern := range []func(m string) bool{
+		func(m string) bool { return m == name },
+		func(m string) bool {
+			return strings.HasSuffix(m, "/*") && strings.HasPrefix(name, strings.TrimSuffix(m, "*"))
+		},
+		func(m string) bool { return m == "*/*" },
+	} {
+		for _, m := range mediaTypes {
+			if pattern(m.name) {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %s", err.Error())
+	}
+	return value, nil
+}
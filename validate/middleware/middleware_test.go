@@ -0,0 +1,347 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+const petstore = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+- url: https://pets.example.com/v1
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: integer
+          maximum: 100
+      - name: tags
+        in: query
+        schema:
+          type: array
+          items:
+            type: string
+            enum: [small, large]
+      - name: X-Request-Id
+        in: header
+        required: true
+        schema:
+          type: string
+          format: uuid
+      responses:
+        "200":
+          description: Pets.
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "201":
+          description: Created.
+        default:
+          description: Error.
+  /pets/{petId}:
+    parameters:
+    - name: petId
+      in: path
+      required: true
+      schema:
+        type: integer
+        format: int64
+    get:
+      parameters:
+      - name: session
+        in: cookie
+        schema:
+          type: string
+          minLength: 4
+      responses:
+        "2XX":
+          description: A pet.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /pets/mine:
+    get:
+      responses:
+        "200":
+          description: My pets.
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+`
+
+// The backend returns a fixed body and records the requests that reach it.
+type backend struct {
+	body    string
+	reached int
+}
+
+func (b *backend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	b.reached++
+	w.Header().Set("Content-Type", "application/json")
+	if req.Method == http.MethodPost {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.Write([]byte(b.body))
+}
+
+func newTestHandler(t *testing.T, b *backend, options *Options) *Handler {
+	document, err := openapi_v3.ParseDocument([]byte(petstore))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	return NewHandler(document, b, options)
+}
+
+func TestRequestValidation(t *testing.T) {
+	const requestID = "0b7c4a3e-6c1e-4bc4-a1f5-b2b8b1e6c3d2"
+	for _, test := range []struct {
+		method  string
+		target  string
+		headers map[string]string
+		body    string
+		status  int
+		errors  []string
+	}{
+		{"GET", "/v1/pets?limit=10&tags=small,large", map[string]string{"X-Request-Id": requestID}, "", 200, nil},
+		{"GET", "/v1/pets?tags=small&tags=large", map[string]string{"X-Request-Id": requestID}, "", 200, nil},
+		{"GET", "/v1/pets?limit=1000&tags=medium", map[string]string{"X-Request-Id": "abc"}, "", 400, []string{
+			"query:limit: 1000 is greater than maximum 100",
+			"query:tags/0: \"medium\" is not one of the allowed values",
+			"header:X-Request-Id: \"abc\" is not a valid uuid"}},
+		{"GET", "/v1/pets?limit=ten", nil, "", 400, []string{
+			"query:limit: expected integer but found string",
+			"header:X-Request-Id: required parameter is missing"}},
+		{"GET", "/v1/pets/7", map[string]string{"Cookie": "session=abcdef"}, "", 200, nil},
+		{"GET", "/v1/pets/seven", map[string]string{"Cookie": "session=abc"}, "", 400, []string{
+			"path:petId: expected integer but found string",
+			"cookie:session: length 3 is less than minLength 4"}},
+		{"GET", "/v1/pets/mine", nil, "", 200, nil},
+		{"POST", "/v1/pets", map[string]string{"Content-Type": "application/json"}, `{"name": "Fluffy"}`, 201, nil},
+		{"POST", "/v1/pets", map[string]string{"Content-Type": "application/json"}, `{"id": 1}`, 400, []string{
+			"body: missing required property \"name\"",
+			"body/id: property \"id\" is read-only"}},
+		{"POST", "/v1/pets", map[string]string{"Content-Type": "application/json"}, ``, 400, []string{
+			"body: request body is required"}},
+		{"POST", "/v1/pets", map[string]string{"Content-Type": "text/plain"}, `Fluffy`, 400, []string{
+			"body: unsupported content type \"text/plain\""}},
+		{"DELETE", "/v1/pets", nil, "", 405, nil},
+		{"GET", "/v1/owners", nil, "", 404, nil},
+	} {
+		b := &backend{body: "[]"}
+		h := newTestHandler(t, b, nil)
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		name := test.method + " " + test.target
+		if w.Code != test.status {
+			t.Errorf("%s: expected status %d, found %d: %s", name, test.status, w.Code, w.Body.String())
+			continue
+		}
+		if test.status < 300 {
+			if b.reached != 1 {
+				t.Errorf("%s: request did not reach the backend", name)
+			}
+			continue
+		}
+		if b.reached != 0 {
+			t.Errorf("%s: invalid request reached the backend", name)
+		}
+		if w.Header().Get("Content-Type") != ProblemContentType {
+			t.Errorf("%s: unexpected content type %s", name, w.Header().Get("Content-Type"))
+		}
+		var problem Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		if problem.Status != test.status || problem.Type != "about:blank" {
+			t.Errorf("%s: unexpected problem %+v", name, problem)
+		}
+		found := make([]string, 0)
+		for _, e := range problem.Errors {
+			found = append(found, e.Location+e.Pointer+": "+e.Message)
+		}
+		if strings.Join(found, "\n") != strings.Join(test.errors, "\n") {
+			t.Errorf("%s: expected errors\n%s\nfound\n%s", name, strings.Join(test.errors, "\n"), strings.Join(found, "\n"))
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	h := newTestHandler(t, &backend{}, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/v1/pets", nil))
+	if allow := w.Header().Get("Allow"); allow != "GET, POST" {
+		t.Errorf("unexpected Allow header %q", allow)
+	}
+}
+
+func TestResponseValidation(t *testing.T) {
+	for body, status := range map[string]int{
+		`{"id": 7, "name": "Fluffy"}`: 200,
+		`{"id": "7"}`:                 500,
+		`not json`:                    500,
+	} {
+		h := newTestHandler(t, &backend{body: body}, &Options{ValidateResponses: true})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/pets/7", nil))
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, found %d", body, status, w.Code)
+		}
+		if status == 200 && w.Body.String() != body {
+			t.Errorf("unexpected body %s", w.Body.String())
+		}
+	}
+
+	// The handler can be tested with a live server.
+	server := httptest.NewServer(newTestHandler(t, &backend{}, &Options{ValidateResponses: true}))
+	defer server.Close()
+	response, err := http.Post(server.URL+"/v1/pets", "application/json", strings.NewReader(`{"name": "Fluffy"}`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(response.Body)
+		t.Errorf("expected status 201, found %d: %s", response.StatusCode, string(body))
+	}
+}
+
+const petstoreV2 = `
+swagger: "2.0"
+info:
+  title: Pets
+  version: 1.0.0
+basePath: /v1
+paths:
+  /pets/{petId}:
+    parameters:
+    - name: petId
+      in: path
+      required: true
+      type: integer
+    put:
+      consumes:
+      - application/x-www-form-urlencoded
+      parameters:
+      - name: name
+        in: formData
+        required: true
+        type: string
+      - name: age
+        in: formData
+        type: integer
+      responses:
+        "200":
+          description: Updated.
+`
+
+func TestOpenAPI2Request(t *testing.T) {
+	document, err := openapi_v2.ParseDocument([]byte(petstoreV2))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	var match *Match
+	h := NewOpenAPI2Handler(document, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		match = MatchFromContext(req.Context())
+	}), nil)
+	for body, status := range map[string]int{
+		"name=Fluffy&age=3": 200,
+		"name=Fluffy":       200,
+		"age=three":         400,
+	} {
+		match = nil
+		req := httptest.NewRequest("PUT", "/v1/pets/7", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, found %d: %s", body, status, w.Code, w.Body.String())
+		}
+		if status == 200 && (match == nil || match.Template != "/pets/{petId}" || match.PathParameters["petId"] != "7") {
+			t.Errorf("%s: unexpected match %+v", body, match)
+		}
+	}
+}
+
+func TestEscapedPathParameters(t *testing.T) {
+	r := newRouter([]string{"/v1"}, []string{"/files/{name}", "/files/{name}/size"})
+	for path, expected := range map[string]string{
+		"/v1/files/a%2520b":    "a%20b",
+		"/v1/files/a%2Fb":      "a/b",
+		"/v1/files/a%20b":      "a b",
+		"/v1/files/a%2Fb/size": "a/b",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		route, parameters := r.find(req.URL.EscapedPath())
+		if route == nil {
+			t.Errorf("%s: no route matches", path)
+			continue
+		}
+		if parameters["name"] != expected {
+			t.Errorf("%s: expected name %q, found %q", path, expected, parameters["name"])
+		}
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	b := &backend{}
+	h := newTestHandler(t, b, &Options{MaxBodySize: 16})
+	req := httptest.NewRequest("POST", "/v1/pets", strings.NewReader(`{"name": "Fluffy the Magnificent"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge || b.reached != 0 {
+		t.Errorf("expected status %d, found %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	}
+}
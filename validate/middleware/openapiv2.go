@@ -0,0 +1,192 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	"github.com/google/gnostic/validate"
+)
+
+// openAPI2Builder builds the operations of an OpenAPI v2 document.
+type openAPI2Builder struct {
+	document *openapi_v2.Document
+	schemas  *validate.Schemas
+}
+
+// NewOpenAPI2Handler returns a handler that validates requests against an
+// OpenAPI v2 description before passing them to the wrapped handler.
+func NewOpenAPI2Handler(document *openapi_v2.Document, handler http.Handler, options *Options) *Handler {
+	b := &openAPI2Builder{document: document, schemas: validate.NewOpenAPI2Schemas(document, options.source())}
+	templates := make([]string, 0)
+	if document.Paths != nil {
+		for _, pair := range document.Paths.Path {
+			templates = append(templates, pair.Name)
+		}
+	}
+	h := newHandler(newRouter([]string{document.BasePath}, templates), handler, options)
+	if document.Paths != nil {
+		for _, pair := range document.Paths.Path {
+			r := h.router.route(pair.Name)
+			item := pair.Value
+			for _, o := range []struct {
+				method    string
+				operation *openapi_v2.Operation
+			}{
+				{http.MethodGet, item.Get},
+				{http.MethodPut, item.Put},
+				{http.MethodPost, item.Post},
+				{http.MethodDelete, item.Delete},
+				{http.MethodOptions, item.Options},
+				{http.MethodHead, item.Head},
+				{http.MethodPatch, item.Patch},
+			} {
+				if o.operation != nil {
+					r.operations[o.method] = b.newOperation(item.Parameters, o.operation)
+				}
+			}
+		}
+	}
+	return h
+}
+
+func (b *openAPI2Builder) newOperation(common []*openapi_v2.ParametersItem, op *openapi_v2.Operation) *operation {
+	result := &operation{responses: make(map[string][]*mediaType)}
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = b.document.Consumes
+	}
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = b.document.Produces
+	}
+	// Operation parameters override path item parameters with the same name and location.
+	parameters := make(map[string]*parameter)
+	keys := make([]string, 0)
+	for _, item := range append(append([]*openapi_v2.ParametersItem{}, common...), op.Parameters...) {
+		p := b.resolveParameter(item)
+		if p == nil {
+			continue
+		}
+		if body := p.GetBodyParameter(); body != nil {
+			result.body = b.mediaTypes(consumes, b.schemas.ForOpenAPI2(body.Schema))
+			result.bodyRequired = body.Required
+			continue
+		}
+		np := newOpenAPI2Parameter(p.GetNonBodyParameter())
+		if np == nil {
+			continue
+		}
+		if np.in == "formData" && result.body == nil {
+			// Form parameters are validated individually, so the body only needs an accepted content type.
+			result.body = b.mediaTypes(consumes, nil)
+		}
+		key := np.in + ":" + np.name
+		if _, ok := parameters[key]; !ok {
+			keys = append(keys, key)
+		}
+		parameters[key] = np
+	}
+	for _, key := range keys {
+		result.parameters = append(result.parameters, parameters[key])
+	}
+	if op.Responses != nil {
+		for _, pair := range op.Responses.ResponseCode {
+			if response := b.resolveResponse(pair.Value); response != nil {
+				var schema *validate.Schema
+				if response.Schema != nil {
+					schema = b.schemas.ForOpenAPI2(response.Schema.GetSchema())
+				}
+				code := strings.ToUpper(pair.Name)
+				if schema == nil {
+					result.responses[code] = []*mediaType{}
+				} else {
+					result.responses[code] = b.mediaTypes(produces, schema)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// newOpenAPI2Parameter returns a non-body parameter of an OpenAPI v2 document.
+func newOpenAPI2Parameter(p *openapi_v2.NonBodyParameter) *parameter {
+	if s := p.GetPathParameterSubSchema(); s != nil {
+		return &parameter{name: s.Name, in: "path", required: true,
+			schema: validate.ForOpenAPI2Primitive(s.Type, s.Format, s.Enum, s.Items)}
+	}
+	if s := p.GetQueryParameterSubSchema(); s != nil {
+		return &parameter{name: s.Name, in: "query", required: s.Required,
+			schema: validate.ForOpenAPI2Primitive(s.Type, s.Format, s.Enum, s.Items)}
+	}
+	if s := p.GetHeaderParameterSubSchema(); s != nil {
+		return &parameter{name: s.Name, in: "header", required: s.Required,
+			schema: validate.ForOpenAPI2Primitive(s.Type, s.Format, s.Enum, s.Items)}
+	}
+	if s := p.GetFormDataParameterSubSchema(); s != nil {
+		var schema *validate.Schema
+		if s.Type != "file" {
+			schema = validate.ForOpenAPI2Primitive(s.Type, s.Format, s.Enum, s.Items)
+		}
+		return &parameter{name: s.Name, in: "formData", required: s.Required, schema: schema}
+	}
+	return nil
+}
+
+// mediaTypes returns the media types of a body, which default to JSON.
+func (b *openAPI2Builder) mediaTypes(names []string, schema *validate.Schema) []*mediaType {
+	if len(names) == 0 {
+		names = []string{"application/json"}
+	}
+	result := make([]*mediaType, 0, len(names))
+	for _, name := range names {
+		result = append(result, &mediaType{name: strings.ToLower(name), schema: schema})
+	}
+	return result
+}
+
+func (b *openAPI2Builder) resolveParameter(item *openapi_v2.ParametersItem) *openapi_v2.Parameter {
+	if p := item.GetParameter(); p != nil {
+		return p
+	}
+	name := strings.TrimPrefix(item.GetJsonReference().GetXRef(), "#/parameters/")
+	if b.document.Parameters == nil {
+		return nil
+	}
+	for _, pair := range b.document.Parameters.AdditionalProperties {
+		if pair.Name == name {
+			return pair.Value
+		}
+	}
+	return nil
+}
+
+func (b *openAPI2Builder) resolveResponse(item *openapi_v2.ResponseValue) *openapi_v2.Response {
+	if response := item.GetResponse(); response != nil {
+		return response
+	}
+	name := strings.TrimPrefix(item.GetJsonReference().GetXRef(), "#/responses/")
+	if b.document.Responses == nil {
+		return nil
+	}
+	for _, pair := range b.document.Responses.AdditionalProperties {
+		if pair.Name == name {
+			return pair.Value
+		}
+	}
+	return nil
+}
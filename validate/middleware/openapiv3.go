@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	"github.com/google/gnostic/validate"
+)
+
+// openAPI3Builder builds the operations of an OpenAPI v3 document.
+type openAPI3Builder struct {
+	document *openapi_v3.Document
+	schemas  *validate.Schemas
+}
+
+// NewHandler returns a handler that validates requests against an OpenAPI v3
+// description before passing them to the wrapped handler.
+func NewHandler(document *openapi_v3.Document, handler http.Handler, options *Options) *Handler {
+	b := &openAPI3Builder{document: document, schemas: validate.NewOpenAPI3Schemas(document, options.source())}
+	basePaths := make([]string, 0)
+	for _, server := range document.Servers {
+		// Server variables are replaced by their default values.
+		serverURL := server.Url
+		if server.Variables != nil {
+			for _, pair := range server.Variables.AdditionalProperties {
+				serverURL = strings.Replace(serverURL, "{"+pair.Name+"}", pair.Value.Default, -1)
+			}
+		}
+		if u, err := url.Parse(serverURL); err == nil {
+			basePaths = append(basePaths, u.Path)
+		}
+	}
+	templates := make([]string, 0)
+	if document.Paths != nil {
+		for _, pair := range document.Paths.Path {
+			templates = append(templates, pair.Name)
+		}
+	}
+	h := newHandler(newRouter(basePaths, templates), handler, options)
+	if document.Paths != nil {
+		for _, pair := range document.Paths.Path {
+			r := h.router.route(pair.Name)
+			item := pair.Value
+			for _, o := range []struct {
+				method    string
+				operation *openapi_v3.Operation
+			}{
+				{http.MethodGet, item.Get},
+				{http.MethodPut, item.Put},
+				{http.MethodPost, item.Post},
+				{http.MethodDelete, item.Delete},
+				{http.MethodOptions, item.Options},
+				{http.MethodHead, item.Head},
+				{http.MethodPatch, item.Patch},
+				{http.MethodTrace, item.Trace},
+			} {
+				if o.operation != nil {
+					r.operations[o.method] = b.newOperation(item.Parameters, o.operation)
+				}
+			}
+		}
+	}
+	return h
+}
+
+func (b *openAPI3Builder) newOperation(common []*openapi_v3.ParameterOrReference, op *openapi_v3.Operation) *operation {
+	result := &operation{responses: make(map[string][]*mediaType)}
+	// Operation parameters override path item parameters with the same name and location.
+	parameters := make(map[string]*parameter)
+	keys := make([]string, 0)
+	for _, item := range append(append([]*openapi_v3.ParameterOrReference{}, common...), op.Parameters...) {
+		p := openapi_v3.ResolveParameter(b.document, item)
+		if p == nil {
+			continue
+		}
+		schema := b.schemas.ForOpenAPI3(p.Schema)
+		if schema == nil && p.Content != nil && len(p.Content.AdditionalProperties) > 0 {
+			schema = b.schemas.ForOpenAPI3(p.Content.AdditionalProperties[0].Value.GetSchema())
+		}
+		key := p.In + ":" + p.Name
+		if _, ok := parameters[key]; !ok {
+			keys = append(keys, key)
+		}
+		parameters[key] = &parameter{name: p.Name, in: p.In, required: p.Required || p.In == "path", schema: schema}
+	}
+	for _, key := range keys {
+		result.parameters = append(result.parameters, parameters[key])
+	}
+	if body := openapi_v3.ResolveRequestBody(b.document, op.RequestBody); body != nil {
+		result.body = b.mediaTypes(body.Content)
+		result.bodyRequired = body.Required
+	}
+	if op.Responses != nil {
+		if op.Responses.Default != nil {
+			if response := openapi_v3.ResolveResponse(b.document, op.Responses.Default); response != nil {
+				result.responses["default"] = b.mediaTypes(response.Content)
+			}
+		}
+		for _, pair := range op.Responses.ResponseOrReference {
+			if response := openapi_v3.ResolveResponse(b.document, pair.Value); response != nil {
+				result.responses[strings.ToUpper(pair.Name)] = b.mediaTypes(response.Content)
+			}
+		}
+	}
+	return result
+}
+
+func (b *openAPI3Builder) mediaTypes(content *openapi_v3.MediaTypes) []*mediaType {
+	result := make([]*mediaType, 0)
+	if content == nil {
+		return result
+	}
+	for _, pair := range content.AdditionalProperties {
+		result = append(result, &mediaType{name: strings.ToLower(pair.Name), schema: b.schemas.ForOpenAPI3(pair.Value.GetSchema())})
+	}
+	return result
+}
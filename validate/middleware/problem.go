@@ -0,0 +1,111 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/gnostic/validate"
+)
+
+// ProblemContentType is the media type of problem reports.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem report that describes a validation failure.
+type Problem struct {
+	Type   string          `json:"type"`
+	Title  string          `json:"title"`
+	Status int             `json:"status"`
+	Detail string          `json:"detail,omitempty"`
+	Errors []*ProblemError `json:"errors,omitempty"`
+}
+
+// ProblemError describes a single validation error. This is an extension
+// member of the problem report.
+type ProblemError struct {
+	// Location is "body", "status" or the location and name of a parameter, e.g. "query:limit".
+	Location string `json:"location"`
+	// Pointer is a JSON pointer to the invalid part of a value.
+	Pointer string `json:"pointer,omitempty"`
+	Message string `json:"message"`
+}
+
+func problemErrors(location string, err error) []*ProblemError {
+	result := make([]*ProblemError, 0)
+	errors, ok := err.(validate.Errors)
+	if !ok {
+		return append(result, &ProblemError{Location: location, Message: err.Error()})
+	}
+	for _, e := range errors {
+		result = append(result, &ProblemError{Location: location, Pointer: e.Pointer, Message: e.Message})
+	}
+	return result
+}
+
+// WriteProblem writes a problem report. The type and title default to
+// "about:blank" and the text of the status code.
+func WriteProblem(w http.ResponseWriter, problem *Problem) {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	bytes, _ := json.Marshal(problem)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(bytes)
+}
+
+// responseRecorder buffers a response so that it can be validated before it is sent.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header)}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) writeTo(w http.ResponseWriter) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	for key, values := range r.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(r.status)
+	w.Write(r.body.Bytes())
+}
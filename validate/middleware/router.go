@@ -0,0 +1,128 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// route matches request paths to a path template of an API description.
+type route struct {
+	template   string
+	pattern    *regexp.Regexp
+	names      []string // names of path parameters, in order
+	literals   int      // number of literal characters, used to prefer more specific templates
+	operations map[string]*operation
+}
+
+var templateParameter = regexp.MustCompile(`{([^}]+)}`)
+
+func newRoute(template string) *route {
+	r := &route{template: template, operations: make(map[string]*operation)}
+	expression := "^"
+	last := 0
+	for _, match := range templateParameter.FindAllStringSubmatchIndex(template, -1) {
+		literal := escapePath(template[last:match[0]])
+		expression += regexp.QuoteMeta(literal)
+		r.literals += len(literal)
+		expression += "([^/]+)"
+		r.names = append(r.names, template[match[2]:match[3]])
+		last = match[1]
+	}
+	literal := escapePath(template[last:])
+	expression += regexp.QuoteMeta(literal) + "$"
+	r.literals += len(literal)
+	r.pattern = regexp.MustCompile(expression)
+	return r
+}
+
+// escapePath escapes a path in the same way as the paths of requests are escaped.
+func escapePath(path string) string {
+	return (&url.URL{Path: path}).EscapedPath()
+}
+
+// match returns the path parameters of an escaped path if it matches the
+// route. Values of path parameters are unescaped.
+func (r *route) match(path string) (map[string]string, bool) {
+	m := r.pattern.FindStringSubmatch(path)
+	if m == nil {
+		return nil, false
+	}
+	parameters := make(map[string]string)
+	for i, name := range r.names {
+		value, err := url.PathUnescape(m[i+1])
+		if err != nil {
+			return nil, false
+		}
+		parameters[name] = value
+	}
+	return parameters, true
+}
+
+// router finds the routes that match request paths.
+type router struct {
+	basePaths []string
+	routes    []*route
+}
+
+// newRouter returns a router for path templates that may be prefixed by any of the base paths.
+func newRouter(basePaths []string, templates []string) *router {
+	r := &router{}
+	for _, path := range basePaths {
+		if path = strings.TrimSuffix(path, "/"); path != "" {
+			r.basePaths = append(r.basePaths, escapePath(path))
+		}
+	}
+	// Longer base paths are tried first.
+	sort.SliceStable(r.basePaths, func(i, j int) bool { return len(r.basePaths[i]) > len(r.basePaths[j]) })
+	for _, template := range templates {
+		r.routes = append(r.routes, newRoute(template))
+	}
+	// Templates with more literal characters are more specific, so "/pets/mine" is preferred to "/pets/{id}".
+	sort.SliceStable(r.routes, func(i, j int) bool { return r.routes[i].literals > r.routes[j].literals })
+	return r
+}
+
+// route returns the route of a path template.
+func (r *router) route(template string) *route {
+	for _, candidate := range r.routes {
+		if candidate.template == template {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// find returns the route that matches an escaped path and the values of its path parameters.
+func (r *router) find(path string) (*route, map[string]string) {
+	candidates := make([]string, 0)
+	for _, base := range r.basePaths {
+		if strings.HasPrefix(path, base+"/") {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+	candidates = append(candidates, path)
+	for _, candidate := range candidates {
+		for _, route := range r.routes {
+			if parameters, ok := route.match(candidate); ok {
+				return route, parameters
+			}
+		}
+	}
+	return nil, nil
+}
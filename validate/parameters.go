@@ -0,0 +1,70 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseStrings converts the string values of a parameter, as they are found in
+// a path, query, header or cookie, to a value that can be validated with the
+// schema. Arrays may be given as repeated values or as a comma-separated list.
+// Values that can't be converted are returned as strings so that validation
+// reports them.
+func (s *Schema) ParseStrings(values []string) interface{} {
+	if s == nil || len(values) == 0 {
+		return nil
+	}
+	if s.hasType("array") {
+		if len(values) == 1 {
+			if values[0] == "" {
+				return []interface{}{}
+			}
+			values = strings.Split(values[0], ",")
+		}
+		result := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			result = append(result, s.items.parseString(value))
+		}
+		return result
+	}
+	return s.parseString(values[0])
+}
+
+func (s *Schema) parseString(value string) interface{} {
+	if s == nil {
+		return value
+	}
+	switch {
+	case s.hasType("integer"), s.hasType("number"):
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case s.hasType("boolean"):
+		// strconv.ParseBool also accepts values like "1" and "T".
+		switch value {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return value
+}
+
+func (s *Schema) hasType(t string) bool {
+	return contains(s.types, t)
+}
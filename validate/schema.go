@@ -0,0 +1,317 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validate checks values against the schemas of OpenAPI descriptions.
+package validate
+
+import (
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+// Schema is a version-independent form of an OpenAPI schema that can be used to validate values.
+type Schema struct {
+	types                []string // empty if any type is allowed
+	nullable             bool
+	format               string
+	enum                 []interface{}
+	multipleOf           float64
+	minimum              float64
+	maximum              float64
+	exclusiveMinimum     bool
+	exclusiveMaximum     bool
+	minLength            int64
+	maxLength            int64
+	pattern              *regexp.Regexp
+	minItems             int64
+	maxItems             int64
+	uniqueItems          bool
+	items                *Schema
+	minProperties        int64
+	maxProperties        int64
+	required             []string
+	properties           map[string]*Schema
+	additionalProperties *Schema
+	noAdditional         bool // true if additionalProperties is false
+	readOnly             bool
+	writeOnly            bool
+	allOf                []*Schema
+	anyOf                []*Schema
+	oneOf                []*Schema
+	not                  *Schema
+	// present holds the keywords with limits that are present. The compiled
+	// models don't distinguish zero values, like "minimum: 0", from missing ones.
+	present map[string]bool
+}
+
+// limitKeywords are the keywords of limits that are enforced when they are zero.
+var limitKeywords = []string{"minimum", "maximum", "maxLength", "maxItems", "maxProperties"}
+
+// has returns true if a schema has a limit keyword.
+func (s *Schema) has(keyword string) bool {
+	return s.present[keyword]
+}
+
+// Schemas builds validation schemas for the schemas of a document, resolving
+// references to named schemas.
+type Schemas struct {
+	v2    *openapi_v2.Document
+	v3    *openapi_v3.Document
+	named map[string]*Schema
+	nodes map[proto.Message]*yaml.Node
+}
+
+// NewOpenAPI2Schemas returns the validation schemas of an OpenAPI v2 document.
+// info is the yaml that the document was compiled from. Limits with zero values,
+// like "minimum: 0", are read from it, and if it is nil, they aren't enforced.
+func NewOpenAPI2Schemas(document *openapi_v2.Document, info *yaml.Node) *Schemas {
+	return &Schemas{v2: document, named: make(map[string]*Schema), nodes: sourceNodes(document, info)}
+}
+
+// NewOpenAPI3Schemas returns the validation schemas of an OpenAPI v3 document.
+// info is the yaml that the document was compiled from. Limits with zero values,
+// like "minimum: 0", are read from it, and if it is nil, they aren't enforced.
+func NewOpenAPI3Schemas(document *openapi_v3.Document, info *yaml.Node) *Schemas {
+	return &Schemas{v3: document, named: make(map[string]*Schema), nodes: sourceNodes(document, info)}
+}
+
+// setPresent records the limit keywords of a schema that are present in its
+// source, or, without one, the limits that aren't zero.
+func (s *Schemas) setPresent(result *Schema, schema proto.Message) {
+	result.present = make(map[string]bool)
+	if node, ok := s.nodes[schema]; ok {
+		for _, keyword := range limitKeywords {
+			result.present[keyword] = mappingValue(node, keyword) != nil
+		}
+		return
+	}
+	result.present["minimum"] = result.minimum != 0
+	result.present["maximum"] = result.maximum != 0
+	result.present["maxLength"] = result.maxLength > 0
+	result.present["maxItems"] = result.maxItems > 0
+	result.present["maxProperties"] = result.maxProperties > 0
+}
+
+// ForOpenAPI2 returns the validation schema for an OpenAPI v2 schema.
+func (s *Schemas) ForOpenAPI2(schema *openapi_v2.Schema) *Schema {
+	if schema == nil {
+		return nil
+	}
+	if schema.XRef != "" {
+		name := strings.TrimPrefix(schema.XRef, "#/definitions/")
+		if result, ok := s.named[name]; ok {
+			return result
+		}
+		result := &Schema{}
+		s.named[name] = result
+		if s.v2 != nil && s.v2.Definitions != nil {
+			for _, pair := range s.v2.Definitions.AdditionalProperties {
+				if pair.Name == name {
+					if named := s.ForOpenAPI2(pair.Value); named != nil {
+						*result = *named
+					}
+				}
+			}
+		}
+		return result
+	}
+	result := &Schema{
+		format:           schema.Format,
+		multipleOf:       schema.MultipleOf,
+		minimum:          schema.Minimum,
+		maximum:          schema.Maximum,
+		exclusiveMinimum: schema.ExclusiveMinimum,
+		exclusiveMaximum: schema.ExclusiveMaximum,
+		minLength:        schema.MinLength,
+		maxLength:        schema.MaxLength,
+		minItems:         schema.MinItems,
+		maxItems:         schema.MaxItems,
+		uniqueItems:      schema.UniqueItems,
+		minProperties:    schema.MinProperties,
+		maxProperties:    schema.MaxProperties,
+		required:         schema.Required,
+		readOnly:         schema.ReadOnly,
+	}
+	s.setPresent(result, schema)
+	if schema.Type != nil {
+		result.types = schema.Type.Value
+	}
+	result.pattern = compilePattern(schema.Pattern)
+	result.enum = enumValuesForOpenAPI2(schema.Enum)
+	if schema.Items != nil && len(schema.Items.Schema) > 0 {
+		result.items = s.ForOpenAPI2(schema.Items.Schema[0])
+	}
+	if schema.Properties != nil {
+		result.properties = make(map[string]*Schema)
+		for _, pair := range schema.Properties.AdditionalProperties {
+			result.properties[pair.Name] = s.ForOpenAPI2(pair.Value)
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		switch v := schema.AdditionalProperties.Oneof.(type) {
+		case *openapi_v2.AdditionalPropertiesItem_Schema:
+			result.additionalProperties = s.ForOpenAPI2(v.Schema)
+		case *openapi_v2.AdditionalPropertiesItem_Boolean:
+			result.noAdditional = !v.Boolean
+		}
+	}
+	for _, member := range schema.AllOf {
+		result.allOf = append(result.allOf, s.ForOpenAPI2(member))
+	}
+	return result
+}
+
+// ForOpenAPI2Primitive returns the validation schema for a v2 non-body parameter or header.
+func ForOpenAPI2Primitive(typ string, format string, enum []*openapi_v2.Any, items *openapi_v2.PrimitivesItems) *Schema {
+	result := &Schema{format: format, enum: enumValuesForOpenAPI2(enum)}
+	if typ != "" {
+		result.types = []string{typ}
+	}
+	if items != nil {
+		result.items = ForOpenAPI2Primitive(items.Type, items.Format, items.Enum, items.Items)
+	}
+	return result
+}
+
+// ForOpenAPI3 returns the validation schema for an OpenAPI v3 schema or reference.
+func (s *Schemas) ForOpenAPI3(item *openapi_v3.SchemaOrReference) *Schema {
+	if item == nil {
+		return nil
+	}
+	if reference := item.GetReference(); reference != nil {
+		name := strings.TrimPrefix(reference.XRef, "#/components/schemas/")
+		if result, ok := s.named[name]; ok {
+			return result
+		}
+		result := &Schema{}
+		s.named[name] = result
+		if s.v3 != nil && s.v3.Components != nil && s.v3.Components.Schemas != nil {
+			for _, pair := range s.v3.Components.Schemas.AdditionalProperties {
+				if pair.Name == name {
+					if named := s.ForOpenAPI3(pair.Value); named != nil {
+						*result = *named
+					}
+				}
+			}
+		}
+		return result
+	}
+	return s.forOpenAPI3Schema(item.GetSchema())
+}
+
+func (s *Schemas) forOpenAPI3Schema(schema *openapi_v3.Schema) *Schema {
+	if schema == nil {
+		return nil
+	}
+	result := &Schema{
+		nullable:         schema.Nullable,
+		format:           schema.Format,
+		multipleOf:       schema.MultipleOf,
+		minimum:          schema.Minimum,
+		maximum:          schema.Maximum,
+		exclusiveMinimum: schema.ExclusiveMinimum,
+		exclusiveMaximum: schema.ExclusiveMaximum,
+		minLength:        schema.MinLength,
+		maxLength:        schema.MaxLength,
+		minItems:         schema.MinItems,
+		maxItems:         schema.MaxItems,
+		uniqueItems:      schema.UniqueItems,
+		minProperties:    schema.MinProperties,
+		maxProperties:    schema.MaxProperties,
+		required:         schema.Required,
+		readOnly:         schema.ReadOnly,
+		writeOnly:        schema.WriteOnly,
+	}
+	s.setPresent(result, schema)
+	if schema.Type != "" {
+		result.types = []string{schema.Type}
+	}
+	result.pattern = compilePattern(schema.Pattern)
+	result.enum = enumValuesForOpenAPI3(schema.Enum)
+	if schema.Items != nil && len(schema.Items.SchemaOrReference) > 0 {
+		result.items = s.ForOpenAPI3(schema.Items.SchemaOrReference[0])
+	}
+	if schema.Properties != nil {
+		result.properties = make(map[string]*Schema)
+		for _, pair := range schema.Properties.AdditionalProperties {
+			result.properties[pair.Name] = s.ForOpenAPI3(pair.Value)
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		switch v := schema.AdditionalProperties.Oneof.(type) {
+		case *openapi_v3.AdditionalPropertiesItem_SchemaOrReference:
+			result.additionalProperties = s.ForOpenAPI3(v.SchemaOrReference)
+		case *openapi_v3.AdditionalPropertiesItem_Boolean:
+			result.noAdditional = !v.Boolean
+		}
+	}
+	for _, member := range schema.AllOf {
+		result.allOf = append(result.allOf, s.ForOpenAPI3(member))
+	}
+	for _, member := range schema.AnyOf {
+		result.anyOf = append(result.anyOf, s.ForOpenAPI3(member))
+	}
+	for _, member := range schema.OneOf {
+		result.oneOf = append(result.oneOf, s.ForOpenAPI3(member))
+	}
+	result.not = s.forOpenAPI3Schema(schema.Not)
+	return result
+}
+
+// Invalid patterns are ignored; they are reported by the compiler.
+func compilePattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+func enumValuesForOpenAPI2(values []*openapi_v2.Any) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if v, err := valueForYAML(value.Yaml); err == nil {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func enumValuesForOpenAPI3(values []*openapi_v3.Any) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if v, err := valueForYAML(value.Yaml); err == nil {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// valueForYAML decodes the YAML text of an Any message into a value that can be validated.
+func valueForYAML(text string) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(text), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
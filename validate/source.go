@@ -0,0 +1,142 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v3"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+// sourceNodes maps the schemas of a compiled document to the yaml nodes that
+// they were compiled from. The compiled models don't distinguish zero values
+// from missing ones, so the nodes are used to find the keywords that are present.
+func sourceNodes(document proto.Message, info *yaml.Node) map[proto.Message]*yaml.Node {
+	nodes := make(map[proto.Message]*yaml.Node)
+	if document != nil && info != nil {
+		indexSourceNodes(document.ProtoReflect(), info, nodes)
+	}
+	return nodes
+}
+
+// indexSourceNodes walks a compiled message and the node that it was compiled
+// from together and records the nodes of schemas.
+func indexSourceNodes(m protoreflect.Message, node *yaml.Node, nodes map[proto.Message]*yaml.Node) {
+	node = unwrapNode(node)
+	if node == nil || !m.IsValid() {
+		return
+	}
+	switch m.Interface().(type) {
+	case *openapi_v2.Schema, *openapi_v3.Schema:
+		nodes[m.Interface()] = node
+	}
+	fields := m.Descriptor().Fields()
+	// Messages with a single oneof, like SchemaOrReference, wrap the message
+	// that the node was compiled to.
+	if oneofs := m.Descriptor().Oneofs(); oneofs.Len() == 1 && oneofs.Get(0).Fields().Len() == fields.Len() {
+		if field := m.WhichOneof(oneofs.Get(0)); field != nil && field.Message() != nil {
+			indexSourceNodes(m.Get(field).Message(), node, nodes)
+		}
+		return
+	}
+	// Messages with a single list, like ItemsItem, hold one message for each
+	// item of a sequence or one message for the node itself.
+	if fields.Len() == 1 && fields.Get(0).IsList() && fields.Get(0).Message() != nil && !isNamedValue(fields.Get(0).Message()) {
+		list := m.Get(fields.Get(0)).List()
+		if node.Kind != yaml.SequenceNode {
+			if list.Len() > 0 {
+				indexSourceNodes(list.Get(0).Message(), node, nodes)
+			}
+			return
+		}
+		for i := 0; i < list.Len() && i < len(node.Content); i++ {
+			indexSourceNodes(list.Get(i).Message(), node.Content[i], nodes)
+		}
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	m.Range(func(field protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if field.Message() == nil || field.IsMap() ||
+			field.Name() == "specification_extension" || field.Name() == "vendor_extension" {
+			return true
+		}
+		if field.IsList() && isNamedValue(field.Message()) {
+			// Lists of named values hold the entries of the node, keyed by name.
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				entry := list.Get(i).Message()
+				entryFields := entry.Descriptor().Fields()
+				value := entryFields.ByName("value")
+				if value.Message() != nil {
+					indexSourceNodes(entry.Get(value).Message(), mappingValue(node, entry.Get(entryFields.ByName("name")).String()), nodes)
+				}
+			}
+			return true
+		}
+		child := mappingValue(node, field.JSONName())
+		if child == nil {
+			return true
+		}
+		if !field.IsList() {
+			indexSourceNodes(v.Message(), child, nodes)
+			return true
+		}
+		child = unwrapNode(child)
+		list := v.List()
+		for i := 0; i < list.Len() && child.Kind == yaml.SequenceNode && i < len(child.Content); i++ {
+			indexSourceNodes(list.Get(i).Message(), child.Content[i], nodes)
+		}
+		return true
+	})
+}
+
+// isNamedValue returns true for the messages of maps of names, like NamedSchema.
+func isNamedValue(message protoreflect.MessageDescriptor) bool {
+	fields := message.Fields()
+	return fields.Len() == 2 && fields.ByName("name") != nil && fields.ByName("value") != nil
+}
+
+// mappingValue returns the value of a key of a mapping node, or nil if the key is missing.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	node = unwrapNode(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// unwrapNode returns the content of document nodes and the targets of aliases.
+func unwrapNode(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+			node = node.Content[0]
+		} else if node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		} else {
+			break
+		}
+	}
+	return node
+}
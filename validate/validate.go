@@ -0,0 +1,449 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Error describes a part of a value that does not conform to its schema.
+type Error struct {
+	// Pointer is a JSON pointer to the invalid part of the value.
+	Pointer string
+	// Message describes the problem.
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Pointer == "" {
+		return e.Message
+	}
+	return e.Pointer + ": " + e.Message
+}
+
+// Errors is a list of validation errors.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// direction controls the handling of readOnly and writeOnly properties.
+type direction int
+
+const (
+	anyDirection direction = iota
+	requestDirection
+	responseDirection
+)
+
+// Validate checks a value against a schema. Values are the results of decoding
+// JSON or YAML into interface{} values. The returned error is nil or an Errors list.
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate(value, anyDirection)
+}
+
+// ValidateRequest checks a value sent by a client. Properties marked readOnly are not allowed.
+func (s *Schema) ValidateRequest(value interface{}) error {
+	return s.validate(value, requestDirection)
+}
+
+// ValidateResponse checks a value returned to a client. Properties marked writeOnly are not allowed.
+func (s *Schema) ValidateResponse(value interface{}) error {
+	return s.validate(value, responseDirection)
+}
+
+func (s *Schema) validate(value interface{}, d direction) error {
+	v := &validator{direction: d}
+	v.check(s, normalize(value), "")
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+type validator struct {
+	direction direction
+	errors    Errors
+}
+
+func (v *validator) fail(pointer string, format string, args ...interface{}) {
+	v.errors = append(v.errors, &Error{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid runs a nested validation and reports whether it succeeded without recording errors.
+func (v *validator) valid(s *Schema, value interface{}, pointer string) bool {
+	nested := &validator{direction: v.direction}
+	nested.check(s, value, pointer)
+	return len(nested.errors) == 0
+}
+
+func (v *validator) check(s *Schema, value interface{}, pointer string) {
+	if s == nil {
+		return
+	}
+	if value == nil {
+		if !s.nullable && len(s.types) > 0 && !contains(s.types, "null") {
+			v.fail(pointer, "null is not allowed")
+		}
+		return
+	}
+	if len(s.types) > 0 && !matchesType(value, s.types) {
+		v.fail(pointer, "expected %s but found %s", strings.Join(s.types, " or "), typeOf(value))
+		return
+	}
+	if len(s.enum) > 0 {
+		found := false
+		for _, e := range s.enum {
+			if reflect.DeepEqual(normalize(e), value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(pointer, "%s is not one of the allowed values", describe(value))
+		}
+	}
+	switch x := value.(type) {
+	case string:
+		v.checkString(s, x, pointer)
+	case float64:
+		v.checkNumber(s, x, pointer)
+	case []interface{}:
+		v.checkArray(s, x, pointer)
+	case map[string]interface{}:
+		v.checkObject(s, x, pointer)
+	}
+	for _, member := range s.allOf {
+		v.check(member, value, pointer)
+	}
+	if len(s.anyOf) > 0 {
+		matched := false
+		for _, member := range s.anyOf {
+			if v.valid(member, value, pointer) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(pointer, "value does not match any of the anyOf schemas")
+		}
+	}
+	if len(s.oneOf) > 0 {
+		count := 0
+		for _, member := range s.oneOf {
+			if v.valid(member, value, pointer) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(pointer, "value matches %d of the oneOf schemas, expected exactly 1", count)
+		}
+	}
+	if s.not != nil && v.valid(s.not, value, pointer) {
+		v.fail(pointer, "value matches a schema that it must not match")
+	}
+}
+
+func (v *validator) checkString(s *Schema, x string, pointer string) {
+	length := int64(utf8.RuneCountInString(x))
+	if s.minLength > 0 && length < s.minLength {
+		v.fail(pointer, "length %d is less than minLength %d", length, s.minLength)
+	}
+	if s.has("maxLength") && length > s.maxLength {
+		v.fail(pointer, "length %d is greater than maxLength %d", length, s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(x) {
+		v.fail(pointer, "%q does not match pattern %q", x, s.pattern.String())
+	}
+	if s.format != "" && !validStringFormat(s.format, x) {
+		v.fail(pointer, "%q is not a valid %s", x, s.format)
+	}
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// validStringFormat checks the formats defined by OpenAPI and JSON Schema. Unknown formats are allowed.
+func validStringFormat(format string, x string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, x)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", x)
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(x)
+		return err == nil && address.Address == x
+	case "uuid":
+		return uuidPattern.MatchString(x)
+	case "uri", "url":
+		u, err := url.Parse(x)
+		return err == nil && u.Scheme != ""
+	case "ipv4":
+		ip := net.ParseIP(x)
+		return ip != nil && ip.To4() != nil && !strings.Contains(x, ":")
+	case "ipv6":
+		ip := net.ParseIP(x)
+		return ip != nil && strings.Contains(x, ":")
+	case "hostname":
+		return len(x) <= 253 && hostnamePattern.MatchString(x)
+	case "byte":
+		_, err := base64.StdEncoding.DecodeString(x)
+		return err == nil
+	case "int32", "int64", "uint32", "uint64":
+		// Integers may be encoded as strings (this is the JSON mapping of 64-bit protobuf integers).
+		var n json.Number = json.Number(x)
+		f, err := n.Float64()
+		return err == nil && validNumberFormat(format, f)
+	}
+	return true
+}
+
+func (v *validator) checkNumber(s *Schema, x float64, pointer string) {
+	if s.has("minimum") {
+		if s.exclusiveMinimum && x <= s.minimum {
+			v.fail(pointer, "%v is not greater than %v", x, s.minimum)
+		} else if x < s.minimum {
+			v.fail(pointer, "%v is less than minimum %v", x, s.minimum)
+		}
+	}
+	if s.has("maximum") {
+		if s.exclusiveMaximum && x >= s.maximum {
+			v.fail(pointer, "%v is not less than %v", x, s.maximum)
+		} else if x > s.maximum {
+			v.fail(pointer, "%v is greater than maximum %v", x, s.maximum)
+		}
+	}
+	if s.multipleOf > 0 {
+		quotient := x / s.multipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			v.fail(pointer, "%v is not a multiple of %v", x, s.multipleOf)
+		}
+	}
+	if s.format != "" && !validNumberFormat(s.format, x) {
+		v.fail(pointer, "%v is not a valid %s", x, s.format)
+	}
+}
+
+func validNumberFormat(format string, x float64) bool {
+	switch format {
+	case "int32":
+		return x == math.Trunc(x) && x >= math.MinInt32 && x <= math.MaxInt32
+	case "int64":
+		return x == math.Trunc(x) && x >= math.MinInt64 && x <= math.MaxInt64
+	case "uint32":
+		return x == math.Trunc(x) && x >= 0 && x <= math.MaxUint32
+	case "uint64":
+		return x == math.Trunc(x) && x >= 0 && x <= math.MaxUint64
+	case "float":
+		return math.Abs(x) <= math.MaxFloat32
+	}
+	return true
+}
+
+func (v *validator) checkArray(s *Schema, x []interface{}, pointer string) {
+	count := int64(len(x))
+	if s.minItems > 0 && count < s.minItems {
+		v.fail(pointer, "%d items is less than minItems %d", count, s.minItems)
+	}
+	if s.has("maxItems") && count > s.maxItems {
+		v.fail(pointer, "%d items is more than maxItems %d", count, s.maxItems)
+	}
+	if s.uniqueItems {
+		for i := 0; i < len(x); i++ {
+			for j := i + 1; j < len(x); j++ {
+				if reflect.DeepEqual(x[i], x[j]) {
+					v.fail(pointer, "items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+	for i, item := range x {
+		v.check(s.items, item, fmt.Sprintf("%s/%d", pointer, i))
+	}
+}
+
+func (v *validator) checkObject(s *Schema, x map[string]interface{}, pointer string) {
+	count := int64(len(x))
+	if s.minProperties > 0 && count < s.minProperties {
+		v.fail(pointer, "%d properties is less than minProperties %d", count, s.minProperties)
+	}
+	if s.has("maxProperties") && count > s.maxProperties {
+		v.fail(pointer, "%d properties is more than maxProperties %d", count, s.maxProperties)
+	}
+	for _, name := range s.required {
+		if _, ok := x[name]; ok {
+			continue
+		}
+		// readOnly properties are not sent in requests and writeOnly properties are not returned.
+		if property := s.properties[name]; property != nil &&
+			(v.direction == requestDirection && property.readOnly ||
+				v.direction == responseDirection && property.writeOnly) {
+			continue
+		}
+		v.fail(pointer, "missing required property %q", name)
+	}
+	names := make([]string, 0, len(x))
+	for name := range x {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPointer := pointer + "/" + escapePointer(name)
+		if property, ok := s.properties[name]; ok {
+			if property != nil && v.direction == requestDirection && property.readOnly {
+				v.fail(propertyPointer, "property %q is read-only", name)
+			}
+			if property != nil && v.direction == responseDirection && property.writeOnly {
+				v.fail(propertyPointer, "property %q is write-only", name)
+			}
+			v.check(property, x[name], propertyPointer)
+		} else if s.noAdditional {
+			v.fail(propertyPointer, "additional property %q is not allowed", name)
+		} else if s.additionalProperties != nil {
+			v.check(s.additionalProperties, x[name], propertyPointer)
+		}
+	}
+}
+
+func escapePointer(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch x := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if x == math.Trunc(x) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesType(value interface{}, types []string) bool {
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(bytes)
+}
+
+// normalize converts decoded values to a canonical representation:
+// numbers become float64, maps become map[string]interface{} and timestamps become strings.
+func normalize(value interface{}) interface{} {
+	switch x := value.(type) {
+	case int:
+		return float64(x)
+	case int8:
+		return float64(x)
+	case int16:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	case uint8:
+		return float64(x)
+	case uint16:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return x.String()
+		}
+		return f
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case []interface{}:
+		result := make([]interface{}, len(x))
+		for i, item := range x {
+			result[i] = normalize(item)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(x))
+		for k, item := range x {
+			result[k] = normalize(item)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(x))
+		for k, item := range x {
+			result[fmt.Sprintf("%v", k)] = normalize(item)
+		}
+		return result
+	}
+	return value
+}
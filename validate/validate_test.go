@@ -0,0 +1,202 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+)
+
+const schemasDocument = `
+openapi: 3.0.0
+info:
+  title: Schemas
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name, kind]
+      additionalProperties: false
+      properties:
+        id:
+          type: integer
+          format: int64
+          readOnly: true
+        name:
+          type: string
+          minLength: 1
+          maxLength: 10
+          pattern: '^[A-Z]'
+        kind:
+          type: string
+          enum: [cat, dog]
+        born:
+          type: string
+          format: date
+        weight:
+          type: number
+          minimum: 1
+          maximum: 100
+          exclusiveMaximum: true
+        tags:
+          type: array
+          maxItems: 2
+          uniqueItems: true
+          items:
+            type: string
+        owner:
+          $ref: '#/components/schemas/Owner'
+        parent:
+          $ref: '#/components/schemas/Pet'
+    Owner:
+      type: object
+      nullable: true
+      properties:
+        email:
+          type: string
+          format: email
+        labels:
+          type: object
+          additionalProperties:
+            type: integer
+    Shape:
+      oneOf:
+      - type: object
+        required: [radius]
+        properties:
+          radius:
+            type: number
+      - type: object
+        required: [side]
+        properties:
+          side:
+            type: number
+`
+
+func petSchema(t *testing.T) *Schema {
+	document, err := openapi_v3.ParseDocument([]byte(schemasDocument))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	schemas := NewOpenAPI3Schemas(document, nil)
+	return schemas.ForOpenAPI3(&openapi_v3.SchemaOrReference{
+		Oneof: &openapi_v3.SchemaOrReference_Reference{
+			Reference: &openapi_v3.Reference{XRef: "#/components/schemas/Pet"}}})
+}
+
+func TestValidate(t *testing.T) {
+	schema := petSchema(t)
+	for _, test := range []struct {
+		value  string
+		errors []string
+	}{
+		{`{"name": "Fluffy", "kind": "cat"}`, nil},
+		{`{"name": "Fluffy", "kind": "cat", "born": "2019-04-01", "weight": 4.5, "tags": ["a", "b"], "owner": null}`, nil},
+		{`{"name": "Rex", "kind": "dog", "parent": {"name": "Max", "kind": "dog"}, "owner": {"email": "a@example.com", "labels": {"x": 1}}}`, nil},
+		{`{"name": "Fluffy"}`, []string{`missing required property "kind"`}},
+		{`{"name": 7, "kind": "cat"}`, []string{`/name: expected string but found integer`}},
+		{`{"name": "fluffy", "kind": "cow"}`, []string{
+			`/kind: "cow" is not one of the allowed values`,
+			`/name: "fluffy" does not match pattern "^[A-Z]"`}},
+		{`{"name": "Fluffy the Great", "kind": "cat"}`, []string{`/name: length 16 is greater than maxLength 10`}},
+		{`{"name": "Fluffy", "kind": "cat", "born": "April"}`, []string{`/born: "April" is not a valid date`}},
+		{`{"name": "Fluffy", "kind": "cat", "weight": 100}`, []string{`/weight: 100 is not less than 100`}},
+		{`{"name": "Fluffy", "kind": "cat", "weight": 0.5}`, []string{`/weight: 0.5 is less than minimum 1`}},
+		{`{"name": "Fluffy", "kind": "cat", "tags": ["a", "a", "b"]}`, []string{
+			`/tags: 3 items is more than maxItems 2`,
+			`/tags: items 0 and 1 are equal`}},
+		{`{"name": "Fluffy", "kind": "cat", "color": "black"}`, []string{`/color: additional property "color" is not allowed`}},
+		{`{"name": "Fluffy", "kind": "cat", "owner": {"email": "nobody", "labels": {"x": "y"}}}`, []string{
+			`/owner/email: "nobody" is not a valid email`,
+			`/owner/labels/x: expected integer but found string`}},
+		{`{"name": "Fluffy", "kind": "cat", "parent": {"kind": "cat"}}`, []string{`/parent: missing required property "name"`}},
+		{`[]`, []string{`expected object but found array`}},
+	} {
+		value, err := valueForYAML(test.value)
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		err = schema.Validate(value)
+		if err == nil {
+			if len(test.errors) > 0 {
+				t.Errorf("%s: expected errors %v", test.value, test.errors)
+			}
+			continue
+		}
+		messages := make([]string, 0)
+		for _, e := range err.(Errors) {
+			messages = append(messages, e.Error())
+		}
+		if strings.Join(messages, "\n") != strings.Join(test.errors, "\n") {
+			t.Errorf("%s: expected errors\n%s\nfound\n%s", test.value, strings.Join(test.errors, "\n"), err.Error())
+		}
+	}
+}
+
+func TestValidateDirections(t *testing.T) {
+	schema := petSchema(t)
+	value, _ := valueForYAML(`{"id": 1, "name": "Fluffy", "kind": "cat"}`)
+	if err := schema.ValidateResponse(value); err != nil {
+		t.Errorf("%s", err.Error())
+	}
+	err := schema.ValidateRequest(value)
+	if err == nil || err.Error() != `/id: property "id" is read-only` {
+		t.Errorf("expected a read-only error, found %v", err)
+	}
+}
+
+func TestValidateOneOf(t *testing.T) {
+	document, err := openapi_v3.ParseDocument([]byte(schemasDocument))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	schemas := NewOpenAPI3Schemas(document, nil)
+	var shape *Schema
+	for _, pair := range document.Components.Schemas.AdditionalProperties {
+		if pair.Name == "Shape" {
+			shape = schemas.ForOpenAPI3(pair.Value)
+		}
+	}
+	for value, valid := range map[string]bool{
+		`{"radius": 1}`:            true,
+		`{"side": 2}`:              true,
+		`{"radius": 1, "side": 2}`: false,
+		`{}`:                       false,
+	} {
+		v, _ := valueForYAML(value)
+		if err := shape.Validate(v); (err == nil) != valid {
+			t.Errorf("%s: expected valid=%t, found %v", value, valid, err)
+		}
+	}
+}
+
+func TestParseBooleans(t *testing.T) {
+	s := &Schema{types: []string{"boolean"}}
+	for value, expected := range map[string]interface{}{
+		"true":  true,
+		"false": false,
+		"1":     "1",
+		"T":     "T",
+		"TRUE":  "TRUE",
+	} {
+		if parsed := s.ParseStrings([]string{value}); parsed != expected {
+			t.Errorf("%s: expected %#v, found %#v", value, expected, parsed)
+		}
+	}
+}